@@ -7,8 +7,9 @@ It is standardized by IEC 61334-6 standard [4] and used in DLMS APDUs.
 package axdr
 
 import (
-	"bytes"
 	"fmt"
+	"io"
+	"math"
 	"strings"
 	"time"
 )
@@ -44,12 +45,60 @@ const (
 	TagDontCare           dataTag = 255
 )
 
+// Name of every tag as written in the standard. Also used
+// as struct tag value by Marshal and Unmarshal
+var dataTagName = map[dataTag]string{
+	TagNull:               "null-data",
+	TagArray:              "array",
+	TagStructure:          "structure",
+	TagBoolean:            "boolean",
+	TagBitString:          "bit-string",
+	TagDoubleLong:         "double-long",
+	TagDoubleLongUnsigned: "double-long-unsigned",
+	TagFloatingPoint:      "floating-point",
+	TagOctetString:        "octet-string",
+	TagVisibleString:      "visible-string",
+	TagUTF8String:         "utf8-string",
+	TagBCD:                "bcd",
+	TagInteger:            "integer",
+	TagLong:               "long",
+	TagUnsigned:           "unsigned",
+	TagLongUnsigned:       "long-unsigned",
+	TagCompactArray:       "compact-array",
+	TagLong64:             "long64",
+	TagLong64Unsigned:     "long64-unsigned",
+	TagEnum:               "enum",
+	TagFloat32:            "float32",
+	TagFloat64:            "float64",
+	TagDateTime:           "date-time",
+	TagDate:               "date",
+	TagTime:               "time",
+	TagDontCare:           "dont-care",
+}
+
+func (t dataTag) String() string {
+	if name, ok := dataTagName[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
+// DlmsData is one value of A-XDR Data. Value of date-time, date and time
+// decoded by Decoder is time.Time, or CosemDateTime, CosemDate and
+// CosemTime when it has wildcard, so it can not be time.Time. Which one
+// depends on the bytes sent by meter, so code asserting Value as
+// time.Time breaks only when a meter sends wildcards. Read them with
+// Time, CosemDateTime, CosemDate or CosemTime method instead, which
+// accept both representations
 type DlmsData struct {
 	Tag       dataTag
 	Value     interface{}
 	rawLength []byte
 	rawValue  []byte
-	raw       bytes.Buffer
+
+	// encoded contents-description of compact array, nil when it is
+	// taken from the first element
+	description []byte
 }
 
 func CreateAxdrArray(data []*DlmsData) *DlmsData {
@@ -64,11 +113,10 @@ func CreateAxdrBoolean(data bool) *DlmsData {
 	return &DlmsData{Tag: TagBoolean, Value: data}
 }
 
+// CreateAxdrBitString takes data as string of binary, example: 11100000.
+// Data which is not binary fails on Encode
 func CreateAxdrBitString(data string) *DlmsData {
 	data = strings.ReplaceAll(data, " ", "")
-	if len(strings.Trim(data, "01")) > 0 {
-		panic("Data must be a string of binary, example: 11100000")
-	}
 	return &DlmsData{Tag: TagBitString, Value: data}
 }
 
@@ -119,6 +167,19 @@ func CreateAxdrLongUnsigned(data uint16) *DlmsData {
 	return &DlmsData{Tag: TagLongUnsigned, Value: data}
 }
 
+// Elements of a compact array must share the same type, it is
+// encoded on the wire without per-element tags
+func CreateAxdrCompactArray(data []*DlmsData) *DlmsData {
+	return &DlmsData{Tag: TagCompactArray, Value: data}
+}
+
+// CreateAxdrCompactArrayOf is CreateAxdrCompactArray with type of the
+// elements given as encoded contents-description, so data may be empty.
+// See EncodeCompactArrayOf
+func CreateAxdrCompactArrayOf(description []byte, data []*DlmsData) *DlmsData {
+	return &DlmsData{Tag: TagCompactArray, Value: data, description: description}
+}
+
 func CreateAxdrLong64(data uint8) *DlmsData {
 	return &DlmsData{Tag: TagLong64, Value: data}
 }
@@ -151,357 +212,338 @@ func CreateAxdrTime(data time.Time) *DlmsData {
 	return &DlmsData{Tag: TagTime, Value: data}
 }
 
-// Encodes Value of DlmsData object according to the Tag
-// It will panic if Value is nil, data type does not match
-// the Tag or if failed happen in encoding length/value level.
+// Encodes Value of DlmsData object according to the Tag. It fails if
+// Value is nil, data type does not match the Tag or if encoding of
+// length/value fails. Neither d nor its elements are modified
 func (d *DlmsData) Encode() (out []byte, err error) {
-	if d.Value == nil {
+	return d.AppendEncode(nil)
+}
+
+// AppendEncode appends encoded d to dst and returns the extended slice.
+// Neither d nor its elements are modified, so the same value can be
+// encoded from many goroutines at once
+func (d *DlmsData) AppendEncode(dst []byte) ([]byte, error) {
+	return d.appendValue(dst)
+}
+
+// EncodeTo writes encoded d to w, without modifying d
+func (d *DlmsData) EncodeTo(w io.Writer) error {
+	out, err := d.AppendEncode(nil)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// Error built only on failure, so encoding does not allocate for it
+func (d *DlmsData) errDataType() error {
+	return fmt.Errorf("cannot encode value %v with tag %v", d.Value, d.Tag)
+}
+
+func (d *DlmsData) appendValue(dst []byte) (out []byte, err error) {
+	if d.Value == nil && d.Tag != TagNull {
 		err = fmt.Errorf("value to encode cannot be nil")
 		return
 	}
 
-	errDataType := fmt.Errorf("cannot encode value %v with tag %v", d.Value, d.Tag)
-	var dataLength []byte
+	out = append(dst, byte(d.Tag))
+	var rawValue []byte
 
 	switch d.Tag {
 	case TagNull:
-		d.rawValue = []byte{0}
+		// null-data is only the tag itself
 
-	case TagArray:
+	case TagArray, TagStructure:
 		data, ok := d.Value.([]*DlmsData)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeArray(data)
-		d.rawValue = rawValue
-
-		if dl, errLength := EncodeLength(len(data)); errLength != nil {
-			err = errLength
+		if out, err = AppendLength(out, len(data)); err != nil {
 			return
-		} else {
-			dataLength = dl
 		}
-
-	case TagStructure:
-		// what's the difference between array & structure?
-		data, ok := d.Value.([]*DlmsData)
-		if !ok {
-			err = errDataType
-			return
-		}
-		rawValue, _ := EncodeStructure(data)
-		d.rawValue = rawValue
-
-		if dl, errLength := EncodeLength(len(data)); errLength != nil {
-			err = errLength
-			return
-		} else {
-			dataLength = dl
+		for _, dt := range data {
+			if out, err = dt.appendValue(out); err != nil {
+				return
+			}
 		}
+		return
 
 	case TagBoolean:
 		data, ok := d.Value.(bool)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeBoolean(data)
-		d.rawValue = rawValue
+		rawValue, _ = EncodeBoolean(data)
 
 	case TagBitString:
 		data, ok := d.Value.(string)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-
-		if rv, errEncoding := EncodeBitString(data); errEncoding != nil {
-			err = errEncoding
+		if rawValue, err = EncodeBitString(data); err != nil {
 			return
-		} else {
-			d.rawValue = rv
 		}
-
 		// length of bitstring is count by bits, not bytes
 		// length of "1110" is 4, not 1
-		if dl, errLength := EncodeLength(len(data)); errLength != nil {
-			err = errLength
+		if out, err = AppendLength(out, len(data)); err != nil {
 			return
-		} else {
-			dataLength = dl
 		}
 
 	case TagDoubleLong:
 		data, ok := d.Value.(int32)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeDoubleLong(data)
-		d.rawValue = rawValue
+		return appendUint(out, uint64(uint32(data)), 4), nil
 
 	case TagDoubleLongUnsigned:
 		data, ok := d.Value.(uint32)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeDoubleLongUnsigned(data)
-		d.rawValue = rawValue
+		return appendUint(out, uint64(data), 4), nil
 
-	case TagFloatingPoint:
+	case TagFloatingPoint, TagFloat32:
 		data, ok := d.Value.(float32)
 		if !ok {
-			err = errDataType
-			return
-		}
-		rawValue, _ := EncodeFloat32(data)
-		d.rawValue = rawValue
-
-	case TagOctetString:
-		data, ok := d.Value.(string)
-		if !ok {
-			err = errDataType
-			return
-		}
-
-		if rv, errEncoding := EncodeOctetString(data); errEncoding != nil {
-			err = errEncoding
-			return
-		} else {
-			d.rawValue = rv
-		}
-
-		if dl, errLength := EncodeLength(len(d.rawValue)); errLength != nil {
-			err = errLength
-			return
-		} else {
-			dataLength = dl
-		}
-
-	case TagVisibleString:
-		data, ok := d.Value.(string)
-		if !ok {
-			err = errDataType
-			return
-		}
-
-		if rv, errEncoding := EncodeVisibleString(data); errEncoding != nil {
-			err = errEncoding
-			return
-		} else {
-			d.rawValue = rv
-		}
-
-		if dl, errLength := EncodeLength(len(d.rawValue)); errLength != nil {
-			err = errLength
+			err = d.errDataType()
 			return
-		} else {
-			dataLength = dl
 		}
+		return appendUint(out, uint64(math.Float32bits(data)), 4), nil
 
-	case TagUTF8String:
+	case TagOctetString, TagVisibleString, TagUTF8String:
 		data, ok := d.Value.(string)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-
-		if rv, errEncoding := EncodeUTF8String(data); errEncoding != nil {
-			err = errEncoding
-			return
-		} else {
-			d.rawValue = rv
+		switch d.Tag {
+		case TagOctetString:
+			rawValue, err = EncodeOctetString(data)
+		case TagVisibleString:
+			rawValue, err = EncodeVisibleString(data)
+		default:
+			rawValue, err = EncodeUTF8String(data)
 		}
-
-		if dl, errLength := EncodeLength(len(d.rawValue)); errLength != nil {
-			err = errLength
+		if err != nil {
 			return
-		} else {
-			dataLength = dl
 		}
-
-	case TagBCD:
-		data, ok := d.Value.(int8)
-		if !ok {
-			err = errDataType
+		if out, err = AppendLength(out, len(rawValue)); err != nil {
 			return
 		}
-		rawValue, _ := EncodeBCD(data)
-		d.rawValue = rawValue
 
-	case TagInteger:
+	case TagBCD, TagInteger:
 		data, ok := d.Value.(int8)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeInteger(data)
-		d.rawValue = rawValue
+		return append(out, byte(data)), nil
 
 	case TagLong:
 		data, ok := d.Value.(int16)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeLong(data)
-		d.rawValue = rawValue
+		return appendUint(out, uint64(uint16(data)), 2), nil
 
-	case TagUnsigned:
+	case TagUnsigned, TagEnum:
 		data, ok := d.Value.(uint8)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeUnsigned(data)
-		d.rawValue = rawValue
+		return append(out, data), nil
 
 	case TagLongUnsigned:
 		data, ok := d.Value.(uint16)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeLongUnsigned(data)
-		d.rawValue = rawValue
+		return appendUint(out, uint64(data), 2), nil
 
 	case TagCompactArray:
-		err = fmt.Errorf("not yet implemented")
-		return
-
-	case TagLong64:
-		data, ok := d.Value.(int64)
+		data, ok := d.Value.([]*DlmsData)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeLong64(data)
-		d.rawValue = rawValue
-
-	case TagLong64Unsigned:
-		data, ok := d.Value.(uint64)
-		if !ok {
-			err = errDataType
+		// length of compact array is part of its value, as the
+		// contents-description come before the packed contents
+		if d.description != nil {
+			rawValue, err = EncodeCompactArrayOf(d.description, data)
+		} else {
+			rawValue, err = EncodeCompactArray(data)
+		}
+		if err != nil {
 			return
 		}
-		rawValue, _ := EncodeLong64Unsigned(data)
-		d.rawValue = rawValue
 
-	case TagEnum:
-		data, ok := d.Value.(uint8)
+	case TagLong64:
+		data, ok := d.Value.(int64)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeEnum(data)
-		d.rawValue = rawValue
+		return appendUint(out, uint64(data), 8), nil
 
-	case TagFloat32:
-		data, ok := d.Value.(float32)
+	case TagLong64Unsigned:
+		data, ok := d.Value.(uint64)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeFloat32(data)
-		d.rawValue = rawValue
+		return appendUint(out, data, 8), nil
 
 	case TagFloat64:
 		data, ok := d.Value.(float64)
 		if !ok {
-			err = errDataType
+			err = d.errDataType()
 			return
 		}
-		rawValue, _ := EncodeFloat64(data)
-		d.rawValue = rawValue
+		return appendUint(out, math.Float64bits(data), 8), nil
 
 	case TagDateTime:
-		var data time.Time
 		switch value := d.Value.(type) {
+		case CosemDateTime:
+			rawValue, err = EncodeCosemDateTime(value)
 		case time.Time:
-			data = value
+			rawValue, err = EncodeDateTime(value)
 		case string:
 			// max year value using parse string is 9999, over will give year 0000
-			data, _ = time.Parse("2006-01-02 15:04:05", value)
+			data, _ := time.Parse("2006-01-02 15:04:05", value)
+			rawValue, err = EncodeDateTime(data)
 		default:
-			err = errDataType
-			return
+			err = d.errDataType()
 		}
-
-		if rv, errEncoding := EncodeDateTime(data); errEncoding != nil {
-			err = errEncoding
+		if err != nil {
 			return
-		} else {
-			d.rawValue = rv
 		}
 
 	case TagDate:
-		var data time.Time
 		switch value := d.Value.(type) {
+		case CosemDate:
+			rawValue, err = EncodeCosemDate(value)
 		case time.Time:
-			data = value
+			rawValue, err = EncodeDate(value)
 		case string:
-			data, _ = time.Parse("2006-01-02", value)
+			data, _ := time.Parse("2006-01-02", value)
+			rawValue, err = EncodeDate(data)
 		default:
-			err = errDataType
-			return
+			err = d.errDataType()
 		}
-
-		if rv, errEncoding := EncodeDate(data); errEncoding != nil {
-			err = errEncoding
+		if err != nil {
 			return
-		} else {
-			d.rawValue = rv
 		}
 
 	case TagTime:
-		var data time.Time
 		switch value := d.Value.(type) {
+		case CosemTime:
+			rawValue, err = EncodeCosemTime(value)
 		case time.Time:
-			data = value
+			rawValue, err = EncodeTime(value)
 		case string:
-			data, _ = time.Parse("15:04:05", value)
+			data, _ := time.Parse("15:04:05", value)
+			rawValue, err = EncodeTime(data)
 		default:
-			err = errDataType
-			return
+			err = d.errDataType()
 		}
-
-		if rv, errEncoding := EncodeTime(data); errEncoding != nil {
-			err = errEncoding
+		if err != nil {
 			return
-		} else {
-			d.rawValue = rv
 		}
 
 	case TagDontCare:
-		d.rawValue = []byte{0}
+		rawValue = []byte{0}
+	}
+
+	out = append(out, rawValue...)
+	return
+}
+
+// AppendLength appends A-XDR length of n to dst, encoded as EncodeLength
+// does, and returns the extended slice
+func AppendLength(dst []byte, n int) ([]byte, error) {
+	if n < 0 {
+		return dst, fmt.Errorf("%v value cannot be negative", n)
 	}
+	if n < 0x80 {
+		return append(dst, byte(n)), nil
+	}
+	size := 1
+	for n>>(8*uint(size)) > 0 {
+		size++
+	}
+	return appendUint(append(dst, byte(0x80+size)), uint64(n), size), nil
+}
 
-	d.raw.Reset()
-	d.raw.WriteByte(byte(d.Tag))
-	if len(dataLength) > 0 {
-		d.rawLength = dataLength
-		d.raw.Write(dataLength)
+// Append size bytes of value to dst in big endian
+func appendUint(dst []byte, value uint64, size int) []byte {
+	for i := size - 1; i >= 0; i-- {
+		dst = append(dst, byte(value>>(8*uint(i))))
 	}
-	d.raw.Write(d.rawValue)
-	return d.raw.Bytes(), nil
+	return dst
 }
 
-// Return bytes of raw data if Encode() has been called before
-// raw data is combination of Tag, Length(if any), and Value
+// Return bytes of raw data, which is combination of Tag, Length(if any),
+// and Value. Decoded data gives bytes it was decoded from, any other is
+// encoded on every call. Nil is returned if encoding fails
 func (d *DlmsData) Raw() []byte {
-	return d.raw.Bytes()
+	if !d.decoded() {
+		out, _ := d.AppendEncode(nil)
+		return out
+	}
+	// raw is built on every call without storing it, so d stays safe to
+	// read from many goroutines
+	out := make([]byte, 0, 1+len(d.rawLength)+len(d.rawValue))
+	out = append(out, byte(d.Tag))
+	if lengthAfterTag[d.Tag] {
+		out = append(out, d.rawLength...)
+	}
+	return append(out, d.rawValue...)
 }
 
-// Return bytes of raw value if Encode() has been called before
-// raw value does not include Tag and Length
+// Return bytes of raw value, raw value does not include Tag and Length
 func (d *DlmsData) RawValue() []byte {
-	return d.rawValue
+	if d.decoded() {
+		return d.rawValue
+	}
+	_, value := d.encodedParts()
+	return value
 }
 
-// Return bytes of raw length if Encode() has been called before
-// raw length does not include Tag and Value
+// Return bytes of raw length, raw length does not include Tag and Value
 func (d *DlmsData) RawLength() []byte {
-	return d.rawLength
+	if d.decoded() {
+		return d.rawLength
+	}
+	length, _ := d.encodedParts()
+	return length
+}
+
+// Decoder sets raw length of every element it decodes, except arrays and
+// structures inside compact array. Those are packed without tags, so
+// their raw is encoded again from their value
+func (d *DlmsData) decoded() bool {
+	return d.rawLength != nil || d.rawValue != nil
+}
+
+// Encode d and split it into length (nil if Tag has none) and value
+func (d *DlmsData) encodedParts() (length []byte, value []byte) {
+	out, err := d.AppendEncode(nil)
+	if err != nil {
+		return
+	}
+	value = out[1:]
+	if lengthAfterTag[d.Tag] {
+		length, _, _ = DecodeLength(&value)
+	}
+	return
 }
@@ -3,6 +3,7 @@ package axdr
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -469,32 +470,32 @@ func TestEncodeDate(t *testing.T) {
 }
 
 func TestEncodeTime(t *testing.T) {
-	dt := time.Date(2020, time.January, 1, 10, 0, 0, 255, time.UTC)
+	dt := time.Date(2020, time.January, 1, 10, 0, 0, 120000000, time.UTC)
 	ts, err := EncodeTime(dt)
-	res := bytes.Compare(ts, []byte{10, 0, 0, 255})
+	res := bytes.Compare(ts, []byte{10, 0, 0, 12})
 	if res != 0 || err != nil {
 		t.Errorf("t1 failed. val: %d, err:%v", ts, err)
 	}
 
-	dt = time.Date(2020, time.January, 1, 23, 59, 59, 255, time.UTC)
+	dt = time.Date(2020, time.January, 1, 23, 59, 59, 999999999, time.UTC)
 	ts, err = EncodeTime(dt)
-	res = bytes.Compare(ts, []byte{23, 59, 59, 255})
+	res = bytes.Compare(ts, []byte{23, 59, 59, 99})
 	if res != 0 || err != nil {
 		t.Errorf("t2 failed. val: %d, err:%v", ts, err)
 	}
 }
 
 func TestEncodeDateTime(t *testing.T) {
-	dt := time.Date(20000, time.December, 30, 23, 59, 59, 255, time.UTC)
+	dt := time.Date(20000, time.December, 30, 23, 59, 59, 990000000, time.UTC)
 	ts, err := EncodeDateTime(dt)
-	res := bytes.Compare(ts, []byte{78, 32, 12, 30, 6, 23, 59, 59, 255, 0, 0, 0})
+	res := bytes.Compare(ts, []byte{78, 32, 12, 30, 6, 23, 59, 59, 99, 0, 0, 0})
 	if res != 0 || err != nil {
 		t.Errorf("t1 failed. val: %d, err:%v", ts, err)
 	}
 
 	dt = time.Date(1500, time.January, 1, 0, 0, 0, 255, time.UTC)
 	ts, err = EncodeDateTime(dt)
-	res = bytes.Compare(ts, []byte{5, 220, 1, 1, 1, 0, 0, 0, 255, 0, 0, 0})
+	res = bytes.Compare(ts, []byte{5, 220, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0})
 	if res != 0 || err != nil {
 		t.Errorf("t2 failed. val: %d, err:%v", ts, err)
 	}
@@ -665,6 +666,117 @@ func TestDlmsData_Array(t *testing.T) {
 	}
 }
 
+func TestEncodeCompactArray(t *testing.T) {
+	s1 := CreateAxdrStructure([]*DlmsData{CreateAxdrLongUnsigned(1), CreateAxdrOctetString("0102")})
+	s2 := CreateAxdrStructure([]*DlmsData{CreateAxdrLongUnsigned(2), CreateAxdrOctetString("0304")})
+	ts, err := EncodeCompactArray([]*DlmsData{s1, s2})
+	res := bytes.Compare(ts, []byte{2, 2, 18, 9, 10, 0, 1, 2, 1, 2, 0, 2, 2, 3, 4})
+	if res != 0 || err != nil {
+		t.Errorf("t1 failed. val: %d, err:%v", ts, err)
+	}
+
+	a1 := CreateAxdrArray([]*DlmsData{CreateAxdrUnsigned(1), CreateAxdrUnsigned(2)})
+	a2 := CreateAxdrArray([]*DlmsData{CreateAxdrUnsigned(3), CreateAxdrUnsigned(4)})
+	ts, err = EncodeCompactArray([]*DlmsData{a1, a2})
+	res = bytes.Compare(ts, []byte{1, 0, 2, 17, 4, 1, 2, 3, 4})
+	if res != 0 || err != nil {
+		t.Errorf("t2 failed. val: %d, err:%v", ts, err)
+	}
+
+	ts, err = EncodeCompactArray([]*DlmsData{CreateAxdrLongUnsigned(1), CreateAxdrUnsigned(2)})
+	if err == nil {
+		t.Errorf("t3 should fail on mixed element type. val: %d", ts)
+	}
+
+	a3 := CreateAxdrArray([]*DlmsData{CreateAxdrUnsigned(5)})
+	ts, err = EncodeCompactArray([]*DlmsData{a1, a3})
+	if err == nil {
+		t.Errorf("t4 should fail on different array size. val: %d", ts)
+	}
+
+	ts, err = EncodeCompactArray([]*DlmsData{})
+	if err == nil {
+		t.Errorf("t5 should fail on empty compact array. val: %d", ts)
+	}
+
+	// type given by contents-description
+	ts, err = EncodeCompactArrayOf([]byte{18}, []*DlmsData{})
+	if err != nil || !bytes.Equal(ts, []byte{18, 0}) {
+		t.Errorf("t6 failed. val: %d, err:%v", ts, err)
+	}
+	src := append([]byte(nil), ts...)
+	if _, val, err := DecodeCompactArray(&src); err != nil || len(val) != 0 || len(src) != 0 {
+		t.Errorf("t6 decode failed. val: %v, err:%v", val, err)
+	}
+
+	ts, err = EncodeCompactArrayOf([]byte{2, 2, 18, 9}, []*DlmsData{s1, s2})
+	if err != nil || !bytes.Equal(ts, []byte{2, 2, 18, 9, 10, 0, 1, 2, 1, 2, 0, 2, 2, 3, 4}) {
+		t.Errorf("t7 failed. val: %d, err:%v", ts, err)
+	}
+	if ts, err = EncodeCompactArrayOf([]byte{17}, []*DlmsData{s1}); err == nil {
+		t.Errorf("t8 should fail on element not matching description. val: %d", ts)
+	}
+	if ts, err = EncodeCompactArrayOf([]byte{17, 17}, nil); err == nil {
+		t.Errorf("t9 should fail on bytes after description. val: %d", ts)
+	}
+
+	// elements without content are refused on both sides
+	empty := []struct {
+		data    *DlmsData
+		encoded []byte
+	}{
+		{CreateAxdrStructure([]*DlmsData{}), []byte{2, 0, 0}},
+		{&DlmsData{Tag: TagNull}, []byte{0, 0}},
+		{&DlmsData{Tag: TagDontCare}, []byte{255, 0}},
+		{CreateAxdrStructure([]*DlmsData{CreateAxdrUnsigned(1), {Tag: TagNull}}), []byte{2, 2, 17, 0, 1, 1}},
+	}
+	for i, e := range empty {
+		if ts, err = EncodeCompactArray([]*DlmsData{e.data}); err == nil {
+			t.Errorf("t%v should fail on element without content. val: %d", 10+i, ts)
+		}
+		src := append([]byte(nil), e.encoded...)
+		if _, val, err := DecodeCompactArray(&src); err == nil {
+			t.Errorf("t%v decode should fail on element without content. val: %v", 10+i, val)
+		}
+	}
+}
+
+func TestDlmsData_CompactArray(t *testing.T) {
+	tDD := CreateAxdrCompactArray([]*DlmsData{CreateAxdrDoubleLongUnsigned(1), CreateAxdrDoubleLongUnsigned(2)})
+	encoded, err := tDD.Encode()
+	if err != nil {
+		t.Errorf("DlmsData Encode CompactArray get error. %v", err)
+	}
+	res := bytes.Compare(encoded, []byte{byte(TagCompactArray), 6, 8, 0, 0, 0, 1, 0, 0, 0, 2})
+	if res != 0 {
+		t.Errorf("t1 failed. val: %d", encoded)
+	}
+
+	tDD = &DlmsData{Tag: TagCompactArray, Value: true}
+	_, err = tDD.Encode()
+	if err == nil {
+		t.Errorf("t2 should fail on wrong Value")
+	}
+
+	// empty compact array needs type of the elements
+	if _, err = CreateAxdrCompactArray([]*DlmsData{}).Encode(); err == nil {
+		t.Errorf("t3 should fail on empty compact array without description")
+	}
+	encoded, err = CreateAxdrCompactArrayOf([]byte{18}, []*DlmsData{}).Encode()
+	if err != nil || bytes.Compare(encoded, []byte{byte(TagCompactArray), 18, 0}) != 0 {
+		t.Errorf("t4 failed. val: %d, err: %v", encoded, err)
+	}
+	src := append([]byte(nil), encoded...)
+	dec := NewDataDecoder(&src)
+	decoded, err := dec.Decode(&src)
+	if err != nil {
+		t.Fatalf("t4 decode failed. err: %v", err)
+	}
+	if reencoded, err := decoded.Encode(); err != nil || bytes.Compare(reencoded, encoded) != 0 {
+		t.Errorf("t4 round trip failed. get: %d, err: %v", reencoded, err)
+	}
+}
+
 // ---------- decoding tests
 
 func TestDecodeLength(t *testing.T) {
@@ -1216,8 +1328,8 @@ func TestDecodeTime(t *testing.T) {
 		bt  []byte
 		val time.Time
 	}{
-		{[]byte{10, 0, 0, 255, 1, 2, 3}, []byte{10, 0, 0, 255}, time.Date(0, time.January, 1, 10, 0, 0, 255, time.UTC)},
-		{[]byte{23, 59, 59, 255, 1, 2, 3}, []byte{23, 59, 59, 255}, time.Date(0, time.January, 1, 23, 59, 59, 255, time.UTC)},
+		{[]byte{10, 0, 0, 255, 1, 2, 3}, []byte{10, 0, 0, 255}, time.Date(0, time.January, 1, 10, 0, 0, 0, time.UTC)},
+		{[]byte{23, 59, 59, 50, 1, 2, 3}, []byte{23, 59, 59, 50}, time.Date(0, time.January, 1, 23, 59, 59, 500000000, time.UTC)},
 	}
 	for idx, table := range tables {
 		bt, val, err := DecodeTime(&table.src)
@@ -1248,8 +1360,8 @@ func TestDecodeDateTime(t *testing.T) {
 		bt  []byte
 		val time.Time
 	}{
-		{[]byte{78, 32, 12, 30, 6, 23, 59, 59, 255, 0, 0, 0, 1, 2, 3}, []byte{78, 32, 12, 30, 6, 23, 59, 59, 255, 0, 0, 0}, time.Date(20000, time.December, 30, 23, 59, 59, 255, time.UTC)},
-		{[]byte{5, 220, 1, 1, 1, 0, 0, 0, 255, 0, 0, 0, 1, 2, 3}, []byte{5, 220, 1, 1, 1, 0, 0, 0, 255, 0, 0, 0}, time.Date(1500, time.January, 1, 0, 0, 0, 255, time.UTC)},
+		{[]byte{78, 32, 12, 30, 6, 23, 59, 59, 99, 0, 0, 0, 1, 2, 3}, []byte{78, 32, 12, 30, 6, 23, 59, 59, 99, 0, 0, 0}, time.Date(20000, time.December, 30, 23, 59, 59, 990000000, time.UTC)},
+		{[]byte{5, 220, 1, 1, 1, 0, 0, 0, 255, 0, 0, 0, 1, 2, 3}, []byte{5, 220, 1, 1, 1, 0, 0, 0, 255, 0, 0, 0}, time.Date(1500, time.January, 1, 0, 0, 0, 0, time.UTC)},
 	}
 	for idx, table := range tables {
 		bt, val, err := DecodeDateTime(&table.src)
@@ -1272,11 +1384,28 @@ func TestDecodeDateTime(t *testing.T) {
 			t.Errorf("combination %v failed. Reminder get: %v, should:[1, 2, 3]", idx, table.src)
 		}
 	}
+
+	// wildcard can not be time.Time, src is left as is
+	src := []byte{255, 255, 12, 30, 255, 23, 59, 59, 255, 128, 0, 255}
+	if _, val, err := DecodeDateTime(&src); err != ErrDateTimeNotSpecified || len(src) != 12 {
+		t.Errorf("wildcard should fail with ErrDateTimeNotSpecified. val: %v, err:%v", val, err)
+	}
+
+	// while decoder keeps it as CosemDateTime
+	src = append([]byte{byte(TagDateTime)}, src...)
+	dec := NewDataDecoder(&src)
+	d, err := dec.Decode(&src)
+	if err != nil {
+		t.Errorf("decoding wildcard failed. err:%v", err)
+	}
+	if dt, ok := d.Value.(CosemDateTime); !ok || dt.Date.Year != YearNotSpecified || dt.Time.Hour != 23 {
+		t.Errorf("wildcard should be kept as CosemDateTime. val: %v", d.Value)
+	}
 }
 
 func TestDecoder1(t *testing.T) {
 	d1 := DlmsData{Tag: TagLongUnsigned, Value: uint16(60226)}
-	d2 := DlmsData{Tag: TagDateTime, Value: time.Date(2020, time.March, 16, 0, 0, 0, 255, time.UTC)}
+	d2 := DlmsData{Tag: TagDateTime, Value: time.Date(2020, time.March, 16, 0, 0, 0, 0, time.UTC)}
 	d3 := DlmsData{Tag: TagBitString, Value: "0"}
 	d4 := DlmsData{Tag: TagDoubleLongUnsigned, Value: uint32(33426304)}
 	d5 := DlmsData{Tag: TagLongUnsigned, Value: uint16(3105)}
@@ -1327,3 +1456,352 @@ func TestDecoder1(t *testing.T) {
 		t.Errorf("src after error should still be the same length (%v)", src)
 	}
 }
+
+func TestDecodeCompactArray(t *testing.T) {
+	src := []byte{2, 2, 18, 9, 10, 0, 1, 2, 1, 2, 0, 2, 2, 3, 4, 1, 2, 3}
+	bt, val, err := DecodeCompactArray(&src)
+	if err != nil {
+		t.Errorf("t1 failed. got an error:%v", err)
+	}
+	sameByte := bytes.Compare(bt, []byte{2, 2, 18, 9, 10, 0, 1, 2, 1, 2, 0, 2, 2, 3, 4})
+	if sameByte != 0 {
+		t.Errorf("t1 failed. Byte get: %v", bt)
+	}
+	sameReminder := bytes.Compare(src, []byte{1, 2, 3})
+	if sameReminder != 0 {
+		t.Errorf("t1 failed. Reminder get: %v, should:[1, 2, 3]", src)
+	}
+	if len(val) != 2 {
+		t.Fatalf("t1 failed. should have 2 elements, get: %v", len(val))
+	}
+	for idx, table := range []struct {
+		id  uint16
+		obj string
+	}{{1, "0102"}, {2, "0304"}} {
+		if val[idx].Tag != TagStructure {
+			t.Errorf("t1 element %v should be TagStructure, get: %v", idx, val[idx].Tag)
+		}
+		members := val[idx].Value.([]*DlmsData)
+		if members[0].Value != table.id {
+			t.Errorf("t1 element %v id get: %v, should: %v", idx, members[0].Value, table.id)
+		}
+		if members[1].Value != table.obj {
+			t.Errorf("t1 element %v octet-string get: %v, should: %v", idx, members[1].Value, table.obj)
+		}
+	}
+
+	src = []byte{1, 0, 2, 17, 4, 1, 2, 3, 4}
+	_, val, err = DecodeCompactArray(&src)
+	if err != nil {
+		t.Errorf("t2 failed. got an error:%v", err)
+	}
+	if len(val) != 2 || val[1].Tag != TagArray || val[1].Value.([]*DlmsData)[1].Value != uint8(4) {
+		t.Errorf("t2 failed. get: %v", val)
+	}
+
+	src = []byte{6, 8, 0, 0, 0, 1, 0, 0}
+	_, _, err = DecodeCompactArray(&src)
+	if err == nil {
+		t.Errorf("t3 should fail on not enough contents")
+	}
+
+	src = []byte{6, 6, 0, 0, 0, 1, 0, 0}
+	_, _, err = DecodeCompactArray(&src)
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Path != "[1].double-long-unsigned" || de.Offset != 6 {
+		t.Errorf("t4 should fail on element cut in the middle. err: %v", err)
+	}
+
+	// every element of structure(0) is empty, count alone would allocate
+	// 0xFFFF * 0xFFFF values
+	src = append([]byte{1, 0xFF, 0xFF, 1, 0xFF, 0xFF, 2, 0, 0x82, 0xFF, 0xFF}, make([]byte, 0xFFFF)...)
+	_, _, err = DecodeCompactArray(&src)
+	if err == nil {
+		t.Errorf("t5 should fail on element without content")
+	}
+
+	src = []byte{0, 1, 0}
+	_, _, err = DecodeCompactArray(&src)
+	if err == nil {
+		t.Errorf("t6 should fail on null-data element")
+	}
+}
+
+func TestDecoder_CompactArray(t *testing.T) {
+	s1 := CreateAxdrStructure([]*DlmsData{CreateAxdrLongUnsigned(60226), CreateAxdrDoubleLong(-1)})
+	s2 := CreateAxdrStructure([]*DlmsData{CreateAxdrLongUnsigned(3105), CreateAxdrDoubleLong(7)})
+	tDD := CreateAxdrCompactArray([]*DlmsData{s1, s2})
+	encoded, err := tDD.Encode()
+	if err != nil {
+		t.Errorf("got an error when encoding:%v", err)
+	}
+
+	src := append([]byte(nil), encoded...)
+	dec := NewDataDecoder(&src)
+	t1, err := dec.Decode(&src)
+	if err != nil {
+		t.Errorf("got an error when decoding:%v", err)
+	}
+	if len(src) != 0 {
+		t.Errorf("src should be consumed, left: %v", src)
+	}
+	if t1.Tag != TagCompactArray {
+		t.Errorf("First level should be TagCompactArray, received: %v", t1.Tag)
+	}
+	t2 := t1.Value.([]*DlmsData)
+	if len(t2) != 2 {
+		t.Fatalf("should have 2 elements, received: %v", len(t2))
+	}
+	if t2[1].Value.([]*DlmsData)[0].Value != uint16(3105) || t2[0].Value.([]*DlmsData)[1].Value != int32(-1) {
+		t.Errorf("element value is not decoded correctly")
+	}
+
+	reencoded, err := t1.Encode()
+	if err != nil || bytes.Compare(reencoded, encoded) != 0 {
+		t.Errorf("round trip failed. get: %v, should: %v", reencoded, encoded)
+	}
+
+	// raw of element is tagged, so it decodes again to the same value
+	s1Encoded, _ := s1.Encode()
+	if raw := t2[0].Raw(); bytes.Compare(raw, s1Encoded) != 0 {
+		t.Errorf("raw of element failed. get: %v, should: %v", raw, s1Encoded)
+	}
+	raw := t2[1].Raw()
+	again, err := NewDataDecoder(&raw).Decode(&raw)
+	if err != nil || again.Value.([]*DlmsData)[0].Value != uint16(3105) || again.Value.([]*DlmsData)[1].Value != int32(7) {
+		t.Errorf("raw of element does not decode again. get: %v, err: %v", again, err)
+	}
+	if bytes.Compare(t2[0].RawValue(), s1Encoded[2:]) != 0 || bytes.Compare(t2[0].RawLength(), []byte{2}) != 0 {
+		t.Errorf("raw value of element failed. get: %v %v", t2[0].RawLength(), t2[0].RawValue())
+	}
+}
+
+func TestDecoder_Error(t *testing.T) {
+	tables := []struct {
+		src    []byte
+		offset int
+		path   string
+		tag    dataTag
+	}{
+		{[]byte{1, 3, 17, 1, 17, 2, 2, 4, 17, 0, 17, 0, 17, 0, 9, 5, 0xAA, 0xBB}, 14, "[2].structure[3].octet-string", TagOctetString},
+		{[]byte{9, 5, 0xAA}, 0, "octet-string", TagOctetString},
+		{[]byte{1, 2, 17, 1}, 4, "[1]", TagArray},
+		{[]byte{2, 1, 2, 2, 18, 0, 1, 18, 0}, 7, "[0].structure[1].long-unsigned", TagLongUnsigned},
+		{[]byte{19, 2, 2, 18, 9, 9, 0, 1, 2, 0xAA, 0xBB, 0, 2, 5, 0xAA}, 13, "[1].structure[1].octet-string", TagOctetString},
+		{[]byte{2, 1, 19, 6, 6, 0, 0, 0, 1, 0, 0}, 9, "[0].compact-array[1].double-long-unsigned", TagDoubleLongUnsigned},
+	}
+	for i, table := range tables {
+		src := append([]byte(nil), table.src...)
+		dec := NewDataDecoder(&src)
+		_, err := dec.Decode(&src)
+
+		var de *DecodeError
+		if !errors.As(err, &de) {
+			t.Errorf("t%d should fail with DecodeError. err: %v", i, err)
+			continue
+		}
+		if de.Offset != table.offset || de.Path != table.path || de.Tag != table.tag {
+			t.Errorf("t%d Failed. get: %v %v %v, should: %v %v %v", i, de.Offset, de.Path, de.Tag, table.offset, table.path, table.tag)
+		}
+		if !errors.Is(err, ErrLengthLess) {
+			t.Errorf("t%d Failed. should unwrap to ErrLengthLess, err: %v", i, err)
+		}
+	}
+
+	src := []byte{2, 1, 99}
+	dec := NewDataDecoder(&src)
+	_, err := dec.Decode(&src)
+	if err == nil || err.Error() != "axdr: decoding [0].unknown(99) at offset 2: data tag unknown(99) is not recognized" {
+		t.Errorf("t5 Failed. err: %v", err)
+	}
+}
+
+func TestDecoder_Limits(t *testing.T) {
+	tables := []struct {
+		src    []byte
+		limits Limits
+	}{
+		{[]byte{2, 1, 2, 1, 2, 1, 17, 0}, Limits{MaxDepth: 2, MaxElements: 0xFF, MaxOctetString: 0xFF}},
+		{[]byte{1, 3, 17, 0, 17, 1, 17, 2}, Limits{MaxDepth: 4, MaxElements: 2, MaxOctetString: 0xFF}},
+		{[]byte{9, 4, 1, 2, 3, 4}, Limits{MaxDepth: 4, MaxElements: 0xFF, MaxOctetString: 3}},
+		{[]byte{1, 0x83, 0x01, 0x00, 0x00, 17, 0}, DefaultLimits},
+		{[]byte{19, 1, 0x01, 0x00, 1, 0x01, 0x00, 17, 0}, Limits{MaxDepth: 4, MaxElements: 0xFF, MaxOctetString: 0xFF}},
+		{[]byte{19, 9, 4, 3, 1, 2, 3}, Limits{MaxDepth: 4, MaxElements: 0xFF, MaxOctetString: 2}},
+	}
+	for i, table := range tables {
+		src := append([]byte(nil), table.src...)
+		dec := NewDataDecoder(&src)
+		dec.Limits = table.limits
+		_, err := dec.Decode(&src)
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("t%d should fail with ErrLimitExceeded. err: %v", i, err)
+		}
+	}
+
+	src := []byte{2, 1, 2, 1, 17, 0}
+	dec := NewDataDecoder(&src)
+	dec.Limits = Limits{MaxDepth: 2, MaxElements: 1, MaxOctetString: 0}
+	if _, err := dec.Decode(&src); err != nil {
+		t.Errorf("t4 Failed. err: %v", err)
+	}
+
+	var empty []byte
+	dec = NewDataDecoder(&empty)
+	if _, err := dec.Decode(&empty); err == nil {
+		t.Errorf("t5 should fail on empty input")
+	}
+}
+
+func TestDecoder_ZeroCopy(t *testing.T) {
+	input := []byte{1, 1, 9, 2, 0xAA, 0xBB, 0xCC}
+	for _, zeroCopy := range []bool{false, true} {
+		data := append([]byte(nil), input...)
+		src := data
+		dec := NewDataDecoder(&src)
+		dec.ZeroCopy = zeroCopy
+		out, err := dec.Decode(&src)
+		if err != nil {
+			t.Fatalf("t1 Decode Failed. err: %v", err)
+		}
+		if !bytes.Equal(out.Raw(), input[:6]) || !bytes.Equal(src, []byte{0xCC}) {
+			t.Errorf("t1 Failed. get: %X %X, should:%X %X", out.Raw(), src, input[:6], []byte{0xCC})
+		}
+
+		data[4] = 0x00
+		el := out.Value.([]*DlmsData)[0]
+		if got := el.RawValue()[0] == 0x00; got != zeroCopy {
+			t.Errorf("t2 Failed. zero copy %v, raw value %X follows input: %v", zeroCopy, el.RawValue(), got)
+		}
+		if el.Value.(string) != "aabb" {
+			t.Errorf("t3 Failed. get: %v, should:%v", el.Value, "aabb")
+		}
+	}
+}
+
+func TestDecoder_RawConcurrent(t *testing.T) {
+	input := []byte{1, 2, 9, 2, 0xAA, 0xBB, 17, 5}
+	src := append([]byte(nil), input...)
+	dec := NewDataDecoder(&src)
+	out, err := dec.Decode(&src)
+	if err != nil {
+		t.Fatalf("Decode Failed. err: %v", err)
+	}
+
+	// decoded data is only read, so Raw can be called from many goroutines
+	done := make(chan []byte)
+	for i := 0; i < 8; i++ {
+		go func() {
+			done <- out.Raw()
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if raw := <-done; !bytes.Equal(raw, input) {
+			t.Errorf("t1 Failed. get: %X, should:%X", raw, input)
+		}
+	}
+}
+
+func TestDlmsData_AppendEncode(t *testing.T) {
+	d1 := DlmsData{Tag: TagOctetString, Value: "0102"}
+	d2 := DlmsData{Tag: TagLongUnsigned, Value: uint16(513)}
+	d3 := DlmsData{Tag: TagBitString, Value: "111"}
+	tDD := DlmsData{Tag: TagStructure, Value: []*DlmsData{&d1, &d2, &d3}}
+	result := []byte{2, 3, 9, 2, 1, 2, 18, 2, 1, 4, 3, 224}
+
+	t1, err := tDD.AppendEncode([]byte{0xAA})
+	if err != nil {
+		t.Errorf("t1 AppendEncode Failed. err: %v", err)
+	}
+	if !bytes.Equal(t1, append([]byte{0xAA}, result...)) {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+	if tDD.decoded() || d1.decoded() {
+		t.Errorf("t2 Failed. AppendEncode should not keep raw, get: %d %d", tDD.rawValue, d1.rawValue)
+	}
+	// raw of value which was not decoded is its encoding
+	if !bytes.Equal(tDD.Raw(), result) || !bytes.Equal(d1.RawLength(), []byte{2}) || !bytes.Equal(d1.RawValue(), []byte{1, 2}) {
+		t.Errorf("t2 Failed. get: %d %d %d, should:%v [2] [1 2]", tDD.Raw(), d1.RawLength(), d1.RawValue(), result)
+	}
+
+	var buf bytes.Buffer
+	if err = tDD.EncodeTo(&buf); err != nil || !bytes.Equal(buf.Bytes(), result) {
+		t.Errorf("t3 Failed. get: %d, should:%v, err: %v", buf.Bytes(), result, err)
+	}
+
+	done := make(chan []byte)
+	for i := 0; i < 8; i++ {
+		go func() {
+			out, _ := tDD.AppendEncode(nil)
+			done <- out
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if out := <-done; !bytes.Equal(out, result) {
+			t.Errorf("t4 Failed. get: %d, should:%v", out, result)
+		}
+	}
+
+	bad := DlmsData{Tag: TagArray, Value: []*DlmsData{&d1, {Tag: TagUnsigned, Value: "x"}}}
+	if _, err = bad.AppendEncode(nil); err == nil {
+		t.Errorf("t5 should fail on element with wrong value")
+	}
+}
+
+// Load profile buffer as read from a meter: array of rows, each row is
+// structure of date-time, two registers and status
+func benchmarkProfile(rows int) []byte {
+	row := []*DlmsData{
+		CreateAxdrOctetString("07E40C1F04172D0000800000"),
+		CreateAxdrDoubleLongUnsigned(123456),
+		CreateAxdrDoubleLongUnsigned(654321),
+		CreateAxdrUnsigned(8),
+	}
+	list := make([]*DlmsData, rows)
+	for i := range list {
+		list[i] = CreateAxdrStructure(row)
+	}
+	out, err := CreateAxdrArray(list).Encode()
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func benchmarkDecoder(b *testing.B, src []byte, zeroCopy bool) {
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		in := src
+		dec := NewDataDecoder(&in)
+		dec.ZeroCopy = zeroCopy
+		if _, err := dec.Decode(&in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecoder_Profile10(b *testing.B)   { benchmarkDecoder(b, benchmarkProfile(10), false) }
+func BenchmarkDecoder_Profile100(b *testing.B)  { benchmarkDecoder(b, benchmarkProfile(100), false) }
+func BenchmarkDecoder_Profile1000(b *testing.B) { benchmarkDecoder(b, benchmarkProfile(1000), false) }
+
+func BenchmarkDecoder_Profile1000ZeroCopy(b *testing.B) {
+	benchmarkDecoder(b, benchmarkProfile(1000), true)
+}
+
+// Rows of load profile one after another, each decoded on its own
+func BenchmarkDecoder_Sequence1000(b *testing.B) {
+	// skip array tag and its 3 bytes length
+	src := benchmarkProfile(1000)[4:]
+
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		in := src
+		for len(in) > 0 {
+			dec := NewDataDecoder(&in)
+			if _, err := dec.Decode(&in); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
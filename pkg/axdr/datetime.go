@@ -0,0 +1,470 @@
+package axdr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Wildcards of COSEM date & time fields. Every field except year and
+// deviation uses NotSpecified (0xFF) when its value is not given
+const (
+	YearNotSpecified         uint16 = 0xFFFF
+	NotSpecified             uint8  = 0xFF
+	MonthDaylightSavingEnd   uint8  = 0xFD
+	MonthDaylightSavingBegin uint8  = 0xFE
+	DaySecondLastOfMonth     uint8  = 0xFD
+	DayLastOfMonth           uint8  = 0xFE
+	DeviationNotSpecified    int16  = -0x8000
+)
+
+// ClockStatus is the status byte of date-time. 0xFF means not specified
+type ClockStatus uint8
+
+const (
+	ClockStatusOk             ClockStatus = 0x00
+	ClockStatusInvalid        ClockStatus = 0x01
+	ClockStatusDoubtful       ClockStatus = 0x02
+	ClockStatusDifferentBase  ClockStatus = 0x04
+	ClockStatusInvalidStatus  ClockStatus = 0x08
+	ClockStatusDaylightSaving ClockStatus = 0x80
+	ClockStatusNotSpecified   ClockStatus = 0xFF
+)
+
+var clockStatusName = []struct {
+	flag ClockStatus
+	name string
+}{
+	{ClockStatusInvalid, "invalid"},
+	{ClockStatusDoubtful, "doubtful"},
+	{ClockStatusDifferentBase, "different-base"},
+	{ClockStatusInvalidStatus, "invalid-status"},
+	{ClockStatusDaylightSaving, "daylight-saving"},
+}
+
+// Has reports whether every bit of flag is set. Status that is
+// not specified never has any flag
+func (s ClockStatus) Has(flag ClockStatus) bool {
+	if s == ClockStatusNotSpecified {
+		return false
+	}
+	return s&flag == flag
+}
+
+func (s ClockStatus) String() string {
+	switch s {
+	case ClockStatusOk:
+		return "ok"
+	case ClockStatusNotSpecified:
+		return "not-specified"
+	}
+
+	names := []string{}
+	for _, c := range clockStatusName {
+		if s.Has(c.flag) {
+			names = append(names, c.name)
+		}
+	}
+	if rest := s &^ (ClockStatusInvalid | ClockStatusDoubtful | ClockStatusDifferentBase | ClockStatusInvalidStatus | ClockStatusDaylightSaving); rest != 0 {
+		names = append(names, fmt.Sprintf("0x%02X", uint8(rest)))
+	}
+	return strings.Join(names, "|")
+}
+
+// CosemDate is date as it is sent on the wire, wildcards included.
+// DayOfWeek is 1 for Monday until 7 for Sunday
+type CosemDate struct {
+	Year      uint16
+	Month     uint8
+	Day       uint8
+	DayOfWeek uint8
+}
+
+// CosemTime is time as it is sent on the wire, wildcards included
+type CosemTime struct {
+	Hour       uint8
+	Minute     uint8
+	Second     uint8
+	Hundredths uint8
+}
+
+// CosemDateTime is date-time as it is sent on the wire. Deviation is
+// in minutes of local time to UTC, so local time of UTC+01:00 has
+// deviation -60
+type CosemDateTime struct {
+	Date        CosemDate
+	Time        CosemTime
+	Deviation   int16
+	ClockStatus ClockStatus
+}
+
+var (
+	ErrDateTimeNotSpecified = errors.New("date-time has field which is not specified")
+)
+
+// NewCosemDate creates CosemDate from date part of t
+func NewCosemDate(t time.Time) CosemDate {
+	return CosemDate{
+		Year:      uint16(t.Year()),
+		Month:     uint8(t.Month()),
+		Day:       uint8(t.Day()),
+		DayOfWeek: cosemWeekday(t.Weekday()),
+	}
+}
+
+// NewCosemTime creates CosemTime from clock part of t
+func NewCosemTime(t time.Time) CosemTime {
+	return CosemTime{
+		Hour:       uint8(t.Hour()),
+		Minute:     uint8(t.Minute()),
+		Second:     uint8(t.Second()),
+		Hundredths: uint8(t.Nanosecond() / 10000000),
+	}
+}
+
+// NewCosemDateTime creates CosemDateTime out of t, deviation is taken
+// from location of t. Clock status is set to ok, daylight saving bit
+// has to be set by caller if needed
+func NewCosemDateTime(t time.Time) CosemDateTime {
+	_, offset := t.Zone()
+	return CosemDateTime{
+		Date:        NewCosemDate(t),
+		Time:        NewCosemTime(t),
+		Deviation:   int16(-offset / 60),
+		ClockStatus: ClockStatusOk,
+	}
+}
+
+func cosemWeekday(w time.Weekday) uint8 {
+	if w == time.Sunday {
+		return 7
+	}
+	return uint8(w)
+}
+
+// IsSpecified reports whether year, month and day is given
+func (d CosemDate) IsSpecified() bool {
+	return d.Year != YearNotSpecified && d.Month >= 1 && d.Month <= 12 && d.Day >= 1 && d.Day <= 31
+}
+
+// IsSpecified reports whether hour, minute and second is given.
+// Hundredths that is not specified is taken as zero
+func (t CosemTime) IsSpecified() bool {
+	return t.Hour <= 23 && t.Minute <= 59 && t.Second <= 59
+}
+
+// IsSpecified reports whether date and time is given,
+// deviation & clock status may still be not specified
+func (dt CosemDateTime) IsSpecified() bool {
+	return dt.Date.IsSpecified() && dt.Time.IsSpecified()
+}
+
+func (t CosemTime) nanosecond() int {
+	if t.Hundredths > 99 {
+		return 0
+	}
+	return int(t.Hundredths) * 10000000
+}
+
+// Location returns fixed zone of the deviation. If deviation is
+// not specified, UTC is returned
+func (dt CosemDateTime) Location() *time.Location {
+	if dt.Deviation == DeviationNotSpecified || dt.Deviation == 0 {
+		return time.UTC
+	}
+	return time.FixedZone("", -int(dt.Deviation)*60)
+}
+
+// ToTime converts date into time.Time at midnight UTC. Day that is
+// not in the month, such as 31 February, is refused
+func (d CosemDate) ToTime() (time.Time, error) {
+	if !d.IsSpecified() {
+		return time.Time{}, ErrDateTimeNotSpecified
+	}
+	if err := d.validate(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(int(d.Year), time.Month(d.Month), int(d.Day), 0, 0, 0, 0, time.UTC), nil
+}
+
+// ToTime converts time into time.Time on 1 January of year 0 UTC. Hour,
+// minute and second must be specified
+func (t CosemTime) ToTime() (time.Time, error) {
+	if !t.IsSpecified() {
+		return time.Time{}, ErrDateTimeNotSpecified
+	}
+	if err := t.validate(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(0, time.January, 1, int(t.Hour), int(t.Minute), int(t.Second), t.nanosecond(), time.UTC), nil
+}
+
+// ToTime converts date-time into time.Time with UTC offset taken from
+// deviation. Every date & time field except hundredths must be specified
+// and day must be in the month
+func (dt CosemDateTime) ToTime() (time.Time, error) {
+	if !dt.IsSpecified() {
+		return time.Time{}, ErrDateTimeNotSpecified
+	}
+	if err := dt.validate(); err != nil {
+		return time.Time{}, err
+	}
+	d, t := dt.Date, dt.Time
+	return time.Date(int(d.Year), time.Month(d.Month), int(d.Day), int(t.Hour), int(t.Minute), int(t.Second), t.nanosecond(), dt.Location()), nil
+}
+
+func (d CosemDate) validate() error {
+	if d.Month != NotSpecified && d.Month != MonthDaylightSavingBegin && d.Month != MonthDaylightSavingEnd && (d.Month < 1 || d.Month > 12) {
+		return fmt.Errorf("month value(%v) is out of range", d.Month)
+	}
+	if d.Day != NotSpecified && d.Day != DayLastOfMonth && d.Day != DaySecondLastOfMonth && (d.Day < 1 || d.Day > 31) {
+		return fmt.Errorf("day of month value(%v) is out of range", d.Day)
+	}
+	if d.Month >= 1 && d.Month <= 12 && d.Day <= 31 && int(d.Day) > daysIn(d.Year, d.Month) {
+		return fmt.Errorf("day of month value(%v) is out of range of month %v", d.Day, d.Month)
+	}
+	if d.DayOfWeek != NotSpecified && (d.DayOfWeek < 1 || d.DayOfWeek > 7) {
+		return fmt.Errorf("day of week value(%v) is out of range", d.DayOfWeek)
+	}
+	return nil
+}
+
+// Number of days in month of year. Year that is not specified
+// may be leap year, so February has 29 days
+func daysIn(year uint16, month uint8) int {
+	if year == YearNotSpecified {
+		year = 2000
+	}
+	return time.Date(int(year), time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func (t CosemTime) validate() error {
+	if t.Hour != NotSpecified && t.Hour > 23 {
+		return fmt.Errorf("hour value(%v) is out of range", t.Hour)
+	}
+	if t.Minute != NotSpecified && t.Minute > 59 {
+		return fmt.Errorf("minute value(%v) is out of range", t.Minute)
+	}
+	if t.Second != NotSpecified && t.Second > 59 {
+		return fmt.Errorf("second value(%v) is out of range", t.Second)
+	}
+	if t.Hundredths != NotSpecified && t.Hundredths > 99 {
+		return fmt.Errorf("hundredths value(%v) is out of range", t.Hundredths)
+	}
+	return nil
+}
+
+func (dt CosemDateTime) validate() error {
+	if err := dt.Date.validate(); err != nil {
+		return err
+	}
+	if err := dt.Time.validate(); err != nil {
+		return err
+	}
+	if dt.Deviation != DeviationNotSpecified && (dt.Deviation < -720 || dt.Deviation > 720) {
+		return fmt.Errorf("deviation value(%v) is out of range", dt.Deviation)
+	}
+	return nil
+}
+
+func twoDigits(v uint8) string {
+	if v == NotSpecified {
+		return "**"
+	}
+	return fmt.Sprintf("%02d", v)
+}
+
+func (d CosemDate) String() string {
+	year := "****"
+	if d.Year != YearNotSpecified {
+		year = fmt.Sprintf("%04d", d.Year)
+	}
+	month := twoDigits(d.Month)
+	switch d.Month {
+	case MonthDaylightSavingBegin:
+		month = "DB"
+	case MonthDaylightSavingEnd:
+		month = "DE"
+	}
+	day := twoDigits(d.Day)
+	switch d.Day {
+	case DayLastOfMonth:
+		day = "LD"
+	case DaySecondLastOfMonth:
+		day = "SL"
+	}
+	return year + "-" + month + "-" + day
+}
+
+func (t CosemTime) String() string {
+	return twoDigits(t.Hour) + ":" + twoDigits(t.Minute) + ":" + twoDigits(t.Second) + "." + twoDigits(t.Hundredths)
+}
+
+func (dt CosemDateTime) String() string {
+	dev := "****"
+	if dt.Deviation != DeviationNotSpecified {
+		dev = fmt.Sprintf("%+d", dt.Deviation)
+	}
+	return fmt.Sprintf("%v %v %v %v", dt.Date, dt.Time, dev, dt.ClockStatus)
+}
+
+// Encodes CosemDate into 5 bytes data. Weekday is written as is,
+// 1 for Monday until 7 for Sunday
+func EncodeCosemDate(data CosemDate) ([]byte, error) {
+	if err := data.validate(); err != nil {
+		return []byte{}, err
+	}
+	output := make([]byte, 5)
+	binary.BigEndian.PutUint16(output[0:2], data.Year)
+	output[2] = data.Month
+	output[3] = data.Day
+	output[4] = data.DayOfWeek
+	return output, nil
+}
+
+// Encodes CosemTime into 4 bytes data
+func EncodeCosemTime(data CosemTime) ([]byte, error) {
+	if err := data.validate(); err != nil {
+		return []byte{}, err
+	}
+	return []byte{data.Hour, data.Minute, data.Second, data.Hundredths}, nil
+}
+
+// Encodes CosemDateTime into 12 bytes data, every field is written as is
+func EncodeCosemDateTime(data CosemDateTime) ([]byte, error) {
+	if err := data.validate(); err != nil {
+		return []byte{}, err
+	}
+	output := make([]byte, 12)
+	binary.BigEndian.PutUint16(output[0:2], data.Date.Year)
+	output[2] = data.Date.Month
+	output[3] = data.Date.Day
+	output[4] = data.Date.DayOfWeek
+	output[5] = data.Time.Hour
+	output[6] = data.Time.Minute
+	output[7] = data.Time.Second
+	output[8] = data.Time.Hundredths
+	binary.BigEndian.PutUint16(output[9:11], uint16(data.Deviation))
+	output[11] = byte(data.ClockStatus)
+	return output, nil
+}
+
+// Decode 5 bytes data into CosemDate, wildcards are kept
+func DecodeCosemDate(src *[]byte) (outByte []byte, outVal CosemDate, err error) {
+	if len(*src) < 5 {
+		err = ErrLengthLess
+		return
+	}
+	outByte = (*src)[:5]
+	outVal.Year = binary.BigEndian.Uint16(outByte[0:2])
+	outVal.Month = outByte[2]
+	outVal.Day = outByte[3]
+	outVal.DayOfWeek = outByte[4]
+	(*src) = (*src)[5:]
+	return
+}
+
+// Decode 4 bytes data into CosemTime, wildcards are kept
+func DecodeCosemTime(src *[]byte) (outByte []byte, outVal CosemTime, err error) {
+	if len(*src) < 4 {
+		err = ErrLengthLess
+		return
+	}
+	outByte = (*src)[:4]
+	outVal.Hour = outByte[0]
+	outVal.Minute = outByte[1]
+	outVal.Second = outByte[2]
+	outVal.Hundredths = outByte[3]
+	(*src) = (*src)[4:]
+	return
+}
+
+// Decode 12 bytes data into CosemDateTime, wildcards,
+// deviation and clock status are kept
+func DecodeCosemDateTime(src *[]byte) (outByte []byte, outVal CosemDateTime, err error) {
+	if len(*src) < 12 {
+		err = ErrLengthLess
+		return
+	}
+	outByte = (*src)[:12]
+	temp := outByte[:]
+	_, outVal.Date, _ = DecodeCosemDate(&temp)
+	_, outVal.Time, _ = DecodeCosemTime(&temp)
+	outVal.Deviation = int16(binary.BigEndian.Uint16(temp[0:2]))
+	outVal.ClockStatus = ClockStatus(temp[2])
+	(*src) = (*src)[12:]
+	return
+}
+
+// CosemDateTime returns date-time of decoded or encoded DlmsData with
+// every field kept, which is lost when the value is read as time.Time.
+// Value may be either time.Time or CosemDateTime
+func (d *DlmsData) CosemDateTime() (out CosemDateTime, err error) {
+	if d.Tag != TagDateTime {
+		err = fmt.Errorf("data tag %v is not date-time", d.Tag)
+		return
+	}
+	value, err := cosemValueOf(d)
+	if err != nil {
+		return
+	}
+	out = value.(CosemDateTime)
+	return
+}
+
+// CosemDate returns date of decoded or encoded DlmsData with every
+// field kept, whether Value is time.Time or CosemDate
+func (d *DlmsData) CosemDate() (out CosemDate, err error) {
+	if d.Tag != TagDate {
+		err = fmt.Errorf("data tag %v is not date", d.Tag)
+		return
+	}
+	value, err := cosemValueOf(d)
+	if err != nil {
+		return
+	}
+	out = value.(CosemDate)
+	return
+}
+
+// CosemTime returns time of decoded or encoded DlmsData with every
+// field kept, whether Value is time.Time or CosemTime
+func (d *DlmsData) CosemTime() (out CosemTime, err error) {
+	if d.Tag != TagTime {
+		err = fmt.Errorf("data tag %v is not time", d.Tag)
+		return
+	}
+	value, err := cosemValueOf(d)
+	if err != nil {
+		return
+	}
+	out = value.(CosemTime)
+	return
+}
+
+// Time returns date-time, date or time of DlmsData as time.Time, whether
+// Value is time.Time or kept with wildcards. Value with wildcard gives
+// ErrDateTimeNotSpecified
+func (d *DlmsData) Time() (time.Time, error) {
+	var value interface{}
+	var err error
+	switch d.Tag {
+	case TagDateTime, TagDate, TagTime:
+		value, err = cosemValueOf(d)
+	default:
+		err = fmt.Errorf("data tag %v is not date-time, date or time", d.Tag)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch v := value.(type) {
+	case CosemDateTime:
+		return v.ToTime()
+	case CosemDate:
+		return v.ToTime()
+	default:
+		return v.(CosemTime).ToTime()
+	}
+}
@@ -0,0 +1,285 @@
+package axdr
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncodeCosemDateTime(t *testing.T) {
+	dt := CosemDateTime{
+		Date:        CosemDate{Year: 2020, Month: 3, Day: 11, DayOfWeek: 3},
+		Time:        CosemTime{Hour: 18, Minute: 0, Second: 0, Hundredths: 50},
+		Deviation:   -60,
+		ClockStatus: ClockStatusDaylightSaving,
+	}
+	ts, err := EncodeCosemDateTime(dt)
+	res := bytes.Compare(ts, []byte{7, 228, 3, 11, 3, 18, 0, 0, 50, 255, 196, 128})
+	if res != 0 || err != nil {
+		t.Errorf("t1 failed. val: %d, err:%v", ts, err)
+	}
+
+	dt = CosemDateTime{
+		Date:        CosemDate{Year: YearNotSpecified, Month: MonthDaylightSavingBegin, Day: DayLastOfMonth, DayOfWeek: 7},
+		Time:        CosemTime{Hour: 2, Minute: 0, Second: 0, Hundredths: NotSpecified},
+		Deviation:   DeviationNotSpecified,
+		ClockStatus: ClockStatusNotSpecified,
+	}
+	ts, err = EncodeCosemDateTime(dt)
+	res = bytes.Compare(ts, []byte{255, 255, 254, 254, 7, 2, 0, 0, 255, 128, 0, 255})
+	if res != 0 || err != nil {
+		t.Errorf("t2 failed. val: %d, err:%v", ts, err)
+	}
+
+	dt.Time.Minute = 60
+	_, err = EncodeCosemDateTime(dt)
+	if err == nil {
+		t.Errorf("t3 should fail on minute out of range")
+	}
+
+	dt.Time.Minute = 0
+	dt.Deviation = 800
+	_, err = EncodeCosemDateTime(dt)
+	if err == nil {
+		t.Errorf("t4 should fail on deviation out of range")
+	}
+}
+
+func TestDecodeCosemDateTime(t *testing.T) {
+	src := []byte{255, 255, 254, 254, 7, 2, 0, 0, 255, 128, 0, 255, 1, 2, 3}
+	bt, val, err := DecodeCosemDateTime(&src)
+	if err != nil {
+		t.Errorf("t1 failed. got an error:%v", err)
+	}
+	if bytes.Compare(bt, []byte{255, 255, 254, 254, 7, 2, 0, 0, 255, 128, 0, 255}) != 0 {
+		t.Errorf("t1 failed. Byte get: %v", bt)
+	}
+	if val.Date.Year != YearNotSpecified || val.Date.Month != MonthDaylightSavingBegin || val.Date.Day != DayLastOfMonth {
+		t.Errorf("t1 failed. Date get: %v", val.Date)
+	}
+	if val.Deviation != DeviationNotSpecified || val.ClockStatus != ClockStatusNotSpecified {
+		t.Errorf("t1 failed. Deviation: %v, status: %v", val.Deviation, val.ClockStatus)
+	}
+	if val.IsSpecified() {
+		t.Errorf("t1 should not be specified")
+	}
+	if _, err = val.ToTime(); err != ErrDateTimeNotSpecified {
+		t.Errorf("t1 ToTime should fail, get: %v", err)
+	}
+	if bytes.Compare(src, []byte{1, 2, 3}) != 0 {
+		t.Errorf("t1 failed. Reminder get: %v, should:[1, 2, 3]", src)
+	}
+
+	src = []byte{7, 228, 3, 11, 3, 18, 0, 0, 50, 255, 196, 128}
+	_, val, err = DecodeCosemDateTime(&src)
+	if err != nil {
+		t.Errorf("t2 failed. got an error:%v", err)
+	}
+	if !val.ClockStatus.Has(ClockStatusDaylightSaving) || val.ClockStatus.Has(ClockStatusInvalid) {
+		t.Errorf("t2 failed. status get: %v", val.ClockStatus)
+	}
+	tm, err := val.ToTime()
+	should := time.Date(2020, time.March, 11, 17, 0, 0, 500000000, time.UTC)
+	if err != nil || !tm.Equal(should) {
+		t.Errorf("t2 failed. time get: %v, should:%v", tm, should)
+	}
+	if _, offset := tm.Zone(); offset != 3600 {
+		t.Errorf("t2 failed. offset get: %v", offset)
+	}
+
+	src = []byte{7, 228, 3, 11}
+	_, _, err = DecodeCosemDateTime(&src)
+	if err == nil {
+		t.Errorf("t3 should fail on short source")
+	}
+}
+
+func TestNewCosemDateTime(t *testing.T) {
+	tm := time.Date(2021, time.January, 3, 10, 20, 30, 120000000, time.FixedZone("", -5*3600))
+	dt := NewCosemDateTime(tm)
+	if dt.Deviation != 300 {
+		t.Errorf("t1 failed. deviation get: %v", dt.Deviation)
+	}
+	if dt.Date.DayOfWeek != 7 {
+		t.Errorf("t1 failed. sunday should be 7, get: %v", dt.Date.DayOfWeek)
+	}
+	if dt.Time.Hundredths != 12 {
+		t.Errorf("t1 failed. hundredths get: %v", dt.Time.Hundredths)
+	}
+	back, err := dt.ToTime()
+	if err != nil || !back.Equal(tm) {
+		t.Errorf("t1 failed. round trip get: %v, should:%v", back, tm)
+	}
+}
+
+func TestClockStatus_String(t *testing.T) {
+	tables := []struct {
+		status ClockStatus
+		str    string
+	}{
+		{ClockStatusOk, "ok"},
+		{ClockStatusNotSpecified, "not-specified"},
+		{ClockStatusInvalid | ClockStatusDaylightSaving, "invalid|daylight-saving"},
+		{ClockStatusDoubtful | 0x10, "doubtful|0x10"},
+	}
+	for idx, table := range tables {
+		if table.status.String() != table.str {
+			t.Errorf("combination %v failed. get: %v, should:%v", idx, table.status.String(), table.str)
+		}
+	}
+}
+
+func TestDlmsData_CosemDateTime(t *testing.T) {
+	dt := CosemDateTime{
+		Date:        CosemDate{Year: 2020, Month: 3, Day: 11, DayOfWeek: NotSpecified},
+		Time:        CosemTime{Hour: 18, Minute: NotSpecified, Second: NotSpecified, Hundredths: NotSpecified},
+		Deviation:   DeviationNotSpecified,
+		ClockStatus: ClockStatusDoubtful,
+	}
+	d := DlmsData{Tag: TagDateTime, Value: dt}
+	encoded, err := d.Encode()
+	res := bytes.Compare(encoded, []byte{byte(TagDateTime), 7, 228, 3, 11, 255, 18, 255, 255, 255, 128, 0, 2})
+	if res != 0 || err != nil {
+		t.Errorf("t1 failed. val: %d, err:%v", encoded, err)
+	}
+
+	src := encoded[:]
+	dec := NewDataDecoder(&src)
+	decoded, err := dec.Decode(&src)
+	if err != nil {
+		t.Fatalf("t2 failed. err:%v", err)
+	}
+	back, err := decoded.CosemDateTime()
+	if err != nil || back != dt {
+		t.Errorf("t2 failed. get: %v, should:%v", back, dt)
+	}
+
+	// accessor reads time.Time value as well
+	d = *CreateAxdrDateTime(time.Date(2020, time.March, 11, 18, 30, 0, 0, time.UTC))
+	back, err = d.CosemDateTime()
+	if err != nil || back.Date != (CosemDate{2020, 3, 11, 3}) || back.Time != (CosemTime{18, 30, 0, 0}) {
+		t.Errorf("t4 failed. get: %v, err:%v", back, err)
+	}
+
+	d = DlmsData{Tag: TagDate, Value: CosemDate{Year: YearNotSpecified, Month: 12, Day: 25, DayOfWeek: NotSpecified}}
+	encoded, err = d.Encode()
+	res = bytes.Compare(encoded, []byte{byte(TagDate), 255, 255, 12, 25, 255})
+	if res != 0 || err != nil {
+		t.Errorf("t3 failed. val: %d, err:%v", encoded, err)
+	}
+}
+
+func TestDecodeDateTime_Deviation(t *testing.T) {
+	src := []byte{7, 228, 3, 11, 3, 18, 0, 0, 0, 255, 196, 255}
+	_, val, err := DecodeDateTime(&src)
+	if err != nil {
+		t.Errorf("t1 failed. got an error:%v", err)
+	}
+	if _, offset := val.Zone(); offset != 3600 {
+		t.Errorf("t1 failed. offset get: %v", offset)
+	}
+
+	ts, err := EncodeDateTime(val)
+	res := bytes.Compare(ts, []byte{7, 228, 3, 11, 3, 18, 0, 0, 0, 255, 196, 0})
+	if res != 0 || err != nil {
+		t.Errorf("t2 failed. val: %d, err:%v", ts, err)
+	}
+}
+
+func TestCosemDate_DayOfMonth(t *testing.T) {
+	tables := []struct {
+		date  CosemDate
+		valid bool
+	}{
+		{CosemDate{Year: 2020, Month: 2, Day: 29, DayOfWeek: NotSpecified}, true},
+		{CosemDate{Year: 2021, Month: 2, Day: 29, DayOfWeek: NotSpecified}, false},
+		{CosemDate{Year: 2021, Month: 2, Day: 31, DayOfWeek: NotSpecified}, false},
+		{CosemDate{Year: 2021, Month: 4, Day: 31, DayOfWeek: NotSpecified}, false},
+		{CosemDate{Year: YearNotSpecified, Month: 2, Day: 29, DayOfWeek: NotSpecified}, true},
+		{CosemDate{Year: YearNotSpecified, Month: 2, Day: 30, DayOfWeek: NotSpecified}, false},
+		{CosemDate{Year: 2021, Month: 2, Day: DayLastOfMonth, DayOfWeek: NotSpecified}, true},
+	}
+	for idx, table := range tables {
+		_, err := EncodeCosemDate(table.date)
+		if (err == nil) != table.valid {
+			t.Errorf("t%d failed. date: %v, err:%v", idx, table.date, err)
+		}
+	}
+
+	dt := CosemDateTime{Date: CosemDate{Year: 2021, Month: 2, Day: 31, DayOfWeek: NotSpecified}, Deviation: DeviationNotSpecified}
+	if _, err := dt.ToTime(); err == nil || err == ErrDateTimeNotSpecified {
+		t.Errorf("ToTime should refuse 31 February. err:%v", err)
+	}
+	if _, err := dt.Date.ToTime(); err == nil || err == ErrDateTimeNotSpecified {
+		t.Errorf("ToTime should refuse 31 February. err:%v", err)
+	}
+
+	src := []byte{byte(TagDateTime), 7, 229, 2, 31, 255, 0, 0, 0, 0, 128, 0, 0}
+	dec := NewDataDecoder(&src)
+	if d, err := dec.Decode(&src); err == nil {
+		t.Errorf("decoder should refuse 31 February. val: %v", d.Value)
+	}
+}
+
+func TestDecoder_DateTimeWildcard(t *testing.T) {
+	// wildcard date and time are kept, not turned into time.Time
+	src := []byte{byte(TagDate), 255, 255, 255, 255, 255}
+	decoded, err := NewDataDecoder(&src).Decode(&src)
+	if err != nil {
+		t.Fatalf("t1 failed. err:%v", err)
+	}
+	date := CosemDate{Year: YearNotSpecified, Month: NotSpecified, Day: NotSpecified, DayOfWeek: NotSpecified}
+	if decoded.Value != date {
+		t.Errorf("t1 failed. get: %v, should:%v", decoded.Value, date)
+	}
+	if back, err := decoded.CosemDate(); err != nil || back != date {
+		t.Errorf("t1 CosemDate failed. get: %v, err:%v", back, err)
+	}
+	if _, err = decoded.Time(); err != ErrDateTimeNotSpecified {
+		t.Errorf("t1 Time should fail. err:%v", err)
+	}
+
+	src = []byte{byte(TagTime), 255, 255, 255, 255}
+	decoded, err = NewDataDecoder(&src).Decode(&src)
+	if err != nil {
+		t.Fatalf("t2 failed. err:%v", err)
+	}
+	clock := CosemTime{Hour: NotSpecified, Minute: NotSpecified, Second: NotSpecified, Hundredths: NotSpecified}
+	if decoded.Value != clock {
+		t.Errorf("t2 failed. get: %v, should:%v", decoded.Value, clock)
+	}
+	if back, err := decoded.CosemTime(); err != nil || back != clock {
+		t.Errorf("t2 CosemTime failed. get: %v, err:%v", back, err)
+	}
+
+	// plain decoders refuse wildcards
+	src = []byte{255, 255, 255, 255, 255}
+	if _, _, err = DecodeDate(&src); err != ErrDateTimeNotSpecified || len(src) != 5 {
+		t.Errorf("t3 failed. err:%v", err)
+	}
+	src = []byte{255, 255, 255, 255}
+	if _, _, err = DecodeTime(&src); err != ErrDateTimeNotSpecified || len(src) != 4 {
+		t.Errorf("t4 failed. err:%v", err)
+	}
+
+	// specified values are time.Time, accessors read them both ways
+	src = []byte{byte(TagTime), 10, 20, 30, 255}
+	decoded, _ = NewDataDecoder(&src).Decode(&src)
+	should := time.Date(0, time.January, 1, 10, 20, 30, 0, time.UTC)
+	if decoded.Value != should {
+		t.Errorf("t5 failed. get: %v", decoded.Value)
+	}
+	if back, err := decoded.CosemTime(); err != nil || back != (CosemTime{10, 20, 30, 255}) {
+		t.Errorf("t5 CosemTime failed. get: %v, err:%v", back, err)
+	}
+	if value, err := decoded.Time(); err != nil || value != should {
+		t.Errorf("t5 Time failed. get: %v, err:%v", value, err)
+	}
+	d := CreateAxdrDateTime(time.Date(2020, time.March, 11, 18, 0, 0, 0, time.UTC))
+	if value, err := d.Time(); err != nil || !value.Equal(time.Date(2020, time.March, 11, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("t6 Time failed. get: %v, err:%v", value, err)
+	}
+	if _, err = CreateAxdrUnsigned(1).Time(); err == nil {
+		t.Errorf("t7 Time of unsigned should fail")
+	}
+}
@@ -13,216 +13,352 @@ import (
 
 type Decoder struct {
 	tag dataTag
+	err error
+	// Limits applied by Decode, taken from DefaultLimits on creation
+	Limits Limits
+	// ZeroCopy makes Raw, RawValue and RawLength of decoded data slice
+	// the input instead of a copy of it. Input must not be modified
+	// while decoded data is in use
+	ZeroCopy bool
 }
 
 var ErrLengthLess = errors.New("not enough byte length provided")
 
-// Get dataTag equivalent of supplied uint8
-func getDataTag(in uint8) (t dataTag) {
-	mapToDataTag := map[uint8]dataTag{
-		0:   TagNull,
-		1:   TagArray,
-		2:   TagStructure,
-		3:   TagBoolean,
-		4:   TagBitString,
-		5:   TagDoubleLong,
-		6:   TagDoubleLongUnsigned,
-		7:   TagFloatingPoint,
-		9:   TagOctetString,
-		10:  TagVisibleString,
-		12:  TagUTF8String,
-		13:  TagBCD,
-		15:  TagInteger,
-		16:  TagLong,
-		17:  TagUnsigned,
-		18:  TagLongUnsigned,
-		19:  TagCompactArray,
-		20:  TagLong64,
-		21:  TagLong64Unsigned,
-		22:  TagEnum,
-		23:  TagFloat32,
-		24:  TagFloat64,
-		25:  TagDateTime,
-		26:  TagDate,
-		27:  TagTime,
-		255: TagDontCare,
-	}
-
-	t = mapToDataTag[in]
-	return
+// Limits bound what Decode accepts, so hostile length fields cannot blow
+// memory up. Zero value of a field means no limit
+type Limits struct {
+	// MaxDepth is how deep arrays and structures may be nested
+	MaxDepth int
+	// MaxElements is how many elements an array, structure or compact array may have
+	MaxElements int
+	// MaxOctetString is the longest octet-string, visible-string or utf8-string in bytes
+	MaxOctetString int
+}
+
+// DefaultLimits is used by every new Decoder
+var DefaultLimits = Limits{
+	MaxDepth:       16,
+	MaxElements:    0xFFFF,
+	MaxOctetString: 0xFFFF,
+}
+
+// ErrLimitExceeded is wrapped by error of Decode when data is over Limits
+var ErrLimitExceeded = errors.New("decode limit exceeded")
+
+// DecodeError is returned by Decoder.Decode, it tells which element
+// failed. Offset is position of the element tag in the bytes given to
+// NewDataDecoder, or of its first byte inside compact array which has no
+// tags. Path locates the element inside its parents such as
+// "[2].structure[3].octet-string" and Tag is its data tag
+type DecodeError struct {
+	Offset int
+	Path   string
+	Tag    dataTag
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("axdr: decoding %s at offset %d: %v", e.Path, e.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Get dataTag equivalent of supplied uint8. Unknown byte is kept as it is
+// so Decode is able to tell it is unknown
+func getDataTag(in uint8) dataTag {
+	return dataTag(in)
+}
+
+// Tags which value is preceded by A-XDR length, looked up by tag byte
+var lengthAfterTag = [256]bool{
+	TagArray:         true,
+	TagStructure:     true,
+	TagBitString:     true,
+	TagOctetString:   true,
+	TagVisibleString: true,
+	TagUTF8String:    true,
 }
 
 // Create new decode from either supplied dataTag or byte slice pointer.
-// If input is byte slice, it will remove first byte from source
+// If input is byte slice, it will remove first byte from source. Empty
+// slice or other input gives decoder which fails on Decode
 func NewDataDecoder(in interface{}) *Decoder {
 	switch src := in.(type) {
 	case dataTag:
-		return &Decoder{tag: src}
+		return &Decoder{tag: src, Limits: DefaultLimits}
 
 	case *[]byte:
+		if src == nil || len(*src) < 1 {
+			return &Decoder{err: ErrLengthLess, Limits: DefaultLimits}
+		}
 		tag := getDataTag(uint8((*src)[0]))
 		(*src) = (*src)[1:]
-		return &Decoder{tag: tag}
+		return &Decoder{tag: tag, Limits: DefaultLimits}
 
 	default:
-		panic("Input must be either dataTag or byte slice pointer")
+		return &Decoder{err: fmt.Errorf("input must be either dataTag or byte slice pointer, received %T", in), Limits: DefaultLimits}
 	}
 }
 
-// Decode expect byte second after tag byte.
+// Decode expect byte second after tag byte. Error is *DecodeError
+// of the innermost element that failed
 func (dec *Decoder) Decode(ori *[]byte) (r DlmsData, err error) {
-	lengthAfterTag := map[dataTag]bool{
-		TagNull:               false,
-		TagArray:              true,
-		TagStructure:          true,
-		TagBoolean:            false,
-		TagBitString:          true,
-		TagDoubleLong:         false,
-		TagDoubleLongUnsigned: false,
-		TagFloatingPoint:      false,
-		TagOctetString:        true,
-		TagVisibleString:      true,
-		TagUTF8String:         true,
-		TagBCD:                false,
-		TagInteger:            false,
-		TagLong:               false,
-		TagUnsigned:           false,
-		TagLongUnsigned:       false,
-		TagCompactArray:       false,
-		TagLong64:             false,
-		TagLong64Unsigned:     false,
-		TagEnum:               false,
-		TagFloat32:            false,
-		TagFloat64:            false,
-		TagDateTime:           false,
-		TagDate:               false,
-		TagTime:               false,
-		TagDontCare:           false,
-	}
-
-	src := append([]byte(nil), (*ori)...)
+	if dec.err != nil {
+		return r, &DecodeError{Path: dec.tag.String(), Tag: dec.tag, Err: dec.err}
+	}
+
+	src := *ori
+	if !dec.ZeroCopy {
+		// copy only bytes of this element, every decoded element slices
+		// the copy. Broken element is left for decode to report
+		n, e := dec.size(dec.tag, src, 0)
+		if e != nil {
+			n = len(src)
+		}
+		src = append([]byte(nil), src[:n]...)
+	}
+	n, err := dec.decode(&r, src, 0, 0)
+	if err != nil {
+		return
+	}
+
+	// remove bytes from original on success
+	(*ori) = (*ori)[n:]
+	return
+}
+
+// Decode element into r, src starts right after its tag which is at
+// offset. It returns count of bytes read from src
+func (dec *Decoder) decode(r *DlmsData, src []byte, offset int, depth int) (n int, err error) {
+	defer func() {
+		if err == nil {
+			return
+		}
+		if _, ok := err.(*DecodeError); !ok {
+			err = &DecodeError{Offset: offset, Path: dec.tag.String(), Tag: dec.tag, Err: err}
+		}
+	}()
 
 	r.Tag = dec.tag
 	haveLength := lengthAfterTag[dec.tag]
-	var lengthByte []byte
 	var lengthInt uint64
 	if haveLength {
-		lengthByte, lengthInt, err = DecodeLength(&src)
-		if err != nil {
+		rest := src
+		if _, lengthInt, err = DecodeLength(&rest); err != nil {
 			return
 		}
-		r.rawLength = lengthByte
+		n = len(src) - len(rest)
+	}
+	if err = dec.checkLimits(depth, lengthInt, len(src)-n); err != nil {
+		return
 	}
 
-	var rawValue []byte
+	rest := src[n:]
 	var value interface{}
 	switch dec.tag {
 	case TagNull:
-		err = fmt.Errorf("not yet implemented")
-	case TagArray:
-		output := make([]*DlmsData, lengthInt)
-		// make carbon copy of src to calc rawValue later
-		temp := append([]byte(nil), src...)
-		for i := 0; i < int(lengthInt); i++ {
-			thisDecoder := NewDataDecoder(&temp)
-			thisDlmsData, thisError := thisDecoder.Decode(&temp)
-			if thisError != nil {
-				err = thisError
-				return
-			}
-			output[i] = &thisDlmsData
-		}
-		rawValue = src[:len(src)-len(temp)]
-		value = output
-
-	case TagStructure:
-		// same same as array
-		output := make([]*DlmsData, lengthInt)
-		// make carbon copy of src to calc rawValue later
-		temp := append([]byte(nil), src...)
-		for i := 0; i < int(lengthInt); i++ {
-			thisDecoder := NewDataDecoder(&temp)
-			thisDlmsData, thisError := thisDecoder.Decode(&temp)
-			if thisError != nil {
-				err = thisError
-				return
-			}
-			// fmt.Printf("%v: %v; rawLength: %v; rawValue: %v; raw: %v;\n", thisDecoder, thisDlmsData, thisDlmsData.rawLength, thisDlmsData.rawValue, thisDlmsData.raw)
-			output[i] = &thisDlmsData
-		}
-		rawValue = src[:len(src)-len(temp)]
-		value = output
-
+	case TagArray, TagStructure:
+		value, err = dec.decodeElements(&rest, offset+1+n, depth, int(lengthInt))
 	case TagBoolean:
-		rawValue, value, err = DecodeBoolean(&src)
+		_, value, err = DecodeBoolean(&rest)
 	case TagBitString:
-		rawValue, value, err = DecodeBitString(&src, lengthInt)
+		_, value, err = DecodeBitString(&rest, lengthInt)
 	case TagDoubleLong:
-		rawValue, value, err = DecodeDoubleLong(&src)
+		_, value, err = DecodeDoubleLong(&rest)
 	case TagDoubleLongUnsigned:
-		rawValue, value, err = DecodeDoubleLongUnsigned(&src)
+		_, value, err = DecodeDoubleLongUnsigned(&rest)
 	case TagFloatingPoint:
-		rawValue, value, err = DecodeFloat32(&src)
+		_, value, err = DecodeFloat32(&rest)
 	case TagOctetString:
-		rawValue, value, err = DecodeOctetString(&src, lengthInt)
+		_, value, err = DecodeOctetString(&rest, lengthInt)
 	case TagVisibleString:
-		rawValue, value, err = DecodeVisibleString(&src, lengthInt)
+		_, value, err = DecodeVisibleString(&rest, lengthInt)
 	case TagUTF8String:
-		rawValue, value, err = DecodeUTF8String(&src, lengthInt)
+		_, value, err = DecodeUTF8String(&rest, lengthInt)
 	case TagBCD:
-		rawValue, value, err = DecodeBCD(&src)
+		_, value, err = DecodeBCD(&rest)
 	case TagInteger:
-		rawValue, value, err = DecodeInteger(&src)
+		_, value, err = DecodeInteger(&rest)
 	case TagLong:
-		rawValue, value, err = DecodeLong(&src)
+		_, value, err = DecodeLong(&rest)
 	case TagUnsigned:
-		rawValue, value, err = DecodeUnsigned(&src)
+		_, value, err = DecodeUnsigned(&rest)
 	case TagLongUnsigned:
-		rawValue, value, err = DecodeLongUnsigned(&src)
+		_, value, err = DecodeLongUnsigned(&rest)
 	case TagCompactArray:
-		err = fmt.Errorf("not yet implemented")
+		_, r.description, value, err = decodeCompactArray(&rest, offset+1, depth, dec.Limits)
 	case TagLong64:
-		rawValue, value, err = DecodeLong64(&src)
+		_, value, err = DecodeLong64(&rest)
 	case TagLong64Unsigned:
-		rawValue, value, err = DecodeLong64Unsigned(&src)
+		_, value, err = DecodeLong64Unsigned(&rest)
 	case TagEnum:
-		rawValue, value, err = DecodeEnum(&src)
+		_, value, err = DecodeEnum(&rest)
 	case TagFloat32:
-		rawValue, value, err = DecodeFloat32(&src)
+		_, value, err = DecodeFloat32(&rest)
 	case TagFloat64:
-		rawValue, value, err = DecodeFloat64(&src)
+		_, value, err = DecodeFloat64(&rest)
 	case TagDateTime:
-		rawValue, value, err = DecodeDateTime(&src)
+		value, err = decodeDateTimeValue(&rest)
 	case TagDate:
-		rawValue, value, err = DecodeDate(&src)
+		value, err = decodeDateValue(&rest)
 	case TagTime:
-		rawValue, value, err = DecodeTime(&src)
+		value, err = decodeTimeValue(&rest)
 	case TagDontCare:
 		err = fmt.Errorf("not yet implemented")
+	default:
+		err = fmt.Errorf("data tag %v is not recognized", dec.tag)
 	}
-
 	if err != nil {
 		return
 	}
 
-	r.rawValue = rawValue
+	end := len(src) - len(rest)
 	r.Value = value
-	r.raw.WriteByte(byte(dec.tag))
+	r.rawValue = src[n:end:end]
 	if haveLength {
-		r.raw.Write(lengthByte)
+		r.rawLength = src[:n:n]
 	} else {
-		r.rawLength = []byte{byte(len(rawValue))}
+		r.rawLength = []byte{byte(end - n)}
 	}
-	r.raw.Write(rawValue)
 
-	// remove bytes from original on success
-	(*ori) = (*ori)[len(r.raw.Bytes())-1:]
+	n = end
+	return
+}
+
+// Count of bytes taken by element of tag, src starts right after the
+// tag. Only lengths are read, values are checked by decode
+func (dec *Decoder) size(tag dataTag, src []byte, depth int) (n int, err error) {
+	var length uint64
+	if lengthAfterTag[tag] {
+		rest := src
+		if _, length, err = DecodeLength(&rest); err != nil {
+			return
+		}
+		n = len(src) - len(rest)
+		if length > uint64(len(rest))*8 {
+			return 0, ErrLengthLess
+		}
+	}
+
+	switch tag {
+	case TagNull:
+	case TagBoolean, TagBCD, TagInteger, TagUnsigned, TagEnum:
+		n = 1
+	case TagLong, TagLongUnsigned:
+		n = 2
+	case TagDoubleLong, TagDoubleLongUnsigned, TagFloatingPoint, TagFloat32, TagTime:
+		n = 4
+	case TagDate:
+		n = 5
+	case TagLong64, TagLong64Unsigned, TagFloat64:
+		n = 8
+	case TagDateTime:
+		n = 12
+	case TagBitString:
+		n += int((length + 7) / 8)
+	case TagOctetString, TagVisibleString, TagUTF8String:
+		n += int(length)
+	case TagArray, TagStructure:
+		if dec.Limits.MaxDepth > 0 && depth >= dec.Limits.MaxDepth {
+			return 0, ErrLimitExceeded
+		}
+		for i := uint64(0); i < length; i++ {
+			if n >= len(src) {
+				return 0, ErrLengthLess
+			}
+			m, e := dec.size(getDataTag(src[n]), src[n+1:], depth+1)
+			if e != nil {
+				return 0, e
+			}
+			n += 1 + m
+		}
+	case TagCompactArray:
+		rest := src
+		if _, err = decodeTypeDescription(&rest, dec.Limits, 0); err != nil {
+			return
+		}
+		if _, length, err = DecodeLength(&rest); err != nil {
+			return
+		}
+		if length > uint64(len(rest)) {
+			return 0, ErrLengthLess
+		}
+		n = len(src) - len(rest) + int(length)
+	default:
+		return 0, fmt.Errorf("data tag %v is not recognized", tag)
+	}
 
-	// fmt.Printf("Tag: %v; Value: %v; rawLength: %v; rawValue: %v; raw: %v;\n", r.Tag, r.Value, r.rawLength, r.rawValue, r.raw)
+	if n > len(src) {
+		return 0, ErrLengthLess
+	}
 	return
 }
 
+// Decode count elements of array or structure from src. Elements are
+// stored in single slice so each of them is not allocated on its own
+func (dec *Decoder) decodeElements(src *[]byte, offset int, depth int, count int) (out []*DlmsData, err error) {
+	items := make([]DlmsData, count)
+	out = make([]*DlmsData, count)
+	pos := 0
+	for i := 0; i < count; i++ {
+		if pos >= len(*src) {
+			err = &DecodeError{Offset: offset + pos, Path: dec.childPath(depth, i, ""), Tag: dec.tag, Err: ErrLengthLess}
+			return
+		}
+		child := Decoder{tag: getDataTag((*src)[pos]), Limits: dec.Limits}
+		n, e := child.decode(&items[i], (*src)[pos+1:], offset+pos, depth+1)
+		if e != nil {
+			de := e.(*DecodeError)
+			de.Path = dec.childPath(depth, i, "."+de.Path)
+			err = de
+			return
+		}
+		out[i] = &items[i]
+		pos += 1 + n
+	}
+	(*src) = (*src)[pos:]
+	return
+}
+
+// Path of i-th element followed by rest. Children of root are found by index only
+func (dec *Decoder) childPath(depth int, i int, rest string) string {
+	if depth == 0 {
+		return fmt.Sprintf("[%d]%s", i, rest)
+	}
+	return fmt.Sprintf("%s[%d]%s", dec.tag, i, rest)
+}
+
+// Check length of element against limits. Every element of array or
+// structure takes at least a byte, so count bigger than bytes left fails
+// before anything is allocated
+func (dec *Decoder) checkLimits(depth int, length uint64, left int) error {
+	l := dec.Limits
+	switch dec.tag {
+	case TagArray, TagStructure:
+		if l.MaxDepth > 0 && depth >= l.MaxDepth {
+			return fmt.Errorf("%w: nesting is deeper than %d", ErrLimitExceeded, l.MaxDepth)
+		}
+		if l.MaxElements > 0 && length > uint64(l.MaxElements) {
+			return fmt.Errorf("%w: %d elements, limit is %d", ErrLimitExceeded, length, l.MaxElements)
+		}
+		if length > uint64(left) {
+			return ErrLengthLess
+		}
+	case TagOctetString, TagVisibleString, TagUTF8String:
+		if l.MaxOctetString > 0 && length > uint64(l.MaxOctetString) {
+			return fmt.Errorf("%w: %d bytes string, limit is %d", ErrLimitExceeded, length, l.MaxOctetString)
+		}
+	}
+	return nil
+}
+
 func DecodeLength(src *[]byte) (outByte []byte, outVal uint64, err error) {
+	if len(*src) < 1 {
+		err = ErrLengthLess
+		return
+	}
 	if (*src)[0] > byte(128) {
 		lOfLength := int((*src)[0]) - 128 // L-of-length part
 		if len((*src)) < lOfLength+1 {
@@ -258,6 +394,220 @@ func DecodeLength(src *[]byte) (outByte []byte, outVal uint64, err error) {
 	return
 }
 
+// Decode contents-description of compact array, see typeDescription
+func decodeTypeDescription(src *[]byte, limits Limits, depth int) (out typeDescription, err error) {
+	if len(*src) < 1 {
+		err = ErrLengthLess
+		return
+	}
+	if limits.MaxDepth > 0 && depth >= limits.MaxDepth {
+		err = fmt.Errorf("%w: nesting is deeper than %d", ErrLimitExceeded, limits.MaxDepth)
+		return
+	}
+	out.tag = getDataTag(uint8((*src)[0]))
+	(*src) = (*src)[1:]
+
+	switch out.tag {
+	case TagArray:
+		_, count, e := DecodeLongUnsigned(src)
+		if e != nil {
+			err = e
+			return
+		}
+		el, e := decodeTypeDescription(src, limits, depth+1)
+		if e != nil {
+			err = e
+			return
+		}
+		if count == 0 {
+			err = fmt.Errorf("compact array element of type %v has no content", out.tag)
+			return
+		}
+		out.count = int(count)
+		out.elements = []typeDescription{el}
+
+	case TagStructure:
+		if len(*src) < 1 {
+			err = ErrLengthLess
+			return
+		}
+		_, count, e := DecodeLength(src)
+		if e != nil {
+			err = e
+			return
+		}
+		if count > uint64(len(*src)) {
+			err = ErrLengthLess
+			return
+		}
+		for i := 0; i < int(count); i++ {
+			el, e := decodeTypeDescription(src, limits, depth+1)
+			if e != nil {
+				err = e
+				return
+			}
+			out.elements = append(out.elements, el)
+		}
+		if count == 0 {
+			err = fmt.Errorf("compact array element of type %v has no content", out.tag)
+			return
+		}
+		out.count = int(count)
+
+	case TagCompactArray:
+		err = fmt.Errorf("compact array cannot be nested in another compact array")
+		return
+
+	case TagNull, TagDontCare:
+		// element without content would let any count pass the length check
+		err = fmt.Errorf("compact array element of type %v has no content", out.tag)
+		return
+	}
+
+	if limits.MaxElements > 0 && out.weight(limits.MaxElements) > limits.MaxElements {
+		err = fmt.Errorf("%w: compact array element has more than %d values", ErrLimitExceeded, limits.MaxElements)
+	}
+	return
+}
+
+// Number of values in one element of type description, counting nested
+// arrays and structures as well. Counting stops once it is over max.
+func (td typeDescription) weight(max int) int {
+	n := 1
+	switch td.tag {
+	case TagArray:
+		w := td.elements[0].weight(max)
+		if td.count > max/w {
+			return max + 1
+		}
+		n += td.count * w
+	case TagStructure:
+		for _, el := range td.elements {
+			n += el.weight(max)
+			if n > max {
+				return max + 1
+			}
+		}
+	}
+	if n > max {
+		return max + 1
+	}
+	return n
+}
+
+// Decode single element of compact array which has no tag, offset is
+// position of its first byte. Error is *DecodeError of the innermost
+// element that failed, with Path as Decoder.Decode gives it
+func decodeCompactValue(td typeDescription, src *[]byte, offset int, limits Limits) (out *DlmsData, err error) {
+	defer func() {
+		if err == nil {
+			return
+		}
+		if _, ok := err.(*DecodeError); !ok {
+			err = &DecodeError{Offset: offset, Path: td.tag.String(), Tag: td.tag, Err: err}
+		}
+	}()
+
+	switch td.tag {
+	case TagArray, TagStructure:
+		// every element takes at least a byte
+		if td.count > len(*src) {
+			err = ErrLengthLess
+			return
+		}
+		output := make([]*DlmsData, td.count)
+		start := len(*src)
+		for i := 0; i < td.count; i++ {
+			el := td.elements[0]
+			if td.tag == TagStructure {
+				el = td.elements[i]
+			}
+			output[i], err = decodeCompactValue(el, src, offset+start-len(*src), limits)
+			if err != nil {
+				de := err.(*DecodeError)
+				de.Path = fmt.Sprintf("%s[%d].%s", td.tag, i, de.Path)
+				return
+			}
+		}
+		// packed bytes have no tags, raw is left unset to be encoded again
+		out = &DlmsData{Tag: td.tag, Value: output}
+
+	default:
+		// contents are already sliced out of decoded input, no need to copy
+		decoder := Decoder{tag: td.tag, Limits: limits}
+		out = &DlmsData{}
+		n, e := decoder.decode(out, *src, offset, 0)
+		if e != nil {
+			err = e
+			return
+		}
+		(*src) = (*src)[n:]
+	}
+
+	return
+}
+
+// Decode compact array into slice of DlmsData, same as TagArray. Input
+// starts with contents-description followed by length (in bytes) of contents
+func DecodeCompactArray(src *[]byte) (outByte []byte, outVal []*DlmsData, err error) {
+	outByte, _, outVal, err = decodeCompactArray(src, 0, 0, DefaultLimits)
+	return
+}
+
+// decodeCompactArray also returns contents-description as it is in src.
+// Offset is position of src and depth the one of compact array, element
+// which fails is reported by *DecodeError as in Decoder.Decode
+func decodeCompactArray(src *[]byte, offset int, depth int, limits Limits) (outByte []byte, outDesc []byte, outVal []*DlmsData, err error) {
+	temp := *src
+
+	td, err := decodeTypeDescription(&temp, limits, 0)
+	if err != nil {
+		return
+	}
+	outDesc = (*src)[: len(*src)-len(temp) : len(*src)-len(temp)]
+	if len(temp) < 1 {
+		err = ErrLengthLess
+		return
+	}
+	_, length, err := DecodeLength(&temp)
+	if err != nil {
+		return
+	}
+	if uint64(len(temp)) < length {
+		err = ErrLengthLess
+		return
+	}
+
+	contents := temp[:length]
+	contentsOffset := offset + len(*src) - len(temp)
+	parent := Decoder{tag: TagCompactArray}
+	outVal = []*DlmsData{}
+	for len(contents) > 0 {
+		before := len(contents)
+		dt, e := decodeCompactValue(td, &contents, contentsOffset+int(length)-before, limits)
+		if e != nil {
+			de := e.(*DecodeError)
+			de.Path = parent.childPath(depth, len(outVal), "."+de.Path)
+			err = de
+			return
+		}
+		if len(contents) == before {
+			err = fmt.Errorf("compact array element of type %v has no content", td.tag)
+			return
+		}
+		if limits.MaxElements > 0 && len(outVal) >= limits.MaxElements {
+			err = fmt.Errorf("%w: compact array has more than %d elements", ErrLimitExceeded, limits.MaxElements)
+			return
+		}
+		outVal = append(outVal, dt)
+	}
+
+	temp = temp[length:]
+	outByte = (*src)[:len(*src)-len(temp)]
+	(*src) = temp
+	return
+}
+
 func DecodeBoolean(src *[]byte) (outByte []byte, outVal bool, err error) {
 	if len(*src) < 1 {
 		err = ErrLengthLess
@@ -274,8 +624,11 @@ func DecodeBoolean(src *[]byte) (outByte []byte, outVal bool, err error) {
 }
 
 func DecodeBitString(src *[]byte, length uint64) (outByte []byte, outVal string, err error) {
-	byteLength := int(math.Ceil(float64(length) / 8))
-	if len(*src) < byteLength {
+	byteLength := length / 8
+	if length%8 != 0 {
+		byteLength++
+	}
+	if uint64(len(*src)) < byteLength {
 		err = ErrLengthLess
 		return
 	}
@@ -367,6 +720,10 @@ func DecodeUTF8String(src *[]byte, length uint64) (outByte []byte, outVal string
 }
 
 func DecodeBCD(src *[]byte) (outByte []byte, outVal int8, err error) {
+	if len(*src) < 1 {
+		err = ErrLengthLess
+		return
+	}
 	outByte = (*src)[:1]
 	outVal = int8(outByte[0])
 	(*src) = (*src)[1:]
@@ -374,6 +731,10 @@ func DecodeBCD(src *[]byte) (outByte []byte, outVal int8, err error) {
 }
 
 func DecodeInteger(src *[]byte) (outByte []byte, outVal int8, err error) {
+	if len(*src) < 1 {
+		err = ErrLengthLess
+		return
+	}
 	outByte = (*src)[:1]
 	outVal = int8(outByte[0])
 	(*src) = (*src)[1:]
@@ -393,6 +754,10 @@ func DecodeLong(src *[]byte) (outByte []byte, outVal int16, err error) {
 }
 
 func DecodeUnsigned(src *[]byte) (outByte []byte, outVal uint8, err error) {
+	if len(*src) < 1 {
+		err = ErrLengthLess
+		return
+	}
 	outByte = (*src)[:1]
 	outVal = uint8(outByte[0])
 	(*src) = (*src)[1:]
@@ -448,6 +813,10 @@ func DecodeLong64Unsigned(src *[]byte) (outByte []byte, outVal uint64, err error
 }
 
 func DecodeEnum(src *[]byte) (outByte []byte, outVal uint8, err error) {
+	if len(*src) < 1 {
+		err = ErrLengthLess
+		return
+	}
 	outByte = (*src)[:1]
 	outVal = uint8(outByte[0])
 	(*src) = (*src)[1:]
@@ -482,21 +851,20 @@ func DecodeFloat64(src *[]byte) (outByte []byte, outVal float64, err error) {
 // month,
 // day of month,
 // day of week
+// Date with wildcard gives ErrDateTimeNotSpecified, use DecodeCosemDate
+// to keep them
 func DecodeDate(src *[]byte) (outByte []byte, outVal time.Time, err error) {
-	if len(*src) < 5 {
-		err = ErrLengthLess
+	temp := *src
+	outByte, d, err := DecodeCosemDate(&temp)
+	if err != nil {
 		return
 	}
-	outByte = (*src)[:5]
-
-	year := int(binary.BigEndian.Uint16(outByte[0:2]))
-	month := int(outByte[2])
-	day := int(outByte[3])
-	// weekday := int(outByte[4])
-
-	outVal = time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
-
-	(*src) = (*src)[5:]
+	outVal, err = d.ToTime()
+	if err != nil {
+		outByte = nil
+		return
+	}
+	(*src) = temp
 	return
 }
 
@@ -505,21 +873,20 @@ func DecodeDate(src *[]byte) (outByte []byte, outVal time.Time, err error) {
 // minute,
 // second,
 // hundredths
+// Time with wildcard gives ErrDateTimeNotSpecified, use DecodeCosemTime
+// to keep them
 func DecodeTime(src *[]byte) (outByte []byte, outVal time.Time, err error) {
-	if len(*src) < 4 {
-		err = ErrLengthLess
+	temp := *src
+	outByte, t, err := DecodeCosemTime(&temp)
+	if err != nil {
 		return
 	}
-	outByte = (*src)[:4]
-
-	hour := int(outByte[0])
-	minute := int(outByte[1])
-	second := int(outByte[2])
-	hundredths := int(outByte[3])
-
-	outVal = time.Date(0, time.Month(1), 1, hour, minute, second, hundredths, time.UTC)
-
-	(*src) = (*src)[4:]
+	outVal, err = t.ToTime()
+	if err != nil {
+		outByte = nil
+		return
+	}
+	(*src) = temp
 	return
 }
 
@@ -533,32 +900,59 @@ func DecodeTime(src *[]byte) (outByte []byte, outVal time.Time, err error) {
 // minute,
 // second,
 // hundredths of second,
-// deviation highbyte, -- interpreted as long in minutes of local time of UTC
+// deviation highbyte, -- interpreted as long in minutes of local time of UTC, 0x8000 gives UTC
 // deviation lowbyte,
 // clock status -- 0x00 means ok, 0xFF means not specified
+// Clock status is lost. Date-time with wildcard in date or clock gives
+// ErrDateTimeNotSpecified, use DecodeCosemDateTime to keep them
 func DecodeDateTime(src *[]byte) (outByte []byte, outVal time.Time, err error) {
-	if len(*src) < 12 {
-		err = ErrLengthLess
+	temp := *src
+	outByte, dt, err := DecodeCosemDateTime(&temp)
+	if err != nil {
 		return
 	}
-	outByte = (*src)[:12]
-
-	if outByte[11] == 0xff {
-		err = fmt.Errorf("clock status value(%v) not OK(0x00)", outByte[11])
+	outVal, err = dt.ToTime()
+	if err != nil {
+		outByte = nil
 		return
 	}
+	(*src) = temp
+	return
+}
 
-	year := int(binary.BigEndian.Uint16(outByte[0:2]))
-	month := int(outByte[2])
-	day := int(outByte[3])
-	// weekday := int(outByte[4])
-	hour := int(outByte[5])
-	minute := int(outByte[6])
-	second := int(outByte[7])
-	hundredths := int(outByte[8])
-	// deviation := int(binary.BigEndian.Uint16(outByte[9:11]))
+// Date-time with wildcards can not be time.Time, so such value
+// is kept as CosemDateTime
+func decodeDateTimeValue(src *[]byte) (interface{}, error) {
+	_, dt, err := DecodeCosemDateTime(src)
+	if err != nil {
+		return nil, err
+	}
+	if !dt.IsSpecified() {
+		return dt, nil
+	}
+	return dt.ToTime()
+}
 
-	outVal = time.Date(year, time.Month(month), day, hour, minute, second, hundredths, time.UTC)
-	(*src) = (*src)[12:]
-	return
+// Date with wildcards is kept as CosemDate, same as date-time
+func decodeDateValue(src *[]byte) (interface{}, error) {
+	_, d, err := DecodeCosemDate(src)
+	if err != nil {
+		return nil, err
+	}
+	if !d.IsSpecified() {
+		return d, nil
+	}
+	return d.ToTime()
+}
+
+// Time with wildcards is kept as CosemTime, same as date-time
+func decodeTimeValue(src *[]byte) (interface{}, error) {
+	_, t, err := DecodeCosemTime(src)
+	if err != nil {
+		return nil, err
+	}
+	if !t.IsSpecified() {
+		return t, nil
+	}
+	return t.ToTime()
 }
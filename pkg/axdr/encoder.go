@@ -335,7 +335,7 @@ func EncodeTime(data time.Time) ([]byte, error) {
 	output[0] = byte(data.Hour())
 	output[1] = byte(data.Minute())
 	output[2] = byte(data.Second())
-	output[3] = byte(data.Nanosecond())
+	output[3] = byte(data.Nanosecond() / 10000000)
 
 	return output, nil
 }
@@ -350,9 +350,10 @@ func EncodeTime(data time.Time) ([]byte, error) {
 // minute,
 // second,
 // hundredths of second,
-// deviation highbyte, -- interpreted as long in minutes of local time of UTC
+// deviation highbyte, -- interpreted as long in minutes of local time of UTC, taken from location
 // deviation lowbyte,
 // clock status -- 0x00 means ok, 0xFF means not specified
+// Use EncodeCosemDateTime to write wildcards or clock status
 func EncodeDateTime(data time.Time) ([]byte, error) {
 	output := make([]byte, 12)
 
@@ -367,10 +368,223 @@ func EncodeDateTime(data time.Time) ([]byte, error) {
 	output[5] = byte(data.Hour())
 	output[6] = byte(data.Minute())
 	output[7] = byte(data.Second())
-	output[8] = byte(data.Nanosecond())
-	output[9] = 0x00
-	output[10] = 0x00
+	output[8] = byte(data.Nanosecond() / 10000000)
+	_, offset := data.Zone()
+	binary.BigEndian.PutUint16(output[9:11], uint16(int16(-offset/60)))
 	output[11] = 0x00
 
 	return output, nil
 }
+
+// typeDescription is the contents-description of a compact array.
+// Simple types only carry their tag, array carries the number of
+// elements and type of the element, while structure carries type of
+// every member.
+type typeDescription struct {
+	tag      dataTag
+	count    int
+	elements []typeDescription
+}
+
+// Build typeDescription out of existing DlmsData. Array inside compact
+// array must have at least one element to know the type of the elements,
+// structure at least one member. Null-data and dont-care have no content
+// and are refused, as by decodeTypeDescription
+func typeDescriptionOf(d *DlmsData) (out typeDescription, err error) {
+	out.tag = d.Tag
+
+	switch d.Tag {
+	case TagArray:
+		data, ok := d.Value.([]*DlmsData)
+		if !ok {
+			err = fmt.Errorf("cannot encode value %v with tag %v", d.Value, d.Tag)
+			return
+		}
+		if len(data) < 1 || len(data) > 65535 {
+			err = fmt.Errorf("array inside compact array must have 1 to 65535 elements")
+			return
+		}
+		el, e := typeDescriptionOf(data[0])
+		if e != nil {
+			err = e
+			return
+		}
+		out.count = len(data)
+		out.elements = []typeDescription{el}
+
+	case TagStructure:
+		data, ok := d.Value.([]*DlmsData)
+		if !ok {
+			err = fmt.Errorf("cannot encode value %v with tag %v", d.Value, d.Tag)
+			return
+		}
+		if len(data) < 1 {
+			err = fmt.Errorf("structure inside compact array must have at least one member")
+			return
+		}
+		out.count = len(data)
+		for _, dt := range data {
+			el, e := typeDescriptionOf(dt)
+			if e != nil {
+				err = e
+				return
+			}
+			out.elements = append(out.elements, el)
+		}
+
+	case TagCompactArray:
+		err = fmt.Errorf("compact array cannot be nested in another compact array")
+
+	case TagNull, TagDontCare:
+		err = fmt.Errorf("compact array element of type %v has no content", d.Tag)
+	}
+
+	return
+}
+
+// Check if data is of the same type as described
+func (td typeDescription) match(d *DlmsData) bool {
+	if d == nil || d.Tag != td.tag {
+		return false
+	}
+	if td.tag != TagArray && td.tag != TagStructure {
+		return true
+	}
+
+	data, ok := d.Value.([]*DlmsData)
+	if !ok || len(data) != td.count {
+		return false
+	}
+	for i, dt := range data {
+		el := td.elements[0]
+		if td.tag == TagStructure {
+			el = td.elements[i]
+		}
+		if !el.match(dt) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (td typeDescription) encode() ([]byte, error) {
+	var output bytes.Buffer
+	output.WriteByte(byte(td.tag))
+
+	switch td.tag {
+	case TagArray:
+		count, _ := EncodeLongUnsigned(uint16(td.count))
+		output.Write(count)
+		el, err := td.elements[0].encode()
+		if err != nil {
+			return []byte{}, err
+		}
+		output.Write(el)
+
+	case TagStructure:
+		count, err := EncodeLength(td.count)
+		if err != nil {
+			return []byte{}, err
+		}
+		output.Write(count)
+		for _, e := range td.elements {
+			el, err := e.encode()
+			if err != nil {
+				return []byte{}, err
+			}
+			output.Write(el)
+		}
+	}
+
+	return output.Bytes(), nil
+}
+
+// Encodes the value of an element without its tag. Array & structure
+// also drop their length as it is already known from typeDescription
+func encodeCompactValue(d *DlmsData) ([]byte, error) {
+	switch d.Tag {
+	case TagArray, TagStructure:
+		var output bytes.Buffer
+		for _, dt := range d.Value.([]*DlmsData) {
+			res, err := encodeCompactValue(dt)
+			if err != nil {
+				return []byte{}, err
+			}
+			output.Write(res)
+		}
+		return output.Bytes(), nil
+
+	default:
+		res, err := d.AppendEncode(nil)
+		if err != nil {
+			return []byte{}, err
+		}
+		return res[1:], nil
+	}
+}
+
+// Encodes array of same typed DlmsData into compact array value, which is
+// contents-description followed by length of the contents and the contents.
+// Type of the elements is taken from the first element, so empty array
+// needs EncodeCompactArrayOf.
+// Sample: [{long-unsigned 1, octet-string 0102}] -> 02 02 12 09 05 00 01 02 01 02
+func EncodeCompactArray(data []*DlmsData) ([]byte, error) {
+	if len(data) == 0 {
+		return []byte{}, fmt.Errorf("compact array must have at least one element, or use EncodeCompactArrayOf")
+	}
+
+	td, err := typeDescriptionOf(data[0])
+	if err != nil {
+		return []byte{}, err
+	}
+	return encodeCompactArray(td, data)
+}
+
+// EncodeCompactArrayOf is EncodeCompactArray with type of the elements
+// given as encoded contents-description, such as 02 02 12 09 for structure
+// of long-unsigned and octet-string. Data may be empty.
+// Sample: 12, [] -> 12 00
+func EncodeCompactArrayOf(description []byte, data []*DlmsData) ([]byte, error) {
+	src := description
+	td, err := decodeTypeDescription(&src, DefaultLimits, 0)
+	if err != nil {
+		return []byte{}, err
+	}
+	if len(src) != 0 {
+		return []byte{}, fmt.Errorf("contents-description has %v bytes left over", len(src))
+	}
+	return encodeCompactArray(td, data)
+}
+
+func encodeCompactArray(td typeDescription, data []*DlmsData) ([]byte, error) {
+	var output bytes.Buffer
+
+	desc, err := td.encode()
+	if err != nil {
+		return []byte{}, err
+	}
+
+	var contents bytes.Buffer
+	for i, d := range data {
+		if !td.match(d) {
+			return []byte{}, fmt.Errorf("element %v of compact array does not match type of the first element", i)
+		}
+		res, err := encodeCompactValue(d)
+		if err != nil {
+			return []byte{}, err
+		}
+		contents.Write(res)
+	}
+
+	length, err := EncodeLength(contents.Len())
+	if err != nil {
+		return []byte{}, err
+	}
+
+	output.Write(desc)
+	output.Write(length)
+	output.Write(contents.Bytes())
+
+	return output.Bytes(), nil
+}
@@ -0,0 +1,57 @@
+package axdr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzDecoder(f *testing.F) {
+	f.Add([]byte{1, 3, 17, 1, 17, 2, 2, 4, 17, 0, 17, 0, 17, 0, 9, 2, 0xAA, 0xBB})
+	f.Add([]byte{2, 2, 9, 6, 1, 0, 0, 3, 0, 255, 18, 0, 2})
+	f.Add([]byte{4, 10, 0xAB, 0xC0})
+	f.Add([]byte{19, 2, 2, 18, 17, 6, 0, 1, 2, 0, 3, 4})
+	f.Add([]byte{25, 7, 228, 12, 31, 4, 23, 59, 58, 0, 0x80, 0, 0})
+	f.Add([]byte{10, 0x82, 0xFF, 0xFF, 'a'})
+	f.Add([]byte{1, 0x88, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	f.Add(append([]byte{19, 1, 0xFF, 0xFF, 1, 0xFF, 0xFF, 2, 0, 0x82, 0xFF, 0xFF}, make([]byte, 0xFFFF)...))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		src := append([]byte(nil), data...)
+		dec := NewDataDecoder(&src)
+		out, err := dec.Decode(&src)
+
+		// copying only bytes of element decodes same as input itself
+		zc := append([]byte(nil), data...)
+		zcDec := NewDataDecoder(&zc)
+		zcDec.ZeroCopy = true
+		zcOut, zcErr := zcDec.Decode(&zc)
+		if (err == nil) != (zcErr == nil) {
+			t.Fatalf("error %v differs from zero copy error %v", err, zcErr)
+		}
+		if err != nil {
+			return
+		}
+		// decoded bytes are kept as they are
+		raw := out.Raw()
+		if !bytes.Equal(raw, data[:len(raw)]) || len(src) != len(data)-len(raw) {
+			t.Errorf("decoded %X does not match input %X", raw, data)
+		}
+		if !bytes.Equal(raw, zcOut.Raw()) {
+			t.Errorf("decoded %X does not match zero copy %X", raw, zcOut.Raw())
+		}
+	})
+}
+
+func FuzzDecodeLength(f *testing.F) {
+	f.Add([]byte{0x7F})
+	f.Add([]byte{0x82, 0x01, 0x00})
+	f.Add([]byte{0x89, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		src := append([]byte(nil), data...)
+		outByte, _, err := DecodeLength(&src)
+		if err == nil && len(outByte)+len(src) != len(data) {
+			t.Errorf("length %X and rest %X do not add up to %X", outByte, src, data)
+		}
+	})
+}
@@ -0,0 +1,657 @@
+package axdr
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+var cosemDateTimeType = reflect.TypeOf(CosemDateTime{})
+var cosemDateType = reflect.TypeOf(CosemDate{})
+var cosemTimeType = reflect.TypeOf(CosemTime{})
+var dlmsDataType = reflect.TypeOf(DlmsData{})
+
+type fieldTag struct {
+	name string
+	tag  dataTag
+	hex  bool
+	obis bool
+	skip bool
+}
+
+func parseFieldTag(str string) (out fieldTag, err error) {
+	if str == "-" {
+		out.skip = true
+		return
+	}
+
+	parts := strings.Split(str, ",")
+	out.name = strings.TrimSpace(parts[0])
+	if out.name != "" {
+		found := false
+		for t, name := range dataTagName {
+			if name == out.name {
+				out.tag = t
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf("unknown data type %q", out.name)
+			return
+		}
+	}
+
+	for _, opt := range parts[1:] {
+		switch strings.TrimSpace(opt) {
+		case "hex":
+			out.hex = true
+		case "obis":
+			out.obis = true
+		case "":
+		default:
+			err = fmt.Errorf("unknown option %q", opt)
+			return
+		}
+	}
+
+	return
+}
+
+// Guess data type from Go type when it is not given by struct tag
+func inferTag(t reflect.Type) (dataTag, bool) {
+	switch t {
+	case timeType, cosemDateTimeType:
+		return TagDateTime, true
+	case cosemDateType:
+		return TagDate, true
+	case cosemTimeType:
+		return TagTime, true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return TagBoolean, true
+	case reflect.Int8:
+		return TagInteger, true
+	case reflect.Int16:
+		return TagLong, true
+	case reflect.Int32, reflect.Int:
+		return TagDoubleLong, true
+	case reflect.Int64:
+		return TagLong64, true
+	case reflect.Uint8:
+		return TagUnsigned, true
+	case reflect.Uint16:
+		return TagLongUnsigned, true
+	case reflect.Uint32, reflect.Uint:
+		return TagDoubleLongUnsigned, true
+	case reflect.Uint64:
+		return TagLong64Unsigned, true
+	case reflect.Float32:
+		return TagFloat32, true
+	case reflect.Float64:
+		return TagFloat64, true
+	case reflect.String:
+		return TagVisibleString, true
+	case reflect.Struct:
+		return TagStructure, true
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return TagOctetString, true
+		}
+		return TagArray, true
+	}
+
+	return TagNull, false
+}
+
+func isByteSequence(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+// Marshal returns A-XDR encoding of v, see MarshalData. Marshal and
+// Unmarshal map Go values to DlmsData and back, driven by struct tag with
+// key "axdr". Value of the tag is the name of data type as written in the
+// standard, optionally followed by comma separated options
+//
+//	type Register struct {
+//		LogicalName string  `axdr:"octet-string,obis"`
+//		Value       uint32  `axdr:"double-long-unsigned"`
+//		Scaler      int8    `axdr:"integer"`
+//		Serial      string  `axdr:"octet-string,hex"`
+//		Ignored     string  `axdr:"-"`
+//	}
+//
+// If data type is omitted, it is taken from the Go type. Struct is mapped
+// to structure, slice & array to array, []byte to octet-string, string to
+// visible-string, time.Time & CosemDateTime to date-time, CosemDate to
+// date, CosemTime to time, bool to boolean, int8/16/32/64 to
+// integer/long/double-long/long64 and the unsigned counterpart to
+// unsigned/long-unsigned/double-long-unsigned/long64-unsigned.
+// On slice, data type other than array or compact-array describes the
+// elements. Options:
+//
+//	hex   string field holds octet-string as hexstring
+//	obis  string field holds octet-string as Obis code, sample: 1.0.0.3.0.255
+func Marshal(v interface{}) ([]byte, error) {
+	d, err := MarshalData(v)
+	if err != nil {
+		return []byte{}, err
+	}
+	return d.AppendEncode(nil)
+}
+
+// MarshalData converts v into DlmsData according to its axdr struct tags
+func MarshalData(v interface{}) (*DlmsData, error) {
+	if v == nil {
+		return &DlmsData{Tag: TagNull}, nil
+	}
+	rv := reflect.ValueOf(v)
+	return marshalValue(rv, fieldTag{}, rv.Type().String())
+}
+
+func marshalValue(v reflect.Value, ft fieldTag, path string) (*DlmsData, error) {
+	if v.Type() == dlmsDataType {
+		d := v.Interface().(DlmsData)
+		return &d, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return &DlmsData{Tag: TagNull}, nil
+		}
+		return marshalValue(v.Elem(), ft, path)
+	}
+
+	tag := ft.tag
+	if ft.name == "" {
+		inferred, ok := inferTag(v.Type())
+		if !ok {
+			return nil, fmt.Errorf("axdr: %s: cannot marshal Go type %v", path, v.Type())
+		}
+		tag = inferred
+	}
+
+	// tag on a slice describes its elements, unless it is the container itself
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !isByteSequence(v.Type()) {
+		elemTag := fieldTag{hex: ft.hex, obis: ft.obis}
+		container := tag
+		if tag != TagArray && tag != TagCompactArray {
+			elemTag.name, elemTag.tag = ft.name, ft.tag
+			container = TagArray
+		}
+		output := make([]*DlmsData, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			d, err := marshalValue(v.Index(i), elemTag, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			output[i] = d
+		}
+		return &DlmsData{Tag: container, Value: output}, nil
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("axdr: %s: cannot marshal Go type %v as %v", path, v.Type(), tag)
+	}
+
+	switch tag {
+	case TagNull:
+		return &DlmsData{Tag: tag}, nil
+
+	case TagStructure:
+		if v.Kind() != reflect.Struct || v.Type() == timeType {
+			return nil, mismatch()
+		}
+		output := []*DlmsData{}
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			sft, err := parseFieldTag(sf.Tag.Get("axdr"))
+			if err != nil {
+				return nil, fmt.Errorf("axdr: %s.%s: %v", path, sf.Name, err)
+			}
+			if sft.skip {
+				continue
+			}
+			d, err := marshalValue(v.Field(i), sft, path+"."+sf.Name)
+			if err != nil {
+				return nil, err
+			}
+			output = append(output, d)
+		}
+		return &DlmsData{Tag: TagStructure, Value: output}, nil
+
+	case TagBoolean:
+		if v.Kind() != reflect.Bool {
+			return nil, mismatch()
+		}
+		return &DlmsData{Tag: tag, Value: v.Bool()}, nil
+
+	case TagInteger, TagBCD, TagLong, TagDoubleLong, TagLong64,
+		TagUnsigned, TagEnum, TagLongUnsigned, TagDoubleLongUnsigned, TagLong64Unsigned:
+		value, err := marshalInteger(v, tag)
+		if err != nil {
+			return nil, fmt.Errorf("axdr: %s: %v", path, err)
+		}
+		return &DlmsData{Tag: tag, Value: value}, nil
+
+	case TagFloatingPoint, TagFloat32:
+		if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			return nil, mismatch()
+		}
+		return &DlmsData{Tag: tag, Value: float32(v.Float())}, nil
+
+	case TagFloat64:
+		if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			return nil, mismatch()
+		}
+		return &DlmsData{Tag: tag, Value: v.Float()}, nil
+
+	case TagOctetString:
+		switch {
+		case isByteSequence(v.Type()):
+			bt := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(bt), v)
+			return &DlmsData{Tag: tag, Value: hex.EncodeToString(bt)}, nil
+		case v.Kind() == reflect.String && (ft.hex || ft.obis):
+			// EncodeOctetString already understand both hexstring & Obis
+			return &DlmsData{Tag: tag, Value: v.String()}, nil
+		case v.Kind() == reflect.String:
+			return &DlmsData{Tag: tag, Value: hex.EncodeToString([]byte(v.String()))}, nil
+		}
+		return nil, mismatch()
+
+	case TagVisibleString, TagUTF8String, TagBitString:
+		if v.Kind() != reflect.String {
+			return nil, mismatch()
+		}
+		return &DlmsData{Tag: tag, Value: v.String()}, nil
+
+	case TagDateTime, TagDate, TagTime:
+		cosemType := map[dataTag]reflect.Type{TagDateTime: cosemDateTimeType, TagDate: cosemDateType, TagTime: cosemTimeType}[tag]
+		if v.Type() != timeType && v.Type() != cosemType {
+			return nil, mismatch()
+		}
+		return &DlmsData{Tag: tag, Value: v.Interface()}, nil
+	}
+
+	return nil, mismatch()
+}
+
+// Convert any Go integer into the exact Go type expected by DlmsData.Encode
+func marshalInteger(v reflect.Value, tag dataTag) (interface{}, error) {
+	var signed bool
+	var i int64
+	var u uint64
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		signed, i = true, v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u = v.Uint()
+	default:
+		return nil, fmt.Errorf("cannot marshal Go type %v as %v", v.Type(), tag)
+	}
+
+	overflow := func() error {
+		if signed {
+			return fmt.Errorf("value %v overflows %v", i, tag)
+		}
+		return fmt.Errorf("value %v overflows %v", u, tag)
+	}
+	inRange := func(min int64, max uint64) bool {
+		if signed {
+			return i >= min && (i < 0 || uint64(i) <= max)
+		}
+		return u <= max
+	}
+	asInt := func() int64 {
+		if signed {
+			return i
+		}
+		return int64(u)
+	}
+
+	switch tag {
+	case TagInteger, TagBCD:
+		if !inRange(-1<<7, 1<<7-1) {
+			return nil, overflow()
+		}
+		return int8(asInt()), nil
+	case TagLong:
+		if !inRange(-1<<15, 1<<15-1) {
+			return nil, overflow()
+		}
+		return int16(asInt()), nil
+	case TagDoubleLong:
+		if !inRange(-1<<31, 1<<31-1) {
+			return nil, overflow()
+		}
+		return int32(asInt()), nil
+	case TagLong64:
+		if !inRange(-1<<63, 1<<63-1) {
+			return nil, overflow()
+		}
+		return asInt(), nil
+	case TagUnsigned, TagEnum:
+		if !inRange(0, 1<<8-1) {
+			return nil, overflow()
+		}
+		return uint8(asInt()), nil
+	case TagLongUnsigned:
+		if !inRange(0, 1<<16-1) {
+			return nil, overflow()
+		}
+		return uint16(asInt()), nil
+	case TagDoubleLongUnsigned:
+		if !inRange(0, 1<<32-1) {
+			return nil, overflow()
+		}
+		return uint32(asInt()), nil
+	case TagLong64Unsigned:
+		if !inRange(0, 1<<64-1) {
+			return nil, overflow()
+		}
+		if signed {
+			return uint64(i), nil
+		}
+		return u, nil
+	}
+
+	return nil, fmt.Errorf("%v is not an integer type", tag)
+}
+
+// Unmarshal decodes A-XDR encoded data and stores the result
+// in the value pointed by v, see UnmarshalData. Data must hold exactly
+// one value, bytes left after it are an error
+func Unmarshal(data []byte, v interface{}) error {
+	if len(data) < 1 {
+		return ErrLengthLess
+	}
+	src := append([]byte(nil), data...)
+	dec := NewDataDecoder(&src)
+	dec.ZeroCopy = true
+	d, err := dec.Decode(&src)
+	if err != nil {
+		return err
+	}
+	if len(src) != 0 {
+		return fmt.Errorf("axdr: %d bytes left after decoded value", len(src))
+	}
+	return UnmarshalData(&d, v)
+}
+
+// UnmarshalData stores value of DlmsData in the value pointed by v
+// according to its axdr struct tags
+func UnmarshalData(d *DlmsData, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("axdr: Unmarshal expect non-nil pointer, received %T", v)
+	}
+	return unmarshalValue(d, rv.Elem(), fieldTag{}, rv.Elem().Type().String())
+}
+
+func unmarshalValue(d *DlmsData, v reflect.Value, ft fieldTag, path string) error {
+	if v.Type() == dlmsDataType {
+		v.Set(reflect.ValueOf(*d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if d.Tag == TagNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return unmarshalValue(d, v.Elem(), ft, path)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(d.Value))
+			return nil
+		}
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("axdr: %s: cannot unmarshal %v into Go type %v", path, d.Tag, v.Type())
+	}
+
+	if ft.name != "" && d.Tag != ft.tag {
+		// tag on a slice may describe its elements, and both
+		// array & compact-array are accepted for the slice itself
+		isList := (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !isByteSequence(v.Type())
+		if !isList || (d.Tag != TagArray && d.Tag != TagCompactArray) {
+			return fmt.Errorf("axdr: %s: expecting %v, received %v", path, ft.tag, d.Tag)
+		}
+	}
+
+	switch d.Tag {
+	case TagNull:
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+
+	case TagArray, TagCompactArray:
+		data, ok := d.Value.([]*DlmsData)
+		if !ok {
+			return mismatch()
+		}
+		elemTag := fieldTag{hex: ft.hex, obis: ft.obis}
+		if ft.tag != TagArray && ft.tag != TagCompactArray {
+			elemTag.name, elemTag.tag = ft.name, ft.tag
+		}
+		switch v.Kind() {
+		case reflect.Slice:
+			if isByteSequence(v.Type()) {
+				return mismatch()
+			}
+			out := reflect.MakeSlice(v.Type(), len(data), len(data))
+			for i, dt := range data {
+				if err := unmarshalValue(dt, out.Index(i), elemTag, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			v.Set(out)
+			return nil
+		case reflect.Array:
+			if isByteSequence(v.Type()) {
+				return mismatch()
+			}
+			if v.Len() != len(data) {
+				return fmt.Errorf("axdr: %s: array has %d elements, Go type %v expect %d", path, len(data), v.Type(), v.Len())
+			}
+			for i, dt := range data {
+				if err := unmarshalValue(dt, v.Index(i), elemTag, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return mismatch()
+
+	case TagStructure:
+		data, ok := d.Value.([]*DlmsData)
+		if !ok || v.Kind() != reflect.Struct || v.Type() == timeType {
+			return mismatch()
+		}
+		idx := 0
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			sft, err := parseFieldTag(sf.Tag.Get("axdr"))
+			if err != nil {
+				return fmt.Errorf("axdr: %s.%s: %v", path, sf.Name, err)
+			}
+			if sft.skip {
+				continue
+			}
+			if idx >= len(data) {
+				return fmt.Errorf("axdr: %s: structure has %d members, not enough for field %s", path, len(data), sf.Name)
+			}
+			if err := unmarshalValue(data[idx], v.Field(i), sft, path+"."+sf.Name); err != nil {
+				return err
+			}
+			idx++
+		}
+		if idx != len(data) {
+			return fmt.Errorf("axdr: %s: structure has %d members, Go type %v only take %d", path, len(data), v.Type(), idx)
+		}
+		return nil
+
+	case TagBoolean:
+		value, ok := d.Value.(bool)
+		if !ok || v.Kind() != reflect.Bool {
+			return mismatch()
+		}
+		v.SetBool(value)
+		return nil
+
+	case TagInteger, TagBCD, TagLong, TagDoubleLong, TagLong64,
+		TagUnsigned, TagEnum, TagLongUnsigned, TagDoubleLongUnsigned, TagLong64Unsigned:
+		dv := reflect.ValueOf(d.Value)
+		switch dv.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			value := dv.Int()
+			switch v.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				if v.OverflowInt(value) {
+					return fmt.Errorf("axdr: %s: value %v overflows Go type %v", path, value, v.Type())
+				}
+				v.SetInt(value)
+				return nil
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				if value < 0 || v.OverflowUint(uint64(value)) {
+					return fmt.Errorf("axdr: %s: value %v overflows Go type %v", path, value, v.Type())
+				}
+				v.SetUint(uint64(value))
+				return nil
+			}
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			value := dv.Uint()
+			switch v.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				if value > 1<<63-1 || v.OverflowInt(int64(value)) {
+					return fmt.Errorf("axdr: %s: value %v overflows Go type %v", path, value, v.Type())
+				}
+				v.SetInt(int64(value))
+				return nil
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				if v.OverflowUint(value) {
+					return fmt.Errorf("axdr: %s: value %v overflows Go type %v", path, value, v.Type())
+				}
+				v.SetUint(value)
+				return nil
+			}
+		}
+		return mismatch()
+
+	case TagFloatingPoint, TagFloat32, TagFloat64:
+		dv := reflect.ValueOf(d.Value)
+		if (dv.Kind() != reflect.Float32 && dv.Kind() != reflect.Float64) ||
+			(v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64) {
+			return mismatch()
+		}
+		v.SetFloat(dv.Float())
+		return nil
+
+	case TagOctetString:
+		value, ok := d.Value.(string)
+		if !ok {
+			return mismatch()
+		}
+		bt, err := hex.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("axdr: %s: %v", path, err)
+		}
+		switch {
+		case v.Kind() == reflect.Slice && isByteSequence(v.Type()):
+			v.SetBytes(bt)
+		case v.Kind() == reflect.Array && isByteSequence(v.Type()):
+			if v.Len() != len(bt) {
+				return fmt.Errorf("axdr: %s: octet-string has %d bytes, Go type %v expect %d", path, len(bt), v.Type(), v.Len())
+			}
+			reflect.Copy(v, reflect.ValueOf(bt))
+		case v.Kind() == reflect.String && ft.obis:
+			if len(bt) != 6 {
+				return fmt.Errorf("axdr: %s: octet-string has %d bytes, Obis code needs 6", path, len(bt))
+			}
+			v.SetString(fmt.Sprintf("%d.%d.%d.%d.%d.%d", bt[0], bt[1], bt[2], bt[3], bt[4], bt[5]))
+		case v.Kind() == reflect.String && ft.hex:
+			v.SetString(value)
+		case v.Kind() == reflect.String:
+			v.SetString(string(bt))
+		default:
+			return mismatch()
+		}
+		return nil
+
+	case TagVisibleString, TagUTF8String, TagBitString:
+		value, ok := d.Value.(string)
+		if !ok || v.Kind() != reflect.String {
+			return mismatch()
+		}
+		v.SetString(value)
+		return nil
+
+	case TagDateTime, TagDate, TagTime:
+		if v.Type() == timeType {
+			value, err := d.Time()
+			if err != nil {
+				return fmt.Errorf("axdr: %s: %v", path, err)
+			}
+			v.Set(reflect.ValueOf(value))
+			return nil
+		}
+		value, err := cosemValueOf(d)
+		if err != nil {
+			return fmt.Errorf("axdr: %s: %v", path, err)
+		}
+		if reflect.TypeOf(value) != v.Type() {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	return mismatch()
+}
+
+// Read date, time or date-time of d as CosemDate, CosemTime or
+// CosemDateTime, taken from the raw value so wildcards are kept
+func cosemValueOf(d *DlmsData) (interface{}, error) {
+	switch d.Value.(type) {
+	case CosemDateTime, CosemDate, CosemTime:
+		return d.Value, nil
+	}
+	src := d.rawValue
+	if len(src) == 0 {
+		raw, err := d.AppendEncode(nil)
+		if err != nil {
+			return nil, err
+		}
+		src = raw[1:]
+	}
+
+	switch d.Tag {
+	case TagDateTime:
+		_, value, err := DecodeCosemDateTime(&src)
+		return value, err
+	case TagDate:
+		_, value, err := DecodeCosemDate(&src)
+		return value, err
+	default:
+		_, value, err := DecodeCosemTime(&src)
+		return value, err
+	}
+}
@@ -0,0 +1,203 @@
+package axdr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testScalerUnit struct {
+	Scaler int8  `axdr:"integer"`
+	Unit   uint8 `axdr:"enum"`
+}
+
+type testRegister struct {
+	LogicalName string         `axdr:"octet-string,obis"`
+	Value       uint32         `axdr:"double-long-unsigned"`
+	ScalerUnit  testScalerUnit `axdr:"structure"`
+	Serial      string         `axdr:"octet-string,hex"`
+	Flags       []uint16       `axdr:"long-unsigned"`
+	Captured    time.Time
+	Comment     string `axdr:"-"`
+	internal    int
+}
+
+func TestMarshal(t *testing.T) {
+	reg := testRegister{
+		LogicalName: "1.0.1.8.0.255",
+		Value:       1234,
+		ScalerUnit:  testScalerUnit{Scaler: -2, Unit: 30},
+		Serial:      "0A0B",
+		Flags:       []uint16{1, 2},
+		Captured:    time.Date(2020, time.March, 11, 18, 0, 0, 0, time.UTC),
+		Comment:     "not encoded",
+	}
+
+	ts, err := Marshal(reg)
+	if err != nil {
+		t.Fatalf("t1 failed. err:%v", err)
+	}
+	result := []byte{
+		2, 6,
+		9, 6, 1, 0, 1, 8, 0, 255,
+		6, 0, 0, 4, 210,
+		2, 2, 15, 254, 22, 30,
+		9, 2, 10, 11,
+		1, 2, 18, 0, 1, 18, 0, 2,
+		25, 7, 228, 3, 11, 3, 18, 0, 0, 0, 0, 0, 0,
+	}
+	res := bytes.Compare(ts, result)
+	if res != 0 {
+		t.Errorf("t1 failed. get: %d, should:%v", ts, result)
+	}
+
+	ts, err = Marshal([]byte{1, 2, 3})
+	res = bytes.Compare(ts, []byte{9, 3, 1, 2, 3})
+	if res != 0 || err != nil {
+		t.Errorf("t2 failed. get: %d, err:%v", ts, err)
+	}
+
+	var nilPtr *uint16
+	ts, err = Marshal(struct{ A *uint16 }{nilPtr})
+	res = bytes.Compare(ts, []byte{2, 1, 0})
+	if res != 0 || err != nil {
+		t.Errorf("t3 failed. get: %d, err:%v", ts, err)
+	}
+
+	ts, err = Marshal([]*DlmsData{CreateAxdrBoolean(true)})
+	res = bytes.Compare(ts, []byte{1, 1, 3, 255})
+	if res != 0 || err != nil {
+		t.Errorf("t4 failed. get: %d, err:%v", ts, err)
+	}
+
+	_, err = Marshal(map[string]int{})
+	if err == nil {
+		t.Errorf("t5 should fail on unsupported type")
+	}
+}
+
+func TestMarshal_Error(t *testing.T) {
+	_, err := Marshal(struct {
+		Inner struct {
+			Value uint16 `axdr:"unsigned"`
+		}
+	}{Inner: struct {
+		Value uint16 `axdr:"unsigned"`
+	}{Value: 300}})
+	if err == nil || !strings.Contains(err.Error(), ".Inner.Value") {
+		t.Errorf("t1 error should name the field path, get: %v", err)
+	}
+
+	_, err = Marshal(struct {
+		List []string `axdr:"long"`
+	}{List: []string{"a"}})
+	if err == nil || !strings.Contains(err.Error(), ".List[0]") {
+		t.Errorf("t2 error should name the field path, get: %v", err)
+	}
+
+	_, err = Marshal(struct {
+		Value int `axdr:"unknown-type"`
+	}{})
+	if err == nil {
+		t.Errorf("t3 should fail on unknown data type")
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	src := []byte{
+		2, 6,
+		9, 6, 1, 0, 1, 8, 0, 255,
+		6, 0, 0, 4, 210,
+		2, 2, 15, 254, 22, 30,
+		9, 2, 10, 11,
+		1, 2, 18, 0, 1, 18, 0, 2,
+		25, 7, 228, 3, 11, 3, 18, 0, 0, 0, 0, 0, 0,
+	}
+
+	var reg testRegister
+	err := Unmarshal(src, &reg)
+	if err != nil {
+		t.Fatalf("t1 failed. err:%v", err)
+	}
+	if reg.LogicalName != "1.0.1.8.0.255" {
+		t.Errorf("t1 LogicalName get: %v", reg.LogicalName)
+	}
+	if reg.Value != 1234 {
+		t.Errorf("t1 Value get: %v", reg.Value)
+	}
+	if reg.ScalerUnit.Scaler != -2 || reg.ScalerUnit.Unit != 30 {
+		t.Errorf("t1 ScalerUnit get: %v", reg.ScalerUnit)
+	}
+	if reg.Serial != "0a0b" {
+		t.Errorf("t1 Serial get: %v", reg.Serial)
+	}
+	if len(reg.Flags) != 2 || reg.Flags[1] != 2 {
+		t.Errorf("t1 Flags get: %v", reg.Flags)
+	}
+	if !reg.Captured.Equal(time.Date(2020, time.March, 11, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("t1 Captured get: %v", reg.Captured)
+	}
+
+	// compact array is accepted the same as array
+	var values []uint32
+	err = Unmarshal([]byte{19, 6, 8, 0, 0, 0, 1, 0, 0, 0, 2}, &values)
+	if err != nil || len(values) != 2 || values[1] != 2 {
+		t.Errorf("t2 failed. get: %v, err:%v", values, err)
+	}
+
+	var raw []byte
+	err = Unmarshal([]byte{9, 3, 1, 2, 3}, &raw)
+	if err != nil || bytes.Compare(raw, []byte{1, 2, 3}) != 0 {
+		t.Errorf("t3 failed. get: %v, err:%v", raw, err)
+	}
+}
+
+func TestUnmarshal_Error(t *testing.T) {
+	var reg testRegister
+	// Value is long-unsigned instead of double-long-unsigned
+	src := []byte{
+		2, 6,
+		9, 6, 1, 0, 1, 8, 0, 255,
+		18, 4, 210,
+		2, 2, 15, 254, 22, 30,
+		9, 2, 10, 11,
+		1, 0,
+		25, 7, 228, 3, 11, 3, 18, 0, 0, 0, 0, 0, 0,
+	}
+	err := Unmarshal(src, &reg)
+	if err == nil || !strings.Contains(err.Error(), "testRegister.Value") {
+		t.Errorf("t1 error should name the field path, get: %v", err)
+	}
+
+	var small struct {
+		List []int8
+	}
+	src = []byte{2, 1, 1, 2, 15, 1, 16, 1, 0}
+	err = Unmarshal(src, &small)
+	if err == nil || !strings.Contains(err.Error(), ".List[1]") {
+		t.Errorf("t2 error should name the field path, get: %v", err)
+	}
+
+	var tooFew struct{ A, B uint8 }
+	err = Unmarshal([]byte{2, 1, 17, 1}, &tooFew)
+	if err == nil {
+		t.Errorf("t3 should fail on structure with less member")
+	}
+
+	var overflow uint8
+	err = Unmarshal([]byte{18, 1, 0}, &overflow)
+	if err == nil {
+		t.Errorf("t4 should fail on overflowing value")
+	}
+
+	err = Unmarshal([]byte{17, 1}, overflow)
+	if err == nil {
+		t.Errorf("t5 should fail on non pointer")
+	}
+
+	err = Unmarshal([]byte{17, 1, 17, 2}, &overflow)
+	if err == nil {
+		t.Errorf("t6 should fail on bytes after the value")
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"fmt"
+	"io"
+
+	"gosem/pkg/dlms"
+)
+
+// BlockTransport is Transport carrying APDUs in general block transfer.
+// APDU is sent in blocks of BlockSize, blocks sent by server are
+// acknowledged and put together again, so it can be used by Client on
+// top of any Transport. Window is own receive window, how many blocks
+// server may stream before waiting for ack. MaxSize is the biggest APDU
+// put together from blocks of server, dlms.DefaultGeneralBlockMaxSize
+// when zero. Server has to grant ConformanceGeneralBlockTransfer.
+type BlockTransport struct {
+	Transport Transport
+	BlockSize int
+	Window    uint8
+	MaxSize   int
+
+	blockNumber uint16
+	sender      *dlms.GeneralBlockSender
+	receiver    *dlms.GeneralBlockReceiver
+}
+
+func NewBlockTransport(transport Transport, blockSize int, window uint8) *BlockTransport {
+	return &BlockTransport{Transport: transport, BlockSize: blockSize, Window: window}
+}
+
+// Send starts new transfer. Blocks of apdu are sent a window at a time,
+// the last window is acknowledged by answer of server, read by Receive.
+// Even apdu fitting one block is sent as block, to let server know the
+// window. ACSE APDUs are sent as they are, as they are not part of
+// an association yet
+func (t *BlockTransport) Send(apdu []byte) (err error) {
+	t.blockNumber = 0
+	t.sender = nil
+	t.receiver = &dlms.GeneralBlockReceiver{MaxSize: t.MaxSize}
+	if len(apdu) > 0 && (apdu[0] == dlms.TagAARQ.Value() || apdu[0] == dlms.TagRLRQ.Value()) {
+		return t.Transport.Send(apdu)
+	}
+
+	if t.sender, err = dlms.NewGeneralBlockSender(apdu, t.BlockSize, t.Window, 1); err != nil {
+		return
+	}
+	for {
+		last, e := t.sendWindow()
+		if e != nil || last {
+			return e
+		}
+		block, e := t.receiveBlock()
+		if e != nil {
+			return e
+		}
+		if err = t.sender.Acknowledge(block); err != nil {
+			return
+		}
+		if _, err = t.receiver.Add(block); err != nil {
+			return
+		}
+	}
+}
+
+// Receive returns APDU sent by server, after every block of it is
+// received. Blocks of Send that server did not get are sent again.
+// APDU which is not a block is returned as it is
+func (t *BlockTransport) Receive() (out []byte, err error) {
+	if t.receiver == nil {
+		t.receiver = &dlms.GeneralBlockReceiver{MaxSize: t.MaxSize}
+	}
+	for {
+		in, e := t.Transport.Receive()
+		if e != nil {
+			return nil, e
+		}
+		if len(in) == 0 || in[0] != dlms.TagGeneralBlockTransfer.Value() {
+			t.sender, t.receiver = nil, nil
+			return in, nil
+		}
+		block, e := dlms.DecodeGeneralBlockTransfer(&in)
+		if e != nil {
+			return nil, e
+		}
+
+		if t.sender != nil && !t.sender.Done() {
+			if err = t.sender.Acknowledge(block); err != nil {
+				return
+			}
+			if !t.sender.Done() {
+				if _, err = t.sendWindow(); err != nil {
+					return
+				}
+				continue
+			}
+		}
+
+		ack, e := t.receiver.Add(block)
+		if e != nil {
+			return nil, e
+		}
+		if t.receiver.Complete() {
+			out = t.receiver.Raw()
+			t.sender, t.receiver = nil, nil
+			return
+		}
+		if ack {
+			if err = t.send(*t.receiver.Ack(t.blockNumber, t.Window)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close closes Transport when it is io.Closer, so Client can stop a
+// cancelled exchange waiting in it
+func (t *BlockTransport) Close() error {
+	if closer, ok := t.Transport.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Send blocks of sender which are not acknowledged yet, up to window of server
+func (t *BlockTransport) sendWindow() (last bool, err error) {
+	blocks := t.sender.Next(t.receiver.BlockNumber())
+	if len(blocks) == 0 {
+		err = fmt.Errorf("no block left to send")
+		return
+	}
+	for _, block := range blocks {
+		if err = t.send(block); err != nil {
+			return
+		}
+		if block.BlockNumber > t.blockNumber {
+			t.blockNumber = block.BlockNumber
+		}
+	}
+	last = blocks[len(blocks)-1].LastBlock
+	return
+}
+
+func (t *BlockTransport) send(block dlms.GeneralBlockTransfer) error {
+	src, err := block.Encode()
+	if err != nil {
+		return err
+	}
+	return t.Transport.Send(src)
+}
+
+func (t *BlockTransport) receiveBlock() (out dlms.GeneralBlockTransfer, err error) {
+	in, err := t.Transport.Receive()
+	if err != nil {
+		return
+	}
+	return dlms.DecodeGeneralBlockTransfer(&in)
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"gosem/pkg/dlms"
+)
+
+// gbtServer is in-memory Transport answering in general block transfer
+// blocks. Blocks in lose are not sent the first time
+type gbtServer struct {
+	handler     func(req []byte) []byte
+	blockSize   int
+	window      uint8
+	lose        map[uint16]bool
+	receiver    *dlms.GeneralBlockReceiver
+	sender      *dlms.GeneralBlockSender
+	blockNumber uint16
+	received    []dlms.GeneralBlockTransfer
+	queue       [][]byte
+}
+
+func (s *gbtServer) push(block dlms.GeneralBlockTransfer) {
+	src, _ := block.Encode()
+	s.queue = append(s.queue, src)
+}
+
+func (s *gbtServer) stream() {
+	for _, block := range s.sender.Next(s.receiver.BlockNumber()) {
+		if s.lose[block.BlockNumber] {
+			delete(s.lose, block.BlockNumber)
+			continue
+		}
+		s.push(block)
+	}
+}
+
+func (s *gbtServer) Send(apdu []byte) (err error) {
+	if apdu[0] != dlms.TagGeneralBlockTransfer.Value() {
+		s.queue = append(s.queue, s.handler(apdu))
+		return
+	}
+	block, err := dlms.DecodeGeneralBlockTransfer(&apdu)
+	if err != nil {
+		return
+	}
+	s.received = append(s.received, block)
+
+	if s.sender != nil {
+		if err = s.sender.Acknowledge(block); err == nil {
+			s.stream()
+		}
+		return
+	}
+
+	if s.receiver == nil {
+		s.receiver = &dlms.GeneralBlockReceiver{}
+	}
+	ack, err := s.receiver.Add(block)
+	if err != nil {
+		return
+	}
+	if s.receiver.Complete() {
+		s.sender, err = dlms.NewGeneralBlockSender(s.handler(s.receiver.Raw()), s.blockSize, s.window, s.blockNumber+1)
+		if err != nil {
+			return
+		}
+		// window of client comes with its last block
+		s.sender.Acknowledge(dlms.GeneralBlockTransfer{Window: block.Window, BlockNumberAck: s.blockNumber})
+		s.stream()
+	} else if ack {
+		s.push(*dlms.CreateGeneralBlockTransfer(false, false, s.window, s.blockNumber, s.receiver.BlockNumber(), nil))
+	}
+	return
+}
+
+func (s *gbtServer) Receive() (out []byte, err error) {
+	if len(s.queue) == 0 {
+		return nil, errors.New("nothing to receive")
+	}
+	out, s.queue = s.queue[0], s.queue[1:]
+	return
+}
+
+func blockBytes(n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(i)
+	}
+	return out
+}
+
+func TestBlockTransport_Streaming(t *testing.T) {
+	response := blockBytes(100)
+	s := &gbtServer{handler: func(req []byte) []byte { return response }, blockSize: 16, window: 1, lose: map[uint16]bool{2: true}}
+	bt := NewBlockTransport(s, 16, 3)
+
+	if err := bt.Send([]byte{0xC0, 1, 2}); err != nil {
+		t.Fatalf("Send failed. err: %v", err)
+	}
+	out, err := bt.Receive()
+	if err != nil || bytes.Compare(out, response) != 0 {
+		t.Fatalf("t1 Failed. get: %v, err: %v", out, err)
+	}
+
+	// request block, then ack of lost block 2 and ack of every window,
+	// acks keep number of the request block
+	acks := []uint16{0, 1, 4}
+	if len(s.received) != len(acks) {
+		t.Fatalf("t1 Failed. received: %v", s.received)
+	}
+	for i, ack := range acks {
+		if s.received[i].BlockNumber != 1 || s.received[i].BlockNumberAck != ack || s.received[i].Window != 3 {
+			t.Errorf("t1 block %d Failed. get: %v, should ack: %v", i, s.received[i], ack)
+		}
+	}
+}
+
+func TestBlockTransport_MaxSize(t *testing.T) {
+	response := blockBytes(3 << 19)
+	s := &gbtServer{handler: func(req []byte) []byte { return response }, blockSize: 1000, window: 7}
+	bt := NewBlockTransport(s, 1000, 7)
+
+	// default MaxSize stops at 1 MiB
+	if err := bt.Send([]byte{0xC0, 1, 2}); err != nil {
+		t.Fatalf("t1 Send failed. err: %v", err)
+	}
+	if _, err := bt.Receive(); err == nil {
+		t.Errorf("t1 APDU bigger than default MaxSize should fail")
+	}
+
+	s = &gbtServer{handler: func(req []byte) []byte { return response }, blockSize: 1000, window: 7}
+	bt = NewBlockTransport(s, 1000, 7)
+	bt.MaxSize = 2 << 20
+	if err := bt.Send([]byte{0xC0, 1, 2}); err != nil {
+		t.Fatalf("t2 Send failed. err: %v", err)
+	}
+	out, err := bt.Receive()
+	if err != nil || bytes.Compare(out, response) != 0 {
+		t.Errorf("t2 Failed. get %v bytes, err: %v", len(out), err)
+	}
+}
+
+func TestBlockTransport_SendBlocks(t *testing.T) {
+	request := blockBytes(40)
+	var got []byte
+	s := &gbtServer{handler: func(req []byte) []byte { got = req; return []byte{0xC5, 1} }, blockSize: 16, window: 2}
+	bt := NewBlockTransport(s, 16, 1)
+
+	if err := bt.Send(request); err != nil {
+		t.Fatalf("Send failed. err: %v", err)
+	}
+	out, err := bt.Receive()
+	if err != nil || bytes.Compare(out, []byte{0xC5, 1}) != 0 {
+		t.Fatalf("t1 Failed. get: %v, err: %v", out, err)
+	}
+	if bytes.Compare(got, request) != 0 {
+		t.Errorf("t1 Failed. server got: %v", got)
+	}
+	// first block alone, then window of server
+	if len(s.received) != 3 || s.received[0].Streaming || !s.received[1].Streaming || !s.received[2].LastBlock {
+		t.Errorf("t1 Failed. received: %v", s.received)
+	}
+}
+
+func TestBlockTransport_ACSE(t *testing.T) {
+	s := &gbtServer{handler: func(req []byte) []byte { return []byte{0x61, 0} }}
+	bt := NewBlockTransport(s, 16, 1)
+
+	if err := bt.Send([]byte{0x60, 0}); err != nil {
+		t.Fatalf("Send failed. err: %v", err)
+	}
+	out, err := bt.Receive()
+	if err != nil || bytes.Compare(out, []byte{0x61, 0}) != 0 || len(s.received) != 0 {
+		t.Errorf("t1 Failed. get: %v, received: %v, err: %v", out, s.received, err)
+	}
+}
+
+func TestBlockTransport_AckNotSent(t *testing.T) {
+	// server acks block 3 when only block 1 is sent
+	ack, _ := dlms.CreateGeneralBlockTransfer(false, false, 1, 1, 3, nil).Encode()
+	s := &gbtServer{handler: func(req []byte) []byte { return req }, blockSize: 2, window: 1, queue: [][]byte{ack}}
+	bt := NewBlockTransport(s, 2, 1)
+
+	if err := bt.Send(blockBytes(6)); err == nil {
+		t.Errorf("t1 should fail on ack of block not sent")
+	}
+}
@@ -0,0 +1,266 @@
+/*
+Provides high level DLMS/COSEM client. It associates with the server,
+then reads, writes and invokes methods of COSEM objects with logical name
+referencing, over any Transport such as hdlc or wrapper connection.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"gosem/pkg/dlms"
+	"gosem/pkg/security"
+)
+
+// Transport sends and receives whole APDUs. Both hdlc.Conn and
+// wrapper.Conn implement it. Transport which is also io.Closer is closed
+// when a request is cancelled while waiting for it
+type Transport interface {
+	Send(apdu []byte) error
+	Receive() ([]byte, error)
+}
+
+// Settings of association. Password is used by low level security. When
+// HLS is set, it replaces Mechanism and Connect authenticates with it,
+// SystemTitle is then sent as calling AP title when set. Mechanisms using
+// system titles, such as GMAC, require it to be 8 bytes
+type Settings struct {
+	ApplicationContext dlms.ApplicationContextName
+	Mechanism          dlms.AuthenticationMechanism
+	Password           []byte
+	Conformance        dlms.ConformanceBlock
+	MaxPduSize         uint16
+	SystemTitle        []byte
+	HLS                security.HLSMechanism
+}
+
+// DefaultSettings returns settings of public client, without security
+func DefaultSettings() Settings {
+	return Settings{
+		ApplicationContext: dlms.TagAppCtxLNNoCipher,
+		Mechanism:          dlms.TagMechLowest,
+		Conformance: dlms.ConformanceBlockTransferWithGet | dlms.ConformanceBlockTransferWithSet |
+			dlms.ConformanceBlockTransferWithAction | dlms.ConformanceMultipleReferences |
+			dlms.ConformanceGet | dlms.ConformanceSet | dlms.ConformanceSelectiveAccess |
+			dlms.ConformanceAction,
+		MaxPduSize: 0xFFFF,
+	}
+}
+
+// Client is safe for concurrent use, requests are sent one at a time.
+// Conformance and MaxPduSize are granted by server on Connect.
+type Client struct {
+	Settings    Settings
+	Conformance dlms.ConformanceBlock
+	MaxPduSize  uint16
+
+	transport Transport
+	mutex     sync.Mutex
+	connected bool
+	invokeId  uint8
+	// exchange left behind by cancelled request, transport is not used
+	// again until it returns
+	pending chan exchangeResult
+}
+
+type exchangeResult struct {
+	pdu dlms.CosemPDU
+	err error
+}
+
+func NewClient(transport Transport, settings Settings) *Client {
+	return &Client{Settings: settings, transport: transport}
+}
+
+// IsConnected returns whether association is established
+func (c *Client) IsConnected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.connected
+}
+
+// Connect sends AARQ and checks AARE of server. With HLS, it then invokes
+// reply_to_HLS_authentication and checks the answer of server
+func (c *Client) Connect(ctx context.Context) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.connected {
+		return ErrAlreadyConnected
+	}
+
+	ir := dlms.CreateInitiateRequest(nil, c.Settings.Conformance, c.Settings.MaxPduSize)
+	userInfo, err := ir.Encode()
+	if err != nil {
+		return
+	}
+	aarq := dlms.CreateAARQ(c.Settings.ApplicationContext, c.Settings.Mechanism, c.Settings.Password, userInfo)
+	var hls *security.HLS
+	if c.Settings.HLS != nil {
+		if hls, err = security.NewHLS(c.Settings.HLS, c.Settings.SystemTitle); err != nil {
+			return
+		}
+		aarq.MechanismName = c.Settings.HLS.Mechanism()
+		aarq.CallingAuthenticationValue = hls.Challenge()
+		if len(c.Settings.SystemTitle) > 0 {
+			aarq.CallingAPTitle = c.Settings.SystemTitle
+		}
+	}
+
+	resp, err := c.exchange(ctx, aarq)
+	if err != nil {
+		return
+	}
+	aare, ok := resp.(dlms.AARE)
+	if !ok {
+		return &ResponseError{Request: aarq, Response: resp}
+	}
+	if aare.Result != dlms.TagAssocAccepted {
+		return &AssociationError{Result: aare.Result, Source: aare.DiagnosticSource, Diagnostic: aare.Diagnostic}
+	}
+
+	initiate, err := aare.InitiateResponse()
+	if err != nil {
+		return
+	}
+	c.Conformance = initiate.NegotiatedConformance
+	c.MaxPduSize = initiate.ServerMaxReceivePduSize
+	c.invokeId = 0
+	c.connected = true
+
+	if hls != nil {
+		if err = hls.SetPeer(aare.RespondingAPTitle, aare.RespondingAuthenticationValue); err != nil {
+			c.connected = false
+			return
+		}
+		if err = c.authenticate(ctx, hls); err != nil {
+			c.connected = false
+		}
+	}
+	return
+}
+
+// Pass 3 and 4 of HLS, association is not usable until server accepts
+// f(StoC) and answers valid f(CtoS)
+func (c *Client) authenticate(ctx context.Context, hls *security.HLS) (err error) {
+	req, err := hls.Request(c.nextInvokeId())
+	if err != nil {
+		return
+	}
+	resp, err := c.request(ctx, req)
+	if err != nil {
+		return
+	}
+	ar, ok := resp.(dlms.ActionResponseNormal)
+	if !ok || !sameInvokeId(req.InvokePriority, ar.InvokePriority) {
+		return &ResponseError{Request: req, Response: resp}
+	}
+	return hls.CheckResponse(ar)
+}
+
+// Close sends RLRQ to release association. If ctx is done before RLRE
+// comes, the association is dropped and transport is closed as on any
+// cancelled request
+func (c *Client) Close(ctx context.Context) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.connected {
+		return ErrNotConnected
+	}
+	c.connected = false
+
+	rlrq := dlms.CreateRLRQ(dlms.TagRlrqNormal, nil)
+	resp, err := c.exchange(ctx, rlrq)
+	if err != nil {
+		return
+	}
+	if _, ok := resp.(dlms.RLRE); !ok {
+		return &ResponseError{Request: rlrq, Response: resp}
+	}
+	return
+}
+
+// Next invoke-id-and-priority, service class confirmed and normal priority
+func (c *Client) nextInvokeId() uint8 {
+	c.invokeId = (c.invokeId + 1) & 0x0F
+	return 0x40 | c.invokeId
+}
+
+// Send pdu and decode answer. If ctx is done before the answer comes, the
+// association is considered broken as late answer cannot be told apart.
+// Transport is closed if it can be, and the next exchange waits until the
+// cancelled one returns, so transport is never used by two at once
+func (c *Client) exchange(ctx context.Context, pdu dlms.CosemPDU) (out dlms.CosemPDU, err error) {
+	if c.pending != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.pending:
+			c.pending = nil
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	src, err := pdu.Encode()
+	if err != nil {
+		return
+	}
+
+	done := make(chan exchangeResult, 1)
+	go func() {
+		if err := c.transport.Send(src); err != nil {
+			done <- exchangeResult{err: err}
+			return
+		}
+		in, err := c.transport.Receive()
+		if err != nil {
+			done <- exchangeResult{err: err}
+			return
+		}
+		if len(in) == 0 {
+			done <- exchangeResult{err: fmt.Errorf("received empty APDU")}
+			return
+		}
+		resp, err := dlms.DecodeCosem(&in)
+		done <- exchangeResult{pdu: resp, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		c.connected = false
+		c.pending = done
+		if closer, ok := c.transport.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, ctx.Err()
+	case r := <-done:
+		out, err = r.pdu, r.err
+	}
+	if err != nil {
+		return
+	}
+
+	switch resp := out.(type) {
+	case dlms.ExceptionResponse:
+		err = &dlms.ExceptionError{Response: resp}
+	case dlms.ConfirmedServiceError:
+		err = &dlms.ServiceError{Response: resp}
+	}
+	return
+}
+
+// Send request of an established association, after checking the
+// services it needs are granted
+func (c *Client) request(ctx context.Context, pdu dlms.CosemPDU) (out dlms.CosemPDU, err error) {
+	if !c.connected {
+		return nil, ErrNotConnected
+	}
+	if missing := c.Conformance.Missing(dlms.RequiredConformance(pdu)); missing != 0 {
+		return nil, &ConformanceError{Missing: missing}
+	}
+	return c.exchange(ctx, pdu)
+}
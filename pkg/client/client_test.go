@@ -0,0 +1,595 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gosem/pkg/axdr"
+	"gosem/pkg/dlms"
+	"gosem/pkg/hdlc"
+	"gosem/pkg/security"
+	"gosem/pkg/wrapper"
+)
+
+var _ Transport = &hdlc.Conn{}
+var _ Transport = &wrapper.Conn{}
+
+// meter is in-memory Transport, answering every request by handler
+type meter struct {
+	handler     func(req dlms.CosemPDU) dlms.CosemPDU
+	conformance dlms.ConformanceBlock
+	maxPduSize  uint16
+	hls         *security.HLS
+	apTitle     []byte
+	requests    []dlms.CosemPDU
+	response    []byte
+}
+
+func (m *meter) Send(apdu []byte) error {
+	req, err := dlms.DecodeCosem(&apdu)
+	if err != nil {
+		return err
+	}
+	m.requests = append(m.requests, req)
+
+	var resp dlms.CosemPDU
+	switch r := req.(type) {
+	case dlms.AARQ:
+		resp = m.acceptedAARE(r)
+	case dlms.RLRQ:
+		resp = dlms.CreateRLRE(dlms.TagRlreNormal, nil)
+	default:
+		resp = m.handler(req)
+	}
+	m.response, err = resp.Encode()
+	return err
+}
+
+func (m *meter) Receive() ([]byte, error) {
+	return m.response, nil
+}
+
+var grantedConformance = dlms.ConformanceGet | dlms.ConformanceSet | dlms.ConformanceAction
+
+func (m *meter) acceptedAARE(r dlms.AARQ) dlms.CosemPDU {
+	conformance, maxPduSize := grantedConformance, uint16(0x400)
+	if m.conformance != 0 {
+		conformance, maxPduSize = m.conformance, m.maxPduSize
+	}
+	ir := dlms.CreateInitiateResponse(conformance, maxPduSize, dlms.VAANameLN)
+	userInfo, _ := ir.Encode()
+	aare := dlms.CreateAARE(r.ApplicationContextName, dlms.TagAssocAccepted, dlms.TagDiagACSEServiceUser, dlms.TagDiagNull, userInfo)
+	if m.hls != nil {
+		m.hls.SetPeer(r.CallingAPTitle, r.CallingAuthenticationValue)
+		aare.MechanismName = r.MechanismName
+		aare.RespondingAPTitle = m.hls.Exchange.SystemTitle
+		if m.apTitle != nil {
+			aare.RespondingAPTitle = m.apTitle
+		}
+		aare.RespondingAuthenticationValue = m.hls.Challenge()
+	}
+	return aare
+}
+
+func connectedClient(t *testing.T, handler func(req dlms.CosemPDU) dlms.CosemPDU) (*Client, *meter) {
+	m := &meter{handler: handler}
+	c := NewClient(m, DefaultSettings())
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed. err: %v", err)
+	}
+	return c, m
+}
+
+func TestClient_Connect(t *testing.T) {
+	c, m := connectedClient(t, nil)
+	if !c.IsConnected() || c.Conformance != grantedConformance || c.MaxPduSize != 0x400 {
+		t.Errorf("t1 Failed. conformance: %v, max pdu: %v", c.Conformance, c.MaxPduSize)
+	}
+	aarq := m.requests[0].(dlms.AARQ)
+	ir, err := aarq.InitiateRequest()
+	if err != nil || ir.ProposedConformance != DefaultSettings().Conformance {
+		t.Errorf("t1 Failed. initiate request: %v, err:%v", ir, err)
+	}
+	if err = c.Connect(context.Background()); err != ErrAlreadyConnected {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+
+	if err = c.Close(context.Background()); err != nil || c.IsConnected() {
+		t.Errorf("t3 Close failed. err:%v", err)
+	}
+	if _, ok := m.requests[len(m.requests)-1].(dlms.RLRQ); !ok {
+		t.Errorf("t3 RLRQ is not sent")
+	}
+	if err = c.Close(context.Background()); err != ErrNotConnected {
+		t.Errorf("t4 Failed. err:%v", err)
+	}
+}
+
+func TestClient_ConnectRejected(t *testing.T) {
+	m := &meter{}
+	c := NewClient(rejecting{m}, DefaultSettings())
+	err := c.Connect(context.Background())
+	var ae *AssociationError
+	if !errors.As(err, &ae) || ae.Diagnostic != dlms.TagDiagAuthenticationFailure {
+		t.Errorf("Failed. err:%v", err)
+	}
+	if c.IsConnected() {
+		t.Errorf("Client should not be connected")
+	}
+}
+
+// rejecting answers every AARQ with rejected AARE
+type rejecting struct {
+	*meter
+}
+
+func (r rejecting) Receive() ([]byte, error) {
+	aare := dlms.CreateAARE(dlms.TagAppCtxLNNoCipher, dlms.TagAssocRejectedPermanent, dlms.TagDiagACSEServiceUser, dlms.TagDiagAuthenticationFailure, nil)
+	return aare.Encode()
+}
+
+func TestClient_ConnectHLS(t *testing.T) {
+	clientTitle := []byte("MMM\x00\x00\x00\x00\x01")
+	serverTitle := []byte("MMM\x00\x00\xBC\x61\x4E")
+	ek := bytes.Repeat([]byte{0x01}, 16)
+	ak := bytes.Repeat([]byte{0x02}, 16)
+	var serverAPTitle []byte
+
+	connect := func(serverAK []byte, challenge []byte, title []byte) (*Client, *meter, error) {
+		server, _ := security.NewHLS(security.CreateGMAC(security.CreateContext(security.SecurityAuthentication, serverTitle, nil, ek, serverAK)), serverTitle)
+		if challenge != nil {
+			server.Exchange.Challenge = challenge
+		}
+		m := &meter{hls: server, apTitle: serverAPTitle}
+		m.handler = func(req dlms.CosemPDU) dlms.CosemPDU {
+			resp, _ := server.Reply(req.(dlms.ActionRequestNormal))
+			return resp
+		}
+		settings := DefaultSettings()
+		settings.SystemTitle = title
+		settings.HLS = security.CreateGMAC(security.CreateContext(security.SecurityAuthentication, clientTitle, nil, ek, ak))
+		c := NewClient(m, settings)
+		return c, m, c.Connect(context.Background())
+	}
+
+	c, m, err := connect(ak, nil, clientTitle)
+	if err != nil || !c.IsConnected() {
+		t.Fatalf("t1 Connect failed. err: %v", err)
+	}
+	aarq := m.requests[0].(dlms.AARQ)
+	if aarq.MechanismName != dlms.TagMechHighGMAC || !bytes.Equal(aarq.CallingAPTitle, clientTitle) || len(aarq.CallingAuthenticationValue) != security.HLSChallengeLength {
+		t.Errorf("t1 Failed. AARQ: %v", aarq)
+	}
+	if ar, ok := m.requests[1].(dlms.ActionRequestNormal); !ok || ar.MethodInfo.ClassId != 15 {
+		t.Errorf("t1 Failed. reply_to_HLS_authentication is not sent: %v", m.requests[1])
+	}
+
+	// server does not know key of client
+	c, _, err = connect(ek, nil, clientTitle)
+	var actionErr *dlms.ActionError
+	if !errors.As(err, &actionErr) || c.IsConnected() {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+
+	// GMAC refuses short system title of client before pass 3
+	c, m, err = connect(ak, nil, clientTitle[:7])
+	if err == nil || c.IsConnected() || len(m.requests) != 1 {
+		t.Errorf("t3 Failed. requests: %v, err:%v", m.requests, err)
+	}
+
+	// challenge of server is too short
+	c, m, err = connect(ak, []byte{1, 2, 3, 4}, clientTitle)
+	if err == nil || c.IsConnected() || len(m.requests) != 1 {
+		t.Errorf("t4 Failed. requests: %v, err:%v", m.requests, err)
+	}
+
+	// system title of server is too short
+	serverAPTitle = serverTitle[:7]
+	c, m, err = connect(ak, nil, clientTitle)
+	if err == nil || errors.As(err, &actionErr) || c.IsConnected() || len(m.requests) != 2 {
+		t.Errorf("t5 Failed. requests: %v, err:%v", m.requests, err)
+	}
+}
+
+// digestHLS is HLS mechanism without system titles, response is SHA-256 of
+// secret and challenge
+type digestHLS struct {
+	secret []byte
+}
+
+func (d digestHLS) Mechanism() dlms.AuthenticationMechanism {
+	return dlms.TagMechHighSHA256
+}
+
+func (d digestHLS) Response(x security.HLSExchange) ([]byte, error) {
+	sum := sha256.Sum256(append(append([]byte{}, d.secret...), x.PeerChallenge...))
+	return sum[:], nil
+}
+
+func (d digestHLS) Verify(x security.HLSExchange, response []byte) error {
+	sum := sha256.Sum256(append(append([]byte{}, d.secret...), x.Challenge...))
+	if !bytes.Equal(sum[:], response) {
+		return security.ErrAuthenticationFailed
+	}
+	return nil
+}
+
+func TestClient_ConnectHLSWithoutSystemTitle(t *testing.T) {
+	mech := digestHLS{secret: []byte("secret")}
+	server, _ := security.NewHLS(mech, nil)
+	m := &meter{hls: server}
+	m.handler = func(req dlms.CosemPDU) dlms.CosemPDU {
+		resp, _ := server.Reply(req.(dlms.ActionRequestNormal))
+		return resp
+	}
+	settings := DefaultSettings()
+	settings.HLS = mech
+	c := NewClient(m, settings)
+	if err := c.Connect(context.Background()); err != nil || !c.IsConnected() {
+		t.Fatalf("t1 Connect failed. err: %v", err)
+	}
+	aarq := m.requests[0].(dlms.AARQ)
+	if aarq.MechanismName != dlms.TagMechHighSHA256 || aarq.CallingAPTitle != nil || len(m.requests) != 2 {
+		t.Errorf("t1 Failed. requests: %v", m.requests)
+	}
+}
+
+func TestClient_Get(t *testing.T) {
+	c, m := connectedClient(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		gr := req.(dlms.GetRequestNormal)
+		if gr.AttributeInfo.ClassId == 1 {
+			return dlms.CreateGetResponseNormal(gr.InvokePriority, *dlms.CreateGetDataResultAsResult(dlms.TagAccObjectUndefined))
+		}
+		return dlms.CreateGetResponseNormal(gr.InvokePriority, *dlms.CreateGetDataResultAsData(*axdr.CreateAxdrDoubleLongUnsigned(1234)))
+	})
+
+	t1, err := c.Get(context.Background(), *dlms.CreateAttributeDescriptor(3, "1.0.1.8.0.255", 2), nil)
+	if err != nil || t1.Value != uint32(1234) {
+		t.Errorf("t1 Failed. get: %v, err:%v", t1.Value, err)
+	}
+	if gr := m.requests[1].(dlms.GetRequestNormal); gr.InvokePriority != 0x41 {
+		t.Errorf("t1 Invoke id get: %02X, should:%02X", gr.InvokePriority, 0x41)
+	}
+
+	_, err = c.Get(context.Background(), *dlms.CreateAttributeDescriptor(1, "0.0.96.1.0.255", 2), nil)
+	var ae *dlms.DataAccessError
+	if !errors.As(err, &ae) || ae.Result != dlms.TagAccObjectUndefined {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+	if gr := m.requests[2].(dlms.GetRequestNormal); gr.InvokePriority != 0x42 {
+		t.Errorf("t2 Invoke id get: %02X, should:%02X", gr.InvokePriority, 0x42)
+	}
+
+	// selective access is not granted
+	acc := dlms.CreateSelectiveAccessDescriptor(dlms.AccessSelectorEntry, []uint32{0, 5})
+	_, err = c.Get(context.Background(), *dlms.CreateAttributeDescriptor(7, "1.0.99.1.0.255", 2), acc)
+	var ce *ConformanceError
+	if !errors.As(err, &ce) || ce.Missing != dlms.ConformanceSelectiveAccess {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+	if len(m.requests) != 3 {
+		t.Errorf("t3 Request should not be sent")
+	}
+}
+
+func TestClient_GetWrongInvokeId(t *testing.T) {
+	c, _ := connectedClient(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		gr := req.(dlms.GetRequestNormal)
+		return dlms.CreateGetResponseNormal(gr.InvokePriority+1, *dlms.CreateGetDataResultAsData(*axdr.CreateAxdrDoubleLongUnsigned(1234)))
+	})
+	_, err := c.Get(context.Background(), *dlms.CreateAttributeDescriptor(3, "1.0.1.8.0.255", 2), nil)
+	var re *ResponseError
+	if !errors.As(err, &re) {
+		t.Errorf("Failed. err:%v", err)
+	}
+}
+
+func TestClient_Set(t *testing.T) {
+	c, _ := connectedClient(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		sr := req.(dlms.SetRequestNormal)
+		if sr.Value.Value == uint16(0) {
+			return dlms.CreateSetResponseNormal(sr.InvokePriority, dlms.TagAccReadWriteDenied)
+		}
+		return dlms.CreateSetResponseNormal(sr.InvokePriority, dlms.TagAccSuccess)
+	})
+
+	att := *dlms.CreateAttributeDescriptor(1, "0.0.96.1.0.255", 2)
+	if err := c.Set(context.Background(), att, nil, *axdr.CreateAxdrLongUnsigned(1)); err != nil {
+		t.Errorf("t1 Failed. err:%v", err)
+	}
+	err := c.Set(context.Background(), att, nil, *axdr.CreateAxdrLongUnsigned(0))
+	var ae *dlms.DataAccessError
+	if !errors.As(err, &ae) || ae.Result != dlms.TagAccReadWriteDenied {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+}
+
+func TestClient_Action(t *testing.T) {
+	c, _ := connectedClient(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		ar := req.(dlms.ActionRequestNormal)
+		switch ar.MethodInfo.MethodId {
+		case 1:
+			return dlms.CreateActionResponseNormal(ar.InvokePriority, *dlms.CreateActResponse(dlms.TagActSuccess, nil))
+		case 2:
+			ret := dlms.CreateGetDataResultAsData(*axdr.CreateAxdrUnsigned(7))
+			return dlms.CreateActionResponseNormal(ar.InvokePriority, *dlms.CreateActResponse(dlms.TagActSuccess, ret))
+		default:
+			return dlms.CreateActionResponseNormal(ar.InvokePriority, *dlms.CreateActResponse(dlms.TagActObjectUnavailable, nil))
+		}
+	})
+
+	param := axdr.CreateAxdrInteger(0)
+	t1, err := c.Action(context.Background(), *dlms.CreateMethodDescriptor(70, "0.0.96.3.10.255", 1), param)
+	if err != nil || t1 != nil {
+		t.Errorf("t1 Failed. get: %v, err:%v", t1, err)
+	}
+	t2, err := c.Action(context.Background(), *dlms.CreateMethodDescriptor(70, "0.0.96.3.10.255", 2), param)
+	if err != nil || t2 == nil || t2.Value != uint8(7) {
+		t.Errorf("t2 Failed. get: %v, err:%v", t2, err)
+	}
+	_, err = c.Action(context.Background(), *dlms.CreateMethodDescriptor(70, "0.0.96.3.10.255", 3), param)
+	var ae *dlms.ActionError
+	if !errors.As(err, &ae) || ae.Result != dlms.TagActObjectUnavailable {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+}
+
+func TestClient_Exception(t *testing.T) {
+	c, _ := connectedClient(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		return dlms.CreateExceptionResponse(dlms.TagExcServiceNotAllowed, dlms.TagExcServiceNotSupported)
+	})
+	_, err := c.Get(context.Background(), *dlms.CreateAttributeDescriptor(3, "1.0.1.8.0.255", 2), nil)
+	var ee *dlms.ExceptionError
+	if !errors.As(err, &ee) || ee.Response.ServiceError != dlms.TagExcServiceNotSupported {
+		t.Errorf("Failed. err:%v", err)
+	}
+}
+
+// blocking never answers
+type blocking struct {
+	*meter
+	block chan struct{}
+}
+
+func (b blocking) Receive() ([]byte, error) {
+	if _, ok := b.meter.requests[len(b.meter.requests)-1].(dlms.AARQ); ok {
+		return b.meter.Receive()
+	}
+	<-b.block
+	return nil, errors.New("closed")
+}
+
+func otherReason(req dlms.CosemPDU) dlms.CosemPDU {
+	gr := req.(dlms.GetRequestNormal)
+	return dlms.CreateGetResponseNormal(gr.InvokePriority, *dlms.CreateGetDataResultAsResult(dlms.TagAccOtherReason))
+}
+
+func TestClient_Cancel(t *testing.T) {
+	b := blocking{meter: &meter{handler: otherReason}, block: make(chan struct{})}
+	defer close(b.block)
+
+	c := NewClient(b, DefaultSettings())
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed. err: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Get(ctx, *dlms.CreateAttributeDescriptor(3, "1.0.1.8.0.255", 2), nil)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Failed. err:%v", err)
+	}
+	if c.IsConnected() {
+		t.Errorf("Client should be disconnected after cancel")
+	}
+}
+
+func TestClient_CancelReuse(t *testing.T) {
+	b := blocking{meter: &meter{handler: otherReason}, block: make(chan struct{})}
+	c := NewClient(b, DefaultSettings())
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed. err: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx, *dlms.CreateAttributeDescriptor(3, "1.0.1.8.0.255", 2), nil); err != context.DeadlineExceeded {
+		t.Fatalf("t1 Failed. err:%v", err)
+	}
+
+	// transport is still used by cancelled request
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel2()
+	if err := c.Connect(ctx2); err != context.DeadlineExceeded {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+
+	close(b.block)
+	if err := c.Connect(context.Background()); err != nil {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+	// AARQ, cancelled request and AARQ again
+	if len(b.meter.requests) != 3 {
+		t.Errorf("t3 Failed. AARQ should be sent after cancelled request returns, requests: %v", b.meter.requests)
+	}
+}
+
+// closable unblocks Receive on Close
+type closable struct {
+	blocking
+	closed bool
+}
+
+func (c *closable) Close() error {
+	c.closed = true
+	close(c.block)
+	return nil
+}
+
+func TestClient_CancelClose(t *testing.T) {
+	b := &closable{blocking: blocking{meter: &meter{handler: otherReason}, block: make(chan struct{})}}
+	c := NewClient(b, DefaultSettings())
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed. err: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx, *dlms.CreateAttributeDescriptor(3, "1.0.1.8.0.255", 2), nil); err != context.DeadlineExceeded {
+		t.Errorf("Failed. err:%v", err)
+	}
+	if !b.closed || c.IsConnected() {
+		t.Errorf("Failed. transport should be closed and client disconnected")
+	}
+}
+
+func TestClient_CloseTimeout(t *testing.T) {
+	b := &closable{blocking: blocking{meter: &meter{handler: otherReason}, block: make(chan struct{})}}
+	c := NewClient(b, DefaultSettings())
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed. err: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); err != context.DeadlineExceeded {
+		t.Errorf("t1 Failed. err:%v", err)
+	}
+	if !b.closed || c.IsConnected() {
+		t.Errorf("t1 Failed. transport should be closed and client disconnected")
+	}
+	// lock is released, client answers at once
+	if err := c.Close(context.Background()); err != ErrNotConnected {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+}
+
+func blockMeter(t *testing.T, handler func(req dlms.CosemPDU) dlms.CosemPDU) (*Client, *meter) {
+	m := &meter{handler: handler, conformance: DefaultSettings().Conformance, maxPduSize: 64}
+	c := NewClient(m, DefaultSettings())
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed. err: %v", err)
+	}
+	return c, m
+}
+
+func TestClient_GetWithBlocks(t *testing.T) {
+	value := *axdr.CreateAxdrOctetString(strings.Repeat("5A", 150))
+	raw, _ := value.Encode()
+	blocks, _ := dlms.SplitDataBlocks(raw, 40)
+
+	c, m := blockMeter(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		switch r := req.(type) {
+		case dlms.GetRequestNormal:
+			b := blocks[0]
+			return dlms.CreateGetResponseWithDataBlock(r.InvokePriority, *dlms.CreateDataBlockGAsData(b.LastBlock, b.BlockNumber, b.Raw))
+		case dlms.GetRequestNext:
+			b := blocks[r.BlockNum]
+			return dlms.CreateGetResponseWithDataBlock(r.InvokePriority, *dlms.CreateDataBlockGAsData(b.LastBlock, b.BlockNumber, b.Raw))
+		}
+		return nil
+	})
+
+	t1, err := c.Get(context.Background(), *dlms.CreateAttributeDescriptor(1, "0.0.42.0.0.255", 2), nil)
+	if err != nil || t1.Tag != axdr.TagOctetString {
+		t.Fatalf("t1 Failed. get: %v, err:%v", t1, err)
+	}
+	if encoded, _ := t1.Encode(); bytes.Compare(encoded, raw) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X", encoded, raw)
+	}
+	if len(m.requests) != 1+len(blocks) {
+		t.Errorf("t1 Failed. %v requests sent, should:%v", len(m.requests), 1+len(blocks))
+	}
+}
+
+func TestClient_GetWithWrongBlock(t *testing.T) {
+	c, _ := blockMeter(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		switch r := req.(type) {
+		case dlms.GetRequestNormal:
+			return dlms.CreateGetResponseWithDataBlock(r.InvokePriority, *dlms.CreateDataBlockGAsData(false, 1, []byte{0x09, 0x03}))
+		case dlms.GetRequestNext:
+			// block 2 is skipped
+			return dlms.CreateGetResponseWithDataBlock(r.InvokePriority, *dlms.CreateDataBlockGAsData(true, 3, []byte{1, 2, 3}))
+		}
+		return nil
+	})
+
+	_, err := c.Get(context.Background(), *dlms.CreateAttributeDescriptor(1, "0.0.42.0.0.255", 2), nil)
+	var be *dlms.BlockTransferError
+	if !errors.As(err, &be) || be.Result != dlms.TagAccDataBlockNumberInvalid {
+		t.Errorf("Failed. err:%v", err)
+	}
+}
+
+func TestClient_SetWithBlocks(t *testing.T) {
+	var received dlms.DataBlockAssembler
+	c, m := blockMeter(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		switch r := req.(type) {
+		case dlms.SetRequestWithFirstDataBlock:
+			received.AddSA(r.DataBlock)
+			return dlms.CreateSetResponseDataBlock(r.InvokePriority, r.DataBlock.BlockNumber)
+		case dlms.SetRequestWithDataBlock:
+			received.AddSA(r.DataBlock)
+			if r.DataBlock.LastBlock {
+				return dlms.CreateSetResponseLastDataBlock(r.InvokePriority, dlms.TagAccSuccess, r.DataBlock.BlockNumber)
+			}
+			return dlms.CreateSetResponseDataBlock(r.InvokePriority, r.DataBlock.BlockNumber)
+		}
+		return nil
+	})
+
+	value := *axdr.CreateAxdrOctetString(strings.Repeat("C3", 150))
+	if err := c.Set(context.Background(), *dlms.CreateAttributeDescriptor(1, "0.0.42.0.0.255", 2), nil, value); err != nil {
+		t.Fatalf("t1 Failed. err:%v", err)
+	}
+	raw, _ := value.Encode()
+	if !received.Complete() || bytes.Compare(received.Raw(), raw) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X", received.Raw(), raw)
+	}
+	for i, req := range m.requests[1:] {
+		if src, _ := req.Encode(); len(src) > 64 {
+			t.Errorf("t1 request %d is %v bytes, bigger than max PDU size", i, len(src))
+		}
+	}
+}
+
+func TestClient_ActionWithBlocks(t *testing.T) {
+	var received dlms.DataBlockAssembler
+	ret := dlms.CreateGetDataResultAsData(*axdr.CreateAxdrOctetString(strings.Repeat("e1", 100)))
+	response, _ := dlms.CreateActResponse(dlms.TagActSuccess, ret).Encode()
+	blocks, _ := dlms.SplitDataBlocks(response, 50)
+
+	c, _ := blockMeter(t, func(req dlms.CosemPDU) dlms.CosemPDU {
+		var block dlms.DataBlockSA
+		switch r := req.(type) {
+		case dlms.ActionRequestWithFirstPBlock:
+			block = r.PBlock
+		case dlms.ActionRequestWithPBlock:
+			block = r.PBlock
+		case dlms.ActionRequestNextPBlock:
+			return dlms.CreateActionResponseWithPBlock(r.InvokePriority, blocks[r.BlockNum])
+		default:
+			return nil
+		}
+		received.AddSA(block)
+		if !block.LastBlock {
+			return dlms.CreateActionResponseNextPBlock(0x41, block.BlockNumber)
+		}
+		return dlms.CreateActionResponseWithPBlock(0x41, blocks[0])
+	})
+
+	param := axdr.CreateAxdrOctetString(strings.Repeat("D2", 120))
+	t1, err := c.Action(context.Background(), *dlms.CreateMethodDescriptor(18, "0.0.44.0.0.255", 2), param)
+	if err != nil || t1 == nil {
+		t.Fatalf("t1 Failed. get: %v, err:%v", t1, err)
+	}
+	should, _ := ret.ValueAsData()
+	if a, b := t1.Value, should.Value; a != b {
+		t.Errorf("t1 Failed. get: %v, should:%v", a, b)
+	}
+	raw, _ := param.Encode()
+	if !received.Complete() || bytes.Compare(received.Raw(), raw) != 0 {
+		t.Errorf("t1 Failed. parameter get: %X, should:%X", received.Raw(), raw)
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"gosem/pkg/dlms"
+)
+
+var (
+	ErrNotConnected     = errors.New("client is not connected")
+	ErrAlreadyConnected = errors.New("client is already connected")
+)
+
+// AssociationError is returned by Connect when server refused the AARQ
+type AssociationError struct {
+	Result     dlms.AssociationResult
+	Source     dlms.DiagnosticSource
+	Diagnostic dlms.SourceDiagnostic
+}
+
+func (e *AssociationError) Error() string {
+	return fmt.Sprintf("association %v, diagnostic source %v value %v", e.Result, e.Source, e.Diagnostic)
+}
+
+// ConformanceError is returned before sending a request which needs
+// services the server did not grant on association
+type ConformanceError struct {
+	Missing dlms.ConformanceBlock
+}
+
+func (e *ConformanceError) Error() string {
+	return fmt.Sprintf("service is not granted by server, missing conformance: %v", e.Missing)
+}
+
+// ResponseError is returned when response does not answer the request,
+// either by its type or by its invoke-id
+type ResponseError struct {
+	Request  dlms.CosemPDU
+	Response dlms.CosemPDU
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("unexpected response %T to request %T", e.Response, e.Request)
+}
@@ -0,0 +1,297 @@
+package client
+
+import (
+	"context"
+
+	"gosem/pkg/axdr"
+	"gosem/pkg/dlms"
+)
+
+// Only the invoke-id bits are compared, server may change priority bits
+func sameInvokeId(request uint8, response uint8) bool {
+	return request&0x0F == response&0x0F
+}
+
+// Blocks of raw data fitting in server max PDU size, when carried by
+// first block request
+func (c *Client) splitBlocks(first func(block dlms.DataBlockSA) dlms.CosemPDU, raw []byte) (out []dlms.DataBlockSA, err error) {
+	header, err := first(dlms.DataBlockSA{}).Encode()
+	if err != nil {
+		return
+	}
+	size, err := dlms.DataBlockSize(int(c.MaxPduSize), len(header))
+	if err != nil {
+		return
+	}
+	return dlms.SplitDataBlocks(raw, size)
+}
+
+// Whether encoded request is bigger than server max PDU size
+func (c *Client) tooBig(pdu dlms.CosemPDU) (bool, error) {
+	src, err := pdu.Encode()
+	if err != nil {
+		return false, err
+	}
+	return c.MaxPduSize > 0 && len(src) > int(c.MaxPduSize), nil
+}
+
+// Get reads attribute of object, acc is optional selective access.
+// Value sent by server on several blocks is reassembled
+func (c *Client) Get(ctx context.Context, att dlms.AttributeDescriptor, acc *dlms.SelectiveAccessDescriptor) (out axdr.DlmsData, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	req := dlms.CreateGetRequestNormal(c.nextInvokeId(), att, acc)
+	resp, err := c.request(ctx, req)
+	if err != nil {
+		return
+	}
+
+	var assembler dlms.DataBlockAssembler
+	for {
+		switch gr := resp.(type) {
+		case dlms.GetResponseNormal:
+			if !sameInvokeId(req.InvokePriority, gr.InvokePriority) || assembler.BlockNumber() != 0 {
+				break
+			}
+			if !gr.Result.IsData {
+				result, _ := gr.Result.ValueAsAccess()
+				err = &dlms.DataAccessError{Result: result}
+				return
+			}
+			return gr.Result.ValueAsData()
+
+		case dlms.GetResponseWithDataBlock:
+			if !sameInvokeId(req.InvokePriority, gr.InvokePriority) {
+				break
+			}
+			if err = assembler.AddG(gr.Result); err != nil {
+				return
+			}
+			if assembler.Complete() {
+				return assembler.Data()
+			}
+			next := dlms.CreateGetRequestNext(req.InvokePriority, assembler.BlockNumber())
+			if resp, err = c.request(ctx, next); err != nil {
+				return
+			}
+			continue
+		}
+
+		err = &ResponseError{Request: req, Response: resp}
+		return
+	}
+}
+
+// Set writes value to attribute of object, acc is optional selective access.
+// Value bigger than server max PDU size is sent on several blocks
+func (c *Client) Set(ctx context.Context, att dlms.AttributeDescriptor, acc *dlms.SelectiveAccessDescriptor, value axdr.DlmsData) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	req := dlms.CreateSetRequestNormal(c.nextInvokeId(), att, acc, value)
+	big, err := c.tooBig(req)
+	if err != nil {
+		return
+	}
+	if big {
+		return c.setWithBlocks(ctx, req)
+	}
+
+	resp, err := c.request(ctx, req)
+	if err != nil {
+		return
+	}
+	sr, ok := resp.(dlms.SetResponseNormal)
+	if !ok || !sameInvokeId(req.InvokePriority, sr.InvokePriority) {
+		return &ResponseError{Request: req, Response: resp}
+	}
+	if sr.Result != dlms.TagAccSuccess {
+		return &dlms.DataAccessError{Result: sr.Result}
+	}
+	return
+}
+
+func (c *Client) setWithBlocks(ctx context.Context, req *dlms.SetRequestNormal) (err error) {
+	raw, err := req.Value.AppendEncode(nil)
+	if err != nil {
+		return
+	}
+	blocks, err := c.splitBlocks(func(block dlms.DataBlockSA) dlms.CosemPDU {
+		return dlms.CreateSetRequestWithFirstDataBlock(req.InvokePriority, req.AttributeInfo, req.SelectiveAccessInfo, block)
+	}, raw)
+	if err != nil {
+		return
+	}
+
+	for i, block := range blocks {
+		var blockReq dlms.CosemPDU
+		if i == 0 {
+			blockReq = dlms.CreateSetRequestWithFirstDataBlock(req.InvokePriority, req.AttributeInfo, req.SelectiveAccessInfo, block)
+		} else {
+			blockReq = dlms.CreateSetRequestWithDataBlock(req.InvokePriority, block)
+		}
+		resp, rerr := c.request(ctx, blockReq)
+		if rerr != nil {
+			return rerr
+		}
+
+		switch sr := resp.(type) {
+		case dlms.SetResponseDataBlock:
+			if !block.LastBlock && sameInvokeId(req.InvokePriority, sr.InvokePriority) {
+				if sr.BlockNum != block.BlockNumber {
+					return &dlms.BlockTransferError{Result: dlms.TagAccDataBlockNumberInvalid, BlockNumber: sr.BlockNum}
+				}
+				continue
+			}
+		case dlms.SetResponseLastDataBlock:
+			if block.LastBlock && sameInvokeId(req.InvokePriority, sr.InvokePriority) {
+				if sr.BlockNum != block.BlockNumber {
+					return &dlms.BlockTransferError{Result: dlms.TagAccDataBlockNumberInvalid, BlockNumber: sr.BlockNum}
+				}
+				if sr.Result != dlms.TagAccSuccess {
+					return &dlms.DataAccessError{Result: sr.Result}
+				}
+				return
+			}
+		case dlms.SetResponseNormal:
+			// server aborts the transfer
+			if sameInvokeId(req.InvokePriority, sr.InvokePriority) && sr.Result != dlms.TagAccSuccess {
+				return &dlms.DataAccessError{Result: sr.Result}
+			}
+		}
+		return &ResponseError{Request: blockReq, Response: resp}
+	}
+	return
+}
+
+// Action invokes method of object, param is optional method parameter.
+// Returned data is nil if server sends no return parameter. Parameter and
+// return parameter bigger than max PDU size are carried on several blocks
+func (c *Client) Action(ctx context.Context, mth dlms.MethodDescriptor, param *axdr.DlmsData) (out *axdr.DlmsData, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	req := dlms.CreateActionRequestNormal(c.nextInvokeId(), mth, param)
+	big, err := c.tooBig(req)
+	if err != nil {
+		return
+	}
+
+	var resp dlms.CosemPDU
+	if big {
+		resp, err = c.actionWithBlocks(ctx, req)
+	} else {
+		resp, err = c.request(ctx, req)
+	}
+	if err != nil {
+		return
+	}
+
+	var response dlms.ActResponse
+	switch ar := resp.(type) {
+	case dlms.ActionResponseNormal:
+		if !sameInvokeId(req.InvokePriority, ar.InvokePriority) {
+			err = &ResponseError{Request: req, Response: resp}
+			return
+		}
+		response = ar.Response
+	case dlms.ActionResponseWithPBlock:
+		if response, err = c.actionResponseBlocks(ctx, req, ar); err != nil {
+			return
+		}
+	default:
+		err = &ResponseError{Request: req, Response: resp}
+		return
+	}
+
+	if response.Result != dlms.TagActSuccess {
+		err = &dlms.ActionError{Result: response.Result}
+		return
+	}
+	if response.ReturnParam == nil {
+		return
+	}
+	if !response.ReturnParam.IsData {
+		result, _ := response.ReturnParam.ValueAsAccess()
+		err = &dlms.DataAccessError{Result: result}
+		return
+	}
+	data, err := response.ReturnParam.ValueAsData()
+	if err != nil {
+		return
+	}
+	out = &data
+	return
+}
+
+// Send method parameter on several blocks, returns response of the last block
+func (c *Client) actionWithBlocks(ctx context.Context, req *dlms.ActionRequestNormal) (out dlms.CosemPDU, err error) {
+	raw, err := req.MethodParam.AppendEncode(nil)
+	if err != nil {
+		return
+	}
+	blocks, err := c.splitBlocks(func(block dlms.DataBlockSA) dlms.CosemPDU {
+		return dlms.CreateActionRequestWithFirstPBlock(req.InvokePriority, req.MethodInfo, block)
+	}, raw)
+	if err != nil {
+		return
+	}
+
+	for i, block := range blocks {
+		var blockReq dlms.CosemPDU
+		if i == 0 {
+			blockReq = dlms.CreateActionRequestWithFirstPBlock(req.InvokePriority, req.MethodInfo, block)
+		} else {
+			blockReq = dlms.CreateActionRequestWithPBlock(req.InvokePriority, block)
+		}
+		if out, err = c.request(ctx, blockReq); err != nil {
+			return
+		}
+		if block.LastBlock {
+			return
+		}
+
+		ar, ok := out.(dlms.ActionResponseNextPBlock)
+		if !ok || !sameInvokeId(req.InvokePriority, ar.InvokePriority) {
+			if _, normal := out.(dlms.ActionResponseNormal); normal {
+				// server aborts the transfer
+				return
+			}
+			err = &ResponseError{Request: blockReq, Response: out}
+			return
+		}
+		if ar.BlockNum != block.BlockNumber {
+			err = &dlms.BlockTransferError{Result: dlms.TagAccDataBlockNumberInvalid, BlockNumber: ar.BlockNum}
+			return
+		}
+	}
+	return
+}
+
+// Collect return parameter sent on several blocks, raw data of the blocks
+// is the encoded action-response-with-optional-data
+func (c *Client) actionResponseBlocks(ctx context.Context, req *dlms.ActionRequestNormal, first dlms.ActionResponseWithPBlock) (out dlms.ActResponse, err error) {
+	var assembler dlms.DataBlockAssembler
+	resp := dlms.CosemPDU(first)
+	for {
+		ar, ok := resp.(dlms.ActionResponseWithPBlock)
+		if !ok || !sameInvokeId(req.InvokePriority, ar.InvokePriority) {
+			err = &ResponseError{Request: req, Response: resp}
+			return
+		}
+		if err = assembler.AddSA(ar.PBlock); err != nil {
+			return
+		}
+		if assembler.Complete() {
+			break
+		}
+		next := dlms.CreateActionRequestNextPBlock(req.InvokePriority, assembler.BlockNumber())
+		if resp, err = c.request(ctx, next); err != nil {
+			return
+		}
+	}
+
+	src := append([]byte(nil), assembler.Raw()...)
+	return dlms.DecodeActResponse(&src)
+}
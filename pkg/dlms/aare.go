@@ -0,0 +1,178 @@
+package dlms
+
+import (
+	"fmt"
+	"io"
+)
+
+// BER tags of AARE components
+const (
+	aareTagResult                    byte = 0xA2
+	aareTagResultSourceDiagnostic    byte = 0xA3
+	aareTagRespondingAPTitle         byte = 0xA4
+	aareTagResponderACSERequirements byte = 0x88
+	aareTagMechanismName             byte = 0x89
+	aareTagRespondingAuthentication  byte = 0xAA
+)
+
+// AARE is the association response sent by server. On HLS,
+// RespondingAuthenticationValue holds challenge of the server. On
+// accepted association UserInformation holds the encoded xDLMS
+// InitiateResponse, otherwise it may hold a ConfirmedServiceError.
+type AARE struct {
+	ApplicationContextName        ApplicationContextName
+	Result                        AssociationResult
+	DiagnosticSource              DiagnosticSource
+	Diagnostic                    SourceDiagnostic
+	RespondingAPTitle             []byte
+	MechanismName                 AuthenticationMechanism
+	RespondingAuthenticationValue []byte
+	UserInformation               []byte
+}
+
+func CreateAARE(context ApplicationContextName, result AssociationResult, source DiagnosticSource, diagnostic SourceDiagnostic, userInfo []byte) *AARE {
+	return &AARE{
+		ApplicationContextName: context,
+		Result:                 result,
+		DiagnosticSource:       source,
+		Diagnostic:             diagnostic,
+		UserInformation:        userInfo,
+	}
+}
+
+func (ae AARE) Encode() (out []byte, err error) {
+	return ae.AppendEncode(nil)
+}
+
+func (ae AARE) AppendEncode(dst []byte) ([]byte, error) {
+	if ae.DiagnosticSource != TagDiagACSEServiceUser && ae.DiagnosticSource != TagDiagACSEServiceProvider {
+		return dst, fmt.Errorf("diagnostic source %v is not valid", ae.DiagnosticSource)
+	}
+
+	out := append(dst, TagAARE.Value())
+	start := len(out)
+	out = appendApplicationContext(out, berTagApplicationContext, ae.ApplicationContextName)
+	out, err := appendBERWrapped(out, aareTagResult, berTagInteger, []byte{ae.Result.Value()})
+	if err != nil {
+		return dst, err
+	}
+	out = append(out, aareTagResultSourceDiagnostic)
+	diagnostic := len(out)
+	if out, err = appendBERWrapped(out, 0xA0|ae.DiagnosticSource.Value(), berTagInteger, []byte{ae.Diagnostic.Value()}); err != nil {
+		return dst, err
+	}
+	if out, err = insertBERLength(dst, out, diagnostic); err != nil {
+		return dst, err
+	}
+	if len(ae.RespondingAPTitle) > 0 {
+		if out, err = appendBERWrapped(out, aareTagRespondingAPTitle, berTagOctetString, ae.RespondingAPTitle); err != nil {
+			return dst, err
+		}
+	}
+	if ae.MechanismName != TagMechLowest {
+		if out, err = appendBER(out, aareTagResponderACSERequirements, acseRequirementsAuthentication); err != nil {
+			return dst, err
+		}
+		out = appendMechanismName(out, aareTagMechanismName, ae.MechanismName)
+		if len(ae.RespondingAuthenticationValue) > 0 {
+			if out, err = appendBERWrapped(out, aareTagRespondingAuthentication, berTagCharString, ae.RespondingAuthenticationValue); err != nil {
+				return dst, err
+			}
+		}
+	}
+	if len(ae.UserInformation) > 0 {
+		if out, err = appendBERWrapped(out, berTagUserInformation, berTagOctetString, ae.UserInformation); err != nil {
+			return dst, err
+		}
+	}
+
+	return insertBERLength(dst, out, start)
+}
+
+func (ae AARE) EncodeTo(w io.Writer) error { return encodeTo(w, ae) }
+
+func DecodeAARE(ori *[]byte) (out AARE, err error) {
+	src := *ori
+	contents, err := decodeACSE(&src, TagAARE)
+	if err != nil {
+		return
+	}
+
+	// contents end where the APDU ends
+	end := len(*ori) - len(src)
+	for len(contents) > 0 {
+		offset := end - len(contents)
+		var tag byte
+		var value []byte
+		tag, value, err = decodeBER(&contents)
+		if err != nil {
+			return
+		}
+
+		switch tag {
+		case berTagApplicationContext:
+			out.ApplicationContextName, err = decodeApplicationContext(value)
+		case aareTagResult:
+			var result uint8
+			result, err = decodeBERInteger(value)
+			out.Result = AssociationResult(result)
+		case aareTagResultSourceDiagnostic:
+			out.DiagnosticSource, out.Diagnostic, err = decodeSourceDiagnostic(value)
+		case aareTagRespondingAPTitle:
+			out.RespondingAPTitle, err = decodeBERWrapped(value, berTagOctetString)
+		case aareTagMechanismName:
+			out.MechanismName, err = decodeMechanismName(value)
+		case aareTagRespondingAuthentication:
+			out.RespondingAuthenticationValue, err = decodeBERWrapped(value, berTagCharString)
+		case berTagUserInformation:
+			out.UserInformation, err = decodeBERWrapped(value, berTagOctetString)
+		}
+		if err != nil {
+			err = errDecode(offset, fmt.Errorf("AARE component 0x%02X: %w", tag, err))
+			return
+		}
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+func decodeSourceDiagnostic(value []byte) (source DiagnosticSource, diagnostic SourceDiagnostic, err error) {
+	tag, inner, err := decodeBER(&value)
+	if err != nil {
+		return
+	}
+	source = DiagnosticSource(tag &^ 0xA0)
+	if source != TagDiagACSEServiceUser && source != TagDiagACSEServiceProvider {
+		err = errDecode(0, fmt.Errorf("diagnostic source 0x%02X is not recognized", tag))
+		return
+	}
+	d, err := decodeBERInteger(inner)
+	diagnostic = SourceDiagnostic(d)
+	return
+}
+
+// InitiateResponse decodes user-information of AARE which is not ciphered.
+// If server refused the InitiateRequest, the ConfirmedServiceError is
+// returned as *ServiceError
+func (ae AARE) InitiateResponse() (out InitiateResponse, err error) {
+	if len(ae.UserInformation) == 0 {
+		err = errDecode(0, fmt.Errorf("AARE has no user-information"))
+		return
+	}
+	src := ae.UserInformation
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
+	if src[0] == TagConfirmedServiceError.Value() {
+		cse, e := DecodeConfirmedServiceError(&src)
+		if e != nil {
+			err = e
+			return
+		}
+		err = &ServiceError{Response: cse}
+		return
+	}
+	return DecodeInitiateResponse(&src)
+}
@@ -0,0 +1,97 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_AARE(t *testing.T) {
+	userInfo := []byte{8, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176, 0, 7}
+	var a AARE = *CreateAARE(TagAppCtxLNNoCipher, TagAssocAccepted, TagDiagACSEServiceUser, TagDiagNull, userInfo)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{
+		97, 41,
+		161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1,
+		162, 3, 2, 1, 0,
+		163, 5, 161, 3, 2, 1, 0,
+		190, 16, 4, 14, 8, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176, 0, 7,
+	}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	a = *CreateAARE(TagAppCtxLNNoCipher, TagAssocAccepted, TagDiagACSEServiceUser, TagDiagAuthenticationRequired, nil)
+	a.MechanismName = TagMechHighGMAC
+	a.RespondingAPTitle = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	a.RespondingAuthenticationValue = []byte{9, 9}
+	t2, e := a.Encode()
+	if e != nil {
+		t.Errorf("t2 Encode Failed. err: %v", e)
+	}
+	result = []byte{
+		97, 54,
+		161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1,
+		162, 3, 2, 1, 0,
+		163, 5, 161, 3, 2, 1, 14,
+		164, 10, 4, 8, 1, 2, 3, 4, 5, 6, 7, 8,
+		136, 2, 7, 128,
+		137, 7, 96, 133, 116, 5, 8, 2, 5,
+		170, 4, 128, 2, 9, 9,
+	}
+	res = bytes.Compare(t2, result)
+	if res != 0 {
+		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
+	}
+
+	a.DiagnosticSource = 0
+	_, e = a.Encode()
+	if e == nil {
+		t.Errorf("t3 Encode should fail on invalid diagnostic source")
+	}
+}
+
+func TestDecode_AARE(t *testing.T) {
+	src := []byte{
+		97, 54,
+		161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1,
+		162, 3, 2, 1, 1,
+		163, 5, 162, 3, 2, 1, 2,
+		164, 10, 4, 8, 1, 2, 3, 4, 5, 6, 7, 8,
+		136, 2, 7, 128,
+		137, 7, 96, 133, 116, 5, 8, 2, 5,
+		170, 4, 128, 2, 9, 9,
+	}
+	a, err := DecodeAARE(&src)
+	if err != nil {
+		t.Fatalf("t1 failed on DecodeAARE. Err: %v", err)
+	}
+	if a.Result != TagAssocRejectedPermanent {
+		t.Errorf("t1 Result get: %v", a.Result)
+	}
+	if a.DiagnosticSource != TagDiagACSEServiceProvider || a.Diagnostic != TagDiagNoCommonACSEVersion {
+		t.Errorf("t1 Diagnostic get: %v %v", a.DiagnosticSource, a.Diagnostic)
+	}
+	if bytes.Compare(a.RespondingAPTitle, []byte{1, 2, 3, 4, 5, 6, 7, 8}) != 0 {
+		t.Errorf("t1 RespondingAPTitle get: %v", a.RespondingAPTitle)
+	}
+	if a.MechanismName != TagMechHighGMAC {
+		t.Errorf("t1 MechanismName get: %v", a.MechanismName)
+	}
+	if bytes.Compare(a.RespondingAuthenticationValue, []byte{9, 9}) != 0 {
+		t.Errorf("t1 RespondingAuthenticationValue get: %v", a.RespondingAuthenticationValue)
+	}
+	if len(src) != 0 {
+		t.Errorf("t1 remaining byte get: %v", src)
+	}
+
+	// unknown diagnostic source
+	src = []byte{97, 7, 163, 5, 163, 3, 2, 1, 0}
+	_, err = DecodeAARE(&src)
+	if err == nil {
+		t.Errorf("t2 should failed on DecodeAARE")
+	}
+}
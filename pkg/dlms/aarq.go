@@ -0,0 +1,140 @@
+package dlms
+
+import (
+	"fmt"
+	"io"
+)
+
+// BER tags of AARQ components
+const (
+	aarqTagCalledAPTitle          byte = 0xA2
+	aarqTagCallingAPTitle         byte = 0xA6
+	aarqTagCallingAEQualifier     byte = 0xA7
+	aarqTagSenderACSERequirements byte = 0x8A
+	aarqTagMechanismName          byte = 0x8B
+	aarqTagCallingAuthentication  byte = 0xAC
+)
+
+// AARQ is the association request sent by client. MechanismName and
+// CallingAuthenticationValue are only encoded if mechanism is higher than
+// lowest level security. Calling AP title is the system title of client,
+// used by ciphering and HLS. UserInformation holds the encoded xDLMS
+// InitiateRequest, plain or ciphered.
+type AARQ struct {
+	ApplicationContextName     ApplicationContextName
+	CalledAPTitle              []byte
+	CallingAPTitle             []byte
+	CallingAEQualifier         []byte
+	MechanismName              AuthenticationMechanism
+	CallingAuthenticationValue []byte
+	UserInformation            []byte
+}
+
+func CreateAARQ(context ApplicationContextName, mechanism AuthenticationMechanism, authValue []byte, userInfo []byte) *AARQ {
+	return &AARQ{
+		ApplicationContextName:     context,
+		MechanismName:              mechanism,
+		CallingAuthenticationValue: authValue,
+		UserInformation:            userInfo,
+	}
+}
+
+func (aq AARQ) Encode() (out []byte, err error) {
+	return aq.AppendEncode(nil)
+}
+
+func (aq AARQ) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagAARQ.Value())
+	start := len(out)
+	out = appendApplicationContext(out, berTagApplicationContext, aq.ApplicationContextName)
+
+	var err error
+	if len(aq.CalledAPTitle) > 0 {
+		if out, err = appendBERWrapped(out, aarqTagCalledAPTitle, berTagOctetString, aq.CalledAPTitle); err != nil {
+			return dst, err
+		}
+	}
+	if len(aq.CallingAPTitle) > 0 {
+		if out, err = appendBERWrapped(out, aarqTagCallingAPTitle, berTagOctetString, aq.CallingAPTitle); err != nil {
+			return dst, err
+		}
+	}
+	if len(aq.CallingAEQualifier) > 0 {
+		if out, err = appendBERWrapped(out, aarqTagCallingAEQualifier, berTagOctetString, aq.CallingAEQualifier); err != nil {
+			return dst, err
+		}
+	}
+	if aq.MechanismName != TagMechLowest {
+		if out, err = appendBER(out, aarqTagSenderACSERequirements, acseRequirementsAuthentication); err != nil {
+			return dst, err
+		}
+		out = appendMechanismName(out, aarqTagMechanismName, aq.MechanismName)
+		if out, err = appendBERWrapped(out, aarqTagCallingAuthentication, berTagCharString, aq.CallingAuthenticationValue); err != nil {
+			return dst, err
+		}
+	}
+	if len(aq.UserInformation) > 0 {
+		if out, err = appendBERWrapped(out, berTagUserInformation, berTagOctetString, aq.UserInformation); err != nil {
+			return dst, err
+		}
+	}
+
+	return insertBERLength(dst, out, start)
+}
+
+func (aq AARQ) EncodeTo(w io.Writer) error { return encodeTo(w, aq) }
+
+func DecodeAARQ(ori *[]byte) (out AARQ, err error) {
+	src := *ori
+	contents, err := decodeACSE(&src, TagAARQ)
+	if err != nil {
+		return
+	}
+
+	// contents end where the APDU ends
+	end := len(*ori) - len(src)
+	for len(contents) > 0 {
+		offset := end - len(contents)
+		var tag byte
+		var value []byte
+		tag, value, err = decodeBER(&contents)
+		if err != nil {
+			return
+		}
+
+		switch tag {
+		case berTagApplicationContext:
+			out.ApplicationContextName, err = decodeApplicationContext(value)
+		case aarqTagCalledAPTitle:
+			out.CalledAPTitle, err = decodeBERWrapped(value, berTagOctetString)
+		case aarqTagCallingAPTitle:
+			out.CallingAPTitle, err = decodeBERWrapped(value, berTagOctetString)
+		case aarqTagCallingAEQualifier:
+			out.CallingAEQualifier, err = decodeBERWrapped(value, berTagOctetString)
+		case aarqTagMechanismName:
+			out.MechanismName, err = decodeMechanismName(value)
+		case aarqTagCallingAuthentication:
+			out.CallingAuthenticationValue, err = decodeBERWrapped(value, berTagCharString)
+		case berTagUserInformation:
+			out.UserInformation, err = decodeBERWrapped(value, berTagOctetString)
+		}
+		// other components such as protocol-version & acse-requirements are skipped
+		if err != nil {
+			err = errDecode(offset, fmt.Errorf("AARQ component 0x%02X: %w", tag, err))
+			return
+		}
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+// InitiateRequest decodes user-information of AARQ which is not ciphered
+func (aq AARQ) InitiateRequest() (out InitiateRequest, err error) {
+	if len(aq.UserInformation) == 0 {
+		err = errDecode(0, fmt.Errorf("AARQ has no user-information"))
+		return
+	}
+	src := aq.UserInformation
+	return DecodeInitiateRequest(&src)
+}
@@ -0,0 +1,97 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_AARQ(t *testing.T) {
+	userInfo := []byte{1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176}
+	var a AARQ = *CreateAARQ(TagAppCtxLNNoCipher, TagMechLow, []byte("12345678"), userInfo)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{
+		96, 54,
+		161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1,
+		138, 2, 7, 128,
+		139, 7, 96, 133, 116, 5, 8, 2, 1,
+		172, 10, 128, 8, 49, 50, 51, 52, 53, 54, 55, 56,
+		190, 16, 4, 14, 1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176,
+	}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	// lowest level security has no authentication components
+	a = *CreateAARQ(TagAppCtxLNCipher, TagMechLowest, nil, []byte{1, 2})
+	a.CallingAPTitle = []byte{77, 77, 77, 0, 0, 0, 0, 1}
+	t2, e := a.Encode()
+	if e != nil {
+		t.Errorf("t2 Encode Failed. err: %v", e)
+	}
+	result = []byte{
+		96, 29,
+		161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 3,
+		166, 10, 4, 8, 77, 77, 77, 0, 0, 0, 0, 1,
+		190, 4, 4, 2, 1, 2,
+	}
+	res = bytes.Compare(t2, result)
+	if res != 0 {
+		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
+	}
+}
+
+func TestDecode_AARQ(t *testing.T) {
+	src := []byte{
+		96, 54,
+		161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1,
+		138, 2, 7, 128,
+		139, 7, 96, 133, 116, 5, 8, 2, 1,
+		172, 10, 128, 8, 49, 50, 51, 52, 53, 54, 55, 56,
+		190, 16, 4, 14, 1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176,
+		1, 2, 3,
+	}
+	a, err := DecodeAARQ(&src)
+	if err != nil {
+		t.Fatalf("t1 failed on DecodeAARQ. Err: %v", err)
+	}
+	if a.ApplicationContextName != TagAppCtxLNNoCipher {
+		t.Errorf("t1 ApplicationContextName get: %v", a.ApplicationContextName)
+	}
+	if a.MechanismName != TagMechLow {
+		t.Errorf("t1 MechanismName get: %v", a.MechanismName)
+	}
+	if string(a.CallingAuthenticationValue) != "12345678" {
+		t.Errorf("t1 CallingAuthenticationValue get: %v", a.CallingAuthenticationValue)
+	}
+	res := bytes.Compare(a.UserInformation, []byte{1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176})
+	if res != 0 {
+		t.Errorf("t1 UserInformation get: %v", a.UserInformation)
+	}
+	if bytes.Compare(src, []byte{1, 2, 3}) != 0 {
+		t.Errorf("t1 remaining byte get: %v", src)
+	}
+
+	// wrong application context prefix
+	src = []byte{96, 11, 161, 9, 6, 7, 96, 133, 116, 5, 8, 9, 1}
+	_, err = DecodeAARQ(&src)
+	if err == nil {
+		t.Errorf("t2 should failed on DecodeAARQ")
+	}
+
+	// length is longer than source
+	src = []byte{96, 30, 161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1}
+	_, err = DecodeAARQ(&src)
+	if err == nil {
+		t.Errorf("t3 should failed on DecodeAARQ")
+	}
+
+	src = []byte{97, 0}
+	_, err = DecodeAARQ(&src)
+	if err == nil {
+		t.Errorf("t4 should failed on DecodeAARQ")
+	}
+}
@@ -0,0 +1,308 @@
+package dlms
+
+import (
+	"bytes"
+	"fmt"
+
+	"gosem/pkg/axdr"
+)
+
+// ACSE APDUs are BER encoded, unlike xDLMS APDUs which use A-XDR.
+// Every component is written as tag, length and value. Length uses the
+// same definite form of A-XDR length, so axdr.EncodeLength is reused.
+
+type ApplicationContextName uint8
+
+const (
+	TagAppCtxLNNoCipher ApplicationContextName = 1
+	TagAppCtxSNNoCipher ApplicationContextName = 2
+	TagAppCtxLNCipher   ApplicationContextName = 3
+	TagAppCtxSNCipher   ApplicationContextName = 4
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s ApplicationContextName) Value() uint8 {
+	return uint8(s)
+}
+
+func (s ApplicationContextName) String() string {
+	switch s {
+	case TagAppCtxLNNoCipher:
+		return "logical-name-referencing-no-ciphering"
+	case TagAppCtxSNNoCipher:
+		return "short-name-referencing-no-ciphering"
+	case TagAppCtxLNCipher:
+		return "logical-name-referencing-with-ciphering"
+	case TagAppCtxSNCipher:
+		return "short-name-referencing-with-ciphering"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+type AuthenticationMechanism uint8
+
+const (
+	TagMechLowest     AuthenticationMechanism = 0
+	TagMechLow        AuthenticationMechanism = 1
+	TagMechHigh       AuthenticationMechanism = 2
+	TagMechHighMD5    AuthenticationMechanism = 3
+	TagMechHighSHA1   AuthenticationMechanism = 4
+	TagMechHighGMAC   AuthenticationMechanism = 5
+	TagMechHighSHA256 AuthenticationMechanism = 6
+	TagMechHighECDSA  AuthenticationMechanism = 7
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s AuthenticationMechanism) Value() uint8 {
+	return uint8(s)
+}
+
+func (s AuthenticationMechanism) String() string {
+	switch s {
+	case TagMechLowest:
+		return "lowest-level-security"
+	case TagMechLow:
+		return "low-level-security"
+	case TagMechHigh:
+		return "high-level-security"
+	case TagMechHighMD5:
+		return "high-level-security-md5"
+	case TagMechHighSHA1:
+		return "high-level-security-sha1"
+	case TagMechHighGMAC:
+		return "high-level-security-gmac"
+	case TagMechHighSHA256:
+		return "high-level-security-sha256"
+	case TagMechHighECDSA:
+		return "high-level-security-ecdsa"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+type AssociationResult uint8
+
+const (
+	TagAssocAccepted          AssociationResult = 0
+	TagAssocRejectedPermanent AssociationResult = 1
+	TagAssocRejectedTransient AssociationResult = 2
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s AssociationResult) Value() uint8 {
+	return uint8(s)
+}
+
+func (s AssociationResult) String() string {
+	switch s {
+	case TagAssocAccepted:
+		return "accepted"
+	case TagAssocRejectedPermanent:
+		return "rejected-permanent"
+	case TagAssocRejectedTransient:
+		return "rejected-transient"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+type DiagnosticSource uint8
+
+const (
+	TagDiagACSEServiceUser     DiagnosticSource = 1
+	TagDiagACSEServiceProvider DiagnosticSource = 2
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s DiagnosticSource) Value() uint8 {
+	return uint8(s)
+}
+
+type SourceDiagnostic uint8
+
+const (
+	// acse-service-user
+	TagDiagNull                                 SourceDiagnostic = 0
+	TagDiagNoReasonGiven                        SourceDiagnostic = 1
+	TagDiagApplicationContextNameNotSupported   SourceDiagnostic = 2
+	TagDiagCallingAPTitleNotRecognized          SourceDiagnostic = 3
+	TagDiagCallingAPInvocationIDNotRecognized   SourceDiagnostic = 4
+	TagDiagCallingAEQualifierNotRecognized      SourceDiagnostic = 5
+	TagDiagCallingAEInvocationIDNotRecognized   SourceDiagnostic = 6
+	TagDiagCalledAPTitleNotRecognized           SourceDiagnostic = 7
+	TagDiagCalledAPInvocationIDNotRecognized    SourceDiagnostic = 8
+	TagDiagCalledAEQualifierNotRecognized       SourceDiagnostic = 9
+	TagDiagCalledAEInvocationIDNotRecognized    SourceDiagnostic = 10
+	TagDiagAuthenticationMechanismNotRecognized SourceDiagnostic = 11
+	TagDiagAuthenticationMechanismRequired      SourceDiagnostic = 12
+	TagDiagAuthenticationFailure                SourceDiagnostic = 13
+	TagDiagAuthenticationRequired               SourceDiagnostic = 14
+
+	// acse-service-provider
+	TagDiagNoCommonACSEVersion SourceDiagnostic = 2
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s SourceDiagnostic) Value() uint8 {
+	return uint8(s)
+}
+
+// BER tags of ACSE components
+const (
+	berTagApplicationContext byte = 0xA1
+	berTagObjectIdentifier   byte = 0x06
+	berTagOctetString        byte = 0x04
+	berTagInteger            byte = 0x02
+	berTagCharString         byte = 0x80
+	berTagUserInformation    byte = 0xBE
+	berTagReason             byte = 0x80
+)
+
+var (
+	// joint-iso-ctt(2) country(16) country-name(756) identified-organization(5) DLMS-UA(8)
+	oidApplicationContext = []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x01}
+	oidMechanismName      = []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x02}
+	// acse requirements with authentication functional unit
+	acseRequirementsAuthentication = []byte{0x07, 0x80}
+)
+
+// Append BER tag, length & value to dst
+func appendBER(dst []byte, tag byte, value []byte) ([]byte, error) {
+	out, err := axdr.AppendLength(append(dst, tag), len(value))
+	if err != nil {
+		return dst, err
+	}
+	return append(out, value...), nil
+}
+
+// Append BER constructed component, which wraps a primitive
+// element with its own tag
+func appendBERWrapped(dst []byte, tag byte, innerTag byte, value []byte) ([]byte, error) {
+	out := append(dst, tag)
+	start := len(out)
+	out, err := appendBER(out, innerTag, value)
+	if err != nil {
+		return dst, err
+	}
+	return insertBERLength(dst, out, start)
+}
+
+// Insert BER length of out[start:] at start, so bytes after it become
+// value of the element which tag is right before start. Value is
+// appended first as its length is not known before, then moved
+func insertBERLength(dst []byte, out []byte, start int) ([]byte, error) {
+	var buf [9]byte
+	length, err := axdr.AppendLength(buf[:0], len(out)-start)
+	if err != nil {
+		return dst, err
+	}
+	out = append(out, length...)
+	copy(out[start+len(length):], out[start:len(out)-len(length)])
+	copy(out[start:], length)
+	return out, nil
+}
+
+// Read single BER tag, length & value from src
+func decodeBER(src *[]byte) (tag byte, value []byte, err error) {
+	if len(*src) < 2 {
+		err = ErrWrongLength(len(*src), 2)
+		return
+	}
+	tag = (*src)[0]
+	temp := (*src)[1:]
+	_, length, err := axdr.DecodeLength(&temp)
+	if err != nil {
+		return
+	}
+	if uint64(len(temp)) < length {
+		err = errDecode(len(*src)-len(temp), fmt.Errorf("BER element with tag 0x%02X has length %v, only %v bytes left", tag, length, len(temp)))
+		return
+	}
+	value = temp[:length]
+	(*src) = temp[length:]
+	return
+}
+
+// Read BER constructed component which wraps a primitive element
+// with tag of innerTag, returning copy of the value of the inner element
+func decodeBERWrapped(value []byte, innerTag byte) ([]byte, error) {
+	tag, inner, err := decodeBER(&value)
+	if err != nil {
+		return nil, err
+	}
+	if tag != innerTag {
+		return nil, ErrWrongTag(0, tag, innerTag)
+	}
+	return append([]byte(nil), inner...), nil
+}
+
+// Read BER APDU of tag, returning its contents and removing it from src
+func decodeACSE(ori *[]byte, tag cosemTag) (contents []byte, err error) {
+	src := *ori
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	if src[0] != tag.Value() {
+		err = ErrWrongTag(0, src[0], tag.Value())
+		return
+	}
+	_, contents, err = decodeBER(&src)
+	if err != nil {
+		return
+	}
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+func appendApplicationContext(dst []byte, tag byte, name ApplicationContextName) []byte {
+	out := append(dst, tag, byte(len(oidApplicationContext)+3), berTagObjectIdentifier, byte(len(oidApplicationContext)+1))
+	out = append(out, oidApplicationContext...)
+	return append(out, name.Value())
+}
+
+func decodeApplicationContext(value []byte) (name ApplicationContextName, err error) {
+	oid, err := decodeBERWrapped(value, berTagObjectIdentifier)
+	if err != nil {
+		return
+	}
+	if len(oid) != len(oidApplicationContext)+1 || !bytes.HasPrefix(oid, oidApplicationContext) {
+		err = errDecode(0, fmt.Errorf("application-context-name %X is not recognized", oid))
+		return
+	}
+	name = ApplicationContextName(oid[len(oid)-1])
+	return
+}
+
+func appendMechanismName(dst []byte, tag byte, name AuthenticationMechanism) []byte {
+	out := append(dst, tag, byte(len(oidMechanismName)+1))
+	out = append(out, oidMechanismName...)
+	return append(out, name.Value())
+}
+
+func decodeMechanismName(oid []byte) (name AuthenticationMechanism, err error) {
+	if len(oid) != len(oidMechanismName)+1 || !bytes.HasPrefix(oid, oidMechanismName) {
+		err = errDecode(0, fmt.Errorf("mechanism-name %X is not recognized", oid))
+		return
+	}
+	name = AuthenticationMechanism(oid[len(oid)-1])
+	return
+}
+
+// Decode value of EXPLICIT INTEGER such as result & diagnostic
+func decodeBERInteger(value []byte) (uint8, error) {
+	inner, err := decodeBERWrapped(value, berTagInteger)
+	if err != nil {
+		return 0, err
+	}
+	if len(inner) != 1 {
+		return 0, errDecode(0, fmt.Errorf("integer value of %v bytes is not supported", len(inner)))
+	}
+	return inner[0], nil
+}
@@ -1,8 +1,9 @@
 package dlms
 
 import (
-	"bytes"
 	"fmt"
+	"io"
+
 	"gosem/pkg/axdr"
 )
 
@@ -47,6 +48,11 @@ func (gr *ActionRequest) New(tag actionRequestTag) (out CosemPDU, err error) {
 }
 
 func (gr *ActionRequest) Decode(src *[]byte) (out CosemPDU, err error) {
+	if len(*src) < 2 {
+		err = ErrWrongLength(len(*src), 2)
+		return
+	}
+
 	if (*src)[0] != TagActionRequest.Value() {
 		err = ErrWrongTag(0, (*src)[0], byte(TagActionRequest))
 		return
@@ -66,7 +72,7 @@ func (gr *ActionRequest) Decode(src *[]byte) (out CosemPDU, err error) {
 	case TagActionRequestWithPBlock.Value():
 		out, err = DecodeActionRequestWithPBlock(src)
 	default:
-		err = fmt.Errorf("byte tag not recognized (%v)", (*src)[1])
+		err = errDecode(1, fmt.Errorf("byte tag not recognized (%v)", (*src)[1]))
 	}
 
 	return
@@ -88,34 +94,31 @@ func CreateActionRequestNormal(invokeId uint8, mth MethodDescriptor, dt *axdr.Dl
 }
 
 func (ar ActionRequestNormal) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionRequest))
-	buf.WriteByte(byte(TagActionRequestNormal))
-	buf.WriteByte(byte(ar.InvokePriority))
-	val, e := ar.MethodInfo.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(val)
+	return ar.AppendEncode(nil)
+}
+
+// AppendEncode writes request straight into dst, see CosemPDU
+func (ar ActionRequestNormal) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionRequest), byte(TagActionRequestNormal), byte(ar.InvokePriority))
+	out = ar.MethodInfo.appendEncode(out)
 	if ar.MethodParam == nil {
-		buf.WriteByte(0x0)
-	} else {
-		buf.WriteByte(0x1)
-		val, e := ar.MethodParam.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(val)
+		return append(out, 0x0), nil
 	}
-
-	out = buf.Bytes()
-	return
+	out, err := ar.MethodParam.AppendEncode(append(out, 0x1))
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (ar ActionRequestNormal) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionRequestNormal(ori *[]byte) (out ActionRequestNormal, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagActionRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionRequest))
@@ -132,6 +135,10 @@ func DecodeActionRequestNormal(ori *[]byte) (out ActionRequestNormal, err error)
 		return
 	}
 
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	haveMethodParam := src[0]
 	src = src[1:]
 	if haveMethodParam == 0 {
@@ -165,19 +172,22 @@ func CreateActionRequestNextPBlock(invokeId uint8, blockNum uint32) *ActionReque
 }
 
 func (ar ActionRequestNextPBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionRequest))
-	buf.WriteByte(byte(TagActionRequestNextPBlock))
-	buf.WriteByte(byte(ar.InvokePriority))
-	blockNum, _ := axdr.EncodeDoubleLongUnsigned(ar.BlockNum)
-	buf.Write(blockNum)
-
-	out = buf.Bytes()
-	return
+	return ar.AppendEncode(nil)
 }
 
+func (ar ActionRequestNextPBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionRequest), byte(TagActionRequestNextPBlock), byte(ar.InvokePriority))
+	return appendUint32(out, ar.BlockNum), nil
+}
+
+func (ar ActionRequestNextPBlock) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionRequestNextPBlock(ori *[]byte) (out ActionRequestNextPBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagActionRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionRequest))
@@ -227,35 +237,32 @@ func CreateActionRequestWithList(invokeId uint8, mthList []MethodDescriptor, val
 }
 
 func (ar ActionRequestWithList) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionRequest))
-	buf.WriteByte(byte(TagActionRequestWithList))
-	buf.WriteByte(byte(ar.InvokePriority))
-	buf.WriteByte(byte(ar.MethodInfoCount))
+	return ar.AppendEncode(nil)
+}
+
+func (ar ActionRequestWithList) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionRequest), byte(TagActionRequestWithList), byte(ar.InvokePriority), byte(ar.MethodInfoCount))
 	for _, val := range ar.MethodInfoList {
-		mthInfo, e := val.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(mthInfo)
+		out = val.appendEncode(out)
 	}
-	buf.WriteByte(byte(ar.MethodParamCount))
+	out = append(out, byte(ar.MethodParamCount))
 	for _, val := range ar.MethodParamList {
-		mthParam, e := val.Encode()
-		if e != nil {
-			err = e
-			return
+		var err error
+		if out, err = val.AppendEncode(out); err != nil {
+			return dst, err
 		}
-		buf.Write(mthParam)
 	}
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (ar ActionRequestWithList) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionRequestWithList(ori *[]byte) (out ActionRequestWithList, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagActionRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionRequest))
@@ -278,6 +285,10 @@ func DecodeActionRequestWithList(ori *[]byte) (out ActionRequestWithList, err er
 		out.MethodInfoList = append(out.MethodInfoList, v)
 	}
 
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	out.MethodParamCount = uint8(src[0])
 	src = src[1:]
 	for i := 0; i < int(out.MethodParamCount); i++ {
@@ -310,29 +321,27 @@ func CreateActionRequestWithFirstPBlock(invokeId uint8, mth MethodDescriptor, dt
 }
 
 func (ar ActionRequestWithFirstPBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionRequest))
-	buf.WriteByte(byte(TagActionRequestWithFirstPBlock))
-	buf.WriteByte(byte(ar.InvokePriority))
-	mthInfo, e := ar.MethodInfo.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(mthInfo)
-	pb, e := ar.PBlock.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(pb)
+	return ar.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (ar ActionRequestWithFirstPBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionRequest), byte(TagActionRequestWithFirstPBlock), byte(ar.InvokePriority))
+	out = ar.MethodInfo.appendEncode(out)
+	out, err := ar.PBlock.appendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (ar ActionRequestWithFirstPBlock) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionRequestWithFirstPBlock(ori *[]byte) (out ActionRequestWithFirstPBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagActionRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionRequest))
@@ -376,32 +385,29 @@ func CreateActionRequestWithListAndFirstPBlock(invokeId uint8, mthList []MethodD
 }
 
 func (ar ActionRequestWithListAndFirstPBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionRequest))
-	buf.WriteByte(byte(TagActionRequestWithListAndFirstPBlock))
-	buf.WriteByte(byte(ar.InvokePriority))
-	buf.WriteByte(byte(ar.MethodInfoCount))
+	return ar.AppendEncode(nil)
+}
+
+func (ar ActionRequestWithListAndFirstPBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionRequest), byte(TagActionRequestWithListAndFirstPBlock), byte(ar.InvokePriority), byte(ar.MethodInfoCount))
 	for _, val := range ar.MethodInfoList {
-		mthInfo, e := val.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(mthInfo)
+		out = val.appendEncode(out)
 	}
-	pb, e := ar.PBlock.Encode()
-	if e != nil {
-		err = e
-		return
+	out, err := ar.PBlock.appendEncode(out)
+	if err != nil {
+		return dst, err
 	}
-	buf.Write(pb)
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (ar ActionRequestWithListAndFirstPBlock) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionRequestWithListAndFirstPBlock(ori *[]byte) (out ActionRequestWithListAndFirstPBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagActionRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionRequest))
@@ -444,23 +450,26 @@ func CreateActionRequestWithPBlock(invokeId uint8, dt DataBlockSA) *ActionReques
 }
 
 func (ar ActionRequestWithPBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionRequest))
-	buf.WriteByte(byte(TagActionRequestWithPBlock))
-	buf.WriteByte(byte(ar.InvokePriority))
-	pb, e := ar.PBlock.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(pb)
+	return ar.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (ar ActionRequestWithPBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionRequest), byte(TagActionRequestWithPBlock), byte(ar.InvokePriority))
+	out, err := ar.PBlock.appendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (ar ActionRequestWithPBlock) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionRequestWithPBlock(ori *[]byte) (out ActionRequestWithPBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagActionRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionRequest))
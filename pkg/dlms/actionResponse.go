@@ -1,8 +1,9 @@
 package dlms
 
 import (
-	"bytes"
 	"fmt"
+	"io"
+
 	"gosem/pkg/axdr"
 )
 
@@ -41,6 +42,11 @@ func (gr *ActionResponse) New(tag actionResponseTag) (out CosemPDU, err error) {
 }
 
 func (gr *ActionResponse) Decode(src *[]byte) (out CosemPDU, err error) {
+	if len(*src) < 2 {
+		err = ErrWrongLength(len(*src), 2)
+		return
+	}
+
 	if (*src)[0] != TagActionResponse.Value() {
 		err = ErrWrongTag(0, (*src)[0], byte(TagActionResponse))
 		return
@@ -56,7 +62,7 @@ func (gr *ActionResponse) Decode(src *[]byte) (out CosemPDU, err error) {
 	case TagActionResponseNextPBlock.Value():
 		out, err = DecodeActionResponseNextPBlock(src)
 	default:
-		err = fmt.Errorf("byte tag not recognized (%v)", (*src)[1])
+		err = errDecode(1, fmt.Errorf("byte tag not recognized (%v)", (*src)[1]))
 	}
 
 	return
@@ -75,23 +81,27 @@ func CreateActionResponseNormal(invokeId uint8, res ActResponse) *ActionResponse
 }
 
 func (ar ActionResponseNormal) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionResponse))
-	buf.WriteByte(byte(TagActionResponseNormal))
-	buf.WriteByte(byte(ar.InvokePriority))
-	val, e := ar.Response.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(val)
+	return ar.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+// AppendEncode writes response straight into dst, see CosemPDU
+func (ar ActionResponseNormal) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionResponse), byte(TagActionResponseNormal), byte(ar.InvokePriority))
+	out, err := ar.Response.appendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (ar ActionResponseNormal) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionResponseNormal(ori *[]byte) (out ActionResponseNormal, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagActionResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionResponse))
@@ -123,23 +133,26 @@ func CreateActionResponseWithPBlock(invokeId uint8, dt DataBlockSA) *ActionRespo
 }
 
 func (ar ActionResponseWithPBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionResponse))
-	buf.WriteByte(byte(TagActionResponseWithPBlock))
-	buf.WriteByte(byte(ar.InvokePriority))
-	val, e := ar.PBlock.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(val)
+	return ar.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (ar ActionResponseWithPBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionResponse), byte(TagActionResponseWithPBlock), byte(ar.InvokePriority))
+	out, err := ar.PBlock.appendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (ar ActionResponseWithPBlock) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionResponseWithPBlock(ori *[]byte) (out ActionResponseWithPBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagActionResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionResponse))
@@ -176,26 +189,28 @@ func CreateActionResponseWithList(invokeId uint8, resList []ActResponse) *Action
 }
 
 func (ar ActionResponseWithList) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionResponse))
-	buf.WriteByte(byte(TagActionResponseWithList))
-	buf.WriteByte(byte(ar.InvokePriority))
-	buf.WriteByte(byte(ar.ResponseCount))
+	return ar.AppendEncode(nil)
+}
+
+func (ar ActionResponseWithList) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionResponse), byte(TagActionResponseWithList), byte(ar.InvokePriority), byte(ar.ResponseCount))
 	for _, res := range ar.ResponseList {
-		val, e := res.Encode()
-		if e != nil {
-			err = e
-			return
+		var err error
+		if out, err = res.appendEncode(out); err != nil {
+			return dst, err
 		}
-		buf.Write(val)
 	}
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (ar ActionResponseWithList) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionResponseWithList(ori *[]byte) (out ActionResponseWithList, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagActionResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionResponse))
@@ -235,19 +250,22 @@ func CreateActionResponseNextPBlock(invokeId uint8, blockNum uint32) *ActionResp
 }
 
 func (ar ActionResponseNextPBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagActionResponse))
-	buf.WriteByte(byte(TagActionResponseNextPBlock))
-	buf.WriteByte(byte(ar.InvokePriority))
-	blockNum, _ := axdr.EncodeDoubleLongUnsigned(ar.BlockNum)
-	buf.Write(blockNum)
-
-	out = buf.Bytes()
-	return
+	return ar.AppendEncode(nil)
 }
 
+func (ar ActionResponseNextPBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagActionResponse), byte(TagActionResponseNextPBlock), byte(ar.InvokePriority))
+	return appendUint32(out, ar.BlockNum), nil
+}
+
+func (ar ActionResponseNextPBlock) EncodeTo(w io.Writer) error { return encodeTo(w, ar) }
+
 func DecodeActionResponseNextPBlock(ori *[]byte) (out ActionResponseNextPBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagActionResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagActionResponse))
@@ -1,7 +1,6 @@
 package dlms
 
 import (
-	"encoding/binary"
 	"fmt"
 	"gosem/pkg/axdr"
 )
@@ -19,19 +18,17 @@ func CreateAttributeDescriptor(c uint16, i string, a int8) *AttributeDescriptor
 }
 
 func (ad AttributeDescriptor) Encode() (out []byte, err error) {
-	var output []byte
-	var c [2]byte
-	binary.BigEndian.PutUint16(c[:], ad.ClassId)
-	output = append(output, c[:]...)
-	output = append(output, ad.InstanceId.Bytes()...)
-	output = append(output, byte(ad.AttributeId))
-
-	out = output
-	return
+	return ad.appendEncode(nil), nil
+}
+
+func (ad AttributeDescriptor) appendEncode(dst []byte) []byte {
+	dst = append(dst, byte(ad.ClassId>>8), byte(ad.ClassId))
+	dst = append(dst, ad.InstanceId.byteValue[:]...)
+	return append(dst, byte(ad.AttributeId))
 }
 
 func DecodeAttributeDescriptor(ori *[]byte) (out AttributeDescriptor, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
 
 	if len(src) < 9 {
 		err = fmt.Errorf("byte slice length must be at least 9 bytes")
@@ -46,6 +43,10 @@ func DecodeAttributeDescriptor(ori *[]byte) (out AttributeDescriptor, err error)
 	if err != nil {
 		return
 	}
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	out.AttributeId = int8(src[0])
 	src = src[1:]
 
@@ -1,7 +1,6 @@
 package dlms
 
 import (
-	"encoding/binary"
 	"fmt"
 	"gosem/pkg/axdr"
 )
@@ -22,30 +21,29 @@ func CreateAttributeDescriptorWithSelection(c uint16, i string, a int8, sad *Sel
 }
 
 func (ad AttributeDescriptorWithSelection) Encode() (out []byte, err error) {
-	var output []byte
-	var c [2]byte
-	binary.BigEndian.PutUint16(c[:], ad.ClassId)
-	output = append(output, c[:]...)
-	output = append(output, ad.InstanceId.Bytes()...)
-	output = append(output, byte(ad.AttributeId))
-	if ad.AccessDescriptor == nil {
-		output = append(output, 0)
-	} else {
-		output = append(output, 1)
-		val, e := ad.AccessDescriptor.Encode()
-		if e != nil {
-			err = e
-			return
+	return ad.appendEncode(nil)
+}
+
+func (ad AttributeDescriptorWithSelection) appendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(ad.ClassId>>8), byte(ad.ClassId))
+	out = append(out, ad.InstanceId.byteValue[:]...)
+	out = append(out, byte(ad.AttributeId))
+	return appendSelectiveAccess(dst, out, ad.AccessDescriptor)
+}
+
+// Append every descriptor of list to out, dst is returned as it is on error
+func appendAttributeList(dst []byte, out []byte, list []AttributeDescriptorWithSelection) ([]byte, error) {
+	for _, attr := range list {
+		var err error
+		if out, err = attr.appendEncode(out); err != nil {
+			return dst, err
 		}
-		output = append(output, val...)
 	}
-
-	out = output
-	return
+	return out, nil
 }
 
 func DecodeAttributeDescriptorWithSelection(ori *[]byte) (out AttributeDescriptorWithSelection, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
 
 	if len(src) < 11 {
 		err = fmt.Errorf("byte slice length must be at least 11 bytes")
@@ -62,6 +60,10 @@ func DecodeAttributeDescriptorWithSelection(ori *[]byte) (out AttributeDescripto
 		return
 	}
 
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
 	out.AttributeId = int8(src[0])
 	haveAccDesc := src[1]
 	src = src[2:]
@@ -0,0 +1,146 @@
+package dlms
+
+import (
+	"bytes"
+	"fmt"
+
+	"gosem/pkg/axdr"
+)
+
+// BlockTransferError is failure of a block transfer. Result is either
+// sent by peer in place of a block, TagAccDataBlockNumberInvalid and
+// TagAccNoLongGetInProgress when received blocks are out of sequence, or
+// TagAccOtherReason when received blocks are bigger than allowed
+type BlockTransferError struct {
+	Result      AccessResultTag
+	BlockNumber uint32
+}
+
+func (e *BlockTransferError) Error() string {
+	return fmt.Sprintf("block transfer failed on block %v: %v", e.BlockNumber, e.Result)
+}
+
+// Unwrap returns the result as DataAccessError
+func (e *BlockTransferError) Unwrap() error {
+	return &DataAccessError{Result: e.Result}
+}
+
+// Bytes of A-XDR length in front of block raw data, taken at its biggest
+// as APDU size is limited to 0xFFFF
+const dataBlockLengthSize = 3
+
+// DataBlockSize returns room left for raw data of a block, when the
+// request carrying the block with empty raw data encodes to header bytes
+func DataBlockSize(maxPduSize int, header int) (out int, err error) {
+	out = maxPduSize - header - dataBlockLengthSize + 1
+	if out < 1 {
+		err = fmt.Errorf("max PDU size %v leaves no room for data block", maxPduSize)
+	}
+	return
+}
+
+// SplitDataBlocks cuts raw into blocks of at most size bytes. Block
+// number starts from 1 and only the last block has LastBlock set
+func SplitDataBlocks(raw []byte, size int) (out []DataBlockSA, err error) {
+	if size < 1 {
+		err = fmt.Errorf("data block size %v is not valid", size)
+		return
+	}
+	blockNum := uint32(1)
+	for {
+		n := len(raw)
+		if n > size {
+			n = size
+		}
+		out = append(out, DataBlockSA{LastBlock: n == len(raw), BlockNumber: blockNum, Raw: raw[:n]})
+		raw = raw[n:]
+		if len(raw) == 0 {
+			return
+		}
+		blockNum++
+	}
+}
+
+// Biggest raw data put together by DataBlockAssembler with no MaxSize
+const DefaultDataBlockMaxSize = 0x100000
+
+// DataBlockAssembler collects blocks sent by peer, checking that block
+// number increments by one and nothing comes after the last block.
+// MaxSize is the biggest raw data put together, DefaultDataBlockMaxSize
+// when zero
+type DataBlockAssembler struct {
+	MaxSize int
+
+	blockNumber uint32
+	complete    bool
+	buffer      bytes.Buffer
+}
+
+// BlockNumber returns number of last accepted block, used to ask for next one
+func (a *DataBlockAssembler) BlockNumber() uint32 {
+	return a.blockNumber
+}
+
+// Complete returns whether the last block is received
+func (a *DataBlockAssembler) Complete() bool {
+	return a.complete
+}
+
+func (a *DataBlockAssembler) add(lastBlock bool, blockNum uint32, raw []byte) error {
+	if a.complete {
+		return &BlockTransferError{Result: TagAccNoLongGetInProgress, BlockNumber: blockNum}
+	}
+	if blockNum != a.blockNumber+1 {
+		return &BlockTransferError{Result: TagAccDataBlockNumberInvalid, BlockNumber: blockNum}
+	}
+	max := a.MaxSize
+	if max == 0 {
+		max = DefaultDataBlockMaxSize
+	}
+	if a.buffer.Len()+len(raw) > max {
+		return &BlockTransferError{Result: TagAccOtherReason, BlockNumber: blockNum}
+	}
+	a.blockNumber = blockNum
+	a.complete = lastBlock
+	a.buffer.Write(raw)
+	return nil
+}
+
+// AddG accepts block of GET-response. Block holding data-access-result
+// aborts the transfer
+func (a *DataBlockAssembler) AddG(block DataBlockG) error {
+	if block.IsResult {
+		result, _ := block.ResultAsAccess()
+		return &BlockTransferError{Result: result, BlockNumber: block.BlockNumber}
+	}
+	raw, err := block.ResultAsBytes()
+	if err != nil {
+		return err
+	}
+	return a.add(block.LastBlock, block.BlockNumber, raw)
+}
+
+// AddSA accepts block of SET-request, ACTION-request or ACTION-response
+func (a *DataBlockAssembler) AddSA(block DataBlockSA) error {
+	return a.add(block.LastBlock, block.BlockNumber, block.Raw)
+}
+
+// Raw returns raw data of all accepted blocks
+func (a *DataBlockAssembler) Raw() []byte {
+	return a.buffer.Bytes()
+}
+
+// Data decodes raw data of complete transfer as single DlmsData
+func (a *DataBlockAssembler) Data() (out axdr.DlmsData, err error) {
+	if !a.complete {
+		err = fmt.Errorf("block transfer is not complete, last block is %v", a.blockNumber)
+		return
+	}
+	src := append([]byte(nil), a.buffer.Bytes()...)
+	if len(src) == 0 {
+		err = fmt.Errorf("block transfer has no data")
+		return
+	}
+	decoder := axdr.NewDataDecoder(&src)
+	return decoder.Decode(&src)
+}
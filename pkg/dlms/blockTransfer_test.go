@@ -0,0 +1,137 @@
+package dlms
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"gosem/pkg/axdr"
+)
+
+func TestSplitDataBlocks(t *testing.T) {
+	raw := []byte{1, 2, 3, 4, 5, 6, 7}
+	blocks, err := SplitDataBlocks(raw, 3)
+	if err != nil || len(blocks) != 3 {
+		t.Fatalf("t1 Failed. get: %v, err:%v", blocks, err)
+	}
+	for i, block := range blocks {
+		if block.BlockNumber != uint32(i+1) || block.LastBlock != (i == 2) {
+			t.Errorf("t1 block %d Failed. get: %v", i, block)
+		}
+	}
+	if bytes.Compare(blocks[2].Raw, []byte{7}) != 0 {
+		t.Errorf("t1 Failed. last block get: %v", blocks[2].Raw)
+	}
+
+	blocks, err = SplitDataBlocks(raw, 7)
+	if err != nil || len(blocks) != 1 || !blocks[0].LastBlock {
+		t.Errorf("t2 Failed. get: %v, err:%v", blocks, err)
+	}
+
+	if _, err = SplitDataBlocks(raw, 0); err == nil {
+		t.Errorf("t3 Block size 0 should fail")
+	}
+}
+
+func TestDataBlockSize(t *testing.T) {
+	size, err := DataBlockSize(100, 20)
+	if err != nil || size != 78 {
+		t.Errorf("t1 Failed. get: %v, should:%v, err:%v", size, 78, err)
+	}
+	if _, err = DataBlockSize(20, 20); err == nil {
+		t.Errorf("t2 PDU size without room should fail")
+	}
+}
+
+func TestDataBlockAssembler(t *testing.T) {
+	value := *axdr.CreateAxdrOctetString(strings.Repeat("AB", 300))
+	raw, _ := value.Encode()
+
+	var a DataBlockAssembler
+	blocks, _ := SplitDataBlocks(raw, 100)
+	for i, block := range blocks {
+		g := *CreateDataBlockGAsData(block.LastBlock, block.BlockNumber, block.Raw)
+		if err := a.AddG(g); err != nil {
+			t.Fatalf("t1 block %d Failed. err:%v", i, err)
+		}
+		if a.BlockNumber() != block.BlockNumber {
+			t.Errorf("t1 block %d Failed. block number get: %v", i, a.BlockNumber())
+		}
+	}
+	if !a.Complete() {
+		t.Errorf("t1 Failed. transfer should be complete")
+	}
+	data, err := a.Data()
+	if err != nil || data.Tag != axdr.TagOctetString {
+		t.Fatalf("t1 Failed. get: %v, err:%v", data, err)
+	}
+	if encoded, _ := data.Encode(); bytes.Compare(encoded, raw) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X", encoded, raw)
+	}
+
+	// block after the last one
+	var be *BlockTransferError
+	err = a.AddG(*CreateDataBlockGAsData(true, 5, []byte{1}))
+	if !errors.As(err, &be) || be.Result != TagAccNoLongGetInProgress {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+
+	// block number skipped
+	var b DataBlockAssembler
+	b.AddSA(*CreateDataBlockSA(false, 1, []byte{1}))
+	err = b.AddSA(*CreateDataBlockSA(false, 3, []byte{1}))
+	if !errors.As(err, &be) || be.Result != TagAccDataBlockNumberInvalid || be.BlockNumber != 3 {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+	if _, err = b.Data(); err == nil {
+		t.Errorf("t3 Data of incomplete transfer should fail")
+	}
+
+	// server aborts with data-access-result
+	var c DataBlockAssembler
+	err = c.AddG(*CreateDataBlockGAsResult(true, 1, TagAccLongGetAborted))
+	if !errors.As(err, &be) || be.Result != TagAccLongGetAborted {
+		t.Errorf("t4 Failed. err:%v", err)
+	}
+
+	// raw data is not put together beyond MaxSize
+	d := DataBlockAssembler{MaxSize: 3}
+	if err = d.AddSA(*CreateDataBlockSA(false, 1, []byte{1, 2})); err != nil {
+		t.Errorf("t5 Failed. err:%v", err)
+	}
+	err = d.AddSA(*CreateDataBlockSA(false, 2, []byte{3, 4}))
+	if !errors.As(err, &be) || be.Result != TagAccOtherReason || be.BlockNumber != 2 || len(d.Raw()) != 2 {
+		t.Errorf("t5 should fail on data bigger than MaxSize. err:%v", err)
+	}
+}
+
+func TestDataBlock_LongRaw(t *testing.T) {
+	raw := bytes.Repeat([]byte{0x11}, 200)
+	sa := *CreateDataBlockSA(false, 2, raw)
+	src, err := sa.Encode()
+	if err != nil || src[5] != 0x81 || src[6] != 200 {
+		t.Errorf("t1 Failed. get: %X, err:%v", src[:7], err)
+	}
+	decoded, err := DecodeDataBlockSA(&src)
+	if err != nil || bytes.Compare(decoded.Raw, raw) != 0 || len(src) != 0 {
+		t.Errorf("t1 Decode Failed. get: %v, err:%v", decoded, err)
+	}
+
+	g := *CreateDataBlockGAsData(true, 2, raw)
+	src, err = g.Encode()
+	if err != nil || src[6] != 0x81 {
+		t.Errorf("t2 Failed. get: %X, err:%v", src[:8], err)
+	}
+	decodedG, err := DecodeDataBlockG(&src)
+	value, _ := decodedG.ResultAsBytes()
+	if err != nil || bytes.Compare(value, raw) != 0 || len(src) != 0 {
+		t.Errorf("t2 Decode Failed. get: %v, err:%v", decodedG, err)
+	}
+
+	src = []byte{0, 0, 0, 0, 1, 1, 15, 0xAA}
+	decodedG, err = DecodeDataBlockG(&src)
+	if err != nil || len(src) != 1 {
+		t.Errorf("t3 Failed. get: %v, left: %v, err:%v", decodedG, src, err)
+	}
+}
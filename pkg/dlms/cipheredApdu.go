@@ -0,0 +1,133 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"gosem/pkg/axdr"
+)
+
+// glo-* tags are used with global keys, ded-* tags with dedicated key
+var cipheredTagOf = map[cosemTag][2]cosemTag{
+	TagInitiateRequest:          {TagGloInitiateRequest, TagDedInitiateRequest},
+	TagInitiateResponse:         {TagGloInitiateResponse, TagDedInitiateResponse},
+	TagConfirmedServiceError:    {TagGloConfirmedServiceError, TagDedConfirmedServiceError},
+	TagReadRequest:              {TagGloReadRequest, TagDedReadRequest},
+	TagWriteRequest:             {TagGloWriteRequest, TagDedWriteRequest},
+	TagReadResponse:             {TagGloReadResponse, TagDedReadResponse},
+	TagWriteResponse:            {TagGloWriteResponse, TagDedWriteResponse},
+	TagUnconfirmedWriteRequest:  {TagGloUnconfirmedWriteRequest, TagDedUnconfirmedWriteRequest},
+	TagInformationReportRequest: {TagGloInformationReportRequest, TagDedInformationReportRequest},
+	TagGetRequest:               {TagGloGetRequest, TagDedGetRequest},
+	TagSetRequest:               {TagGloSetRequest, TagDedSetRequest},
+	TagEventNotificationRequest: {TagGloEventNotificationRequest, TagDedEventNotificationRequest},
+	TagActionRequest:            {TagGloActionRequest, TagDedActionRequest},
+	TagGetResponse:              {TagGloGetResponse, TagDedGetResponse},
+	TagSetResponse:              {TagGloSetResponse, TagDedSetResponse},
+	TagActionResponse:           {TagGloActionResponse, TagDedActionResponse},
+}
+
+// CipheredTag returns glo-* or ded-* tag used to carry plain APDU
+// which first byte is plain
+func CipheredTag(plain uint8, dedicated bool) (out cosemTag, err error) {
+	tags, ok := cipheredTagOf[cosemTag(plain)]
+	if !ok {
+		err = fmt.Errorf("APDU tag %v cannot be ciphered", plain)
+		return
+	}
+	if dedicated {
+		return tags[1], nil
+	}
+	return tags[0], nil
+}
+
+// PlainTag returns tag of plain APDU carried by glo-* or ded-* tag,
+// and whether the tag is a dedicated one
+func PlainTag(ciphered cosemTag) (out cosemTag, dedicated bool, err error) {
+	for plain, tags := range cipheredTagOf {
+		if tags[0] == ciphered {
+			return plain, false, nil
+		}
+		if tags[1] == ciphered {
+			return plain, true, nil
+		}
+	}
+	err = fmt.Errorf("APDU tag %v is not a glo or ded ciphered tag", ciphered)
+	return
+}
+
+// CipheredAPDU implement CosemPDU. It is a glo-* or ded-* APDU which
+// content is still ciphered, security package does the (de)ciphering.
+// Information holds ciphered APDU followed by authentication tag if any.
+type CipheredAPDU struct {
+	Tag               cosemTag
+	SecurityHeader    uint8
+	InvocationCounter uint32
+	Information       []byte
+}
+
+func CreateCipheredAPDU(tag cosemTag, securityHeader uint8, invocationCounter uint32, information []byte) *CipheredAPDU {
+	return &CipheredAPDU{
+		Tag:               tag,
+		SecurityHeader:    securityHeader,
+		InvocationCounter: invocationCounter,
+		Information:       information,
+	}
+}
+
+func (ca CipheredAPDU) Encode() (out []byte, err error) {
+	return ca.AppendEncode(nil)
+}
+
+// AppendEncode writes APDU straight into dst, see CosemPDU
+func (ca CipheredAPDU) AppendEncode(dst []byte) ([]byte, error) {
+	if _, _, err := PlainTag(ca.Tag); err != nil {
+		return dst, err
+	}
+	return appendCipheredContent(append(dst, ca.Tag.Value()), dst, ca.SecurityHeader, ca.InvocationCounter, ca.Information)
+}
+
+func (ca CipheredAPDU) EncodeTo(w io.Writer) error { return encodeTo(w, ca) }
+
+// Append length, security header, invocation counter and information
+// shared by ciphered APDUs, dst is returned as it is on error
+func appendCipheredContent(out []byte, dst []byte, sh uint8, ic uint32, information []byte) ([]byte, error) {
+	out, err := axdr.AppendLength(out, 5+len(information))
+	if err != nil {
+		return dst, err
+	}
+	out = append(out, sh, byte(ic>>24), byte(ic>>16), byte(ic>>8), byte(ic))
+	return append(out, information...), nil
+}
+
+func DecodeCipheredAPDU(ori *[]byte) (out CipheredAPDU, err error) {
+	src := *ori
+
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	out.Tag = cosemTag(src[0])
+	if _, _, err = PlainTag(out.Tag); err != nil {
+		return
+	}
+	src = src[1:]
+
+	_, length, err := axdr.DecodeLength(&src)
+	if err != nil {
+		return
+	}
+	if length < 5 || uint64(len(src)) < length {
+		err = errDecode(len(*ori)-len(src), fmt.Errorf("ciphered APDU length %v is not valid, %v bytes left", length, len(src)))
+		return
+	}
+
+	out.SecurityHeader = src[0]
+	out.InvocationCounter = binary.BigEndian.Uint32(src[1:5])
+	out.Information = append([]byte(nil), src[5:length]...)
+	src = src[length:]
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
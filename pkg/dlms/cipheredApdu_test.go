@@ -0,0 +1,74 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_CipheredAPDU(t *testing.T) {
+	var a CipheredAPDU = *CreateCipheredAPDU(TagGloGetResponse, 0x30, 0x01234567, []byte{1, 2, 3})
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{204, 8, 48, 1, 35, 69, 103, 1, 2, 3}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	a = *CreateCipheredAPDU(TagGetResponse, 0x30, 0x01234567, []byte{1, 2, 3})
+	_, e = a.Encode()
+	if e == nil {
+		t.Errorf("t2 Encode should fail on plain tag")
+	}
+}
+
+func TestDecode_CipheredAPDU(t *testing.T) {
+	src := []byte{215, 8, 16, 0, 0, 0, 9, 1, 2, 3, 4}
+	a, err := DecodeCipheredAPDU(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeCipheredAPDU. Err: %v", err)
+	}
+	if a.Tag != TagDedActionResponse || a.SecurityHeader != 0x10 || a.InvocationCounter != 9 {
+		t.Errorf("t1 get: %v", a)
+	}
+	if bytes.Compare(a.Information, []byte{1, 2, 3}) != 0 {
+		t.Errorf("t1 Information get: %v", a.Information)
+	}
+	if bytes.Compare(src, []byte{4}) != 0 {
+		t.Errorf("t1 remaining byte get: %v", src)
+	}
+
+	src = []byte{204, 8, 48, 1, 35}
+	_, err = DecodeCipheredAPDU(&src)
+	if err == nil {
+		t.Errorf("t2 should failed on DecodeCipheredAPDU")
+	}
+
+	src = []byte{206, 5, 48, 1, 35, 69, 103}
+	_, err = DecodeCipheredAPDU(&src)
+	if err == nil {
+		t.Errorf("t3 should failed on DecodeCipheredAPDU")
+	}
+}
+
+func TestCipheredTag(t *testing.T) {
+	tag, err := CipheredTag(TagActionRequest.Value(), false)
+	if err != nil || tag != TagGloActionRequest {
+		t.Errorf("t1 failed. get: %v, err: %v", tag, err)
+	}
+	tag, err = CipheredTag(TagInitiateRequest.Value(), true)
+	if err != nil || tag != TagDedInitiateRequest {
+		t.Errorf("t2 failed. get: %v, err: %v", tag, err)
+	}
+	_, err = CipheredTag(TagExceptionResponse.Value(), false)
+	if err == nil {
+		t.Errorf("t3 should fail on exception response")
+	}
+
+	plain, dedicated, err := PlainTag(TagDedSetResponse)
+	if err != nil || plain != TagSetResponse || !dedicated {
+		t.Errorf("t4 failed. get: %v %v, err: %v", plain, dedicated, err)
+	}
+}
@@ -1,13 +1,32 @@
 package dlms
 
-import "bytes"
+import (
+	"fmt"
+	"io"
+)
 
 type confirmedServiceErrorTag uint8
 
 const (
-	TagErrInitiateError confirmedServiceErrorTag = 1
-	TagErrRead          confirmedServiceErrorTag = 5
-	TagErrWrite         confirmedServiceErrorTag = 6
+	TagErrInitiateError        confirmedServiceErrorTag = 1
+	TagErrGetStatus            confirmedServiceErrorTag = 2
+	TagErrGetNameList          confirmedServiceErrorTag = 3
+	TagErrGetVariableAttribute confirmedServiceErrorTag = 4
+	TagErrRead                 confirmedServiceErrorTag = 5
+	TagErrWrite                confirmedServiceErrorTag = 6
+	TagErrGetDataSetAttribute  confirmedServiceErrorTag = 7
+	TagErrGetTIAttribute       confirmedServiceErrorTag = 8
+	TagErrChangeScope          confirmedServiceErrorTag = 9
+	TagErrStart                confirmedServiceErrorTag = 10
+	TagErrStop                 confirmedServiceErrorTag = 11
+	TagErrResume               confirmedServiceErrorTag = 12
+	TagErrMakeUsable           confirmedServiceErrorTag = 13
+	TagErrInitiateLoad         confirmedServiceErrorTag = 14
+	TagErrLoadSegment          confirmedServiceErrorTag = 15
+	TagErrTerminateLoad        confirmedServiceErrorTag = 16
+	TagErrInitiateUpLoad       confirmedServiceErrorTag = 17
+	TagErrUpLoadSegment        confirmedServiceErrorTag = 18
+	TagErrTerminateUpLoad      confirmedServiceErrorTag = 19
 )
 
 // Value will return primitive value of the target.
@@ -16,6 +35,51 @@ func (s confirmedServiceErrorTag) Value() uint8 {
 	return uint8(s)
 }
 
+func (s confirmedServiceErrorTag) String() string {
+	switch s {
+	case TagErrInitiateError:
+		return "initiateError"
+	case TagErrGetStatus:
+		return "getStatus"
+	case TagErrGetNameList:
+		return "getNameList"
+	case TagErrGetVariableAttribute:
+		return "getVariableAttribute"
+	case TagErrRead:
+		return "read"
+	case TagErrWrite:
+		return "write"
+	case TagErrGetDataSetAttribute:
+		return "getDataSetAttribute"
+	case TagErrGetTIAttribute:
+		return "getTIAttribute"
+	case TagErrChangeScope:
+		return "changeScope"
+	case TagErrStart:
+		return "start"
+	case TagErrStop:
+		return "stop"
+	case TagErrResume:
+		return "resume"
+	case TagErrMakeUsable:
+		return "makeUsable"
+	case TagErrInitiateLoad:
+		return "initiateLoad"
+	case TagErrLoadSegment:
+		return "loadSegment"
+	case TagErrTerminateLoad:
+		return "terminateLoad"
+	case TagErrInitiateUpLoad:
+		return "initiateUpLoad"
+	case TagErrUpLoadSegment:
+		return "upLoadSegment"
+	case TagErrTerminateUpLoad:
+		return "terminateUpLoad"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
 type serviceErrorTag uint8
 
 const (
@@ -27,6 +91,7 @@ const (
 	TagErrAccess               serviceErrorTag = 5
 	TagErrInitiate             serviceErrorTag = 6
 	TagErrLoadDataSet          serviceErrorTag = 7
+	TagErrChangeScopeError     serviceErrorTag = 8
 	TagErrTask                 serviceErrorTag = 9
 	TagErrOtherError           serviceErrorTag = 10
 )
@@ -37,6 +102,38 @@ func (s serviceErrorTag) Value() uint8 {
 	return uint8(s)
 }
 
+func (s serviceErrorTag) String() string {
+	switch s {
+	case TagErrApplicationReference:
+		return "application-reference"
+	case TagErrHardwareResource:
+		return "hardware-resource"
+	case TagErrVdeStateError:
+		return "vde-state-error"
+	case TagErrService:
+		return "service"
+	case TagErrDefinition:
+		return "definition"
+	case TagErrAccess:
+		return "access"
+	case TagErrInitiate:
+		return "initiate"
+	case TagErrLoadDataSet:
+		return "load-data-set"
+	case TagErrChangeScopeError:
+		return "change-scope"
+	case TagErrTask:
+		return "task"
+	case TagErrOtherError:
+		return "other"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// ConfirmedServiceError is sent by server when a confirmed service
+// failed. Value is enumeration of the ServiceError choice, Detail
+// returns it typed
 type ConfirmedServiceError struct {
 	ConfirmedServiceError confirmedServiceErrorTag
 	ServiceError          serviceErrorTag
@@ -51,19 +148,53 @@ func CreateConfirmedServiceError(service confirmedServiceErrorTag, serviceError
 	}
 }
 
+// Detail returns Value as enumeration of ServiceError, such as
+// AccessError for TagErrAccess. Unknown choice is taken as OtherError
+func (cse ConfirmedServiceError) Detail() fmt.Stringer {
+	switch cse.ServiceError {
+	case TagErrApplicationReference:
+		return ApplicationReferenceError(cse.Value)
+	case TagErrHardwareResource:
+		return HardwareResourceError(cse.Value)
+	case TagErrVdeStateError:
+		return VdeStateError(cse.Value)
+	case TagErrService:
+		return ServiceKindError(cse.Value)
+	case TagErrDefinition:
+		return DefinitionError(cse.Value)
+	case TagErrAccess:
+		return AccessError(cse.Value)
+	case TagErrInitiate:
+		return InitiateError(cse.Value)
+	case TagErrLoadDataSet:
+		return LoadDataSetError(cse.Value)
+	case TagErrChangeScopeError:
+		return ChangeScopeError(cse.Value)
+	case TagErrTask:
+		return TaskError(cse.Value)
+	default:
+		return OtherError(cse.Value)
+	}
+}
+
+// Error shows service that failed and the reason,
+// such as "read: access: scope-of-access-violated"
+func (cse ConfirmedServiceError) Error() string {
+	return fmt.Sprintf("%v: %v: %v", cse.ConfirmedServiceError, cse.ServiceError, cse.Detail())
+}
+
 func (cse ConfirmedServiceError) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(TagConfirmedServiceError.Value())
-	buf.WriteByte(cse.ConfirmedServiceError.Value())
-	buf.WriteByte(cse.ServiceError.Value())
-	buf.WriteByte(cse.Value)
+	return cse.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (cse ConfirmedServiceError) AppendEncode(dst []byte) ([]byte, error) {
+	return append(dst, TagConfirmedServiceError.Value(), cse.ConfirmedServiceError.Value(), cse.ServiceError.Value(), cse.Value), nil
 }
 
+func (cse ConfirmedServiceError) EncodeTo(w io.Writer) error { return encodeTo(w, cse) }
+
 func DecodeConfirmedServiceError(ori *[]byte) (out ConfirmedServiceError, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
 
 	if len(src) < 4 {
 		err = ErrWrongLength(len(src), 4)
@@ -2,6 +2,7 @@ package dlms
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 )
 
@@ -39,6 +40,20 @@ func TestDecode_ConfirmedServiceError(t *testing.T) {
 		t.Errorf("t1 err Value. get: %v, should: %v", a.Value, b.Value)
 	}
 
+	// change-scope round trip
+	b = *CreateConfirmedServiceError(TagErrChangeScope, TagErrChangeScopeError, TagChangeScopeOther.Value())
+	encoded, _ := b.Encode()
+	if !bytes.Equal(encoded, []byte{14, 9, 8, 0}) {
+		t.Errorf("t2 Encode Failed. get: %d, should:%v", encoded, []byte{14, 9, 8, 0})
+	}
+	a, err = DecodeConfirmedServiceError(&encoded)
+	if err != nil || a != b || len(encoded) != 0 {
+		t.Errorf("t2 Failed. get: %v, should:%v, err:%v", a, b, err)
+	}
+	if a.Detail() != TagChangeScopeOther {
+		t.Errorf("t2 Detail Failed. get: %v, should:%v", a.Detail(), TagChangeScopeOther)
+	}
+
 	src = []byte{14, 1, 6}
 	_, err = DecodeConfirmedServiceError(&src)
 	if err == nil {
@@ -51,3 +66,33 @@ func TestDecode_ConfirmedServiceError(t *testing.T) {
 		t.Errorf("t1 should failed on DecodeConfirmedServiceError. Err: %v", err)
 	}
 }
+
+func TestConfirmedServiceError_Error(t *testing.T) {
+	tables := []struct {
+		cse    ConfirmedServiceError
+		detail fmt.Stringer
+		text   string
+	}{
+		{*CreateConfirmedServiceError(TagErrRead, TagErrAccess, TagAccessScopeOfAccessViolated.Value()), TagAccessScopeOfAccessViolated, "read: access: scope-of-access-violated"},
+		{*CreateConfirmedServiceError(TagErrInitiateError, TagErrInitiate, 2), TagInitIncompatibleConformance, "initiateError: initiate: incompatible-conformance"},
+		{*CreateConfirmedServiceError(TagErrWrite, TagErrDefinition, 1), TagDefObjectUndefined, "write: definition: object-undefined"},
+		{*CreateConfirmedServiceError(TagErrGetNameList, TagErrApplicationReference, 6), TagAppRefDecipheringError, "getNameList: application-reference: deciphering-error"},
+		{*CreateConfirmedServiceError(TagErrStart, TagErrTask, 3), TagTaskTiRunning, "start: task: ti-running"},
+		{*CreateConfirmedServiceError(TagErrLoadSegment, TagErrLoadDataSet, 4), TagLoadNotAwaitedSegment, "loadSegment: load-data-set: not-awaited-segment"},
+		{*CreateConfirmedServiceError(TagErrTerminateUpLoad, TagErrHardwareResource, 1), TagHwResMemoryUnavailable, "terminateUpLoad: hardware-resource: memory-unavailable"},
+		{*CreateConfirmedServiceError(TagErrGetStatus, TagErrVdeStateError, 1), TagVdeNoDlmsContext, "getStatus: vde-state-error: no-dlms-context"},
+		{*CreateConfirmedServiceError(TagErrRead, TagErrService, 9), ServiceKindError(9), "read: service: unknown(9)"},
+		{*CreateConfirmedServiceError(TagErrRead, TagErrOtherError, 7), OtherError(7), "read: other: 7"},
+		{*CreateConfirmedServiceError(TagErrChangeScope, TagErrChangeScopeError, 0), TagChangeScopeOther, "changeScope: change-scope: other"},
+		{*CreateConfirmedServiceError(20, 11, 1), OtherError(1), "unknown(20): unknown(11): 1"},
+	}
+	for i, table := range tables {
+		if table.cse.Detail() != table.detail {
+			t.Errorf("t%d Detail Failed. get: %v, should:%v", i, table.cse.Detail(), table.detail)
+		}
+		var err error = table.cse
+		if err.Error() != table.text {
+			t.Errorf("t%d Error Failed. get: %v, should:%v", i, err.Error(), table.text)
+		}
+	}
+}
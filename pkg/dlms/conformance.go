@@ -0,0 +1,195 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConformanceBlock is the 24 bits xDLMS conformance, proposed by client
+// on InitiateRequest and granted by server on InitiateResponse.
+// Bit 0 is the most significant bit of the bit string.
+type ConformanceBlock uint32
+
+const (
+	ConformanceReservedZero               ConformanceBlock = 1 << (23 - 0)
+	ConformanceGeneralProtection          ConformanceBlock = 1 << (23 - 1)
+	ConformanceGeneralBlockTransfer       ConformanceBlock = 1 << (23 - 2)
+	ConformanceRead                       ConformanceBlock = 1 << (23 - 3)
+	ConformanceWrite                      ConformanceBlock = 1 << (23 - 4)
+	ConformanceUnconfirmedWrite           ConformanceBlock = 1 << (23 - 5)
+	ConformanceDeltaValueEncoding         ConformanceBlock = 1 << (23 - 6)
+	ConformanceReservedSeven              ConformanceBlock = 1 << (23 - 7)
+	ConformanceAttribute0SupportedWithSet ConformanceBlock = 1 << (23 - 8)
+	ConformancePriorityMgmtSupported      ConformanceBlock = 1 << (23 - 9)
+	ConformanceAttribute0SupportedWithGet ConformanceBlock = 1 << (23 - 10)
+	ConformanceBlockTransferWithGet       ConformanceBlock = 1 << (23 - 11)
+	ConformanceBlockTransferWithSet       ConformanceBlock = 1 << (23 - 12)
+	ConformanceBlockTransferWithAction    ConformanceBlock = 1 << (23 - 13)
+	ConformanceMultipleReferences         ConformanceBlock = 1 << (23 - 14)
+	ConformanceInformationReport          ConformanceBlock = 1 << (23 - 15)
+	ConformanceDataNotification           ConformanceBlock = 1 << (23 - 16)
+	ConformanceAccess                     ConformanceBlock = 1 << (23 - 17)
+	ConformanceParameterizedAccess        ConformanceBlock = 1 << (23 - 18)
+	ConformanceGet                        ConformanceBlock = 1 << (23 - 19)
+	ConformanceSet                        ConformanceBlock = 1 << (23 - 20)
+	ConformanceSelectiveAccess            ConformanceBlock = 1 << (23 - 21)
+	ConformanceEventNotification          ConformanceBlock = 1 << (23 - 22)
+	ConformanceAction                     ConformanceBlock = 1 << (23 - 23)
+)
+
+var conformanceName = []string{
+	"reserved-zero",
+	"general-protection",
+	"general-block-transfer",
+	"read",
+	"write",
+	"unconfirmed-write",
+	"delta-value-encoding",
+	"reserved-seven",
+	"attribute0-supported-with-set",
+	"priority-mgmt-supported",
+	"attribute0-supported-with-get",
+	"block-transfer-with-get-or-read",
+	"block-transfer-with-set-or-write",
+	"block-transfer-with-action",
+	"multiple-references",
+	"information-report",
+	"data-notification",
+	"access",
+	"parameterized-access",
+	"get",
+	"set",
+	"selective-access",
+	"event-notification",
+	"action",
+}
+
+// Has reports whether every bit of c is set
+func (cb ConformanceBlock) Has(c ConformanceBlock) bool {
+	return cb&c == c
+}
+
+// Missing returns bits of required which are not set
+func (cb ConformanceBlock) Missing(required ConformanceBlock) ConformanceBlock {
+	return required &^ cb
+}
+
+func (cb ConformanceBlock) String() string {
+	names := []string{}
+	for i, name := range conformanceName {
+		if cb.Has(1 << (23 - uint(i))) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// Encode conformance as BER [APPLICATION 31] IMPLICIT BIT STRING
+func (cb ConformanceBlock) Encode() []byte {
+	return cb.appendEncode(nil)
+}
+
+func (cb ConformanceBlock) appendEncode(dst []byte) []byte {
+	return append(dst, 0x5F, 0x1F, 0x04, 0x00, byte(cb>>16), byte(cb>>8), byte(cb))
+}
+
+func DecodeConformanceBlock(src *[]byte) (out ConformanceBlock, err error) {
+	if len(*src) < 7 {
+		err = ErrWrongLength(len(*src), 7)
+		return
+	}
+	if (*src)[0] != 0x5F || (*src)[1] != 0x1F {
+		err = errDecode(0, fmt.Errorf("conformance tag %X is not recognized", (*src)[0:2]))
+		return
+	}
+	if (*src)[2] != 0x04 {
+		err = errDecode(2, fmt.Errorf("conformance length %v is not valid, expecting 4", (*src)[2]))
+		return
+	}
+	out = ConformanceBlock(binary.BigEndian.Uint32([]byte{0, (*src)[4], (*src)[5], (*src)[6]}))
+	(*src) = (*src)[7:]
+	return
+}
+
+// RequiredConformance returns conformance bits a server must grant
+// before the client is allowed to send pdu
+func RequiredConformance(pdu CosemPDU) ConformanceBlock {
+	// Create* constructors return pointer
+	if v := reflect.ValueOf(pdu); v.Kind() == reflect.Ptr && !v.IsNil() {
+		pdu, _ = v.Elem().Interface().(CosemPDU)
+	}
+
+	switch p := pdu.(type) {
+	case GetRequestNormal:
+		if p.SelectiveAccessInfo != nil {
+			return ConformanceGet | ConformanceSelectiveAccess
+		}
+		return ConformanceGet
+	case GetRequestNext:
+		return ConformanceGet | ConformanceBlockTransferWithGet
+	case GetRequestWithList:
+		return ConformanceGet | ConformanceMultipleReferences | selectiveAccessOfList(p.AttributeInfoList)
+	case SetRequestNormal:
+		if p.SelectiveAccessInfo != nil {
+			return ConformanceSet | ConformanceSelectiveAccess
+		}
+		return ConformanceSet
+	case SetRequestWithFirstDataBlock:
+		if p.SelectiveAccessInfo != nil {
+			return ConformanceSet | ConformanceBlockTransferWithSet | ConformanceSelectiveAccess
+		}
+		return ConformanceSet | ConformanceBlockTransferWithSet
+	case SetRequestWithDataBlock:
+		return ConformanceSet | ConformanceBlockTransferWithSet
+	case SetRequestWithList:
+		return ConformanceSet | ConformanceMultipleReferences | selectiveAccessOfList(p.AttributeInfoList)
+	case SetRequestWithListAndFirstDataBlock:
+		return ConformanceSet | ConformanceMultipleReferences | ConformanceBlockTransferWithSet | selectiveAccessOfList(p.AttributeInfoList)
+	case ActionRequestNormal:
+		return ConformanceAction
+	case ActionRequestNextPBlock, ActionRequestWithFirstPBlock, ActionRequestWithPBlock:
+		return ConformanceAction | ConformanceBlockTransferWithAction
+	case ActionRequestWithList:
+		return ConformanceAction | ConformanceMultipleReferences
+	case ActionRequestWithListAndFirstPBlock:
+		return ConformanceAction | ConformanceMultipleReferences | ConformanceBlockTransferWithAction
+	case ReadRequest:
+		return ConformanceRead | variableAccessOfList(p.Variables)
+	case WriteRequest:
+		return ConformanceWrite | variableAccessOfList(p.Variables)
+	case UnconfirmedWriteRequest:
+		return ConformanceUnconfirmedWrite | variableAccessOfList(p.Variables)
+	case GeneralCiphering, GeneralSigning:
+		return ConformanceGeneralProtection
+	case GeneralBlockTransfer:
+		return ConformanceGeneralBlockTransfer
+	}
+
+	return 0
+}
+
+func selectiveAccessOfList(list []AttributeDescriptorWithSelection) ConformanceBlock {
+	for _, att := range list {
+		if att.AccessDescriptor != nil {
+			return ConformanceSelectiveAccess
+		}
+	}
+	return 0
+}
+
+// Conformance needed by variable access specifications of short name services
+func variableAccessOfList(list []VariableAccessSpecification) (out ConformanceBlock) {
+	for _, va := range list {
+		switch va.Tag {
+		case TagParameterizedAccess:
+			out |= ConformanceParameterizedAccess
+		case TagBlockNumberAccess, TagReadDataBlockAccess:
+			out |= ConformanceBlockTransferWithGet
+		case TagWriteDataBlockAccess:
+			out |= ConformanceBlockTransferWithSet
+		}
+	}
+	return
+}
@@ -0,0 +1,74 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+
+	"gosem/pkg/axdr"
+)
+
+func TestConformanceBlock(t *testing.T) {
+	cb := ConformanceBlock(0x007E1F)
+	if !cb.Has(ConformanceGet | ConformanceSet | ConformanceAction | ConformanceSelectiveAccess) {
+		t.Errorf("t1 should have get, set, action and selective-access")
+	}
+	if cb.Has(ConformanceGeneralProtection) {
+		t.Errorf("t1 should not have general-protection")
+	}
+	if cb.Missing(ConformanceGet|ConformanceDataNotification) != ConformanceDataNotification {
+		t.Errorf("t1 Missing get: %v", cb.Missing(ConformanceGet|ConformanceDataNotification))
+	}
+	str := "priority-mgmt-supported|attribute0-supported-with-get|block-transfer-with-get-or-read|block-transfer-with-set-or-write|block-transfer-with-action|multiple-references|get|set|selective-access|event-notification|action"
+	if cb.String() != str {
+		t.Errorf("t1 String get: %v", cb.String())
+	}
+
+	t2 := cb.Encode()
+	result := []byte{95, 31, 4, 0, 0, 126, 31}
+	res := bytes.Compare(t2, result)
+	if res != 0 {
+		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
+	}
+
+	src := []byte{95, 31, 4, 0, 0, 126, 31, 1}
+	val, err := DecodeConformanceBlock(&src)
+	if err != nil || val != cb || len(src) != 1 {
+		t.Errorf("t3 Failed. get: %v, err:%v", val, err)
+	}
+
+	src = []byte{95, 30, 4, 0, 0, 126, 31}
+	_, err = DecodeConformanceBlock(&src)
+	if err == nil {
+		t.Errorf("t4 should fail on wrong tag")
+	}
+}
+
+func TestRequiredConformance(t *testing.T) {
+	attrDesc := *CreateAttributeDescriptor(1, "1.0.0.3.0.255", 2)
+	sad := *CreateSelectiveAccessDescriptor(AccessSelectorEntry, []uint32{0, 5})
+
+	tables := []struct {
+		pdu CosemPDU
+		cb  ConformanceBlock
+	}{
+		{CreateGetRequestNormal(0x81, attrDesc, nil), ConformanceGet},
+		{*CreateGetRequestNormal(0x81, attrDesc, &sad), ConformanceGet | ConformanceSelectiveAccess},
+		{CreateGetRequestNext(0x81, 1), ConformanceGet | ConformanceBlockTransferWithGet},
+		{CreateActionRequestNormal(0x81, *CreateMethodDescriptor(1, "1.0.0.3.0.255", 1), nil), ConformanceAction},
+		{CreateReadRequest([]VariableAccessSpecification{*CreateVariableName(0xFA00)}), ConformanceRead},
+		{CreateReadRequest([]VariableAccessSpecification{*CreateParameterizedAccess(0xFA00, 1, *axdr.CreateAxdrUnsigned(0))}), ConformanceRead | ConformanceParameterizedAccess},
+		{CreateReadRequest([]VariableAccessSpecification{*CreateBlockNumberAccess(2)}), ConformanceRead | ConformanceBlockTransferWithGet},
+		{CreateWriteRequest([]VariableAccessSpecification{*CreateWriteDataBlockAccess(true, 1)}, nil), ConformanceWrite | ConformanceBlockTransferWithSet},
+		{CreateUnconfirmedWriteRequest([]VariableAccessSpecification{*CreateVariableName(0xFA00)}, nil), ConformanceUnconfirmedWrite},
+		{CreateGeneralBlockTransfer(true, false, 1, 1, 0, []byte{1}), ConformanceGeneralBlockTransfer},
+		{CreateGeneralCiphering(false, []byte{1}, 0x30, 1, []byte{1}), ConformanceGeneralProtection},
+		{CreateGeneralSigning(nil, nil, nil, nil, nil, []byte{1}), ConformanceGeneralProtection},
+		{CreateExceptionResponse(TagExcServiceNotAllowed, TagExcServiceNotSupported), 0},
+	}
+	for idx, table := range tables {
+		cb := RequiredConformance(table.pdu)
+		if cb != table.cb {
+			t.Errorf("combination %v failed. get: %v, should:%v", idx, cb, table.cb)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package dlms
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 type cosemTag uint8
 
@@ -13,8 +16,33 @@ const (
 	TagReadResponse             cosemTag = 12
 	TagWriteResponse            cosemTag = 13
 	TagConfirmedServiceError    cosemTag = 14
+	TagDataNotification         cosemTag = 15
 	TagUnconfirmedWriteRequest  cosemTag = 22
 	TagInformationReportRequest cosemTag = 24
+	// --- ciphered APDUs of association
+	TagGloInitiateRequest          cosemTag = 33
+	TagGloReadRequest              cosemTag = 37
+	TagGloWriteRequest             cosemTag = 38
+	TagGloInitiateResponse         cosemTag = 40
+	TagGloReadResponse             cosemTag = 44
+	TagGloWriteResponse            cosemTag = 45
+	TagGloConfirmedServiceError    cosemTag = 46
+	TagGloUnconfirmedWriteRequest  cosemTag = 54
+	TagGloInformationReportRequest cosemTag = 56
+	TagDedInitiateRequest          cosemTag = 65
+	TagDedReadRequest              cosemTag = 69
+	TagDedWriteRequest             cosemTag = 70
+	TagDedInitiateResponse         cosemTag = 72
+	TagDedReadResponse             cosemTag = 76
+	TagDedWriteResponse            cosemTag = 77
+	TagDedConfirmedServiceError    cosemTag = 78
+	TagDedUnconfirmedWriteRequest  cosemTag = 86
+	TagDedInformationReportRequest cosemTag = 88
+	// --- ACSE APDUs
+	TagAARQ cosemTag = 96
+	TagAARE cosemTag = 97
+	TagRLRQ cosemTag = 98
+	TagRLRE cosemTag = 99
 	// --- APDUs used for data communication services
 	TagGetRequest               cosemTag = 192
 	TagSetRequest               cosemTag = 193
@@ -40,16 +68,13 @@ const (
 	TagDedSetResponse              cosemTag = 213
 	TagDedActionResponse           cosemTag = 215
 	TagExceptionResponse           cosemTag = 216
+	// --- general APDUs
+	TagGeneralGloCiphering  cosemTag = 219
+	TagGeneralDedCiphering  cosemTag = 220
+	TagGeneralSigning       cosemTag = 223
+	TagGeneralBlockTransfer cosemTag = 224
 )
 
-func ErrWrongTag(idx int, get byte, correct byte) error {
-	return fmt.Errorf("wrong data tag on index %v, expecting %v instead of %v", idx, correct, get)
-}
-
-func ErrWrongLength(current int, correct byte) error {
-	return fmt.Errorf("wrong data length, received %d, expecting %v", current, correct)
-}
-
 // Value will return primitive value of the target.
 // This is used for comparing with non custom typed object
 func (s cosemTag) Value() uint8 {
@@ -59,7 +84,20 @@ func (s cosemTag) Value() uint8 {
 func (s cosemTag) isExist(bt byte) bool {
 	switch bt {
 	case
+		TagInitiateRequest.Value(),
+		TagInitiateResponse.Value(),
+		TagReadRequest.Value(),
+		TagWriteRequest.Value(),
+		TagReadResponse.Value(),
+		TagWriteResponse.Value(),
+		TagUnconfirmedWriteRequest.Value(),
+		TagInformationReportRequest.Value(),
+		TagAARQ.Value(),
+		TagAARE.Value(),
+		TagRLRQ.Value(),
+		TagRLRE.Value(),
 		TagConfirmedServiceError.Value(),
+		TagDataNotification.Value(),
 		TagGetRequest.Value(),
 		TagSetRequest.Value(),
 		TagEventNotificationRequest.Value(),
@@ -67,7 +105,15 @@ func (s cosemTag) isExist(bt byte) bool {
 		TagGetResponse.Value(),
 		TagSetResponse.Value(),
 		TagActionResponse.Value(),
-		TagExceptionResponse.Value():
+		TagExceptionResponse.Value(),
+		TagGeneralGloCiphering.Value(),
+		TagGeneralDedCiphering.Value(),
+		TagGeneralSigning.Value(),
+		TagGeneralBlockTransfer.Value():
+		return true
+	}
+
+	if _, _, err := PlainTag(cosemTag(bt)); err == nil {
 		return true
 	}
 
@@ -81,17 +127,59 @@ type CosemI interface {
 
 type CosemPDU interface {
 	Encode() ([]byte, error)
+	// AppendEncode appends encoded APDU to dst and returns the extended slice
+	AppendEncode(dst []byte) ([]byte, error)
+	// EncodeTo writes encoded APDU to w
+	EncodeTo(w io.Writer) error
+}
+
+func encodeTo(w io.Writer, pdu CosemPDU) error {
+	out, err := pdu.AppendEncode(nil)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
 }
 
 // DecodeCosem is a global function to decode payload based on implemented DLMS/COSEM APDU en/decoder
 func DecodeCosem(src *[]byte) (out CosemPDU, err error) {
+	if len(*src) < 1 {
+		err = ErrWrongLength(0, 1)
+		return
+	}
+
 	var t cosemTag
 	if !t.isExist((*src)[0]) {
-		err = fmt.Errorf("byte idx 0 (%v) is not recognized, or relevant DLMS/COSEM is not yet implemented", (*src)[0])
+		err = errDecode(0, fmt.Errorf("byte idx 0 (%v) is not recognized, or relevant DLMS/COSEM is not yet implemented", (*src)[0]))
 		return
 	}
 
 	switch (*src)[0] {
+	case TagInitiateRequest.Value():
+		out, err = DecodeInitiateRequest(src)
+	case TagInitiateResponse.Value():
+		out, err = DecodeInitiateResponse(src)
+	case TagReadRequest.Value():
+		out, err = DecodeReadRequest(src)
+	case TagWriteRequest.Value():
+		out, err = DecodeWriteRequest(src)
+	case TagReadResponse.Value():
+		out, err = DecodeReadResponse(src)
+	case TagWriteResponse.Value():
+		out, err = DecodeWriteResponse(src)
+	case TagUnconfirmedWriteRequest.Value():
+		out, err = DecodeUnconfirmedWriteRequest(src)
+	case TagInformationReportRequest.Value():
+		out, err = DecodeInformationReportRequest(src)
+	case TagAARQ.Value():
+		out, err = DecodeAARQ(src)
+	case TagAARE.Value():
+		out, err = DecodeAARE(src)
+	case TagRLRQ.Value():
+		out, err = DecodeRLRQ(src)
+	case TagRLRE.Value():
+		out, err = DecodeRLRE(src)
 	case TagConfirmedServiceError.Value():
 		out, err = DecodeConfirmedServiceError(src)
 	case TagGetRequest.Value():
@@ -114,8 +202,19 @@ func DecodeCosem(src *[]byte) (out CosemPDU, err error) {
 		out, err = decoder.Decode(src)
 	case TagEventNotificationRequest.Value():
 		out, err = DecodeEventNotificationRequest(src)
+	case TagDataNotification.Value():
+		out, err = DecodeDataNotification(src)
 	case TagExceptionResponse.Value():
 		out, err = DecodeExceptionResponse(src)
+	case TagGeneralGloCiphering.Value(), TagGeneralDedCiphering.Value():
+		out, err = DecodeGeneralCiphering(src)
+	case TagGeneralSigning.Value():
+		out, err = DecodeGeneralSigning(src)
+	case TagGeneralBlockTransfer.Value():
+		out, err = DecodeGeneralBlockTransfer(src)
+	default:
+		// glo-* and ded-* APDUs, use security package to decipher
+		out, err = DecodeCipheredAPDU(src)
 	}
 
 	return
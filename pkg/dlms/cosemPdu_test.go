@@ -1,8 +1,11 @@
 package dlms
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
+
+	"gosem/pkg/axdr"
 )
 
 func TestDecode_cosem(t *testing.T) {
@@ -314,6 +317,193 @@ func TestDecode_cosem(t *testing.T) {
 		t.Errorf("Decode supposed to return ExceptionResponse instead of %v", reflect.TypeOf(res).Name())
 	}
 
+	// ------------------  InitiateRequest
+	srcInitiateRequest := []byte{1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176}
+	res, e = DecodeCosem(&srcInitiateRequest)
+	if e != nil {
+		t.Errorf("Decode for InitiateRequest Failed. err:%v", e)
+	}
+	_, assertTrue = res.(InitiateRequest)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return InitiateRequest instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  InitiateResponse
+	srcInitiateResponse := []byte{8, 0, 6, 95, 31, 4, 0, 0, 80, 31, 1, 244, 0, 7}
+	res, e = DecodeCosem(&srcInitiateResponse)
+	if e != nil {
+		t.Errorf("Decode for InitiateResponse Failed. err:%v", e)
+	}
+	_, assertTrue = res.(InitiateResponse)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return InitiateResponse instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  AARQ
+	srcAARQ := []byte{96, 29, 161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1, 190, 16, 4, 14, 1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176}
+	res, e = DecodeCosem(&srcAARQ)
+	if e != nil {
+		t.Errorf("Decode for AARQ Failed. err:%v", e)
+	}
+	_, assertTrue = res.(AARQ)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return AARQ instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  AARE
+	srcAARE := []byte{97, 41, 161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1, 162, 3, 2, 1, 0, 163, 5, 161, 3, 2, 1, 0, 190, 16, 4, 14, 8, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176, 0, 7}
+	res, e = DecodeCosem(&srcAARE)
+	if e != nil {
+		t.Errorf("Decode for AARE Failed. err:%v", e)
+	}
+	_, assertTrue = res.(AARE)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return AARE instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  RLRQ
+	srcRLRQ := []byte{98, 3, 128, 1, 0}
+	res, e = DecodeCosem(&srcRLRQ)
+	if e != nil {
+		t.Errorf("Decode for RLRQ Failed. err:%v", e)
+	}
+	_, assertTrue = res.(RLRQ)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return RLRQ instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  RLRE
+	srcRLRE := []byte{99, 3, 128, 1, 0}
+	res, e = DecodeCosem(&srcRLRE)
+	if e != nil {
+		t.Errorf("Decode for RLRE Failed. err:%v", e)
+	}
+	_, assertTrue = res.(RLRE)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return RLRE instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  CipheredAPDU
+	srcCipheredAPDU := []byte{204, 8, 48, 1, 35, 69, 103, 1, 2, 3}
+	res, e = DecodeCosem(&srcCipheredAPDU)
+	if e != nil {
+		t.Errorf("Decode for CipheredAPDU Failed. err:%v", e)
+	}
+	_, assertTrue = res.(CipheredAPDU)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return CipheredAPDU instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  ReadRequest
+	srcReadRequest := []byte{5, 1, 2, 0xFA, 0x00}
+	res, e = DecodeCosem(&srcReadRequest)
+	if e != nil {
+		t.Errorf("Decode for ReadRequest Failed. err:%v", e)
+	}
+	_, assertTrue = res.(ReadRequest)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return ReadRequest instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  ReadResponse
+	srcReadResponse := []byte{12, 1, 1, 3}
+	res, e = DecodeCosem(&srcReadResponse)
+	if e != nil {
+		t.Errorf("Decode for ReadResponse Failed. err:%v", e)
+	}
+	_, assertTrue = res.(ReadResponse)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return ReadResponse instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  WriteRequest
+	srcWriteRequest := []byte{6, 1, 2, 0x02, 0x08, 1, 17, 1}
+	res, e = DecodeCosem(&srcWriteRequest)
+	if e != nil {
+		t.Errorf("Decode for WriteRequest Failed. err:%v", e)
+	}
+	_, assertTrue = res.(WriteRequest)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return WriteRequest instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  WriteResponse
+	srcWriteResponse := []byte{13, 1, 0}
+	res, e = DecodeCosem(&srcWriteResponse)
+	if e != nil {
+		t.Errorf("Decode for WriteResponse Failed. err:%v", e)
+	}
+	_, assertTrue = res.(WriteResponse)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return WriteResponse instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  UnconfirmedWriteRequest
+	srcUnconfirmedWriteRequest := []byte{22, 1, 2, 0x02, 0x08, 1, 17, 1}
+	res, e = DecodeCosem(&srcUnconfirmedWriteRequest)
+	if e != nil {
+		t.Errorf("Decode for UnconfirmedWriteRequest Failed. err:%v", e)
+	}
+	_, assertTrue = res.(UnconfirmedWriteRequest)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return UnconfirmedWriteRequest instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  InformationReportRequest
+	srcInformationReportRequest := []byte{24, 0, 1, 2, 0xFA, 0x08, 1, 17, 7}
+	res, e = DecodeCosem(&srcInformationReportRequest)
+	if e != nil {
+		t.Errorf("Decode for InformationReportRequest Failed. err:%v", e)
+	}
+	_, assertTrue = res.(InformationReportRequest)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return InformationReportRequest instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  DataNotification
+	srcDataNotification := []byte{15, 0x40, 0, 0, 1, 0, 17, 5}
+	res, e = DecodeCosem(&srcDataNotification)
+	if e != nil {
+		t.Errorf("Decode for DataNotification Failed. err:%v", e)
+	}
+	_, assertTrue = res.(DataNotification)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return DataNotification instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  GeneralBlockTransfer
+	srcGeneralBlockTransfer := []byte{224, 0x83, 0, 2, 0, 1, 2, 0xAA, 0xBB}
+	res, e = DecodeCosem(&srcGeneralBlockTransfer)
+	if e != nil {
+		t.Errorf("Decode for GeneralBlockTransfer Failed. err:%v", e)
+	}
+	_, assertTrue = res.(GeneralBlockTransfer)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return GeneralBlockTransfer instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  GeneralCiphering
+	srcGeneralCiphering := []byte{219, 1, 0xAA, 8, 48, 1, 35, 69, 103, 1, 2, 3}
+	res, e = DecodeCosem(&srcGeneralCiphering)
+	if e != nil {
+		t.Errorf("Decode for GeneralCiphering Failed. err:%v", e)
+	}
+	_, assertTrue = res.(GeneralCiphering)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return GeneralCiphering instead of %v", reflect.TypeOf(res).Name())
+	}
+
+	// ------------------  GeneralSigning
+	srcGeneralSigning := []byte{223, 1, 1, 1, 0xAA, 0, 0, 0, 1, 0xC0, 1, 0xFF}
+	res, e = DecodeCosem(&srcGeneralSigning)
+	if e != nil {
+		t.Errorf("Decode for GeneralSigning Failed. err:%v", e)
+	}
+	_, assertTrue = res.(GeneralSigning)
+	if !assertTrue {
+		t.Errorf("Decode supposed to return GeneralSigning instead of %v", reflect.TypeOf(res).Name())
+	}
+
 	// ------------------  Error test
 	srcError := []byte{255, 255, 255}
 	_, wow := DecodeCosem(&srcError)
@@ -321,3 +511,145 @@ func TestDecode_cosem(t *testing.T) {
 		t.Errorf("Decode should've return error.")
 	}
 }
+
+func TestEncode_cosem(t *testing.T) {
+	value := axdr.CreateAxdrStructure([]*axdr.DlmsData{axdr.CreateAxdrOctetString("0102"), axdr.CreateAxdrUnsigned(5)})
+	attr := *CreateAttributeDescriptor(1, "1.0.0.3.0.255", 2)
+	pdus := []CosemPDU{
+		*CreateGetRequestNormal(81, attr, nil),
+		*CreateSetRequestNormal(81, attr, nil, *value),
+		*CreateGetResponseNormal(81, *CreateGetDataResultAsData(*value)),
+		*CreateDataNotification(1, nil, *value),
+		*CreateGeneralBlockTransfer(true, false, 1, 1, 0, []byte{1, 2}),
+	}
+
+	for i, pdu := range pdus {
+		result, e := pdu.Encode()
+		if e != nil {
+			t.Errorf("t%d Encode Failed. err: %v", i, e)
+			continue
+		}
+		out, e := pdu.AppendEncode([]byte{0xAA})
+		if e != nil || !bytes.Equal(out, append([]byte{0xAA}, result...)) {
+			t.Errorf("t%d AppendEncode Failed. get: %d, should:%v, err: %v", i, out, result, e)
+		}
+		var buf bytes.Buffer
+		if e = pdu.EncodeTo(&buf); e != nil || !bytes.Equal(buf.Bytes(), result) {
+			t.Errorf("t%d EncodeTo Failed. get: %d, should:%v, err: %v", i, buf.Bytes(), result, e)
+		}
+	}
+
+	// APDUs carrying no octet-string are written into reused buffer
+	// without any allocation
+	number := axdr.CreateAxdrDoubleLongUnsigned(7)
+	mth := *CreateMethodDescriptor(70, "0.0.96.3.10.255", 1)
+	hot := []CosemPDU{
+		*CreateGetRequestNormal(81, attr, nil),
+		*CreateSetRequestNormal(81, attr, nil, *number),
+		*CreateGetResponseNormal(81, *CreateGetDataResultAsData(*number)),
+		*CreateSetResponseNormal(81, TagAccSuccess),
+		*CreateActionRequestNormal(81, mth, number),
+		*CreateActionResponseNormal(81, *CreateActResponse(TagActSuccess, CreateGetDataResultAsData(*number))),
+		*CreateCipheredAPDU(TagGloGetRequest, 0x30, 1, []byte{1, 2, 3}),
+		*CreateGeneralCiphering(false, []byte{1, 2, 3, 4, 5, 6, 7, 8}, 0x30, 1, []byte{1, 2, 3}),
+	}
+	buf := make([]byte, 0, 64)
+	for i, pdu := range hot {
+		result, _ := pdu.Encode()
+		allocs := testing.AllocsPerRun(100, func() {
+			buf, _ = pdu.AppendEncode(buf[:0])
+		})
+		if allocs != 0 || !bytes.Equal(buf, result) {
+			t.Errorf("t%d AppendEncode into reused buffer Failed. allocs: %v, get: %d, should:%v", i, allocs, buf, result)
+		}
+	}
+
+	// same APDU encoded from many goroutines
+	result, _ := pdus[1].Encode()
+	done := make(chan []byte)
+	for i := 0; i < 8; i++ {
+		go func() {
+			out, _ := pdus[1].AppendEncode(nil)
+			done <- out
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if out := <-done; !bytes.Equal(out, result) {
+			t.Errorf("concurrent AppendEncode Failed. get: %d, should:%v", out, result)
+		}
+	}
+}
+
+func TestAppendEncode_cosem(t *testing.T) {
+	// APDUs without octet-string data, every one of them is written into
+	// reused buffer without any allocation
+	srcs := [][]byte{
+		{14, 1, 6, 1},
+		{192, 1, 81, 0, 1, 1, 0, 0, 3, 0, 255, 2, 1, 2, 2, 4, 6, 0, 0, 0, 0, 6, 0, 0, 0, 5, 18, 0, 0, 18, 0, 0},
+		{192, 2, 81, 0, 0, 0, 2},
+		{192, 3, 69, 1, 0, 1, 1, 0, 0, 3, 0, 255, 2, 1, 2, 2, 4, 6, 0, 0, 0, 0, 6, 0, 0, 0, 5, 18, 0, 0, 18, 0, 0},
+		{196, 1, 81, 1, 5, 0, 0, 0, 69},
+		{196, 2, 81, 1, 0, 0, 0, 1, 0, 12, 7, 210, 12, 4, 3, 10, 6, 11, 255, 0, 120, 0},
+		{196, 3, 69, 2, 0, 0, 1, 5, 0, 0, 0, 1},
+		{193, 2, 81, 0, 1, 1, 0, 0, 3, 0, 255, 2, 1, 2, 2, 4, 6, 0, 0, 0, 0, 6, 0, 0, 0, 5, 18, 0, 0, 18, 0, 0, 1, 0, 0, 0, 1, 5, 1, 2, 3, 4, 5},
+		{193, 3, 81, 1, 0, 0, 0, 1, 5, 1, 2, 3, 4, 5},
+		{193, 5, 69, 1, 0, 1, 1, 0, 0, 3, 0, 255, 2, 1, 2, 2, 4, 6, 0, 0, 0, 0, 6, 0, 0, 0, 5, 18, 0, 0, 18, 0, 0, 1, 0, 0, 0, 1, 5, 1, 2, 3, 4, 5},
+		{197, 1, 81, 0},
+		{197, 2, 81, 0, 0, 0, 1},
+		{197, 3, 81, 0, 0, 0, 0, 1},
+		{197, 4, 81, 3, 0, 1, 250, 0, 0, 0, 1},
+		{197, 5, 81, 3, 0, 1, 250},
+		{195, 2, 81, 0, 0, 0, 1},
+		{195, 4, 81, 0, 1, 1, 0, 0, 3, 0, 255, 2, 1, 0, 0, 0, 1, 5, 1, 2, 3, 4, 5},
+		{195, 5, 81, 1, 0, 1, 1, 0, 0, 3, 0, 255, 2, 1, 0, 0, 0, 1, 5, 1, 2, 3, 4, 5},
+		{195, 6, 81, 1, 0, 0, 0, 1, 5, 1, 2, 3, 4, 5},
+		{199, 1, 81, 0, 1, 0, 0},
+		{199, 2, 81, 1, 0, 0, 0, 1, 5, 1, 2, 3, 4, 5},
+		{199, 3, 81, 1, 0, 1, 0, 0},
+		{199, 4, 81, 0, 0, 0, 1},
+		{216, 1, 2},
+		{1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176},
+		{8, 0, 6, 95, 31, 4, 0, 0, 80, 31, 1, 244, 0, 7},
+		{96, 29, 161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1, 190, 16, 4, 14, 1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176},
+		{97, 41, 161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1, 162, 3, 2, 1, 0, 163, 5, 161, 3, 2, 1, 0, 190, 16, 4, 14, 8, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176, 0, 7},
+		{98, 3, 128, 1, 0},
+		{99, 3, 128, 1, 0},
+		{5, 1, 2, 0xFA, 0x00},
+		{12, 1, 1, 3},
+		{6, 1, 2, 0x02, 0x08, 1, 17, 1},
+		{13, 1, 0},
+		{22, 1, 2, 0x02, 0x08, 1, 17, 1},
+		{24, 0, 1, 2, 0xFA, 0x08, 1, 17, 7},
+		{15, 0x40, 0, 0, 1, 0, 17, 5},
+		{224, 0x83, 0, 2, 0, 1, 2, 0xAA, 0xBB},
+		{223, 1, 1, 1, 0xAA, 0, 0, 0, 1, 0xC0, 1, 0xFF},
+	}
+
+	buf := make([]byte, 0, 128)
+	for i, src := range srcs {
+		in := src
+		pdu, err := DecodeCosem(&in)
+		if err != nil {
+			t.Errorf("t%d Decode Failed. err: %v", i, err)
+			continue
+		}
+		result, err := pdu.Encode()
+		if err != nil {
+			t.Errorf("t%d Encode Failed. err: %v", i, err)
+			continue
+		}
+		allocs := testing.AllocsPerRun(100, func() {
+			buf, _ = pdu.AppendEncode(buf[:0])
+		})
+		if allocs != 0 || !bytes.Equal(buf, result) {
+			t.Errorf("t%d AppendEncode into reused buffer Failed. allocs: %v, get: %d, should:%v", i, allocs, buf, result)
+		}
+	}
+
+	// failing APDU leaves dst as it is
+	dst := []byte{0xAA}
+	out, err := GeneralBlockTransfer{Window: MaxGeneralBlockWindow + 1}.AppendEncode(dst)
+	if err == nil || !bytes.Equal(out, dst) {
+		t.Errorf("t%d Failed. get: %d, should:%v, err: %v", len(srcs), out, dst, err)
+	}
+}
@@ -0,0 +1,94 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"gosem/pkg/axdr"
+)
+
+// DataNotification implement CosemPDU. It is pushed by server without
+// being requested, DateTime is nil if it is not sent. Date-time is
+// octet-string, sent with zero length when it is not present
+type DataNotification struct {
+	LongInvokeIdAndPriority uint32
+	DateTime                *axdr.CosemDateTime
+	Body                    axdr.DlmsData
+}
+
+func CreateDataNotification(longInvokeId uint32, dateTime *axdr.CosemDateTime, body axdr.DlmsData) *DataNotification {
+	return &DataNotification{
+		LongInvokeIdAndPriority: longInvokeId,
+		DateTime:                dateTime,
+		Body:                    body,
+	}
+}
+
+func (dn DataNotification) Encode() (out []byte, err error) {
+	return dn.AppendEncode(nil)
+}
+
+func (dn DataNotification) AppendEncode(dst []byte) ([]byte, error) {
+	out := appendUint32(append(dst, TagDataNotification.Value()), dn.LongInvokeIdAndPriority)
+	if dn.DateTime == nil {
+		out = append(out, 0)
+	} else {
+		tm, err := axdr.EncodeCosemDateTime(*dn.DateTime)
+		if err != nil {
+			return dst, err
+		}
+		out = append(out, uint8(len(tm)))
+		out = append(out, tm...)
+	}
+	out, err := dn.Body.AppendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
+}
+
+func (dn DataNotification) EncodeTo(w io.Writer) error { return encodeTo(w, dn) }
+
+func DecodeDataNotification(ori *[]byte) (out DataNotification, err error) {
+	src := *ori
+
+	if len(src) < 6 {
+		err = ErrWrongLength(len(src), 6)
+		return
+	}
+	if src[0] != TagDataNotification.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagDataNotification))
+		return
+	}
+	out.LongInvokeIdAndPriority = binary.BigEndian.Uint32(src[1:5])
+	timeLength := src[5]
+	src = src[6:]
+
+	switch timeLength {
+	case 0:
+	case 12:
+		_, dateTime, e := axdr.DecodeCosemDateTime(&src)
+		if e != nil {
+			err = e
+			return
+		}
+		out.DateTime = &dateTime
+	default:
+		err = errDecode(len(*ori)-len(src), fmt.Errorf("date-time length %v is not supported, expecting 0 or 12", timeLength))
+		return
+	}
+
+	if len(src) < 1 {
+		err = ErrWrongLength(0, 1)
+		return
+	}
+	decoder := axdr.NewDataDecoder(&src)
+	out.Body, err = decoder.Decode(&src)
+	if err != nil {
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
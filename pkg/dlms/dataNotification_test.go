@@ -0,0 +1,80 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+
+	"gosem/pkg/axdr"
+)
+
+func dataNotificationDateTime() axdr.CosemDateTime {
+	return axdr.CosemDateTime{
+		Date:      axdr.CosemDate{Year: 2020, Month: 12, Day: 31, DayOfWeek: 4},
+		Time:      axdr.CosemTime{Hour: 23, Minute: 59, Second: 58, Hundredths: 0},
+		Deviation: axdr.DeviationNotSpecified,
+	}
+}
+
+func TestNew_DataNotification(t *testing.T) {
+	dt := dataNotificationDateTime()
+	body := *axdr.CreateAxdrStructure([]*axdr.DlmsData{axdr.CreateAxdrUnsigned(5)})
+
+	var a DataNotification = *CreateDataNotification(0x40000001, &dt, body)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{15, 0x40, 0, 0, 1, 12, 0x07, 0xE4, 12, 31, 4, 23, 59, 58, 0, 0x80, 0, 0, 2, 1, 17, 5}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	// --- without date-time
+	a = *CreateDataNotification(0x40000001, nil, body)
+	t2, e := a.Encode()
+	if e != nil {
+		t.Errorf("t2 Encode Failed. err: %v", e)
+	}
+	result = []byte{15, 0x40, 0, 0, 1, 0, 2, 1, 17, 5}
+	res = bytes.Compare(t2, result)
+	if res != 0 {
+		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
+	}
+}
+
+func TestDecode_DataNotification(t *testing.T) {
+	src := []byte{15, 0x40, 0, 0, 1, 12, 0x07, 0xE4, 12, 31, 4, 23, 59, 58, 0, 0x80, 0, 0, 2, 1, 17, 5}
+	a, err := DecodeDataNotification(&src)
+	if err != nil {
+		t.Fatalf("t1 failed on DecodeDataNotification. Err: %v", err)
+	}
+	if a.LongInvokeIdAndPriority != 0x40000001 {
+		t.Errorf("t1 err LongInvokeIdAndPriority. get: %X", a.LongInvokeIdAndPriority)
+	}
+	if a.DateTime == nil || *a.DateTime != dataNotificationDateTime() {
+		t.Errorf("t1 err DateTime. get: %v, should: %v", a.DateTime, dataNotificationDateTime())
+	}
+	if a.Body.Tag != axdr.TagStructure {
+		t.Errorf("t1 err Body. get: %v", a.Body)
+	}
+	if len(src) != 0 {
+		t.Errorf("t1 Failed. src should be empty (%v)", src)
+	}
+
+	src = []byte{15, 0x40, 0, 0, 1, 0, 17, 5}
+	b, err := DecodeDataNotification(&src)
+	if err != nil || b.DateTime != nil || b.Body.Value != uint8(5) {
+		t.Errorf("t2 Failed. get: %v, err: %v", b, err)
+	}
+
+	src = []byte{15, 0x40, 0, 0, 1, 5, 1, 2, 3, 4, 5, 17, 5}
+	if _, err = DecodeDataNotification(&src); err == nil {
+		t.Errorf("t3 should fail on wrong date-time length")
+	}
+
+	src = []byte{15, 0x40, 0, 0, 1, 0}
+	if _, err = DecodeDataNotification(&src); err == nil {
+		t.Errorf("t4 should fail on missing body")
+	}
+}
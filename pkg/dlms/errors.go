@@ -0,0 +1,138 @@
+package dlms
+
+import "fmt"
+
+// DecodeError is returned when an APDU cannot be decoded. Offset is
+// index in the decoded bytes where decoding failed, it is not set for
+// wrong length. Expected and Got are either tag or length, as told by
+// Length. Err is the cause of failure which is neither wrong tag nor
+// wrong length, such as value that is not recognized
+type DecodeError struct {
+	Offset   int
+	Length   bool
+	Expected int
+	Got      int
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("decoding failed on index %v: %v", e.Offset, e.Err)
+	}
+	if e.Length {
+		return fmt.Sprintf("wrong data length, received %d, expecting %v", e.Got, e.Expected)
+	}
+	return fmt.Sprintf("wrong data tag on index %v, expecting %v instead of %v", e.Offset, e.Expected, e.Got)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func ErrWrongTag(idx int, get byte, correct byte) error {
+	return &DecodeError{Offset: idx, Expected: int(correct), Got: int(get)}
+}
+
+// ErrWrongLength is returned when only current bytes are left where
+// at least correct bytes are needed
+func ErrWrongLength(current int, correct int) error {
+	return &DecodeError{Length: true, Expected: correct, Got: current}
+}
+
+// errDecode returns DecodeError caused by err on index idx
+func errDecode(idx int, err error) error {
+	return &DecodeError{Offset: idx, Err: err}
+}
+
+// DataAccessError is Data-Access-Result of GET, SET or short name
+// services which is not success
+type DataAccessError struct {
+	Result AccessResultTag
+}
+
+func (e *DataAccessError) Error() string {
+	return fmt.Sprintf("data access result: %v (%v)", e.Result, e.Result.Value())
+}
+
+// Is reports whether target is DataAccessError of the same result
+func (e *DataAccessError) Is(target error) bool {
+	t, ok := target.(*DataAccessError)
+	return ok && t.Result == e.Result
+}
+
+// Temporary reports whether the request may succeed when sent again
+func (e *DataAccessError) Temporary() bool {
+	return e.Result == TagAccTemporaryFailure
+}
+
+// ActionError is Action-Result of ACTION which is not success
+type ActionError struct {
+	Result ActionResultTag
+}
+
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("action result: %v (%v)", e.Result, e.Result.Value())
+}
+
+// Is reports whether target is ActionError of the same result
+func (e *ActionError) Is(target error) bool {
+	t, ok := target.(*ActionError)
+	return ok && t.Result == e.Result
+}
+
+// Temporary reports whether the request may succeed when sent again
+func (e *ActionError) Temporary() bool {
+	return e.Result == TagActTemporaryFailure
+}
+
+// ExceptionError is ExceptionResponse sent by server in place of the
+// response, when the request is not allowed or not understood
+type ExceptionError struct {
+	Response ExceptionResponse
+}
+
+func (e *ExceptionError) Error() string {
+	return fmt.Sprintf("exception response, state error: %v, service error: %v", e.Response.StateError, e.Response.ServiceError)
+}
+
+// Is reports whether target is ExceptionError of the same response
+func (e *ExceptionError) Is(target error) bool {
+	t, ok := target.(*ExceptionError)
+	return ok && t.Response == e.Response
+}
+
+// ServiceError is ConfirmedServiceError sent by server in place of the
+// response. The ConfirmedServiceError is unwrapped by errors.As
+type ServiceError struct {
+	Response ConfirmedServiceError
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("confirmed service error, %v", e.Response.Error())
+}
+
+// Is reports whether target is ServiceError of the same response
+func (e *ServiceError) Is(target error) bool {
+	t, ok := target.(*ServiceError)
+	return ok && t.Response == e.Response
+}
+
+func (e *ServiceError) Unwrap() error {
+	return e.Response
+}
+
+// Err returns DataAccessError of the result, nil on success
+func (s AccessResultTag) Err() error {
+	if s == TagAccSuccess {
+		return nil
+	}
+	return &DataAccessError{Result: s}
+}
+
+// Err returns ActionError of the result, nil on success
+func (s ActionResultTag) Err() error {
+	if s == TagActSuccess {
+		return nil
+	}
+	return &ActionError{Result: s}
+}
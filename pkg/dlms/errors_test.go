@@ -0,0 +1,106 @@
+package dlms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDecodeError(t *testing.T) {
+	src := []byte{15, 1, 6, 1}
+	_, err := DecodeConfirmedServiceError(&src)
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Length || de.Offset != 0 || de.Expected != 14 || de.Got != 15 {
+		t.Errorf("t1 Failed. get: %v", err)
+	}
+	if err.Error() != "wrong data tag on index 0, expecting 14 instead of 15" {
+		t.Errorf("t1 Failed. message: %v", err)
+	}
+
+	src = []byte{14, 1}
+	_, err = DecodeConfirmedServiceError(&src)
+	if !errors.As(err, &de) || !de.Length || de.Offset != 0 || de.Expected != 4 || de.Got != 2 {
+		t.Errorf("t2 Failed. get: %v", err)
+	}
+
+	err = ErrWrongLength(10, 300)
+	if !errors.As(err, &de) || de.Expected != 300 || err.Error() != "wrong data length, received 10, expecting 300" {
+		t.Errorf("t3 Failed. get: %v", err)
+	}
+}
+
+func TestDecodeError_Cause(t *testing.T) {
+	src, _ := CreateAARE(TagAppCtxLNNoCipher, TagAssocAccepted, TagDiagACSEServiceUser, TagDiagNull, nil).Encode()
+	// application-context-name is the first component, its OID is broken
+	idx := bytes.Index(src, oidApplicationContext)
+	src[idx+1] = 0x86
+	_, err := DecodeAARE(&src)
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Offset != 2 || de.Err == nil {
+		t.Errorf("t1 Failed. get: %v", err)
+	}
+
+	// data of general block is cut
+	src = []byte{0xE0, 0x80, 0, 1, 0, 0, 5, 1, 2}
+	_, err = DecodeGeneralBlockTransfer(&src)
+	if !errors.As(err, &de) || !de.Length || de.Expected != 5 || de.Got != 2 {
+		t.Errorf("t2 Failed. get: %v", err)
+	}
+
+	// conformance of InitiateResponse has wrong tag
+	src, _ = CreateInitiateResponse(ConformanceBlock(0x1C), 0x100, VAANameLN).Encode()
+	src[3] = 0x1E
+	_, err = DecodeInitiateResponse(&src)
+	if !errors.As(err, &de) || de.Err == nil {
+		t.Errorf("t3 Failed. get: %v", err)
+	}
+}
+
+func TestResultError(t *testing.T) {
+	if TagAccSuccess.Err() != nil || TagActSuccess.Err() != nil {
+		t.Errorf("t1 Failed. success should not be error")
+	}
+
+	err := fmt.Errorf("reading clock: %w", TagAccTemporaryFailure.Err())
+	var dae *DataAccessError
+	if !errors.As(err, &dae) || !dae.Temporary() {
+		t.Errorf("t2 Failed. get: %v", err)
+	}
+	if !errors.Is(err, &DataAccessError{Result: TagAccTemporaryFailure}) || errors.Is(err, &DataAccessError{Result: TagAccObjectUndefined}) {
+		t.Errorf("t2 Failed. errors.Is does not compare result")
+	}
+	if err.Error() != "reading clock: data access result: temporary-failure (2)" {
+		t.Errorf("t2 Failed. message: %v", err)
+	}
+
+	err = TagActObjectUndefined.Err()
+	var ae *ActionError
+	if !errors.As(err, &ae) || ae.Temporary() || !errors.Is(err, &ActionError{Result: TagActObjectUndefined}) {
+		t.Errorf("t3 Failed. get: %v", err)
+	}
+
+	err = &BlockTransferError{Result: TagAccLongGetAborted, BlockNumber: 3}
+	if !errors.Is(err, &DataAccessError{Result: TagAccLongGetAborted}) {
+		t.Errorf("t4 Failed. block transfer error should unwrap to DataAccessError")
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	var err error = &ExceptionError{Response: *CreateExceptionResponse(TagExcServiceNotAllowed, TagExcServiceNotSupported)}
+	if err.Error() != "exception response, state error: service-not-allowed, service error: service-not-supported" {
+		t.Errorf("t1 Failed. message: %v", err)
+	}
+	if !errors.Is(err, &ExceptionError{Response: *CreateExceptionResponse(TagExcServiceNotAllowed, TagExcServiceNotSupported)}) {
+		t.Errorf("t1 Failed. errors.Is does not compare response")
+	}
+
+	err = &ServiceError{Response: *CreateConfirmedServiceError(TagErrRead, TagErrAccess, TagAccessScopeOfAccessViolated.Value())}
+	var cse ConfirmedServiceError
+	if !errors.As(err, &cse) || cse.Detail() != TagAccessScopeOfAccessViolated {
+		t.Errorf("t2 Failed. get: %v", err)
+	}
+	if err.Error() != "confirmed service error, read: access: scope-of-access-violated" {
+		t.Errorf("t2 Failed. message: %v", err)
+	}
+}
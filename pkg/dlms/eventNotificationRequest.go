@@ -1,8 +1,8 @@
 package dlms
 
 import (
-	"bytes"
 	"gosem/pkg/axdr"
+	"io"
 	"time"
 )
 
@@ -25,39 +25,37 @@ func CreateEventNotificationRequest(tm *time.Time, attInfo AttributeDescriptor,
 }
 
 func (ev EventNotificationRequest) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagEventNotificationRequest))
+	return ev.AppendEncode(nil)
+}
+
+func (ev EventNotificationRequest) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagEventNotificationRequest))
 	if ev.Time == nil {
-		buf.WriteByte(0)
+		out = append(out, 0)
 	} else {
-		buf.WriteByte(1)
-		tm, e := axdr.EncodeDateTime(ev.getTimebyValue())
-		if e != nil {
-			err = e
-			return
+		tm, err := axdr.EncodeDateTime(ev.getTimebyValue())
+		if err != nil {
+			return dst, err
 		}
-		buf.WriteByte(uint8(len(tm)))
-		buf.Write(tm)
+		out = append(out, 1, uint8(len(tm)))
+		out = append(out, tm...)
 	}
-	attInfo, eInfo := ev.AttributeInfo.Encode()
-	if eInfo != nil {
-		err = eInfo
-		return
-	}
-	buf.Write(attInfo)
-	attValue, eValue := ev.AttributeValue.Encode()
-	if eValue != nil {
-		err = eValue
-		return
+	out = ev.AttributeInfo.appendEncode(out)
+	out, err := ev.AttributeValue.AppendEncode(out)
+	if err != nil {
+		return dst, err
 	}
-	buf.Write(attValue)
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (ev EventNotificationRequest) EncodeTo(w io.Writer) error { return encodeTo(w, ev) }
+
 func DecodeEventNotificationRequest(ori *[]byte) (out EventNotificationRequest, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
 
 	if src[0] != TagEventNotificationRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagEventNotificationRequest))
@@ -71,6 +69,10 @@ func DecodeEventNotificationRequest(ori *[]byte) (out EventNotificationRequest,
 		var nilTime *time.Time
 		out.Time = nilTime
 	} else {
+		if len(src) < 1 {
+			err = ErrWrongLength(len(src), 1)
+			return
+		}
 		src = src[1:] // length of time
 		_, time, e := axdr.DecodeDateTime(&src)
 		if e != nil {
@@ -87,6 +89,9 @@ func DecodeEventNotificationRequest(ori *[]byte) (out EventNotificationRequest,
 
 	decoder := axdr.NewDataDecoder(&src)
 	out.AttributeValue, err = decoder.Decode(&src)
+	if err != nil {
+		return
+	}
 
 	(*ori) = (*ori)[len((*ori))-len(src):]
 	return
@@ -1,7 +1,7 @@
 package dlms
 
 import (
-	"bytes"
+	"io"
 )
 
 type exceptionStateErrorTag uint8
@@ -17,6 +17,17 @@ func (s exceptionStateErrorTag) Value() uint8 {
 	return uint8(s)
 }
 
+func (s exceptionStateErrorTag) String() string {
+	switch s {
+	case TagExcServiceNotAllowed:
+		return "service-not-allowed"
+	case TagExcServiceUnknown:
+		return "service-unknown"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
 type exceptionServiceErrorTag uint8
 
 const (
@@ -31,6 +42,19 @@ func (s exceptionServiceErrorTag) Value() uint8 {
 	return uint8(s)
 }
 
+func (s exceptionServiceErrorTag) String() string {
+	switch s {
+	case TagExcOperationNotPossible:
+		return "operation-not-possible"
+	case TagExcServiceNotSupported:
+		return "service-not-supported"
+	case TagExcOtherReason:
+		return "other-reason"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
 type ExceptionResponse struct {
 	StateError   exceptionStateErrorTag
 	ServiceError exceptionServiceErrorTag
@@ -44,17 +68,17 @@ func CreateExceptionResponse(stateError exceptionStateErrorTag, serviceError exc
 }
 
 func (er ExceptionResponse) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(TagExceptionResponse.Value())
-	buf.WriteByte(er.StateError.Value())
-	buf.WriteByte(er.ServiceError.Value())
+	return er.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (er ExceptionResponse) AppendEncode(dst []byte) ([]byte, error) {
+	return append(dst, TagExceptionResponse.Value(), er.StateError.Value(), er.ServiceError.Value()), nil
 }
 
+func (er ExceptionResponse) EncodeTo(w io.Writer) error { return encodeTo(w, er) }
+
 func DecodeExceptionResponse(ori *[]byte) (out ExceptionResponse, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
 
 	if len(src) < 3 {
 		err = ErrWrongLength(len(src), 3)
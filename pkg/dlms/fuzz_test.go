@@ -0,0 +1,51 @@
+package dlms
+
+import (
+	"testing"
+)
+
+func FuzzDecodeCosem(f *testing.F) {
+	seeds := [][]byte{
+		{14, 1, 6, 1},
+		{15, 0x40, 0, 0, 1, 0, 17, 5},
+		{5, 2, 2, 0xFA, 0x00, 4, 0x01, 0x00, 1, 17, 5},
+		{12, 4, 0, 18, 0x12, 0x34, 1, 3, 2, 0xFF, 0, 1, 2, 0xAA, 0xBB, 3, 0, 2},
+		{6, 1, 2, 0x02, 0x08, 1, 17, 1},
+		{13, 3, 0, 1, 3, 2, 0, 5},
+		{24, 0, 1, 2, 0xFA, 0x08, 1, 17, 7},
+		{192, 1, 81, 0, 1, 1, 0, 0, 3, 0, 255, 2, 0},
+		{192, 2, 81, 0, 0, 0, 1},
+		{192, 3, 81, 2, 0, 1, 1, 0, 0, 3, 0, 255, 2, 0, 0, 1, 1, 0, 0, 3, 0, 255, 2, 0},
+		{193, 1, 81, 0, 1, 1, 0, 0, 3, 0, 255, 2, 0, 9, 2, 0xAA, 0xBB},
+		{195, 1, 81, 0, 1, 1, 0, 0, 3, 0, 255, 2, 1, 15, 0},
+		{196, 1, 81, 0, 9, 2, 0xAA, 0xBB},
+		{196, 2, 81, 255, 0, 0, 0, 1, 0, 2, 0xAA, 0xBB},
+		{196, 3, 81, 2, 0, 9, 1, 0xAA, 1, 3},
+		{197, 1, 81, 0},
+		{199, 1, 81, 0, 1, 0, 17, 5},
+		{194, 1, 12, 5, 220, 1, 1, 1, 0, 0, 0, 255, 0, 0, 0, 0, 1, 1, 0, 0, 3, 0, 255, 2, 3, 255},
+		{216, 1, 2},
+		{224, 0x83, 0, 2, 0, 1, 2, 0xAA, 0xBB},
+		{1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 0x7E, 0x1F, 0x04, 0xB0},
+		{8, 0, 6, 95, 31, 4, 0, 0, 80, 31, 1, 244, 0, 7},
+		{96, 29, 161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1, 190, 16, 4, 14, 1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176},
+		{97, 41, 161, 9, 6, 7, 96, 133, 116, 5, 8, 1, 1, 162, 3, 2, 1, 0, 163, 5, 161, 3, 2, 1, 0, 190, 16, 4, 14, 8, 0, 6, 95, 31, 4, 0, 0, 80, 31, 1, 244, 0, 7},
+		{98, 3, 128, 1, 0},
+		{99, 3, 128, 1, 0},
+		{200, 5, 0x30, 0, 0, 0, 1, 0xAA},
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		src := append([]byte(nil), data...)
+		out, err := DecodeCosem(&src)
+		if err != nil {
+			return
+		}
+		if len(src) > len(data) {
+			t.Errorf("decoded %T left %d bytes out of %d", out, len(src), len(data))
+		}
+	})
+}
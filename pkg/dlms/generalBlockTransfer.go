@@ -0,0 +1,256 @@
+package dlms
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Biggest window of general block transfer, window is 6 bits of block control
+const MaxGeneralBlockWindow = 0x3F
+
+// GeneralBlockTransfer implement CosemPDU. It carries a block of another
+// APDU. Window is receive window of the sender, BlockNumberAck is number
+// of the last block received in sequence from the peer. With Streaming
+// set, the block is followed by another one without waiting for ack
+type GeneralBlockTransfer struct {
+	LastBlock      bool
+	Streaming      bool
+	Window         uint8
+	BlockNumber    uint16
+	BlockNumberAck uint16
+	BlockData      []byte
+}
+
+// CreateGeneralBlockTransfer returns block as it is, window bigger than
+// MaxGeneralBlockWindow is reported by Encode
+func CreateGeneralBlockTransfer(lastBlock bool, streaming bool, window uint8, blockNum uint16, blockNumAck uint16, data []byte) *GeneralBlockTransfer {
+	return &GeneralBlockTransfer{
+		LastBlock:      lastBlock,
+		Streaming:      streaming,
+		Window:         window,
+		BlockNumber:    blockNum,
+		BlockNumberAck: blockNumAck,
+		BlockData:      data,
+	}
+}
+
+func (gb GeneralBlockTransfer) Encode() (out []byte, err error) {
+	return gb.AppendEncode(nil)
+}
+
+func (gb GeneralBlockTransfer) AppendEncode(dst []byte) ([]byte, error) {
+	if gb.Window > MaxGeneralBlockWindow {
+		return dst, fmt.Errorf("window %v is bigger than %v", gb.Window, MaxGeneralBlockWindow)
+	}
+
+	control := gb.Window
+	if gb.LastBlock {
+		control |= 0x80
+	}
+	if gb.Streaming {
+		control |= 0x40
+	}
+	out := append(dst, TagGeneralBlockTransfer.Value(), control)
+	out = appendUint16(appendUint16(out, gb.BlockNumber), gb.BlockNumberAck)
+	out, err := appendOctetString(out, gb.BlockData)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
+}
+
+func (gb GeneralBlockTransfer) EncodeTo(w io.Writer) error { return encodeTo(w, gb) }
+
+func DecodeGeneralBlockTransfer(ori *[]byte) (out GeneralBlockTransfer, err error) {
+	src := *ori
+
+	if len(src) < 7 {
+		err = ErrWrongLength(len(src), 7)
+		return
+	}
+	if src[0] != TagGeneralBlockTransfer.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagGeneralBlockTransfer))
+		return
+	}
+	out.LastBlock = src[1]&0x80 != 0
+	out.Streaming = src[1]&0x40 != 0
+	out.Window = src[1] & MaxGeneralBlockWindow
+	src = src[2:]
+
+	if out.BlockNumber, err = readUint16(&src); err != nil {
+		return
+	}
+	if out.BlockNumberAck, err = readUint16(&src); err != nil {
+		return
+	}
+	if out.BlockData, err = readOctetString(&src); err != nil {
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+// GeneralBlockSender cuts an APDU into blocks of general block transfer.
+// Blocks are sent as many as window of peer allows, and sent again from
+// the one after the last acknowledged
+type GeneralBlockSender struct {
+	window     uint8
+	peerWindow uint8
+	first      uint16
+	acked      uint16
+	sent       uint16
+	blocks     [][]byte
+}
+
+// NewGeneralBlockSender splits apdu into blocks of at most size bytes,
+// numbered from first. Numbers before first are of blocks already sent,
+// such as acks. Window is own receive window, sent in every block.
+// Window of peer is taken as 1 until it is known from its ack
+func NewGeneralBlockSender(apdu []byte, size int, window uint8, first uint16) (out *GeneralBlockSender, err error) {
+	if size < 1 {
+		err = fmt.Errorf("general block size %v is not valid", size)
+		return
+	}
+	if window > MaxGeneralBlockWindow {
+		err = fmt.Errorf("window %v is bigger than %v", window, MaxGeneralBlockWindow)
+		return
+	}
+	if first < 1 {
+		err = fmt.Errorf("first block number must be at least 1")
+		return
+	}
+	out = &GeneralBlockSender{window: window, peerWindow: 1, first: first, acked: first - 1, sent: first - 1}
+	for len(apdu) > 0 {
+		n := len(apdu)
+		if n > size {
+			n = size
+		}
+		out.blocks = append(out.blocks, apdu[:n])
+		apdu = apdu[n:]
+	}
+	if len(out.blocks) == 0 || int(first)+len(out.blocks)-1 > 0xFFFF {
+		err = fmt.Errorf("APDU cannot be sent in %v blocks from block %v", len(out.blocks), first)
+	}
+	return
+}
+
+// number of the last block
+func (s *GeneralBlockSender) last() uint16 {
+	return s.first + uint16(len(s.blocks)) - 1
+}
+
+// Done returns whether every block is acknowledged by peer
+func (s *GeneralBlockSender) Done() bool {
+	return s.acked == s.last()
+}
+
+// Next returns window of blocks following the last acknowledged one.
+// BlockNumberAck of every block is set to ack
+func (s *GeneralBlockSender) Next(ack uint16) (out []GeneralBlockTransfer) {
+	first := int(s.acked) + 1
+	last := int(s.acked) + int(s.peerWindow)
+	if last > int(s.last()) {
+		last = int(s.last())
+	}
+	for n := first; n <= last; n++ {
+		out = append(out, GeneralBlockTransfer{
+			LastBlock:      n == int(s.last()),
+			Streaming:      n != last,
+			Window:         s.window,
+			BlockNumber:    uint16(n),
+			BlockNumberAck: ack,
+			BlockData:      s.blocks[n-int(s.first)],
+		})
+	}
+	if last > int(s.sent) {
+		s.sent = uint16(last)
+	}
+	return
+}
+
+// Acknowledge takes BlockNumberAck and Window of block sent by peer. Ack
+// going back or beyond the last block given by Next is not valid
+func (s *GeneralBlockSender) Acknowledge(block GeneralBlockTransfer) error {
+	if block.BlockNumberAck < s.acked || block.BlockNumberAck > s.sent {
+		return &BlockTransferError{Result: TagAccDataBlockNumberInvalid, BlockNumber: uint32(block.BlockNumberAck)}
+	}
+	s.acked = block.BlockNumberAck
+	if block.Window > 0 {
+		s.peerWindow = block.Window
+	}
+	return nil
+}
+
+// Biggest APDU put together by GeneralBlockReceiver with no MaxSize
+const DefaultGeneralBlockMaxSize = 0x100000
+
+// GeneralBlockReceiver collects blocks of general block transfer sent by
+// peer. Block out of sequence is dropped, peer sends it again after ack.
+// MaxSize is the biggest APDU put together, DefaultGeneralBlockMaxSize
+// when zero
+type GeneralBlockReceiver struct {
+	MaxSize int
+
+	blockNumber uint16
+	complete    bool
+	buffer      bytes.Buffer
+}
+
+// BlockNumber returns number of the last block received in sequence
+func (r *GeneralBlockReceiver) BlockNumber() uint16 {
+	return r.blockNumber
+}
+
+// Complete returns whether the last block is received
+func (r *GeneralBlockReceiver) Complete() bool {
+	return r.complete
+}
+
+// Add accepts block sent by peer, ack reports whether peer waits for
+// acknowledge. It does after the last block of its window, or after the
+// last block of all when a block before it is lost. Block without data
+// is an ack of peer, it is not a block of the transfer and is ignored
+func (r *GeneralBlockReceiver) Add(block GeneralBlockTransfer) (ack bool, err error) {
+	if r.complete {
+		err = &BlockTransferError{Result: TagAccNoLongGetInProgress, BlockNumber: uint32(block.BlockNumber)}
+		return
+	}
+	if len(block.BlockData) == 0 {
+		return
+	}
+	if block.BlockNumber != r.blockNumber+1 {
+		ack = !block.Streaming || block.LastBlock
+		return
+	}
+	max := r.MaxSize
+	if max == 0 {
+		max = DefaultGeneralBlockMaxSize
+	}
+	if r.buffer.Len()+len(block.BlockData) > max {
+		err = fmt.Errorf("general block transfer is bigger than %v bytes", max)
+		return
+	}
+	r.blockNumber = block.BlockNumber
+	r.buffer.Write(block.BlockData)
+	if block.LastBlock {
+		r.complete = true
+		return
+	}
+	ack = !block.Streaming
+	return
+}
+
+// Ack returns block acknowledging the blocks received so far, blockNum
+// is the number of the last own block, as block without data does not
+// take a number. It has no data and LastBlock is set as own APDU is
+// already sent
+func (r *GeneralBlockReceiver) Ack(blockNum uint16, window uint8) *GeneralBlockTransfer {
+	return CreateGeneralBlockTransfer(true, false, window, blockNum, r.blockNumber, nil)
+}
+
+// Raw returns data of all blocks received in sequence
+func (r *GeneralBlockReceiver) Raw() []byte {
+	return r.buffer.Bytes()
+}
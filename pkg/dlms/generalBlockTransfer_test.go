@@ -0,0 +1,166 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_GeneralBlockTransfer(t *testing.T) {
+	var a GeneralBlockTransfer = *CreateGeneralBlockTransfer(true, false, 3, 2, 1, []byte{0xAA, 0xBB})
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{224, 0x83, 0, 2, 0, 1, 2, 0xAA, 0xBB}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	a = *CreateGeneralBlockTransfer(false, true, 63, 0x1234, 0, nil)
+	t2, e := a.Encode()
+	if e != nil {
+		t.Errorf("t2 Encode Failed. err: %v", e)
+	}
+	result = []byte{224, 0x7F, 0x12, 0x34, 0, 0, 0}
+	res = bytes.Compare(t2, result)
+	if res != 0 {
+		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
+	}
+
+	a = *CreateGeneralBlockTransfer(false, false, 64, 1, 0, nil)
+	if _, e = a.Encode(); e == nil {
+		t.Errorf("t3 should fail on window bigger than 63")
+	}
+}
+
+func TestDecode_GeneralBlockTransfer(t *testing.T) {
+	src := []byte{224, 0x83, 0, 2, 0, 1, 2, 0xAA, 0xBB}
+	a, err := DecodeGeneralBlockTransfer(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeGeneralBlockTransfer. Err: %v", err)
+	}
+	if !a.LastBlock || a.Streaming || a.Window != 3 || a.BlockNumber != 2 || a.BlockNumberAck != 1 || bytes.Compare(a.BlockData, []byte{0xAA, 0xBB}) != 0 {
+		t.Errorf("t1 Failed. get: %v", a)
+	}
+	if len(src) != 0 {
+		t.Errorf("t1 Failed. src should be empty (%v)", src)
+	}
+
+	src = []byte{224, 0x41, 0, 2, 0, 1, 3, 0xAA, 0xBB}
+	if _, err = DecodeGeneralBlockTransfer(&src); err == nil {
+		t.Errorf("t2 should fail on short block data")
+	}
+}
+
+func TestGeneralBlockSender(t *testing.T) {
+	s, err := NewGeneralBlockSender([]byte{1, 2, 3, 4, 5, 6, 7}, 2, 5, 1)
+	if err != nil {
+		t.Fatalf("NewGeneralBlockSender failed. err: %v", err)
+	}
+
+	// window of peer is 1 until it is known
+	blocks := s.Next(0)
+	if len(blocks) != 1 || blocks[0].BlockNumber != 1 || blocks[0].Streaming || blocks[0].LastBlock || blocks[0].Window != 5 {
+		t.Errorf("t1 Failed. get: %v", blocks)
+	}
+
+	if err = s.Acknowledge(GeneralBlockTransfer{Window: 3, BlockNumberAck: 1}); err != nil {
+		t.Errorf("t2 Acknowledge failed. err: %v", err)
+	}
+	blocks = s.Next(7)
+	if len(blocks) != 3 || !blocks[0].Streaming || !blocks[1].Streaming || blocks[2].Streaming || !blocks[2].LastBlock || blocks[2].BlockNumberAck != 7 {
+		t.Errorf("t2 Failed. get: %v", blocks)
+	}
+
+	// block 3 is lost
+	if err = s.Acknowledge(GeneralBlockTransfer{Window: 3, BlockNumberAck: 2}); err != nil {
+		t.Errorf("t3 Acknowledge failed. err: %v", err)
+	}
+	blocks = s.Next(7)
+	if len(blocks) != 2 || blocks[0].BlockNumber != 3 || bytes.Compare(blocks[1].BlockData, []byte{7}) != 0 {
+		t.Errorf("t3 Failed. get: %v", blocks)
+	}
+
+	if err = s.Acknowledge(GeneralBlockTransfer{BlockNumberAck: 5}); err == nil {
+		t.Errorf("t4 should fail on ack of block not sent")
+	}
+	if err = s.Acknowledge(GeneralBlockTransfer{BlockNumberAck: 1}); err == nil {
+		t.Errorf("t4 should fail on ack going back")
+	}
+	if s.Done() {
+		t.Errorf("t4 should not be done")
+	}
+	if err = s.Acknowledge(GeneralBlockTransfer{BlockNumberAck: 4}); err != nil || !s.Done() {
+		t.Errorf("t5 Failed. done: %v, err: %v", s.Done(), err)
+	}
+
+	// numbering continues after blocks already sent
+	s, _ = NewGeneralBlockSender([]byte{1, 2, 3}, 2, 1, 4)
+	blocks = s.Next(0)
+	if len(blocks) != 1 || blocks[0].BlockNumber != 4 || bytes.Compare(blocks[0].BlockData, []byte{1, 2}) != 0 {
+		t.Errorf("t6 Failed. get: %v", blocks)
+	}
+
+	if _, err = NewGeneralBlockSender(nil, 2, 1, 1); err == nil {
+		t.Errorf("t7 should fail on empty APDU")
+	}
+
+	// only block 1 is sent, ack of block 3 is not valid
+	s, _ = NewGeneralBlockSender([]byte{1, 2, 3, 4, 5, 6}, 2, 1, 1)
+	s.Next(0)
+	if err = s.Acknowledge(GeneralBlockTransfer{Window: 1, BlockNumberAck: 3}); err == nil {
+		t.Errorf("t8 should fail on ack of block not sent yet")
+	}
+}
+
+func TestGeneralBlockReceiver(t *testing.T) {
+	var r GeneralBlockReceiver
+	block := func(last bool, streaming bool, num uint16, data ...byte) GeneralBlockTransfer {
+		return GeneralBlockTransfer{LastBlock: last, Streaming: streaming, BlockNumber: num, BlockData: data}
+	}
+
+	if ack, err := r.Add(block(false, true, 1, 1)); ack || err != nil {
+		t.Errorf("t1 Failed. ack: %v, err: %v", ack, err)
+	}
+	// block 2 is lost, end of window is acknowledged
+	if ack, err := r.Add(block(false, false, 3, 3)); !ack || err != nil || r.BlockNumber() != 1 {
+		t.Errorf("t2 Failed. ack: %v, block: %v, err: %v", ack, r.BlockNumber(), err)
+	}
+	ack := r.Ack(2, 4)
+	if !ack.LastBlock || ack.BlockNumber != 2 || ack.BlockNumberAck != 1 || ack.Window != 4 || len(ack.BlockData) != 0 {
+		t.Errorf("t2 Failed. ack: %v", ack)
+	}
+
+	if ack, err := r.Add(block(false, true, 2, 2)); ack || err != nil {
+		t.Errorf("t3 Failed. ack: %v, err: %v", ack, err)
+	}
+	if ack, err := r.Add(block(false, false, 3, 3)); !ack || err != nil || r.BlockNumber() != 3 {
+		t.Errorf("t4 Failed. ack: %v, block: %v, err: %v", ack, r.BlockNumber(), err)
+	}
+	// empty block is ack of peer, it does not take a number
+	if ack, err := r.Add(block(false, false, 4)); ack || err != nil || r.BlockNumber() != 3 {
+		t.Errorf("t5 Failed. ack: %v, block: %v, err: %v", ack, r.BlockNumber(), err)
+	}
+	if ack, err := r.Add(block(true, false, 4)); ack || err != nil || r.BlockNumber() != 3 || r.Complete() {
+		t.Errorf("t5 Failed. ack: %v, block: %v, complete: %v, err: %v", ack, r.BlockNumber(), r.Complete(), err)
+	}
+	if ack, err := r.Add(block(true, false, 4, 5)); ack || err != nil || !r.Complete() {
+		t.Errorf("t6 Failed. ack: %v, complete: %v, err: %v", ack, r.Complete(), err)
+	}
+	if bytes.Compare(r.Raw(), []byte{1, 2, 3, 5}) != 0 {
+		t.Errorf("t6 Failed. get: %v", r.Raw())
+	}
+	if _, err := r.Add(block(true, false, 6, 6)); err == nil {
+		t.Errorf("t7 should fail after the last block")
+	}
+
+	// data is not put together beyond MaxSize
+	r = GeneralBlockReceiver{MaxSize: 3}
+	if _, err := r.Add(block(false, true, 1, 1, 2)); err != nil {
+		t.Errorf("t8 Failed. err: %v", err)
+	}
+	if _, err := r.Add(block(true, false, 2, 3, 4)); err == nil || r.Complete() {
+		t.Errorf("t8 should fail on APDU bigger than MaxSize")
+	}
+}
@@ -0,0 +1,96 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"gosem/pkg/axdr"
+)
+
+// GeneralCiphering implement CosemPDU. It is a general-glo-ciphering or
+// general-ded-ciphering APDU, which carries system title of the sender
+// together with any ciphered APDU, so the receiver finds keys of the
+// sender without binding them to the connection. Content is still
+// ciphered, security package does the (de)ciphering. Information holds
+// ciphered APDU followed by authentication tag if any.
+type GeneralCiphering struct {
+	Tag               cosemTag
+	SystemTitle       []byte
+	SecurityHeader    uint8
+	InvocationCounter uint32
+	Information       []byte
+}
+
+func CreateGeneralCiphering(dedicated bool, systemTitle []byte, securityHeader uint8, invocationCounter uint32, information []byte) *GeneralCiphering {
+	tag := TagGeneralGloCiphering
+	if dedicated {
+		tag = TagGeneralDedCiphering
+	}
+	return &GeneralCiphering{
+		Tag:               tag,
+		SystemTitle:       systemTitle,
+		SecurityHeader:    securityHeader,
+		InvocationCounter: invocationCounter,
+		Information:       information,
+	}
+}
+
+// Dedicated returns whether the APDU is ciphered by dedicated key
+func (gc GeneralCiphering) Dedicated() bool {
+	return gc.Tag == TagGeneralDedCiphering
+}
+
+func (gc GeneralCiphering) Encode() (out []byte, err error) {
+	return gc.AppendEncode(nil)
+}
+
+// AppendEncode writes APDU straight into dst, see CosemPDU
+func (gc GeneralCiphering) AppendEncode(dst []byte) ([]byte, error) {
+	if gc.Tag != TagGeneralGloCiphering && gc.Tag != TagGeneralDedCiphering {
+		return dst, fmt.Errorf("APDU tag %v is not a general ciphering tag", gc.Tag)
+	}
+	out, err := appendOctetString(append(dst, gc.Tag.Value()), gc.SystemTitle)
+	if err != nil {
+		return dst, err
+	}
+	return appendCipheredContent(out, dst, gc.SecurityHeader, gc.InvocationCounter, gc.Information)
+}
+
+func (gc GeneralCiphering) EncodeTo(w io.Writer) error { return encodeTo(w, gc) }
+
+func DecodeGeneralCiphering(ori *[]byte) (out GeneralCiphering, err error) {
+	src := *ori
+
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
+	out.Tag = cosemTag(src[0])
+	if out.Tag != TagGeneralGloCiphering && out.Tag != TagGeneralDedCiphering {
+		err = ErrWrongTag(0, src[0], byte(TagGeneralGloCiphering))
+		return
+	}
+	src = src[1:]
+
+	if out.SystemTitle, err = readOctetString(&src); err != nil {
+		return
+	}
+
+	_, length, err := axdr.DecodeLength(&src)
+	if err != nil {
+		return
+	}
+	if length < 5 || uint64(len(src)) < length {
+		err = errDecode(len(*ori)-len(src), fmt.Errorf("ciphered content length %v is not valid, %v bytes left", length, len(src)))
+		return
+	}
+
+	out.SecurityHeader = src[0]
+	out.InvocationCounter = binary.BigEndian.Uint32(src[1:5])
+	out.Information = append([]byte(nil), src[5:length]...)
+	src = src[length:]
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
@@ -0,0 +1,61 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_GeneralCiphering(t *testing.T) {
+	title := []byte{0x4D, 0x4D, 0x4D, 0x00, 0x00, 0xBC, 0x61, 0x4E}
+	var a GeneralCiphering = *CreateGeneralCiphering(false, title, 0x30, 0x01234567, []byte{1, 2, 3})
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{219, 8, 0x4D, 0x4D, 0x4D, 0x00, 0x00, 0xBC, 0x61, 0x4E, 8, 48, 1, 35, 69, 103, 1, 2, 3}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	a = *CreateGeneralCiphering(true, title, 0x30, 0x01234567, []byte{1, 2, 3})
+	t2, _ := a.Encode()
+	if !a.Dedicated() || t2[0] != 220 {
+		t.Errorf("t2 Failed. get: %d", t2)
+	}
+
+	a.Tag = TagGloGetResponse
+	_, e = a.Encode()
+	if e == nil {
+		t.Errorf("t3 Encode should fail on glo-get-response tag")
+	}
+}
+
+func TestDecode_GeneralCiphering(t *testing.T) {
+	src := []byte{220, 2, 0xAA, 0xBB, 8, 16, 0, 0, 0, 9, 1, 2, 3, 4}
+	a, err := DecodeGeneralCiphering(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeGeneralCiphering. Err: %v", err)
+	}
+	if a.Tag != TagGeneralDedCiphering || a.SecurityHeader != 0x10 || a.InvocationCounter != 9 {
+		t.Errorf("t1 get: %v", a)
+	}
+	if !bytes.Equal(a.SystemTitle, []byte{0xAA, 0xBB}) || !bytes.Equal(a.Information, []byte{1, 2, 3}) {
+		t.Errorf("t1 get: %v", a)
+	}
+	if bytes.Compare(src, []byte{4}) != 0 {
+		t.Errorf("t1 remaining byte get: %v", src)
+	}
+
+	src = []byte{219, 8, 0xAA, 0xBB}
+	_, err = DecodeGeneralCiphering(&src)
+	if err == nil {
+		t.Errorf("t2 should failed on DecodeGeneralCiphering")
+	}
+
+	src = []byte{219, 0, 4, 48, 1, 35, 69}
+	_, err = DecodeGeneralCiphering(&src)
+	if err == nil {
+		t.Errorf("t3 should failed on DecodeGeneralCiphering")
+	}
+}
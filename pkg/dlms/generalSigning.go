@@ -0,0 +1,98 @@
+package dlms
+
+import (
+	"fmt"
+	"io"
+)
+
+// GeneralSigning implement CosemPDU. It carries any APDU in Content,
+// signed by the originator. DateTime and OtherInformation may be empty.
+// Signature is computed on the APDU up to Content, given by SignedData,
+// security package does the signing and verification.
+type GeneralSigning struct {
+	TransactionId         []byte
+	OriginatorSystemTitle []byte
+	RecipientSystemTitle  []byte
+	DateTime              []byte
+	OtherInformation      []byte
+	Content               []byte
+	Signature             []byte
+}
+
+func CreateGeneralSigning(transactionId []byte, originator []byte, recipient []byte, dateTime []byte, otherInfo []byte, content []byte) *GeneralSigning {
+	return &GeneralSigning{
+		TransactionId:         transactionId,
+		OriginatorSystemTitle: originator,
+		RecipientSystemTitle:  recipient,
+		DateTime:              dateTime,
+		OtherInformation:      otherInfo,
+		Content:               content,
+	}
+}
+
+// Append APDU up to Content to dst, dst is returned as it is on error
+func (gs GeneralSigning) appendSigned(dst []byte) ([]byte, error) {
+	if len(gs.DateTime) != 0 && len(gs.DateTime) != 12 {
+		return dst, fmt.Errorf("date-time is %v bytes, should be 0 or 12", len(gs.DateTime))
+	}
+	out := append(dst, TagGeneralSigning.Value())
+	fields := [][]byte{gs.TransactionId, gs.OriginatorSystemTitle, gs.RecipientSystemTitle, gs.DateTime, gs.OtherInformation, gs.Content}
+	for _, field := range fields {
+		var err error
+		if out, err = appendOctetString(out, field); err != nil {
+			return dst, err
+		}
+	}
+	return out, nil
+}
+
+// SignedData returns encoded APDU without signature, which is the input
+// of signature
+func (gs GeneralSigning) SignedData() (out []byte, err error) {
+	return gs.appendSigned(nil)
+}
+
+func (gs GeneralSigning) Encode() (out []byte, err error) {
+	return gs.AppendEncode(nil)
+}
+
+func (gs GeneralSigning) AppendEncode(dst []byte) ([]byte, error) {
+	out, err := gs.appendSigned(dst)
+	if err != nil {
+		return dst, err
+	}
+	if out, err = appendOctetString(out, gs.Signature); err != nil {
+		return dst, err
+	}
+	return out, nil
+}
+
+func (gs GeneralSigning) EncodeTo(w io.Writer) error { return encodeTo(w, gs) }
+
+func DecodeGeneralSigning(ori *[]byte) (out GeneralSigning, err error) {
+	src := *ori
+
+	if len(src) < 8 {
+		err = ErrWrongLength(len(src), 8)
+		return
+	}
+	if src[0] != TagGeneralSigning.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagGeneralSigning))
+		return
+	}
+	src = src[1:]
+
+	fields := []*[]byte{&out.TransactionId, &out.OriginatorSystemTitle, &out.RecipientSystemTitle, &out.DateTime, &out.OtherInformation, &out.Content, &out.Signature}
+	for _, field := range fields {
+		if *field, err = readOctetString(&src); err != nil {
+			return
+		}
+	}
+	if len(out.DateTime) != 0 && len(out.DateTime) != 12 {
+		err = errDecode(0, fmt.Errorf("date-time is %v bytes, should be 0 or 12", len(out.DateTime)))
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
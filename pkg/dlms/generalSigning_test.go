@@ -0,0 +1,60 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_GeneralSigning(t *testing.T) {
+	var a GeneralSigning = *CreateGeneralSigning([]byte{1, 2}, []byte{0xAA}, []byte{0xBB}, nil, nil, []byte{0xC0, 1})
+	a.Signature = []byte{9, 9}
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{223, 2, 1, 2, 1, 0xAA, 1, 0xBB, 0, 0, 2, 0xC0, 1, 2, 9, 9}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	t2, e := a.SignedData()
+	if e != nil || !bytes.Equal(t2, result[:13]) {
+		t.Errorf("t2 Failed. get: %d, should:%v, err: %v", t2, result[:13], e)
+	}
+
+	a.DateTime = []byte{1, 2, 3}
+	_, e = a.Encode()
+	if e == nil {
+		t.Errorf("t3 Encode should fail on date-time length")
+	}
+}
+
+func TestDecode_GeneralSigning(t *testing.T) {
+	src := []byte{223, 2, 1, 2, 1, 0xAA, 1, 0xBB, 0, 0, 2, 0xC0, 1, 2, 9, 9, 4}
+	a, err := DecodeGeneralSigning(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeGeneralSigning. Err: %v", err)
+	}
+	if !bytes.Equal(a.TransactionId, []byte{1, 2}) || !bytes.Equal(a.OriginatorSystemTitle, []byte{0xAA}) || !bytes.Equal(a.RecipientSystemTitle, []byte{0xBB}) {
+		t.Errorf("t1 get: %v", a)
+	}
+	if len(a.DateTime) != 0 || !bytes.Equal(a.Content, []byte{0xC0, 1}) || !bytes.Equal(a.Signature, []byte{9, 9}) {
+		t.Errorf("t1 get: %v", a)
+	}
+	if bytes.Compare(src, []byte{4}) != 0 {
+		t.Errorf("t1 remaining byte get: %v", src)
+	}
+
+	src = []byte{223, 2, 1, 2, 1, 0xAA, 1, 0xBB, 0, 0, 2, 0xC0}
+	_, err = DecodeGeneralSigning(&src)
+	if err == nil {
+		t.Errorf("t2 should failed on DecodeGeneralSigning")
+	}
+
+	src = []byte{223, 0, 0, 0, 1, 0, 0, 0, 0}
+	_, err = DecodeGeneralSigning(&src)
+	if err == nil {
+		t.Errorf("t3 should failed on date-time length")
+	}
+}
@@ -1,7 +1,6 @@
 package dlms
 
 import (
-	"bytes"
 	"encoding/hex"
 	"fmt"
 	"gosem/pkg/axdr"
@@ -20,6 +19,8 @@ func CreateGetDataResultAsResult(value AccessResultTag) *GetDataResult {
 	return &GetDataResult{false, value}
 }
 
+// CreateGetDataResult picks the choice from type of value. Value of any other
+// type than axdr.DlmsData or AccessResultTag will fail on Encode()
 func CreateGetDataResult(value interface{}) *GetDataResult {
 	switch val := value.(type) {
 	case axdr.DlmsData:
@@ -27,29 +28,31 @@ func CreateGetDataResult(value interface{}) *GetDataResult {
 	case AccessResultTag:
 		return CreateGetDataResultAsResult(val)
 	default:
-		panic("Value must be either axdr.DlmsData or AccessResultTag")
+		return &GetDataResult{false, value}
 	}
 }
 
 func (dt GetDataResult) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
+	return dt.appendEncode(nil)
+}
+
+func (dt GetDataResult) appendEncode(dst []byte) (out []byte, err error) {
 	if dt.IsData {
-		buf.WriteByte(0x1)
-		value := dt.Value.(axdr.DlmsData)
-		enc, e := value.Encode()
-		if err != nil {
-			err = e
-			return
+		value, ok := dt.Value.(axdr.DlmsData)
+		if !ok {
+			return dst, fmt.Errorf("value must be axdr.DlmsData, got %T", dt.Value)
 		}
-		buf.Write(enc)
-	} else {
-		buf.WriteByte(0x0)
-		value := dt.Value.(AccessResultTag)
-		buf.WriteByte(byte(value))
+		if out, err = value.AppendEncode(append(dst, 0x1)); err != nil {
+			return dst, err
+		}
+		return
 	}
 
-	out = buf.Bytes()
-	return
+	value, ok := dt.Value.(AccessResultTag)
+	if !ok {
+		return dst, fmt.Errorf("value must be AccessResultTag, got %T", dt.Value)
+	}
+	return append(dst, 0x0, byte(value)), nil
 }
 
 func (dt GetDataResult) ValueAsData() (out axdr.DlmsData, err error) {
@@ -57,7 +60,10 @@ func (dt GetDataResult) ValueAsData() (out axdr.DlmsData, err error) {
 		err = fmt.Errorf("value is DataAccessResult")
 		return
 	}
-	out = dt.Value.(axdr.DlmsData)
+	out, ok := dt.Value.(axdr.DlmsData)
+	if !ok {
+		err = fmt.Errorf("value must be axdr.DlmsData, got %T", dt.Value)
+	}
 
 	return
 }
@@ -67,25 +73,36 @@ func (dt GetDataResult) ValueAsAccess() (out AccessResultTag, err error) {
 		err = fmt.Errorf("value is axdr.DlmsData")
 		return
 	}
-	out = dt.Value.(AccessResultTag)
+	out, ok := dt.Value.(AccessResultTag)
+	if !ok {
+		err = fmt.Errorf("value must be AccessResultTag, got %T", dt.Value)
+	}
 
 	return
 }
 
 func DecodeGetDataResult(ori *[]byte) (out GetDataResult, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
 
 	if src[0] == 0x0 {
 		out.IsData = false
 		out.Value, err = GetAccessTag(uint8(src[1]))
-		if err == nil {
-			src = src[2:]
+		if err != nil {
+			return
 		}
+		src = src[2:]
 	} else {
 		out.IsData = true
 		src = src[1:]
 		decoder := axdr.NewDataDecoder(&src)
 		out.Value, err = decoder.Decode(&src)
+		if err != nil {
+			return
+		}
 	}
 
 	(*ori) = (*ori)[len((*ori))-len(src):]
@@ -99,86 +116,82 @@ type DataBlockG struct {
 	BlockNumber uint32
 	IsResult    bool
 	Result      interface{}
+
+	// err keeps invalid hexstring given on creation until Encode()
+	err error
 }
 
+// CreateDataBlockGAsData accepts hexstring or byte slice as result. Invalid
+// hexstring or result of any other type will fail on Encode()
 func CreateDataBlockGAsData(lastBlock bool, blockNum uint32, result interface{}) *DataBlockG {
 	switch res := result.(type) {
 	case string:
 		bt, e := hex.DecodeString(res)
 		if e != nil {
-			panic(e)
+			return &DataBlockG{LastBlock: lastBlock, BlockNumber: blockNum, err: fmt.Errorf("result is not a valid hexstring: %w", e)}
 		}
-		return &DataBlockG{lastBlock, blockNum, false, bt}
-
-	case []byte:
-		return &DataBlockG{lastBlock, blockNum, false, res}
+		return &DataBlockG{LastBlock: lastBlock, BlockNumber: blockNum, Result: bt}
 
 	default:
-		panic("CreateDataBlockGAsData result only accept hexstring or byte slice.")
+		return &DataBlockG{LastBlock: lastBlock, BlockNumber: blockNum, Result: res}
 	}
 }
 
 func CreateDataBlockGAsResult(lastBlock bool, blockNum uint32, result AccessResultTag) *DataBlockG {
-	return &DataBlockG{lastBlock, blockNum, true, result}
+	return &DataBlockG{LastBlock: lastBlock, BlockNumber: blockNum, IsResult: true, Result: result}
 }
 
+// CreateDataBlockG picks the choice from type of result. Invalid hexstring or
+// result of any other type than string, byte slice or AccessResultTag will
+// fail on Encode()
 func CreateDataBlockG(lastBlock bool, blockNum uint32, result interface{}) *DataBlockG {
 	switch res := result.(type) {
-	case string:
-		bt, e := hex.DecodeString(res)
-		if e != nil {
-			panic(e)
-		}
-		return CreateDataBlockGAsData(lastBlock, blockNum, bt)
-
-	case []byte:
-		return CreateDataBlockGAsData(lastBlock, blockNum, res)
-
 	case AccessResultTag:
 		return CreateDataBlockGAsResult(lastBlock, blockNum, res)
 
 	default:
-		panic("DataBlockG result only accept hexstring, byte slice, or DataAccessResult.")
+		return CreateDataBlockGAsData(lastBlock, blockNum, res)
 	}
 }
 
 func (dt DataBlockG) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-
-	if dt.LastBlock {
-		buf.WriteByte(0xFF)
-	} else {
-		buf.WriteByte(0x0)
-	}
+	return dt.appendEncode(nil)
+}
 
-	blk, e := axdr.EncodeDoubleLongUnsigned(dt.BlockNumber)
-	if e != nil {
-		err = e
-		return
+func (dt DataBlockG) appendEncode(dst []byte) ([]byte, error) {
+	if dt.err != nil {
+		return dst, dt.err
 	}
-	buf.Write(blk)
+	out := appendBoolean(dst, dt.LastBlock)
+	out = appendUint32(out, dt.BlockNumber)
 
 	if dt.IsResult {
-		buf.WriteByte(0x1)
-		value := dt.Result.(AccessResultTag)
-		buf.WriteByte(byte(value))
-	} else {
-		buf.WriteByte(0x0)
-		value := dt.Result.([]byte)
-		// not sure if length is limited only 1 byte, or does it follow KLV as in axdr
-		buf.WriteByte(byte(len(value)))
-		buf.Write(value)
+		value, ok := dt.Result.(AccessResultTag)
+		if !ok {
+			return dst, fmt.Errorf("result must be AccessResultTag, got %T", dt.Result)
+		}
+		return append(out, 0x1, byte(value)), nil
 	}
 
-	out = buf.Bytes()
-	return
+	value, ok := dt.Result.([]byte)
+	if !ok {
+		return dst, fmt.Errorf("result must be byte slice, got %T", dt.Result)
+	}
+	out, err := appendOctetString(append(out, 0x0), value)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
 func (dt DataBlockG) ResultAsBytes() (out []byte, err error) {
 	if dt.IsResult {
 		err = fmt.Errorf("value is DataAccessResult")
-	} else {
-		out = dt.Result.([]byte)
+		return
+	}
+	out, ok := dt.Result.([]byte)
+	if !ok {
+		err = fmt.Errorf("result must be byte slice, got %T", dt.Result)
 	}
 
 	return
@@ -187,15 +200,22 @@ func (dt DataBlockG) ResultAsBytes() (out []byte, err error) {
 func (dt DataBlockG) ResultAsAccess() (out AccessResultTag, err error) {
 	if !dt.IsResult {
 		err = fmt.Errorf("value is byte slice")
-	} else {
-		out = dt.Result.(AccessResultTag)
+		return
+	}
+	out, ok := dt.Result.(AccessResultTag)
+	if !ok {
+		err = fmt.Errorf("result must be AccessResultTag, got %T", dt.Result)
 	}
 
 	return
 }
 
 func DecodeDataBlockG(ori *[]byte) (out DataBlockG, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 7 {
+		err = ErrWrongLength(len(src), 7)
+		return
+	}
 
 	if src[0] == 0x0 {
 		out.LastBlock = false
@@ -205,7 +225,14 @@ func DecodeDataBlockG(ori *[]byte) (out DataBlockG, err error) {
 	src = src[1:]
 
 	_, out.BlockNumber, err = axdr.DecodeDoubleLongUnsigned(&src)
+	if err != nil {
+		return
+	}
 
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	if src[0] == 0x0 {
 		out.IsResult = false
 	} else {
@@ -214,13 +241,27 @@ func DecodeDataBlockG(ori *[]byte) (out DataBlockG, err error) {
 	src = src[1:]
 
 	if out.IsResult {
+		if len(src) < 1 {
+			err = ErrWrongLength(len(src), 1)
+			return
+		}
 		out.Result, err = GetAccessTag(uint8(src[0]))
-		src = src[0:]
+		if err != nil {
+			return
+		}
+		src = src[1:]
 	} else {
-		// not sure if length is limited only 1 byte, or does it follow KLV as in axdr
-		val := uint8(src[0])
-		out.Result = src[1 : 1+val]
-		src = src[1+val:]
+		_, length, e := axdr.DecodeLength(&src)
+		if e != nil {
+			err = e
+			return
+		}
+		if uint64(len(src)) < length {
+			err = ErrWrongLength(len(src), int(length))
+			return
+		}
+		out.Result = append([]byte(nil), src[:length]...)
+		src = src[length:]
 	}
 
 	(*ori) = (*ori)[len((*ori))-len(src):]
@@ -232,51 +273,52 @@ type DataBlockSA struct {
 	LastBlock   bool
 	BlockNumber uint32
 	Raw         []byte
+
+	// err keeps invalid result given on creation until Encode()
+	err error
 }
 
+// CreateDataBlockSA accepts hexstring or byte slice as result. Invalid
+// hexstring or result of any other type will fail on Encode()
 func CreateDataBlockSA(lastBlock bool, blockNum uint32, result interface{}) *DataBlockSA {
 	switch res := result.(type) {
 	case string:
 		bt, e := hex.DecodeString(res)
 		if e != nil {
-			panic(e)
+			return &DataBlockSA{LastBlock: lastBlock, BlockNumber: blockNum, err: fmt.Errorf("result is not a valid hexstring: %w", e)}
 		}
-		return &DataBlockSA{lastBlock, blockNum, bt}
+		return &DataBlockSA{LastBlock: lastBlock, BlockNumber: blockNum, Raw: bt}
 
 	case []byte:
-		return &DataBlockSA{lastBlock, blockNum, res}
+		return &DataBlockSA{LastBlock: lastBlock, BlockNumber: blockNum, Raw: res}
 
 	default:
-		panic("DataBlockSA result only accept hexstring or byte slice.")
+		return &DataBlockSA{LastBlock: lastBlock, BlockNumber: blockNum, err: fmt.Errorf("result must be hexstring or byte slice, got %T", result)}
 	}
 }
 
 func (dt DataBlockSA) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
+	return dt.appendEncode(nil)
+}
 
-	if dt.LastBlock {
-		buf.WriteByte(0xFF)
-	} else {
-		buf.WriteByte(0x0)
+func (dt DataBlockSA) appendEncode(dst []byte) ([]byte, error) {
+	if dt.err != nil {
+		return dst, dt.err
 	}
-
-	blk, e := axdr.EncodeDoubleLongUnsigned(dt.BlockNumber)
-	if e != nil {
-		err = e
-		return
+	out := appendBoolean(dst, dt.LastBlock)
+	out, err := appendOctetString(appendUint32(out, dt.BlockNumber), dt.Raw)
+	if err != nil {
+		return dst, err
 	}
-	buf.Write(blk)
-
-	// not sure if length is limited only 1 byte, or does it follow KLV as in axdr
-	buf.WriteByte(byte(len(dt.Raw)))
-	buf.Write(dt.Raw)
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
 func DecodeDataBlockSA(ori *[]byte) (out DataBlockSA, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 6 {
+		err = ErrWrongLength(len(src), 6)
+		return
+	}
 
 	if src[0] == 0x0 {
 		out.LastBlock = false
@@ -286,11 +328,20 @@ func DecodeDataBlockSA(ori *[]byte) (out DataBlockSA, err error) {
 	src = src[1:]
 
 	_, out.BlockNumber, err = axdr.DecodeDoubleLongUnsigned(&src)
+	if err != nil {
+		return
+	}
 
-	// not sure if length is limited only 1 byte, or does it follow KLV as in axdr
-	val := uint8(src[0])
-	out.Raw = src[1 : val+1]
-	src = src[val+1:]
+	_, length, err := axdr.DecodeLength(&src)
+	if err != nil {
+		return
+	}
+	if uint64(len(src)) < length {
+		err = ErrWrongLength(len(src), int(length))
+		return
+	}
+	out.Raw = append([]byte(nil), src[:length]...)
+	src = src[length:]
 
 	(*ori) = (*ori)[len((*ori))-len(src):]
 	return
@@ -307,28 +358,27 @@ func CreateActResponse(result ActionResultTag, returnParam *GetDataResult) *ActR
 }
 
 func (dt ActResponse) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-
-	buf.WriteByte(byte(dt.Result))
+	return dt.appendEncode(nil)
+}
 
+func (dt ActResponse) appendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(dt.Result))
 	if dt.ReturnParam == nil {
-		buf.WriteByte(0x0)
-	} else {
-		buf.WriteByte(0x1)
-		enc, e := dt.ReturnParam.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(enc)
+		return append(out, 0x0), nil
 	}
-
-	out = buf.Bytes()
-	return
+	out, err := dt.ReturnParam.appendEncode(append(out, 0x1))
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
 func DecodeActResponse(ori *[]byte) (out ActResponse, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
 
 	out.Result, err = GetActionTag(src[0])
 	if err != nil {
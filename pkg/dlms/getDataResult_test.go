@@ -75,13 +75,23 @@ func TestGetDataResult(t *testing.T) {
 		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
 	}
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("t3 should've panic on wrong Value")
-		}
-	}()
+	// value set by hand is checked, not asserted
+	d := GetDataResult{IsData: true, Value: 999}
+	if _, e := d.Encode(); e == nil {
+		t.Errorf("t4 should've failed on wrong Value")
+	}
+	if _, e := d.ValueAsData(); e == nil {
+		t.Errorf("t4 ValueAsData should've failed on wrong Value")
+	}
+	d.IsData = false
+	if _, e := d.ValueAsAccess(); e == nil {
+		t.Errorf("t4 ValueAsAccess should've failed on wrong Value")
+	}
+
 	var c GetDataResult = *CreateGetDataResult(999)
-	c.Encode()
+	if _, e := c.Encode(); e == nil {
+		t.Errorf("t3 should've failed on wrong Value")
+	}
 }
 
 func TestDataBlockGAsData(t *testing.T) {
@@ -110,13 +120,31 @@ func TestDataBlockGAsData(t *testing.T) {
 		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
 	}
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("t3 should've panic on wrong Value")
-		}
-	}()
+	// result set by hand is checked, not asserted
+	d := DataBlockG{LastBlock: true, BlockNumber: 1, Result: TagAccSuccess}
+	if _, e := d.Encode(); e == nil {
+		t.Errorf("t4 should've failed on wrong Result")
+	}
+	if _, e := d.ResultAsBytes(); e == nil {
+		t.Errorf("t4 ResultAsBytes should've failed on wrong Result")
+	}
+	d.IsResult, d.Result = true, []byte{1}
+	if _, e := d.Encode(); e == nil {
+		t.Errorf("t4 should've failed on wrong Result")
+	}
+	if _, e := d.ResultAsAccess(); e == nil {
+		t.Errorf("t4 ResultAsAccess should've failed on wrong Result")
+	}
+
 	var c DataBlockG = *CreateDataBlockGAsData(true, 1, TagAccSuccess)
-	c.Encode()
+	if _, e := c.Encode(); e == nil {
+		t.Errorf("t3 should've failed on wrong Result")
+	}
+
+	var f DataBlockG = *CreateDataBlockG(true, 1, "zz")
+	if _, e := f.Encode(); e == nil {
+		t.Errorf("t5 should've failed on invalid hexstring")
+	}
 }
 
 func TestDataBlockGAsResult(t *testing.T) {
@@ -203,13 +231,16 @@ func TestDataBlockSA(t *testing.T) {
 	}
 
 	// with wrong value
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("t3 should've panic on wrong Value")
-		}
-	}()
 	var c DataBlockSA = *CreateDataBlockSA(true, 1, TagAccSuccess)
-	c.Encode()
+	if _, e := c.Encode(); e == nil {
+		t.Errorf("t3 should've failed on wrong Value")
+	}
+
+	// with invalid hexstring
+	var d DataBlockSA = *CreateDataBlockSA(true, 1, "zz")
+	if _, e := d.Encode(); e == nil {
+		t.Errorf("t4 should've failed on invalid hexstring")
+	}
 }
 
 func TestActResponse(t *testing.T) {
@@ -278,7 +309,8 @@ func TestDecode_GetDataResult(t *testing.T) {
 
 func TestDecode_DataBlockG(t *testing.T) {
 	// with byte slice
-	src := []byte{1, 0, 0, 0, 1, 0, 12, 7, 210, 12, 4, 3, 10, 6, 11, 255, 0, 120, 0}
+	input := []byte{1, 0, 0, 0, 1, 0, 12, 7, 210, 12, 4, 3, 10, 6, 11, 255, 0, 120, 0}
+	src := input
 	a, ae := DecodeDataBlockG(&src)
 
 	if ae != nil {
@@ -298,6 +330,11 @@ func TestDecode_DataBlockG(t *testing.T) {
 	if res != 0 {
 		t.Errorf("t1 Failed. Result is not correct (%v)", val)
 	}
+	// result does not share bytes with input
+	input[7] = 0
+	if val[0] != 7 {
+		t.Errorf("t1 Failed. Result follows input (%v)", val)
+	}
 
 	// with AccessResultTag
 	src = []byte{1, 0, 0, 0, 1, 1, 0}
@@ -322,7 +359,8 @@ func TestDecode_DataBlockG(t *testing.T) {
 }
 
 func TestDecode_DataBlockSA(t *testing.T) {
-	src := []byte{1, 0, 0, 0, 1, 12, 7, 210, 12, 4, 3, 10, 6, 11, 255, 0, 120, 0}
+	input := []byte{1, 0, 0, 0, 1, 12, 7, 210, 12, 4, 3, 10, 6, 11, 255, 0, 120, 0}
+	src := input
 	a, ae := DecodeDataBlockSA(&src)
 
 	if ae != nil {
@@ -338,6 +376,11 @@ func TestDecode_DataBlockSA(t *testing.T) {
 	if res != 0 {
 		t.Errorf("t1 Failed. Result is not correct (%v)", a.Raw)
 	}
+
+	input[6] = 0
+	if a.Raw[0] != 7 {
+		t.Errorf("t2 Failed. Raw follows input (%v)", a.Raw)
+	}
 }
 
 func TestDecode_ActResponse(t *testing.T) {
@@ -1,8 +1,9 @@
 package dlms
 
 import (
-	"bytes"
 	"fmt"
+	"io"
+
 	"gosem/pkg/axdr"
 )
 
@@ -39,6 +40,11 @@ func (gr *GetRequest) New(tag getRequestTag) (out CosemPDU, err error) {
 }
 
 func (gr *GetRequest) Decode(src *[]byte) (out CosemPDU, err error) {
+	if len(*src) < 2 {
+		err = ErrWrongLength(len(*src), 2)
+		return
+	}
+
 	if (*src)[0] != TagGetRequest.Value() {
 		err = ErrWrongTag(0, (*src)[0], byte(TagGetRequest))
 		return
@@ -52,7 +58,7 @@ func (gr *GetRequest) Decode(src *[]byte) (out CosemPDU, err error) {
 	case TagGetRequestWithList.Value():
 		out, err = DecodeGetRequestWithList(src)
 	default:
-		err = fmt.Errorf("byte tag not recognized (%v)", (*src)[1])
+		err = errDecode(1, fmt.Errorf("byte tag not recognized (%v)", (*src)[1]))
 	}
 
 	return
@@ -74,34 +80,36 @@ func CreateGetRequestNormal(invokeId uint8, att AttributeDescriptor, acc *Select
 }
 
 func (gr GetRequestNormal) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagGetRequest))
-	buf.WriteByte(byte(TagGetRequestNormal))
-	buf.WriteByte(byte(gr.InvokePriority))
-	attInfo, e := gr.AttributeInfo.Encode()
-	if e != nil {
-		err = e
-		return
+	return gr.AppendEncode(nil)
+}
+
+// AppendEncode writes request straight into dst, see CosemPDU
+func (gr GetRequestNormal) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagGetRequest), byte(TagGetRequestNormal), byte(gr.InvokePriority))
+	out = gr.AttributeInfo.appendEncode(out)
+	return appendSelectiveAccess(dst, out, gr.SelectiveAccessInfo)
+}
+
+func (gr GetRequestNormal) EncodeTo(w io.Writer) error { return encodeTo(w, gr) }
+
+// Append optional selective access, dst is returned as it is on error
+func appendSelectiveAccess(dst []byte, out []byte, acc *SelectiveAccessDescriptor) ([]byte, error) {
+	if acc == nil {
+		return append(out, 0x0), nil
 	}
-	buf.Write(attInfo)
-	if gr.SelectiveAccessInfo == nil {
-		buf.WriteByte(0x0)
-	} else {
-		buf.WriteByte(0x1)
-		selInfo, e := gr.SelectiveAccessInfo.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(selInfo)
+	out, err := acc.appendEncode(append(out, 0x1))
+	if err != nil {
+		return dst, err
 	}
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
 func DecodeGetRequestNormal(ori *[]byte) (out GetRequestNormal, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagGetRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagGetRequest))
@@ -118,6 +126,10 @@ func DecodeGetRequestNormal(ori *[]byte) (out GetRequestNormal, err error) {
 		return
 	}
 
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	haveAccDesc := src[0]
 	src = src[1:]
 	// SelectiveAccessInfo
@@ -151,19 +163,22 @@ func CreateGetRequestNext(invokeId uint8, blockNum uint32) *GetRequestNext {
 }
 
 func (gr GetRequestNext) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagGetRequest))
-	buf.WriteByte(byte(TagGetRequestNext))
-	buf.WriteByte(byte(gr.InvokePriority))
-	blockNum, _ := axdr.EncodeDoubleLongUnsigned(gr.BlockNum)
-	buf.Write(blockNum)
-
-	out = buf.Bytes()
-	return
+	return gr.AppendEncode(nil)
 }
 
+func (gr GetRequestNext) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagGetRequest), byte(TagGetRequestNext), byte(gr.InvokePriority))
+	return appendUint32(out, gr.BlockNum), nil
+}
+
+func (gr GetRequestNext) EncodeTo(w io.Writer) error { return encodeTo(w, gr) }
+
 func DecodeGetRequestNext(ori *[]byte) (out GetRequestNext, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagGetRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagGetRequest))
@@ -206,26 +221,22 @@ func CreateGetRequestWithList(invokeId uint8, attList []AttributeDescriptorWithS
 }
 
 func (gr GetRequestWithList) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagGetRequest))
-	buf.WriteByte(byte(TagGetRequestWithList))
-	buf.WriteByte(byte(gr.InvokePriority))
-	buf.WriteByte(byte(len(gr.AttributeInfoList)))
-	for _, attr := range gr.AttributeInfoList {
-		val, e := attr.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(val)
-	}
+	return gr.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (gr GetRequestWithList) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagGetRequest), byte(TagGetRequestWithList), byte(gr.InvokePriority), byte(len(gr.AttributeInfoList)))
+	return appendAttributeList(dst, out, gr.AttributeInfoList)
 }
 
+func (gr GetRequestWithList) EncodeTo(w io.Writer) error { return encodeTo(w, gr) }
+
 func DecodeGetRequestWithList(ori *[]byte) (out GetRequestWithList, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagGetRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagGetRequest))
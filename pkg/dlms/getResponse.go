@@ -1,8 +1,8 @@
 package dlms
 
 import (
-	"bytes"
 	"fmt"
+	"io"
 )
 
 type getResponseTag uint8
@@ -37,6 +37,11 @@ func (gr *GetResponse) New(tag getResponseTag) (out CosemPDU, err error) {
 }
 
 func (gr *GetResponse) Decode(src *[]byte) (out CosemPDU, err error) {
+	if len(*src) < 2 {
+		err = ErrWrongLength(len(*src), 2)
+		return
+	}
+
 	if (*src)[0] != TagGetResponse.Value() {
 		err = ErrWrongTag(0, (*src)[0], byte(TagGetResponse))
 		return
@@ -50,7 +55,7 @@ func (gr *GetResponse) Decode(src *[]byte) (out CosemPDU, err error) {
 	case TagGetResponseWithList.Value():
 		out, err = DecodeGetResponseWithList(src)
 	default:
-		err = fmt.Errorf("byte tag not recognized (%v)", (*src)[1])
+		err = errDecode(1, fmt.Errorf("byte tag not recognized (%v)", (*src)[1]))
 	}
 
 	return
@@ -70,23 +75,27 @@ func CreateGetResponseNormal(invokeId uint8, res GetDataResult) *GetResponseNorm
 }
 
 func (gr GetResponseNormal) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagGetResponse))
-	buf.WriteByte(byte(TagGetResponseNormal))
-	buf.WriteByte(byte(gr.InvokePriority))
-	res, e := gr.Result.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(res)
+	return gr.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+// AppendEncode writes response straight into dst, see CosemPDU
+func (gr GetResponseNormal) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagGetResponse), byte(TagGetResponseNormal), byte(gr.InvokePriority))
+	out, err := gr.Result.appendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (gr GetResponseNormal) EncodeTo(w io.Writer) error { return encodeTo(w, gr) }
+
 func DecodeGetResponseNormal(ori *[]byte) (out GetResponseNormal, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagGetResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagGetResponse))
@@ -119,23 +128,26 @@ func CreateGetResponseWithDataBlock(invokeId uint8, res DataBlockG) *GetResponse
 }
 
 func (gr GetResponseWithDataBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagGetResponse))
-	buf.WriteByte(byte(TagGetResponseWithDataBlock))
-	buf.WriteByte(byte(gr.InvokePriority))
-	val, e := gr.Result.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(val)
+	return gr.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (gr GetResponseWithDataBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagGetResponse), byte(TagGetResponseWithDataBlock), byte(gr.InvokePriority))
+	out, err := gr.Result.appendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (gr GetResponseWithDataBlock) EncodeTo(w io.Writer) error { return encodeTo(w, gr) }
+
 func DecodeGetResponseWithDataBlock(ori *[]byte) (out GetResponseWithDataBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagGetResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagGetResponse))
@@ -173,26 +185,28 @@ func CreateGetResponseWithList(invokeId uint8, resList []GetDataResult) *GetResp
 }
 
 func (gr GetResponseWithList) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagGetResponse))
-	buf.WriteByte(byte(TagGetResponseWithList))
-	buf.WriteByte(byte(gr.InvokePriority))
-	buf.WriteByte(byte(len(gr.ResultList)))
+	return gr.AppendEncode(nil)
+}
+
+func (gr GetResponseWithList) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagGetResponse), byte(TagGetResponseWithList), byte(gr.InvokePriority), byte(len(gr.ResultList)))
 	for _, res := range gr.ResultList {
-		val, e := res.Encode()
-		if e != nil {
-			err = e
-			return
+		var err error
+		if out, err = res.appendEncode(out); err != nil {
+			return dst, err
 		}
-		buf.Write(val)
 	}
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (gr GetResponseWithList) EncodeTo(w io.Writer) error { return encodeTo(w, gr) }
+
 func DecodeGetResponseWithList(ori *[]byte) (out GetResponseWithList, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagGetResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagGetResponse))
@@ -0,0 +1,75 @@
+package dlms
+
+import (
+	"io"
+
+	"gosem/pkg/axdr"
+)
+
+// InformationReportRequest implement CosemPDU. It is sent by server with
+// short name referencing, the counterpart of EventNotificationRequest.
+// CurrentTime is optional GeneralizedTime, nil if not present.
+type InformationReportRequest struct {
+	CurrentTime []byte
+	Variables   []VariableAccessSpecification
+	Data        []axdr.DlmsData
+}
+
+// CreateInformationReportRequest creates InformationReportRequest, variables
+// cannot be empty or else it will fail on Encode()
+func CreateInformationReportRequest(currentTime []byte, variables []VariableAccessSpecification, data []axdr.DlmsData) *InformationReportRequest {
+	return &InformationReportRequest{CurrentTime: currentTime, Variables: variables, Data: data}
+}
+
+func (ir InformationReportRequest) Encode() (out []byte, err error) {
+	return ir.AppendEncode(nil)
+}
+
+func (ir InformationReportRequest) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagInformationReportRequest.Value())
+	var err error
+	if ir.CurrentTime == nil {
+		out = append(out, 0x0)
+	} else if out, err = appendOctetString(append(out, 0x1), ir.CurrentTime); err != nil {
+		return dst, err
+	}
+	if out, err = appendVariableAccessList(out, ir.Variables); err != nil {
+		return dst, err
+	}
+	if out, err = appendDataList(out, ir.Data); err != nil {
+		return dst, err
+	}
+	return out, nil
+}
+
+func (ir InformationReportRequest) EncodeTo(w io.Writer) error { return encodeTo(w, ir) }
+
+func DecodeInformationReportRequest(ori *[]byte) (out InformationReportRequest, err error) {
+	src := *ori
+
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	if src[0] != TagInformationReportRequest.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagInformationReportRequest))
+		return
+	}
+	haveTime := src[1] != 0x0
+	src = src[2:]
+
+	if haveTime {
+		if out.CurrentTime, err = readOctetString(&src); err != nil {
+			return
+		}
+	}
+	if out.Variables, err = decodeVariableAccessList(&src); err != nil {
+		return
+	}
+	if out.Data, err = decodeDataList(&src); err != nil {
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
@@ -0,0 +1,54 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+
+	"gosem/pkg/axdr"
+)
+
+func TestNew_InformationReportRequest(t *testing.T) {
+	vars := []VariableAccessSpecification{*CreateVariableName(0xFA08)}
+	data := []axdr.DlmsData{*axdr.CreateAxdrUnsigned(7)}
+	var a InformationReportRequest = *CreateInformationReportRequest(nil, vars, data)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{24, 0, 1, 2, 0xFA, 0x08, 1, 17, 7}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	a.CurrentTime = []byte("20201231235959Z")
+	t2, e := a.Encode()
+	if e != nil || t2[1] != 1 || t2[2] != 15 {
+		t.Errorf("t2 Failed. get: %d, err: %v", t2, e)
+	}
+
+	var b InformationReportRequest = *CreateInformationReportRequest(nil, nil, data)
+	if _, e = b.Encode(); e == nil {
+		t.Errorf("t3 should fail on empty variables")
+	}
+}
+
+func TestDecode_InformationReportRequest(t *testing.T) {
+	src := []byte{24, 0, 1, 2, 0xFA, 0x08, 1, 17, 7}
+	a, err := DecodeInformationReportRequest(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeInformationReportRequest. Err: %v", err)
+	}
+	if a.CurrentTime != nil || len(a.Variables) != 1 || a.Variables[0].VariableName != 0xFA08 || a.Data[0].Value != uint8(7) {
+		t.Errorf("t1 Failed. get: %v", a)
+	}
+
+	src = append([]byte{24, 1, 3, '1', '2', '3'}, 1, 2, 0xFA, 0x08, 1, 17, 7)
+	b, err := DecodeInformationReportRequest(&src)
+	if err != nil || string(b.CurrentTime) != "123" || len(b.Data) != 1 {
+		t.Errorf("t2 Failed. get: %v, err: %v", b, err)
+	}
+	if len(src) != 0 {
+		t.Errorf("t2 Failed. src should be empty (%v)", src)
+	}
+}
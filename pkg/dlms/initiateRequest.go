@@ -0,0 +1,145 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// DlmsVersion is the only DLMS version number used by the current standard
+const DlmsVersion uint8 = 6
+
+// InitiateRequest is the xDLMS APDU carried by AARQ user-information.
+// DedicatedKey and ProposedQualityOfService are optional therefore
+// nil-able. ResponseAllowed is true on confirmed association.
+type InitiateRequest struct {
+	DedicatedKey              []byte
+	ResponseAllowed           bool
+	ProposedQualityOfService  *int8
+	ProposedDlmsVersionNumber uint8
+	ProposedConformance       ConformanceBlock
+	ClientMaxReceivePduSize   uint16
+}
+
+func CreateInitiateRequest(dedicatedKey []byte, conformance ConformanceBlock, maxPduSize uint16) *InitiateRequest {
+	return &InitiateRequest{
+		DedicatedKey:              dedicatedKey,
+		ResponseAllowed:           true,
+		ProposedDlmsVersionNumber: DlmsVersion,
+		ProposedConformance:       conformance,
+		ClientMaxReceivePduSize:   maxPduSize,
+	}
+}
+
+func (ir InitiateRequest) Encode() (out []byte, err error) {
+	return ir.AppendEncode(nil)
+}
+
+func (ir InitiateRequest) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagInitiateRequest.Value())
+	if ir.DedicatedKey == nil {
+		out = append(out, 0x0)
+	} else {
+		if len(ir.DedicatedKey) > 255 {
+			return dst, fmt.Errorf("dedicated key length %v is too long", len(ir.DedicatedKey))
+		}
+		out = append(out, 0x1, byte(len(ir.DedicatedKey)))
+		out = append(out, ir.DedicatedKey...)
+	}
+	// response-allowed is TRUE by default
+	if ir.ResponseAllowed {
+		out = append(out, 0x0)
+	} else {
+		out = append(out, 0x1, 0x0)
+	}
+	if ir.ProposedQualityOfService == nil {
+		out = append(out, 0x0)
+	} else {
+		out = append(out, 0x1, byte(*ir.ProposedQualityOfService))
+	}
+	out = append(out, ir.ProposedDlmsVersionNumber)
+	out = ir.ProposedConformance.appendEncode(out)
+	return appendUint16(out, ir.ClientMaxReceivePduSize), nil
+}
+
+func (ir InitiateRequest) EncodeTo(w io.Writer) error { return encodeTo(w, ir) }
+
+func DecodeInitiateRequest(ori *[]byte) (out InitiateRequest, err error) {
+	src := *ori
+
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	if src[0] != TagInitiateRequest.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagInitiateRequest))
+		return
+	}
+	src = src[1:]
+
+	// dedicated-key
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
+	if src[0] != 0 {
+		if len(src) < 2 || len(src) < 2+int(src[1]) {
+			err = ErrWrongLength(len(src), 2)
+			return
+		}
+		out.DedicatedKey = append([]byte(nil), src[2:2+int(src[1])]...)
+		src = src[2+int(src[1]):]
+	} else {
+		src = src[1:]
+	}
+
+	// response-allowed
+	out.ResponseAllowed = true
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
+	if src[0] != 0 {
+		if len(src) < 2 {
+			err = ErrWrongLength(len(src), 2)
+			return
+		}
+		out.ResponseAllowed = src[1] != 0
+		src = src[2:]
+	} else {
+		src = src[1:]
+	}
+
+	// proposed-quality-of-service
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
+	if src[0] != 0 {
+		if len(src) < 2 {
+			err = ErrWrongLength(len(src), 2)
+			return
+		}
+		qos := int8(src[1])
+		out.ProposedQualityOfService = &qos
+		src = src[2:]
+	} else {
+		src = src[1:]
+	}
+
+	if len(src) < 10 {
+		err = ErrWrongLength(len(src), 10)
+		return
+	}
+	out.ProposedDlmsVersionNumber = src[0]
+	src = src[1:]
+	out.ProposedConformance, err = DecodeConformanceBlock(&src)
+	if err != nil {
+		return
+	}
+	out.ClientMaxReceivePduSize = binary.BigEndian.Uint16(src[0:2])
+	src = src[2:]
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
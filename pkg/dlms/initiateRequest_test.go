@@ -0,0 +1,74 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_InitiateRequest(t *testing.T) {
+	var a InitiateRequest = *CreateInitiateRequest(nil, 0x007E1F, 1200)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	var qos int8 = 1
+	a = *CreateInitiateRequest([]byte{1, 2, 3, 4}, ConformanceGet|ConformanceGeneralProtection, 512)
+	a.ResponseAllowed = false
+	a.ProposedQualityOfService = &qos
+	t2, e := a.Encode()
+	if e != nil {
+		t.Errorf("t2 Encode Failed. err: %v", e)
+	}
+	result = []byte{1, 1, 4, 1, 2, 3, 4, 1, 0, 1, 1, 6, 95, 31, 4, 0, 64, 0, 16, 2, 0}
+	res = bytes.Compare(t2, result)
+	if res != 0 {
+		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
+	}
+}
+
+func TestDecode_InitiateRequest(t *testing.T) {
+	src := []byte{1, 1, 4, 1, 2, 3, 4, 1, 0, 1, 1, 6, 95, 31, 4, 0, 64, 0, 16, 2, 0, 9}
+	a, err := DecodeInitiateRequest(&src)
+	if err != nil {
+		t.Fatalf("t1 failed on DecodeInitiateRequest. Err: %v", err)
+	}
+	if bytes.Compare(a.DedicatedKey, []byte{1, 2, 3, 4}) != 0 {
+		t.Errorf("t1 DedicatedKey get: %v", a.DedicatedKey)
+	}
+	if a.ResponseAllowed {
+		t.Errorf("t1 ResponseAllowed should be false")
+	}
+	if a.ProposedQualityOfService == nil || *a.ProposedQualityOfService != 1 {
+		t.Errorf("t1 ProposedQualityOfService get: %v", a.ProposedQualityOfService)
+	}
+	if a.ProposedDlmsVersionNumber != 6 || a.ProposedConformance != ConformanceGet|ConformanceGeneralProtection || a.ClientMaxReceivePduSize != 512 {
+		t.Errorf("t1 get: %v", a)
+	}
+	if bytes.Compare(src, []byte{9}) != 0 {
+		t.Errorf("t1 remaining byte get: %v", src)
+	}
+
+	src = []byte{1, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176}
+	a, err = DecodeInitiateRequest(&src)
+	if err != nil || a.DedicatedKey != nil || !a.ResponseAllowed || a.ProposedQualityOfService != nil {
+		t.Errorf("t2 failed on DecodeInitiateRequest. get: %v, Err: %v", a, err)
+	}
+
+	src = []byte{1, 1, 16, 1, 2}
+	_, err = DecodeInitiateRequest(&src)
+	if err == nil {
+		t.Errorf("t3 should failed on DecodeInitiateRequest")
+	}
+
+	src = []byte{8, 0, 0, 0, 6, 95, 31, 4, 0, 0, 126, 31, 4, 176}
+	_, err = DecodeInitiateRequest(&src)
+	if err == nil {
+		t.Errorf("t4 should failed on DecodeInitiateRequest")
+	}
+}
@@ -0,0 +1,98 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// VAA name sent on InitiateResponse, depends on referencing
+const (
+	VAANameLN uint16 = 0x0007
+	VAANameSN uint16 = 0xFA00
+)
+
+// InitiateResponse is the xDLMS APDU carried by AARE user-information.
+// NegotiatedConformance holds the services granted by server.
+type InitiateResponse struct {
+	NegotiatedQualityOfService  *int8
+	NegotiatedDlmsVersionNumber uint8
+	NegotiatedConformance       ConformanceBlock
+	ServerMaxReceivePduSize     uint16
+	VAAName                     uint16
+}
+
+func CreateInitiateResponse(conformance ConformanceBlock, maxPduSize uint16, vaaName uint16) *InitiateResponse {
+	return &InitiateResponse{
+		NegotiatedDlmsVersionNumber: DlmsVersion,
+		NegotiatedConformance:       conformance,
+		ServerMaxReceivePduSize:     maxPduSize,
+		VAAName:                     vaaName,
+	}
+}
+
+func (ir InitiateResponse) Encode() (out []byte, err error) {
+	return ir.AppendEncode(nil)
+}
+
+func (ir InitiateResponse) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagInitiateResponse.Value())
+	if ir.NegotiatedQualityOfService == nil {
+		out = append(out, 0x0)
+	} else {
+		out = append(out, 0x1, byte(*ir.NegotiatedQualityOfService))
+	}
+	out = append(out, ir.NegotiatedDlmsVersionNumber)
+	out = ir.NegotiatedConformance.appendEncode(out)
+	out = appendUint16(out, ir.ServerMaxReceivePduSize)
+	return appendUint16(out, ir.VAAName), nil
+}
+
+func (ir InitiateResponse) EncodeTo(w io.Writer) error { return encodeTo(w, ir) }
+
+func DecodeInitiateResponse(ori *[]byte) (out InitiateResponse, err error) {
+	src := *ori
+
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	if src[0] != TagInitiateResponse.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagInitiateResponse))
+		return
+	}
+	src = src[1:]
+
+	// negotiated-quality-of-service
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
+	if src[0] != 0 {
+		if len(src) < 2 {
+			err = ErrWrongLength(len(src), 2)
+			return
+		}
+		qos := int8(src[1])
+		out.NegotiatedQualityOfService = &qos
+		src = src[2:]
+	} else {
+		src = src[1:]
+	}
+
+	if len(src) < 12 {
+		err = ErrWrongLength(len(src), 12)
+		return
+	}
+	out.NegotiatedDlmsVersionNumber = src[0]
+	src = src[1:]
+	out.NegotiatedConformance, err = DecodeConformanceBlock(&src)
+	if err != nil {
+		return
+	}
+	out.ServerMaxReceivePduSize = binary.BigEndian.Uint16(src[0:2])
+	out.VAAName = binary.BigEndian.Uint16(src[2:4])
+	src = src[4:]
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
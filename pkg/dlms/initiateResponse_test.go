@@ -0,0 +1,56 @@
+package dlms
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNew_InitiateResponse(t *testing.T) {
+	var a InitiateResponse = *CreateInitiateResponse(0x00501F, 500, VAANameLN)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{8, 0, 6, 95, 31, 4, 0, 0, 80, 31, 1, 244, 0, 7}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+}
+
+func TestDecode_InitiateResponse(t *testing.T) {
+	src := []byte{8, 0, 6, 95, 31, 4, 0, 0, 80, 31, 1, 244, 0, 7}
+	a, err := DecodeInitiateResponse(&src)
+	if err != nil {
+		t.Fatalf("t1 failed on DecodeInitiateResponse. Err: %v", err)
+	}
+	var b InitiateResponse = *CreateInitiateResponse(0x00501F, 500, VAANameLN)
+	if a.NegotiatedConformance != b.NegotiatedConformance || a.ServerMaxReceivePduSize != b.ServerMaxReceivePduSize || a.VAAName != b.VAAName {
+		t.Errorf("t1 get: %v, should: %v", a, b)
+	}
+	if len(src) != 0 {
+		t.Errorf("t1 remaining byte get: %v", src)
+	}
+
+	src = []byte{8, 0, 6, 95, 31, 4, 0, 0, 80, 31, 1}
+	_, err = DecodeInitiateResponse(&src)
+	if err == nil {
+		t.Errorf("t2 should failed on DecodeInitiateResponse")
+	}
+}
+
+func TestAARE_InitiateResponse(t *testing.T) {
+	aare := *CreateAARE(TagAppCtxLNNoCipher, TagAssocAccepted, TagDiagACSEServiceUser, TagDiagNull, []byte{8, 0, 6, 95, 31, 4, 0, 0, 80, 31, 1, 244, 0, 7})
+	ir, err := aare.InitiateResponse()
+	if err != nil || ir.ServerMaxReceivePduSize != 500 {
+		t.Errorf("t1 failed. get: %v, err: %v", ir, err)
+	}
+
+	aare.UserInformation = []byte{14, 1, 6, 1}
+	_, err = aare.InitiateResponse()
+	var se *ServiceError
+	if !errors.As(err, &se) || se.Response.Detail() != TagInitDlmsVersionTooLow {
+		t.Errorf("t2 should fail on confirmed service error. err: %v", err)
+	}
+}
@@ -1,7 +1,6 @@
 package dlms
 
 import (
-	"encoding/binary"
 	"fmt"
 	"gosem/pkg/axdr"
 )
@@ -19,19 +18,17 @@ func CreateMethodDescriptor(c uint16, i string, a int8) *MethodDescriptor {
 }
 
 func (ad MethodDescriptor) Encode() (out []byte, err error) {
-	var output []byte
-	var c [2]byte
-	binary.BigEndian.PutUint16(c[:], ad.ClassId)
-	output = append(output, c[:]...)
-	output = append(output, ad.InstanceId.Bytes()...)
-	output = append(output, byte(ad.MethodId))
-
-	out = output
-	return
+	return ad.appendEncode(nil), nil
+}
+
+func (ad MethodDescriptor) appendEncode(dst []byte) []byte {
+	dst = append(dst, byte(ad.ClassId>>8), byte(ad.ClassId))
+	dst = append(dst, ad.InstanceId.byteValue[:]...)
+	return append(dst, byte(ad.MethodId))
 }
 
 func DecodeMethodDescriptor(ori *[]byte) (out MethodDescriptor, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
 
 	if len(src) < 9 {
 		err = fmt.Errorf("byte slice length must be at least 9 bytes")
@@ -46,6 +43,10 @@ func DecodeMethodDescriptor(ori *[]byte) (out MethodDescriptor, err error) {
 	if err != nil {
 		return
 	}
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	out.MethodId = int8(src[0])
 	src = src[1:]
 
@@ -0,0 +1,48 @@
+package dlms
+
+import (
+	"io"
+)
+
+// ReadRequest implement CosemPDU. It is the short name referencing
+// counterpart of GetRequest
+type ReadRequest struct {
+	Variables []VariableAccessSpecification
+}
+
+// CreateReadRequest creates ReadRequest, variables cannot be empty or else it
+// will fail on Encode()
+func CreateReadRequest(variables []VariableAccessSpecification) *ReadRequest {
+	return &ReadRequest{Variables: variables}
+}
+
+func (rr ReadRequest) Encode() (out []byte, err error) {
+	return rr.AppendEncode(nil)
+}
+
+func (rr ReadRequest) AppendEncode(dst []byte) ([]byte, error) {
+	return appendVariableAccessList(append(dst, TagReadRequest.Value()), rr.Variables)
+}
+
+func (rr ReadRequest) EncodeTo(w io.Writer) error { return encodeTo(w, rr) }
+
+func DecodeReadRequest(ori *[]byte) (out ReadRequest, err error) {
+	src := *ori
+
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	if src[0] != TagReadRequest.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagReadRequest))
+		return
+	}
+	src = src[1:]
+
+	if out.Variables, err = decodeVariableAccessList(&src); err != nil {
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
@@ -0,0 +1,51 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+
+	"gosem/pkg/axdr"
+)
+
+func TestNew_ReadRequest(t *testing.T) {
+	vars := []VariableAccessSpecification{*CreateVariableName(0xFA00), *CreateParameterizedAccess(0x0100, 1, *axdr.CreateAxdrUnsigned(5))}
+	var a ReadRequest = *CreateReadRequest(vars)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{5, 2, 2, 0xFA, 0x00, 4, 0x01, 0x00, 1, 17, 5}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	var b ReadRequest = *CreateReadRequest(nil)
+	if _, e = b.Encode(); e == nil {
+		t.Errorf("t2 should fail on empty variables")
+	}
+}
+
+func TestDecode_ReadRequest(t *testing.T) {
+	src := []byte{5, 2, 2, 0xFA, 0x00, 4, 0x01, 0x00, 1, 17, 5}
+	a, err := DecodeReadRequest(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeReadRequest. Err: %v", err)
+	}
+	if len(a.Variables) != 2 || a.Variables[0].VariableName != 0xFA00 || a.Variables[1].Tag != TagParameterizedAccess || a.Variables[1].Parameter.Value != uint8(5) {
+		t.Errorf("t1 Failed. get: %v", a)
+	}
+	if len(src) != 0 {
+		t.Errorf("t1 Failed. src should be empty (%v)", src)
+	}
+
+	src = []byte{5, 2, 2, 0xFA, 0x00}
+	if _, err = DecodeReadRequest(&src); err == nil {
+		t.Errorf("t2 should fail on missing variable")
+	}
+
+	src = []byte{6, 1, 2, 0xFA, 0x00}
+	if _, err = DecodeReadRequest(&src); err == nil {
+		t.Errorf("t3 should fail on wrong tag")
+	}
+}
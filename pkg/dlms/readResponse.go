@@ -0,0 +1,187 @@
+package dlms
+
+import (
+	"fmt"
+	"io"
+
+	"gosem/pkg/axdr"
+)
+
+type readResultTag uint8
+
+const (
+	TagReadResultData            readResultTag = 0
+	TagReadResultDataAccessError readResultTag = 1
+	TagReadResultDataBlock       readResultTag = 2
+	TagReadResultBlockNumber     readResultTag = 3
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s readResultTag) Value() uint8 {
+	return uint8(s)
+}
+
+// ReadResult is one result of ReadResponse. Fields used depend on Tag:
+//   - TagReadResultData: Data
+//   - TagReadResultDataAccessError: Result
+//   - TagReadResultDataBlock: LastBlock, BlockNumber, RawData
+//   - TagReadResultBlockNumber: BlockNumber
+type ReadResult struct {
+	Tag         readResultTag
+	Data        axdr.DlmsData
+	Result      AccessResultTag
+	LastBlock   bool
+	BlockNumber uint16
+	RawData     []byte
+}
+
+func CreateReadResultAsData(data axdr.DlmsData) *ReadResult {
+	return &ReadResult{Tag: TagReadResultData, Data: data}
+}
+
+func CreateReadResultAsError(result AccessResultTag) *ReadResult {
+	return &ReadResult{Tag: TagReadResultDataAccessError, Result: result}
+}
+
+func CreateReadResultAsDataBlock(lastBlock bool, blockNum uint16, raw []byte) *ReadResult {
+	return &ReadResult{Tag: TagReadResultDataBlock, LastBlock: lastBlock, BlockNumber: blockNum, RawData: raw}
+}
+
+func CreateReadResultAsBlockNumber(blockNum uint16) *ReadResult {
+	return &ReadResult{Tag: TagReadResultBlockNumber, BlockNumber: blockNum}
+}
+
+func (rs ReadResult) Encode() (out []byte, err error) {
+	return rs.appendEncode(nil)
+}
+
+func (rs ReadResult) appendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, rs.Tag.Value())
+
+	var err error
+	switch rs.Tag {
+	case TagReadResultData:
+		out, err = rs.Data.AppendEncode(out)
+	case TagReadResultDataAccessError:
+		out = append(out, rs.Result.Value())
+	case TagReadResultDataBlock:
+		out = appendUint16(appendBoolean(out, rs.LastBlock), rs.BlockNumber)
+		out, err = appendOctetString(out, rs.RawData)
+	case TagReadResultBlockNumber:
+		out = appendUint16(out, rs.BlockNumber)
+	default:
+		err = fmt.Errorf("read result tag %v is not supported", rs.Tag)
+	}
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
+}
+
+func DecodeReadResult(ori *[]byte) (out ReadResult, err error) {
+	src := *ori
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	out.Tag = readResultTag(src[0])
+	src = src[1:]
+
+	switch out.Tag {
+	case TagReadResultData:
+		out.Data, err = readData(&src)
+	case TagReadResultDataAccessError:
+		if len(src) < 1 {
+			err = ErrWrongLength(len(src), 1)
+			return
+		}
+		out.Result, err = GetAccessTag(src[0])
+		src = src[1:]
+	case TagReadResultDataBlock:
+		if len(src) < 1 {
+			err = ErrWrongLength(len(src), 1)
+			return
+		}
+		out.LastBlock = src[0] != 0x0
+		src = src[1:]
+		if out.BlockNumber, err = readUint16(&src); err != nil {
+			return
+		}
+		out.RawData, err = readOctetString(&src)
+	case TagReadResultBlockNumber:
+		out.BlockNumber, err = readUint16(&src)
+	default:
+		err = errDecode(0, fmt.Errorf("read result tag %v is not supported", out.Tag))
+	}
+	if err != nil {
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+// ReadResponse implement CosemPDU, it holds one result for every variable
+// of ReadRequest
+type ReadResponse struct {
+	Results []ReadResult
+}
+
+// CreateReadResponse creates ReadResponse, results cannot be empty or else it
+// will fail on Encode()
+func CreateReadResponse(results []ReadResult) *ReadResponse {
+	return &ReadResponse{Results: results}
+}
+
+func (rr ReadResponse) Encode() (out []byte, err error) {
+	return rr.AppendEncode(nil)
+}
+
+func (rr ReadResponse) AppendEncode(dst []byte) ([]byte, error) {
+	if len(rr.Results) < 1 {
+		return dst, fmt.Errorf("results cannot have zero member")
+	}
+	out, err := axdr.AppendLength(append(dst, TagReadResponse.Value()), len(rr.Results))
+	if err != nil {
+		return dst, err
+	}
+	for _, res := range rr.Results {
+		if out, err = res.appendEncode(out); err != nil {
+			return dst, err
+		}
+	}
+	return out, nil
+}
+
+func (rr ReadResponse) EncodeTo(w io.Writer) error { return encodeTo(w, rr) }
+
+func DecodeReadResponse(ori *[]byte) (out ReadResponse, err error) {
+	src := *ori
+
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	if src[0] != TagReadResponse.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagReadResponse))
+		return
+	}
+	src = src[1:]
+
+	count, err := readCount(&src)
+	if err != nil {
+		return
+	}
+	for i := 0; i < count; i++ {
+		res, e := DecodeReadResult(&src)
+		if e != nil {
+			err = e
+			return
+		}
+		out.Results = append(out.Results, res)
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
@@ -0,0 +1,63 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+
+	"gosem/pkg/axdr"
+)
+
+func TestNew_ReadResponse(t *testing.T) {
+	results := []ReadResult{
+		*CreateReadResultAsData(*axdr.CreateAxdrLongUnsigned(0x1234)),
+		*CreateReadResultAsError(TagAccReadWriteDenied),
+		*CreateReadResultAsDataBlock(true, 1, []byte{0xAA, 0xBB}),
+		*CreateReadResultAsBlockNumber(2),
+	}
+	var a ReadResponse = *CreateReadResponse(results)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{12, 4, 0, 18, 0x12, 0x34, 1, 3, 2, 0xFF, 0, 1, 2, 0xAA, 0xBB, 3, 0, 2}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	var b ReadResponse = *CreateReadResponse(nil)
+	if _, e = b.Encode(); e == nil {
+		t.Errorf("t2 should fail on empty results")
+	}
+}
+
+func TestDecode_ReadResponse(t *testing.T) {
+	src := []byte{12, 4, 0, 18, 0x12, 0x34, 1, 3, 2, 0xFF, 0, 1, 2, 0xAA, 0xBB, 3, 0, 2}
+	a, err := DecodeReadResponse(&src)
+	if err != nil {
+		t.Fatalf("t1 failed on DecodeReadResponse. Err: %v", err)
+	}
+	if len(a.Results) != 4 {
+		t.Fatalf("t1 Failed. get: %v", a)
+	}
+	if a.Results[0].Tag != TagReadResultData || a.Results[0].Data.Value != uint16(0x1234) {
+		t.Errorf("t1 data Failed. get: %v", a.Results[0])
+	}
+	if a.Results[1].Tag != TagReadResultDataAccessError || a.Results[1].Result != TagAccReadWriteDenied {
+		t.Errorf("t1 error Failed. get: %v", a.Results[1])
+	}
+	if r := a.Results[2]; r.Tag != TagReadResultDataBlock || !r.LastBlock || r.BlockNumber != 1 || bytes.Compare(r.RawData, []byte{0xAA, 0xBB}) != 0 {
+		t.Errorf("t1 data block Failed. get: %v", r)
+	}
+	if a.Results[3].Tag != TagReadResultBlockNumber || a.Results[3].BlockNumber != 2 {
+		t.Errorf("t1 block number Failed. get: %v", a.Results[3])
+	}
+	if len(src) != 0 {
+		t.Errorf("t1 Failed. src should be empty (%v)", src)
+	}
+
+	src = []byte{12, 1, 2, 0xFF, 0, 1, 5, 0xAA}
+	if _, err = DecodeReadResponse(&src); err == nil {
+		t.Errorf("t2 should fail on short raw data")
+	}
+}
@@ -0,0 +1,68 @@
+package dlms
+
+import "io"
+
+type ReleaseResponseReason uint8
+
+const (
+	TagRlreNormal      ReleaseResponseReason = 0
+	TagRlreNotFinished ReleaseResponseReason = 1
+	TagRlreUserDefined ReleaseResponseReason = 30
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s ReleaseResponseReason) Value() uint8 {
+	return uint8(s)
+}
+
+// RLRE is the release response sent by server, UserInformation
+// holds the ciphered xDLMS InitiateResponse if it is any.
+type RLRE struct {
+	Reason          ReleaseResponseReason
+	UserInformation []byte
+}
+
+func CreateRLRE(reason ReleaseResponseReason, userInfo []byte) *RLRE {
+	return &RLRE{
+		Reason:          reason,
+		UserInformation: userInfo,
+	}
+}
+
+func (re RLRE) Encode() (out []byte, err error) {
+	return re.AppendEncode(nil)
+}
+
+func (re RLRE) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagRLRE.Value())
+	start := len(out)
+	out = append(out, berTagReason, 1, re.Reason.Value())
+	if len(re.UserInformation) > 0 {
+		var err error
+		if out, err = appendBERWrapped(out, berTagUserInformation, berTagOctetString, re.UserInformation); err != nil {
+			return dst, err
+		}
+	}
+	return insertBERLength(dst, out, start)
+}
+
+func (re RLRE) EncodeTo(w io.Writer) error { return encodeTo(w, re) }
+
+func DecodeRLRE(ori *[]byte) (out RLRE, err error) {
+	src := *ori
+	contents, err := decodeACSE(&src, TagRLRE)
+	if err != nil {
+		return
+	}
+
+	reason, userInfo, err := decodeReleaseContents(contents, "RLRE", len(*ori)-len(src)-len(contents))
+	if err != nil {
+		return
+	}
+	out.Reason = ReleaseResponseReason(reason)
+	out.UserInformation = userInfo
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
@@ -0,0 +1,39 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_RLRE(t *testing.T) {
+	var a RLRE = *CreateRLRE(TagRlreNotFinished, nil)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{99, 3, 128, 1, 1}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+}
+
+func TestDecode_RLRE(t *testing.T) {
+	src := []byte{99, 3, 128, 1, 0, 1, 2}
+	a, err := DecodeRLRE(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeRLRE. Err: %v", err)
+	}
+	if a.Reason != TagRlreNormal {
+		t.Errorf("t1 Reason get: %v", a.Reason)
+	}
+	if bytes.Compare(src, []byte{1, 2}) != 0 {
+		t.Errorf("t1 remaining byte get: %v", src)
+	}
+
+	src = []byte{98, 3, 128, 1, 0}
+	_, err = DecodeRLRE(&src)
+	if err == nil {
+		t.Errorf("t2 should failed on DecodeRLRE")
+	}
+}
@@ -0,0 +1,104 @@
+package dlms
+
+import (
+	"fmt"
+	"io"
+)
+
+type ReleaseRequestReason uint8
+
+const (
+	TagRlrqNormal      ReleaseRequestReason = 0
+	TagRlrqUrgent      ReleaseRequestReason = 1
+	TagRlrqUserDefined ReleaseRequestReason = 30
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s ReleaseRequestReason) Value() uint8 {
+	return uint8(s)
+}
+
+// RLRQ is the release request sent by client. UserInformation is
+// only needed if the association was ciphered, it then holds the
+// ciphered xDLMS InitiateRequest.
+type RLRQ struct {
+	Reason          ReleaseRequestReason
+	UserInformation []byte
+}
+
+func CreateRLRQ(reason ReleaseRequestReason, userInfo []byte) *RLRQ {
+	return &RLRQ{
+		Reason:          reason,
+		UserInformation: userInfo,
+	}
+}
+
+func (rq RLRQ) Encode() (out []byte, err error) {
+	return rq.AppendEncode(nil)
+}
+
+func (rq RLRQ) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagRLRQ.Value())
+	start := len(out)
+	out = append(out, berTagReason, 1, rq.Reason.Value())
+	if len(rq.UserInformation) > 0 {
+		var err error
+		if out, err = appendBERWrapped(out, berTagUserInformation, berTagOctetString, rq.UserInformation); err != nil {
+			return dst, err
+		}
+	}
+	return insertBERLength(dst, out, start)
+}
+
+func (rq RLRQ) EncodeTo(w io.Writer) error { return encodeTo(w, rq) }
+
+func DecodeRLRQ(ori *[]byte) (out RLRQ, err error) {
+	src := *ori
+	contents, err := decodeACSE(&src, TagRLRQ)
+	if err != nil {
+		return
+	}
+
+	reason, userInfo, err := decodeReleaseContents(contents, "RLRQ", len(*ori)-len(src)-len(contents))
+	if err != nil {
+		return
+	}
+	out.Reason = ReleaseRequestReason(reason)
+	out.UserInformation = userInfo
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+// Read reason & user-information shared by RLRQ and RLRE, name is of
+// the APDU and offset is index of contents in it, told by DecodeError.
+// Reason defaults to normal when it is absent
+func decodeReleaseContents(contents []byte, name string, offset int) (reason uint8, userInfo []byte, err error) {
+	end := offset + len(contents)
+	for len(contents) > 0 {
+		pos := end - len(contents)
+		var tag byte
+		var value []byte
+		tag, value, err = decodeBER(&contents)
+		if err != nil {
+			return
+		}
+
+		switch tag {
+		case berTagReason:
+			if len(value) != 1 {
+				err = errDecode(pos, fmt.Errorf("%v component 0x%02X: reason of %v bytes is not supported", name, tag, len(value)))
+				return
+			}
+			reason = value[0]
+		case berTagUserInformation:
+			userInfo, err = decodeBERWrapped(value, berTagOctetString)
+			if err != nil {
+				err = errDecode(pos, fmt.Errorf("%v component 0x%02X: %w", name, tag, err))
+				return
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_RLRQ(t *testing.T) {
+	var a RLRQ = *CreateRLRQ(TagRlrqNormal, nil)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{98, 3, 128, 1, 0}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	a = *CreateRLRQ(TagRlrqUrgent, []byte{1, 2, 3})
+	t2, e := a.Encode()
+	if e != nil {
+		t.Errorf("t2 Encode Failed. err: %v", e)
+	}
+	result = []byte{98, 10, 128, 1, 1, 190, 5, 4, 3, 1, 2, 3}
+	res = bytes.Compare(t2, result)
+	if res != 0 {
+		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
+	}
+}
+
+func TestDecode_RLRQ(t *testing.T) {
+	src := []byte{98, 10, 128, 1, 1, 190, 5, 4, 3, 1, 2, 3}
+	a, err := DecodeRLRQ(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeRLRQ. Err: %v", err)
+	}
+	if a.Reason != TagRlrqUrgent {
+		t.Errorf("t1 Reason get: %v", a.Reason)
+	}
+	if bytes.Compare(a.UserInformation, []byte{1, 2, 3}) != 0 {
+		t.Errorf("t1 UserInformation get: %v", a.UserInformation)
+	}
+
+	src = []byte{98, 0}
+	a, err = DecodeRLRQ(&src)
+	if err != nil || a.Reason != TagRlrqNormal {
+		t.Errorf("t2 failed on DecodeRLRQ. get: %v, Err: %v", a, err)
+	}
+
+	src = []byte{98, 4, 128, 2, 0, 0}
+	_, err = DecodeRLRQ(&src)
+	if err == nil {
+		t.Errorf("t3 should failed on DecodeRLRQ")
+	}
+}
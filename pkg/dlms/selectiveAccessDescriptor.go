@@ -1,7 +1,6 @@
 package dlms
 
 import (
-	"bytes"
 	"gosem/pkg/axdr"
 	"time"
 )
@@ -58,21 +57,24 @@ func CreateSelectiveAccessDescriptor(as accesSelector, ap interface{}) *Selectiv
 }
 
 func (s SelectiveAccessDescriptor) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(s.AccessSelector.Value()))
-	val, e := s.AccessParameter.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(val)
+	return s.appendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (s SelectiveAccessDescriptor) appendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(s.AccessSelector.Value()))
+	out, err := s.AccessParameter.AppendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
 func DecodeSelectiveAccessDescriptor(ori *[]byte) (out SelectiveAccessDescriptor, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 
 	if src[0] == AccessSelectorRange.Value() {
 		out.AccessSelector = AccessSelectorRange
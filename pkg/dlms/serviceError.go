@@ -0,0 +1,354 @@
+package dlms
+
+import "fmt"
+
+// ApplicationReferenceError is value of TagErrApplicationReference
+type ApplicationReferenceError uint8
+
+const (
+	TagAppRefOther                         ApplicationReferenceError = 0
+	TagAppRefTimeElapsed                   ApplicationReferenceError = 1
+	TagAppRefApplicationUnreachable        ApplicationReferenceError = 2
+	TagAppRefApplicationReferenceInvalid   ApplicationReferenceError = 3
+	TagAppRefApplicationContextUnsupported ApplicationReferenceError = 4
+	TagAppRefProviderCommunicationError    ApplicationReferenceError = 5
+	TagAppRefDecipheringError              ApplicationReferenceError = 6
+)
+
+// HardwareResourceError is value of TagErrHardwareResource
+type HardwareResourceError uint8
+
+const (
+	TagHwResOther                        HardwareResourceError = 0
+	TagHwResMemoryUnavailable            HardwareResourceError = 1
+	TagHwResProcessorResourceUnavailable HardwareResourceError = 2
+	TagHwResMassStorageUnavailable       HardwareResourceError = 3
+	TagHwResOtherResourceUnavailable     HardwareResourceError = 4
+)
+
+// VdeStateError is value of TagErrVdeStateError
+type VdeStateError uint8
+
+const (
+	TagVdeOther            VdeStateError = 0
+	TagVdeNoDlmsContext    VdeStateError = 1
+	TagVdeLoadingDataSet   VdeStateError = 2
+	TagVdeStatusNoChange   VdeStateError = 3
+	TagVdeStatusInoperable VdeStateError = 4
+)
+
+// ServiceKindError is value of TagErrService
+type ServiceKindError uint8
+
+const (
+	TagSvcOther       ServiceKindError = 0
+	TagSvcPduSize     ServiceKindError = 1
+	TagSvcUnsupported ServiceKindError = 2
+)
+
+// DefinitionError is value of TagErrDefinition
+type DefinitionError uint8
+
+const (
+	TagDefOther                       DefinitionError = 0
+	TagDefObjectUndefined             DefinitionError = 1
+	TagDefObjectClassInconsistent     DefinitionError = 2
+	TagDefObjectAttributeInconsistent DefinitionError = 3
+)
+
+// AccessError is value of TagErrAccess
+type AccessError uint8
+
+const (
+	TagAccessOther                 AccessError = 0
+	TagAccessScopeOfAccessViolated AccessError = 1
+	TagAccessObjectAccessViolated  AccessError = 2
+	TagAccessHardwareFault         AccessError = 3
+	TagAccessObjectUnavailable     AccessError = 4
+)
+
+// InitiateError is value of TagErrInitiate
+type InitiateError uint8
+
+const (
+	TagInitOther                   InitiateError = 0
+	TagInitDlmsVersionTooLow       InitiateError = 1
+	TagInitIncompatibleConformance InitiateError = 2
+	TagInitPduSizeTooShort         InitiateError = 3
+	TagInitRefusedByVdeHandler     InitiateError = 4
+)
+
+// LoadDataSetError is value of TagErrLoadDataSet
+type LoadDataSetError uint8
+
+const (
+	TagLoadOther                  LoadDataSetError = 0
+	TagLoadPrimitiveOutOfSequence LoadDataSetError = 1
+	TagLoadNotLoadable            LoadDataSetError = 2
+	TagLoadDataSetSizeTooLarge    LoadDataSetError = 3
+	TagLoadNotAwaitedSegment      LoadDataSetError = 4
+	TagLoadInterpretationFailure  LoadDataSetError = 5
+	TagLoadStorageFailure         LoadDataSetError = 6
+	TagLoadDataSetNotReady        LoadDataSetError = 7
+)
+
+// ChangeScopeError is value of TagErrChangeScopeError
+type ChangeScopeError uint8
+
+const (
+	TagChangeScopeOther ChangeScopeError = 0
+)
+
+// TaskError is value of TagErrTask
+type TaskError uint8
+
+const (
+	TagTaskOther           TaskError = 0
+	TagTaskNoRemoteControl TaskError = 1
+	TagTaskTiStopped       TaskError = 2
+	TagTaskTiRunning       TaskError = 3
+	TagTaskTiUnusable      TaskError = 4
+)
+
+// OtherError is value of TagErrOtherError, it has no defined values
+type OtherError uint8
+
+func unknownName(value uint8) string {
+	return fmt.Sprintf("unknown(%d)", value)
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s ApplicationReferenceError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s ApplicationReferenceError) String() string {
+	switch s {
+	case TagAppRefOther:
+		return "other"
+	case TagAppRefTimeElapsed:
+		return "time-elapsed"
+	case TagAppRefApplicationUnreachable:
+		return "application-unreachable"
+	case TagAppRefApplicationReferenceInvalid:
+		return "application-reference-invalid"
+	case TagAppRefApplicationContextUnsupported:
+		return "application-context-unsupported"
+	case TagAppRefProviderCommunicationError:
+		return "provider-communication-error"
+	case TagAppRefDecipheringError:
+		return "deciphering-error"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s HardwareResourceError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s HardwareResourceError) String() string {
+	switch s {
+	case TagHwResOther:
+		return "other"
+	case TagHwResMemoryUnavailable:
+		return "memory-unavailable"
+	case TagHwResProcessorResourceUnavailable:
+		return "processor-resource-unavailable"
+	case TagHwResMassStorageUnavailable:
+		return "mass-storage-unavailable"
+	case TagHwResOtherResourceUnavailable:
+		return "other-resource-unavailable"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s VdeStateError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s VdeStateError) String() string {
+	switch s {
+	case TagVdeOther:
+		return "other"
+	case TagVdeNoDlmsContext:
+		return "no-dlms-context"
+	case TagVdeLoadingDataSet:
+		return "loading-data-set"
+	case TagVdeStatusNoChange:
+		return "status-nochange"
+	case TagVdeStatusInoperable:
+		return "status-inoperable"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s ServiceKindError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s ServiceKindError) String() string {
+	switch s {
+	case TagSvcOther:
+		return "other"
+	case TagSvcPduSize:
+		return "pdu-size"
+	case TagSvcUnsupported:
+		return "service-unsupported"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s DefinitionError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s DefinitionError) String() string {
+	switch s {
+	case TagDefOther:
+		return "other"
+	case TagDefObjectUndefined:
+		return "object-undefined"
+	case TagDefObjectClassInconsistent:
+		return "object-class-inconsistent"
+	case TagDefObjectAttributeInconsistent:
+		return "object-attribute-inconsistent"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s AccessError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s AccessError) String() string {
+	switch s {
+	case TagAccessOther:
+		return "other"
+	case TagAccessScopeOfAccessViolated:
+		return "scope-of-access-violated"
+	case TagAccessObjectAccessViolated:
+		return "object-access-violated"
+	case TagAccessHardwareFault:
+		return "hardware-fault"
+	case TagAccessObjectUnavailable:
+		return "object-unavailable"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s InitiateError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s InitiateError) String() string {
+	switch s {
+	case TagInitOther:
+		return "other"
+	case TagInitDlmsVersionTooLow:
+		return "dlms-version-too-low"
+	case TagInitIncompatibleConformance:
+		return "incompatible-conformance"
+	case TagInitPduSizeTooShort:
+		return "pdu-size-too-short"
+	case TagInitRefusedByVdeHandler:
+		return "refused-by-the-VDE-Handler"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s LoadDataSetError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s LoadDataSetError) String() string {
+	switch s {
+	case TagLoadOther:
+		return "other"
+	case TagLoadPrimitiveOutOfSequence:
+		return "primitive-out-of-sequence"
+	case TagLoadNotLoadable:
+		return "not-loadable"
+	case TagLoadDataSetSizeTooLarge:
+		return "dataset-size-too-large"
+	case TagLoadNotAwaitedSegment:
+		return "not-awaited-segment"
+	case TagLoadInterpretationFailure:
+		return "interpretation-failure"
+	case TagLoadStorageFailure:
+		return "storage-failure"
+	case TagLoadDataSetNotReady:
+		return "data-set-not-ready"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s ChangeScopeError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s ChangeScopeError) String() string {
+	switch s {
+	case TagChangeScopeOther:
+		return "other"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s TaskError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s TaskError) String() string {
+	switch s {
+	case TagTaskOther:
+		return "other"
+	case TagTaskNoRemoteControl:
+		return "no-remote-control"
+	case TagTaskTiStopped:
+		return "ti-stopped"
+	case TagTaskTiRunning:
+		return "ti-running"
+	case TagTaskTiUnusable:
+		return "ti-unusable"
+	default:
+		return unknownName(uint8(s))
+	}
+}
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s OtherError) Value() uint8 {
+	return uint8(s)
+}
+
+func (s OtherError) String() string {
+	return fmt.Sprintf("%d", uint8(s))
+}
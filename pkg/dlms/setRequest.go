@@ -1,8 +1,9 @@
 package dlms
 
 import (
-	"bytes"
 	"fmt"
+	"io"
+
 	"gosem/pkg/axdr"
 )
 
@@ -44,6 +45,11 @@ func (gr *SetRequest) New(tag setRequestTag) (out CosemPDU, err error) {
 }
 
 func (gr *SetRequest) Decode(src *[]byte) (out CosemPDU, err error) {
+	if len(*src) < 2 {
+		err = ErrWrongLength(len(*src), 2)
+		return
+	}
+
 	if (*src)[0] != TagSetRequest.Value() {
 		err = ErrWrongTag(0, (*src)[0], byte(TagSetRequest))
 		return
@@ -61,7 +67,7 @@ func (gr *SetRequest) Decode(src *[]byte) (out CosemPDU, err error) {
 	case TagSetRequestWithListAndFirstDataBlock.Value():
 		out, err = DecodeSetRequestWithListAndFirstDataBlock(src)
 	default:
-		err = fmt.Errorf("byte tag not recognized (%v)", (*src)[1])
+		err = errDecode(1, fmt.Errorf("byte tag not recognized (%v)", (*src)[1]))
 	}
 
 	return
@@ -85,40 +91,31 @@ func CreateSetRequestNormal(invokeId uint8, att AttributeDescriptor, acc *Select
 }
 
 func (sr SetRequestNormal) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagSetRequest))
-	buf.WriteByte(byte(TagSetRequestNormal))
-	buf.WriteByte(byte(sr.InvokePriority))
-	attInfo, e := sr.AttributeInfo.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(attInfo)
-	if sr.SelectiveAccessInfo == nil {
-		buf.WriteByte(0x0)
-	} else {
-		buf.WriteByte(0x1)
-		selInfo, e := sr.SelectiveAccessInfo.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(selInfo)
+	return sr.AppendEncode(nil)
+}
+
+// AppendEncode writes request straight into dst, see CosemPDU
+func (sr SetRequestNormal) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagSetRequest), byte(TagSetRequestNormal), byte(sr.InvokePriority))
+	out = sr.AttributeInfo.appendEncode(out)
+	out, err := appendSelectiveAccess(dst, out, sr.SelectiveAccessInfo)
+	if err != nil {
+		return dst, err
 	}
-	val, eVal := sr.Value.Encode()
-	if eVal != nil {
-		err = eVal
-		return
+	if out, err = sr.Value.AppendEncode(out); err != nil {
+		return dst, err
 	}
-	buf.Write(val)
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (sr SetRequestNormal) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetRequestNormal(ori *[]byte) (out SetRequestNormal, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagSetRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetRequest))
@@ -135,6 +132,10 @@ func DecodeSetRequestNormal(ori *[]byte) (out SetRequestNormal, err error) {
 		return
 	}
 
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	haveAccDesc := src[0]
 	src = src[1:]
 	// SelectiveAccessInfo
@@ -175,41 +176,30 @@ func CreateSetRequestWithFirstDataBlock(invokeId uint8, att AttributeDescriptor,
 }
 
 func (sr SetRequestWithFirstDataBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagSetRequest))
-	buf.WriteByte(byte(TagSetRequestWithFirstDataBlock))
-	buf.WriteByte(byte(sr.InvokePriority))
-	attInfo, e := sr.AttributeInfo.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(attInfo)
-	if sr.SelectiveAccessInfo == nil {
-		buf.WriteByte(0x0)
-	} else {
-		buf.WriteByte(0x1)
-		selInfo, e := sr.SelectiveAccessInfo.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(selInfo)
-	}
+	return sr.AppendEncode(nil)
+}
 
-	val, eVal := sr.DataBlock.Encode()
-	if eVal != nil {
-		err = eVal
-		return
+func (sr SetRequestWithFirstDataBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagSetRequest), byte(TagSetRequestWithFirstDataBlock), byte(sr.InvokePriority))
+	out = sr.AttributeInfo.appendEncode(out)
+	out, err := appendSelectiveAccess(dst, out, sr.SelectiveAccessInfo)
+	if err != nil {
+		return dst, err
 	}
-	buf.Write(val)
-
-	out = buf.Bytes()
-	return
+	if out, err = sr.DataBlock.appendEncode(out); err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (sr SetRequestWithFirstDataBlock) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetRequestWithFirstDataBlock(ori *[]byte) (out SetRequestWithFirstDataBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagSetRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetRequest))
@@ -226,6 +216,10 @@ func DecodeSetRequestWithFirstDataBlock(ori *[]byte) (out SetRequestWithFirstDat
 		return
 	}
 
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	haveAccDesc := src[0]
 	src = src[1:]
 
@@ -261,23 +255,26 @@ func CreateSetRequestWithDataBlock(invokeId uint8, dt DataBlockSA) *SetRequestWi
 }
 
 func (sr SetRequestWithDataBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagSetRequest))
-	buf.WriteByte(byte(TagSetRequestWithDataBlock))
-	buf.WriteByte(byte(sr.InvokePriority))
-	val, e := sr.DataBlock.Encode()
-	if e != nil {
-		err = e
-		return
-	}
-	buf.Write(val)
+	return sr.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+func (sr SetRequestWithDataBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagSetRequest), byte(TagSetRequestWithDataBlock), byte(sr.InvokePriority))
+	out, err := sr.DataBlock.appendEncode(out)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
 }
 
+func (sr SetRequestWithDataBlock) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetRequestWithDataBlock(ori *[]byte) (out SetRequestWithDataBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagSetRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetRequest))
@@ -322,35 +319,32 @@ func CreateSetRequestWithList(invokeId uint8, attList []AttributeDescriptorWithS
 }
 
 func (sr SetRequestWithList) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagSetRequest))
-	buf.WriteByte(byte(TagSetRequestWithList))
-	buf.WriteByte(byte(sr.InvokePriority))
-	buf.WriteByte(byte(sr.AttributeCount))
-	for _, attr := range sr.AttributeInfoList {
-		attInfo, e := attr.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(attInfo)
+	return sr.AppendEncode(nil)
+}
+
+func (sr SetRequestWithList) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagSetRequest), byte(TagSetRequestWithList), byte(sr.InvokePriority), byte(sr.AttributeCount))
+	out, err := appendAttributeList(dst, out, sr.AttributeInfoList)
+	if err != nil {
+		return dst, err
 	}
-	buf.WriteByte(byte(sr.ValueCount))
+	out = append(out, byte(sr.ValueCount))
 	for _, val := range sr.ValueList {
-		dt, e := val.Encode()
-		if e != nil {
-			err = e
-			return
+		if out, err = val.AppendEncode(out); err != nil {
+			return dst, err
 		}
-		buf.Write(dt)
 	}
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (sr SetRequestWithList) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetRequestWithList(ori *[]byte) (out SetRequestWithList, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagSetRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetRequest))
@@ -373,6 +367,10 @@ func DecodeSetRequestWithList(ori *[]byte) (out SetRequestWithList, err error) {
 		out.AttributeInfoList = append(out.AttributeInfoList, v)
 	}
 
+	if len(src) < 1 {
+		err = ErrWrongLength(len(src), 1)
+		return
+	}
 	out.ValueCount = uint8(src[0])
 	src = src[1:]
 	for i := 0; i < int(out.ValueCount); i++ {
@@ -410,32 +408,29 @@ func CreateSetRequestWithListAndFirstDataBlock(invokeId uint8, attList []Attribu
 }
 
 func (sr SetRequestWithListAndFirstDataBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(TagSetRequest))
-	buf.WriteByte(byte(TagSetRequestWithListAndFirstDataBlock))
-	buf.WriteByte(byte(sr.InvokePriority))
-	buf.WriteByte(byte(sr.AttributeCount))
-	for _, attr := range sr.AttributeInfoList {
-		val, e := attr.Encode()
-		if e != nil {
-			err = e
-			return
-		}
-		buf.Write(val)
+	return sr.AppendEncode(nil)
+}
+
+func (sr SetRequestWithListAndFirstDataBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, byte(TagSetRequest), byte(TagSetRequestWithListAndFirstDataBlock), byte(sr.InvokePriority), byte(sr.AttributeCount))
+	out, err := appendAttributeList(dst, out, sr.AttributeInfoList)
+	if err != nil {
+		return dst, err
 	}
-	val, e := sr.DataBlock.Encode()
-	if e != nil {
-		err = e
-		return
+	if out, err = sr.DataBlock.appendEncode(out); err != nil {
+		return dst, err
 	}
-	buf.Write(val)
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (sr SetRequestWithListAndFirstDataBlock) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetRequestWithListAndFirstDataBlock(ori *[]byte) (out SetRequestWithListAndFirstDataBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagSetRequest.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetRequest))
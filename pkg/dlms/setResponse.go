@@ -1,8 +1,9 @@
 package dlms
 
 import (
-	"bytes"
 	"fmt"
+	"io"
+
 	"gosem/pkg/axdr"
 )
 
@@ -44,6 +45,11 @@ func (gr *SetResponse) New(tag setResponseTag) (out CosemPDU, err error) {
 }
 
 func (gr *SetResponse) Decode(src *[]byte) (out CosemPDU, err error) {
+	if len(*src) < 2 {
+		err = ErrWrongLength(len(*src), 2)
+		return
+	}
+
 	if (*src)[0] != TagSetResponse.Value() {
 		err = ErrWrongTag(0, (*src)[0], byte(TagSetResponse))
 		return
@@ -61,7 +67,7 @@ func (gr *SetResponse) Decode(src *[]byte) (out CosemPDU, err error) {
 	case TagSetResponseWithList.Value():
 		out, err = DecodeSetResponseWithList(src)
 	default:
-		err = fmt.Errorf("byte tag not recognized (%v)", (*src)[1])
+		err = errDecode(1, fmt.Errorf("byte tag not recognized (%v)", (*src)[1]))
 	}
 
 	return
@@ -81,18 +87,22 @@ func CreateSetResponseNormal(invokeId uint8, result AccessResultTag) *SetRespons
 }
 
 func (sr SetResponseNormal) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(TagSetResponse.Value())
-	buf.WriteByte(TagSetResponseNormal.Value())
-	buf.WriteByte(sr.InvokePriority)
-	buf.WriteByte(sr.Result.Value())
+	return sr.AppendEncode(nil)
+}
 
-	out = buf.Bytes()
-	return
+// AppendEncode writes response straight into dst, see CosemPDU
+func (sr SetResponseNormal) AppendEncode(dst []byte) ([]byte, error) {
+	return append(dst, TagSetResponse.Value(), TagSetResponseNormal.Value(), sr.InvokePriority, sr.Result.Value()), nil
 }
 
+func (sr SetResponseNormal) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetResponseNormal(ori *[]byte) (out SetResponseNormal, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagSetResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetResponse))
@@ -127,19 +137,22 @@ func CreateSetResponseDataBlock(invokeId uint8, blockNum uint32) *SetResponseDat
 }
 
 func (sr SetResponseDataBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(TagSetResponse.Value())
-	buf.WriteByte(TagSetResponseDataBlock.Value())
-	buf.WriteByte(sr.InvokePriority)
-	blockNum, _ := axdr.EncodeDoubleLongUnsigned(sr.BlockNum)
-	buf.Write(blockNum)
-
-	out = buf.Bytes()
-	return
+	return sr.AppendEncode(nil)
 }
 
+func (sr SetResponseDataBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagSetResponse.Value(), TagSetResponseDataBlock.Value(), sr.InvokePriority)
+	return appendUint32(out, sr.BlockNum), nil
+}
+
+func (sr SetResponseDataBlock) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetResponseDataBlock(ori *[]byte) (out SetResponseDataBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
 
 	if src[0] != TagSetResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetResponse))
@@ -174,20 +187,22 @@ func CreateSetResponseLastDataBlock(invokeId uint8, result AccessResultTag, bloc
 }
 
 func (sr SetResponseLastDataBlock) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(TagSetResponse.Value())
-	buf.WriteByte(TagSetResponseLastDataBlock.Value())
-	buf.WriteByte(sr.InvokePriority)
-	buf.WriteByte(sr.Result.Value())
-	blockNum, _ := axdr.EncodeDoubleLongUnsigned(sr.BlockNum)
-	buf.Write(blockNum)
-
-	out = buf.Bytes()
-	return
+	return sr.AppendEncode(nil)
+}
+
+func (sr SetResponseLastDataBlock) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagSetResponse.Value(), TagSetResponseLastDataBlock.Value(), sr.InvokePriority, sr.Result.Value())
+	return appendUint32(out, sr.BlockNum), nil
 }
 
+func (sr SetResponseLastDataBlock) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetResponseLastDataBlock(ori *[]byte) (out SetResponseLastDataBlock, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagSetResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetResponse))
@@ -231,23 +246,25 @@ func CreateSetResponseLastDataBlockWithList(invokeId uint8, resList []AccessResu
 }
 
 func (sr SetResponseLastDataBlockWithList) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(TagSetResponse.Value())
-	buf.WriteByte(TagSetResponseLastDataBlockWithList.Value())
-	buf.WriteByte(sr.InvokePriority)
-	buf.WriteByte(sr.ResultCount)
+	return sr.AppendEncode(nil)
+}
+
+func (sr SetResponseLastDataBlockWithList) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagSetResponse.Value(), TagSetResponseLastDataBlockWithList.Value(), sr.InvokePriority, sr.ResultCount)
 	for _, acc := range sr.ResultList {
-		buf.WriteByte(acc.Value())
+		out = append(out, acc.Value())
 	}
-	blockNum, _ := axdr.EncodeDoubleLongUnsigned(sr.BlockNum)
-	buf.Write(blockNum)
-
-	out = buf.Bytes()
-	return
+	return appendUint32(out, sr.BlockNum), nil
 }
 
+func (sr SetResponseLastDataBlockWithList) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetResponseLastDataBlockWithList(ori *[]byte) (out SetResponseLastDataBlockWithList, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagSetResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetResponse))
@@ -262,6 +279,10 @@ func DecodeSetResponseLastDataBlockWithList(ori *[]byte) (out SetResponseLastDat
 	out.ResultCount = uint8(src[3])
 	src = src[4:]
 	for i := 0; i < int(out.ResultCount); i++ {
+		if len(src) < 1 {
+			err = ErrWrongLength(len(src), 1)
+			return
+		}
 		v, e := GetAccessTag(uint8(src[0]))
 		if e != nil {
 			err = e
@@ -296,21 +317,25 @@ func CreateSetResponseWithList(invokeId uint8, resList []AccessResultTag) *SetRe
 }
 
 func (sr SetResponseWithList) Encode() (out []byte, err error) {
-	var buf bytes.Buffer
-	buf.WriteByte(TagSetResponse.Value())
-	buf.WriteByte(TagSetResponseWithList.Value())
-	buf.WriteByte(sr.InvokePriority)
-	buf.WriteByte(sr.ResultCount)
+	return sr.AppendEncode(nil)
+}
+
+func (sr SetResponseWithList) AppendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, TagSetResponse.Value(), TagSetResponseWithList.Value(), sr.InvokePriority, sr.ResultCount)
 	for _, acc := range sr.ResultList {
-		buf.WriteByte(acc.Value())
+		out = append(out, acc.Value())
 	}
-
-	out = buf.Bytes()
-	return
+	return out, nil
 }
 
+func (sr SetResponseWithList) EncodeTo(w io.Writer) error { return encodeTo(w, sr) }
+
 func DecodeSetResponseWithList(ori *[]byte) (out SetResponseWithList, err error) {
-	src := append([]byte(nil), (*ori)...)
+	src := *ori
+	if len(src) < 4 {
+		err = ErrWrongLength(len(src), 4)
+		return
+	}
 
 	if src[0] != TagSetResponse.Value() {
 		err = ErrWrongTag(0, src[0], byte(TagSetResponse))
@@ -325,6 +350,10 @@ func DecodeSetResponseWithList(ori *[]byte) (out SetResponseWithList, err error)
 	out.ResultCount = uint8(src[3])
 	src = src[4:]
 	for i := 0; i < int(out.ResultCount); i++ {
+		if len(src) < 1 {
+			err = ErrWrongLength(len(src), 1)
+			return
+		}
 		v, e := GetAccessTag(uint8(src[0]))
 		if e != nil {
 			err = e
@@ -0,0 +1,271 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gosem/pkg/axdr"
+)
+
+type variableAccessTag uint8
+
+const (
+	TagVariableName         variableAccessTag = 2
+	TagParameterizedAccess  variableAccessTag = 4
+	TagBlockNumberAccess    variableAccessTag = 5
+	TagReadDataBlockAccess  variableAccessTag = 6
+	TagWriteDataBlockAccess variableAccessTag = 7
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s variableAccessTag) Value() uint8 {
+	return uint8(s)
+}
+
+// VariableAccessSpecification is used by short name referencing services.
+// Fields used depend on Tag:
+//   - TagVariableName: VariableName
+//   - TagParameterizedAccess: VariableName, Selector, Parameter
+//   - TagBlockNumberAccess: BlockNumber
+//   - TagReadDataBlockAccess: LastBlock, BlockNumber, RawData
+//   - TagWriteDataBlockAccess: LastBlock, BlockNumber
+type VariableAccessSpecification struct {
+	Tag          variableAccessTag
+	VariableName uint16
+	Selector     uint8
+	Parameter    axdr.DlmsData
+	LastBlock    bool
+	BlockNumber  uint16
+	RawData      []byte
+}
+
+func CreateVariableName(name uint16) *VariableAccessSpecification {
+	return &VariableAccessSpecification{Tag: TagVariableName, VariableName: name}
+}
+
+func CreateParameterizedAccess(name uint16, selector uint8, parameter axdr.DlmsData) *VariableAccessSpecification {
+	return &VariableAccessSpecification{Tag: TagParameterizedAccess, VariableName: name, Selector: selector, Parameter: parameter}
+}
+
+func CreateBlockNumberAccess(blockNum uint16) *VariableAccessSpecification {
+	return &VariableAccessSpecification{Tag: TagBlockNumberAccess, BlockNumber: blockNum}
+}
+
+func CreateReadDataBlockAccess(lastBlock bool, blockNum uint16, raw []byte) *VariableAccessSpecification {
+	return &VariableAccessSpecification{Tag: TagReadDataBlockAccess, LastBlock: lastBlock, BlockNumber: blockNum, RawData: raw}
+}
+
+func CreateWriteDataBlockAccess(lastBlock bool, blockNum uint16) *VariableAccessSpecification {
+	return &VariableAccessSpecification{Tag: TagWriteDataBlockAccess, LastBlock: lastBlock, BlockNumber: blockNum}
+}
+
+func appendUint16(dst []byte, value uint16) []byte {
+	return append(dst, byte(value>>8), byte(value))
+}
+
+func appendUint32(dst []byte, value uint32) []byte {
+	return append(dst, byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+}
+
+func appendBoolean(dst []byte, value bool) []byte {
+	if value {
+		return append(dst, 0xFF)
+	}
+	return append(dst, 0x0)
+}
+
+// A-XDR octet string, length of value followed by value
+func appendOctetString(dst []byte, value []byte) ([]byte, error) {
+	out, err := axdr.AppendLength(dst, len(value))
+	if err != nil {
+		return dst, err
+	}
+	return append(out, value...), nil
+}
+
+func (va VariableAccessSpecification) Encode() (out []byte, err error) {
+	return va.appendEncode(nil)
+}
+
+func (va VariableAccessSpecification) appendEncode(dst []byte) (out []byte, err error) {
+	out = append(dst, va.Tag.Value())
+
+	switch va.Tag {
+	case TagVariableName:
+		out = appendUint16(out, va.VariableName)
+	case TagParameterizedAccess:
+		out = append(appendUint16(out, va.VariableName), va.Selector)
+		out, err = va.Parameter.AppendEncode(out)
+	case TagBlockNumberAccess:
+		out = appendUint16(out, va.BlockNumber)
+	case TagReadDataBlockAccess:
+		out = appendUint16(appendBoolean(out, va.LastBlock), va.BlockNumber)
+		out, err = appendOctetString(out, va.RawData)
+	case TagWriteDataBlockAccess:
+		out = appendUint16(appendBoolean(out, va.LastBlock), va.BlockNumber)
+	default:
+		err = fmt.Errorf("variable access specification tag %v is not supported", va.Tag)
+	}
+	if err != nil {
+		return dst, err
+	}
+	return
+}
+
+func readUint16(src *[]byte) (out uint16, err error) {
+	if len(*src) < 2 {
+		err = ErrWrongLength(len(*src), 2)
+		return
+	}
+	out = binary.BigEndian.Uint16((*src)[:2])
+	(*src) = (*src)[2:]
+	return
+}
+
+func readOctetString(src *[]byte) (out []byte, err error) {
+	_, length, err := axdr.DecodeLength(src)
+	if err != nil {
+		return
+	}
+	if uint64(len(*src)) < length {
+		err = ErrWrongLength(len(*src), int(length))
+		return
+	}
+	out = append([]byte(nil), (*src)[:length]...)
+	(*src) = (*src)[length:]
+	return
+}
+
+func readData(src *[]byte) (out axdr.DlmsData, err error) {
+	if len(*src) < 1 {
+		err = ErrWrongLength(0, 1)
+		return
+	}
+	decoder := axdr.NewDataDecoder(src)
+	return decoder.Decode(src)
+}
+
+// Read A-XDR length of SEQUENCE OF
+func readCount(src *[]byte) (out int, err error) {
+	_, length, err := axdr.DecodeLength(src)
+	if err != nil {
+		return
+	}
+	if length > uint64(len(*src)) {
+		err = errDecode(0, fmt.Errorf("sequence of %v elements is longer than %v bytes left", length, len(*src)))
+		return
+	}
+	out = int(length)
+	return
+}
+
+func DecodeVariableAccessSpecification(ori *[]byte) (out VariableAccessSpecification, err error) {
+	src := *ori
+	if len(src) < 1 {
+		err = ErrWrongLength(0, 1)
+		return
+	}
+	out.Tag = variableAccessTag(src[0])
+	src = src[1:]
+
+	switch out.Tag {
+	case TagVariableName:
+		out.VariableName, err = readUint16(&src)
+	case TagParameterizedAccess:
+		if out.VariableName, err = readUint16(&src); err != nil {
+			return
+		}
+		if len(src) < 1 {
+			err = ErrWrongLength(0, 1)
+			return
+		}
+		out.Selector = src[0]
+		src = src[1:]
+		out.Parameter, err = readData(&src)
+	case TagBlockNumberAccess:
+		out.BlockNumber, err = readUint16(&src)
+	case TagReadDataBlockAccess, TagWriteDataBlockAccess:
+		if len(src) < 1 {
+			err = ErrWrongLength(0, 1)
+			return
+		}
+		out.LastBlock = src[0] != 0x0
+		src = src[1:]
+		if out.BlockNumber, err = readUint16(&src); err != nil {
+			return
+		}
+		if out.Tag == TagReadDataBlockAccess {
+			out.RawData, err = readOctetString(&src)
+		}
+	default:
+		err = errDecode(0, fmt.Errorf("variable access specification tag %v is not supported", out.Tag))
+	}
+	if err != nil {
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+func appendVariableAccessList(dst []byte, list []VariableAccessSpecification) ([]byte, error) {
+	if len(list) < 1 {
+		return dst, fmt.Errorf("variables cannot have zero member")
+	}
+	out, err := axdr.AppendLength(dst, len(list))
+	if err != nil {
+		return dst, err
+	}
+	for _, va := range list {
+		if out, err = va.appendEncode(out); err != nil {
+			return dst, err
+		}
+	}
+	return out, nil
+}
+
+func decodeVariableAccessList(src *[]byte) (out []VariableAccessSpecification, err error) {
+	count, err := readCount(src)
+	if err != nil {
+		return
+	}
+	for i := 0; i < count; i++ {
+		va, e := DecodeVariableAccessSpecification(src)
+		if e != nil {
+			err = e
+			return
+		}
+		out = append(out, va)
+	}
+	return
+}
+
+func appendDataList(dst []byte, list []axdr.DlmsData) ([]byte, error) {
+	out, err := axdr.AppendLength(dst, len(list))
+	if err != nil {
+		return dst, err
+	}
+	for _, dt := range list {
+		if out, err = dt.AppendEncode(out); err != nil {
+			return dst, err
+		}
+	}
+	return out, nil
+}
+
+func decodeDataList(src *[]byte) (out []axdr.DlmsData, err error) {
+	count, err := readCount(src)
+	if err != nil {
+		return
+	}
+	for i := 0; i < count; i++ {
+		dt, e := readData(src)
+		if e != nil {
+			err = e
+			return
+		}
+		out = append(out, dt)
+	}
+	return
+}
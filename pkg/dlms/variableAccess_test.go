@@ -0,0 +1,47 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+
+	"gosem/pkg/axdr"
+)
+
+func TestVariableAccessSpecification(t *testing.T) {
+	tables := []struct {
+		va      VariableAccessSpecification
+		encoded []byte
+	}{
+		{*CreateVariableName(0xFA00), []byte{2, 0xFA, 0x00}},
+		{*CreateParameterizedAccess(0x0100, 1, *axdr.CreateAxdrUnsigned(5)), []byte{4, 0x01, 0x00, 1, 17, 5}},
+		{*CreateBlockNumberAccess(3), []byte{5, 0, 3}},
+		{*CreateReadDataBlockAccess(true, 2, []byte{0xAA, 0xBB}), []byte{6, 0xFF, 0, 2, 2, 0xAA, 0xBB}},
+		{*CreateWriteDataBlockAccess(false, 4), []byte{7, 0, 0, 4}},
+	}
+	for i, table := range tables {
+		t1, err := table.va.Encode()
+		if err != nil || bytes.Compare(t1, table.encoded) != 0 {
+			t.Errorf("t%d Encode Failed. get: %d, should:%v, err:%v", i, t1, table.encoded, err)
+		}
+
+		src := append(t1, 0x99)
+		va, err := DecodeVariableAccessSpecification(&src)
+		if err != nil || len(src) != 1 {
+			t.Errorf("t%d Decode Failed. get: %v, left: %v, err:%v", i, va, src, err)
+			continue
+		}
+		if va.Tag != table.va.Tag || va.VariableName != table.va.VariableName || va.Selector != table.va.Selector ||
+			va.LastBlock != table.va.LastBlock || va.BlockNumber != table.va.BlockNumber || bytes.Compare(va.RawData, table.va.RawData) != 0 {
+			t.Errorf("t%d Decode Failed. get: %v, should:%v", i, va, table.va)
+		}
+	}
+
+	src := []byte{3, 0, 1}
+	if _, err := DecodeVariableAccessSpecification(&src); err == nil {
+		t.Errorf("Detailed access should fail")
+	}
+	src = []byte{2, 0xFA}
+	if _, err := DecodeVariableAccessSpecification(&src); err == nil {
+		t.Errorf("Truncated variable name should fail")
+	}
+}
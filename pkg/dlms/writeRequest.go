@@ -0,0 +1,100 @@
+package dlms
+
+import (
+	"io"
+
+	"gosem/pkg/axdr"
+)
+
+// WriteRequest implement CosemPDU. It is the short name referencing
+// counterpart of SetRequest, Data holds one value for every variable
+type WriteRequest struct {
+	Variables []VariableAccessSpecification
+	Data      []axdr.DlmsData
+}
+
+// CreateWriteRequest creates WriteRequest, variables cannot be empty or else
+// it will fail on Encode()
+func CreateWriteRequest(variables []VariableAccessSpecification, data []axdr.DlmsData) *WriteRequest {
+	return &WriteRequest{Variables: variables, Data: data}
+}
+
+// Append tag, variables and data of write request, dst is returned as
+// it is on error
+func appendWrite(dst []byte, tag cosemTag, variables []VariableAccessSpecification, data []axdr.DlmsData) ([]byte, error) {
+	out, err := appendVariableAccessList(append(dst, tag.Value()), variables)
+	if err != nil {
+		return dst, err
+	}
+	if out, err = appendDataList(out, data); err != nil {
+		return dst, err
+	}
+	return out, nil
+}
+
+func decodeWrite(ori *[]byte, tag cosemTag) (variables []VariableAccessSpecification, data []axdr.DlmsData, err error) {
+	src := *ori
+
+	if len(src) < 3 {
+		err = ErrWrongLength(len(src), 3)
+		return
+	}
+	if src[0] != tag.Value() {
+		err = ErrWrongTag(0, src[0], byte(tag))
+		return
+	}
+	src = src[1:]
+
+	if variables, err = decodeVariableAccessList(&src); err != nil {
+		return
+	}
+	if data, err = decodeDataList(&src); err != nil {
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+func (wr WriteRequest) Encode() (out []byte, err error) {
+	return wr.AppendEncode(nil)
+}
+
+func (wr WriteRequest) AppendEncode(dst []byte) ([]byte, error) {
+	return appendWrite(dst, TagWriteRequest, wr.Variables, wr.Data)
+}
+
+func (wr WriteRequest) EncodeTo(w io.Writer) error { return encodeTo(w, wr) }
+
+func DecodeWriteRequest(ori *[]byte) (out WriteRequest, err error) {
+	out.Variables, out.Data, err = decodeWrite(ori, TagWriteRequest)
+	return
+}
+
+// UnconfirmedWriteRequest implement CosemPDU. It is a WriteRequest which
+// server does not answer
+type UnconfirmedWriteRequest struct {
+	Variables []VariableAccessSpecification
+	Data      []axdr.DlmsData
+}
+
+// CreateUnconfirmedWriteRequest creates UnconfirmedWriteRequest, variables
+// cannot be empty or else it will fail on Encode()
+func CreateUnconfirmedWriteRequest(variables []VariableAccessSpecification, data []axdr.DlmsData) *UnconfirmedWriteRequest {
+	return &UnconfirmedWriteRequest{Variables: variables, Data: data}
+}
+
+func (wr UnconfirmedWriteRequest) Encode() (out []byte, err error) {
+	return wr.AppendEncode(nil)
+}
+
+func (wr UnconfirmedWriteRequest) AppendEncode(dst []byte) ([]byte, error) {
+	return appendWrite(dst, TagUnconfirmedWriteRequest, wr.Variables, wr.Data)
+}
+
+func (wr UnconfirmedWriteRequest) EncodeTo(w io.Writer) error { return encodeTo(w, wr) }
+
+func DecodeUnconfirmedWriteRequest(ori *[]byte) (out UnconfirmedWriteRequest, err error) {
+	out.Variables, out.Data, err = decodeWrite(ori, TagUnconfirmedWriteRequest)
+	return
+}
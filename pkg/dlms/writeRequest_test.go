@@ -0,0 +1,68 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+
+	"gosem/pkg/axdr"
+)
+
+func TestNew_WriteRequest(t *testing.T) {
+	vars := []VariableAccessSpecification{*CreateVariableName(0x0208)}
+	data := []axdr.DlmsData{*axdr.CreateAxdrUnsigned(1)}
+	var a WriteRequest = *CreateWriteRequest(vars, data)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{6, 1, 2, 0x02, 0x08, 1, 17, 1}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	var b UnconfirmedWriteRequest = *CreateUnconfirmedWriteRequest(vars, data)
+	t2, e := b.Encode()
+	if e != nil {
+		t.Errorf("t2 Encode Failed. err: %v", e)
+	}
+	result = []byte{22, 1, 2, 0x02, 0x08, 1, 17, 1}
+	res = bytes.Compare(t2, result)
+	if res != 0 {
+		t.Errorf("t2 Failed. get: %d, should:%v", t2, result)
+	}
+
+	var c WriteRequest = *CreateWriteRequest(nil, data)
+	if _, e = c.Encode(); e == nil {
+		t.Errorf("t3 should fail on empty variables")
+	}
+	var d UnconfirmedWriteRequest = *CreateUnconfirmedWriteRequest(nil, data)
+	if _, e = d.Encode(); e == nil {
+		t.Errorf("t4 should fail on empty variables")
+	}
+}
+
+func TestDecode_WriteRequest(t *testing.T) {
+	src := []byte{6, 1, 2, 0x02, 0x08, 1, 17, 1}
+	a, err := DecodeWriteRequest(&src)
+	if err != nil {
+		t.Errorf("t1 failed on DecodeWriteRequest. Err: %v", err)
+	}
+	if len(a.Variables) != 1 || a.Variables[0].VariableName != 0x0208 || len(a.Data) != 1 || a.Data[0].Value != uint8(1) {
+		t.Errorf("t1 Failed. get: %v", a)
+	}
+	if len(src) != 0 {
+		t.Errorf("t1 Failed. src should be empty (%v)", src)
+	}
+
+	src = []byte{22, 1, 2, 0x02, 0x08, 1, 17, 1}
+	b, err := DecodeUnconfirmedWriteRequest(&src)
+	if err != nil || len(b.Variables) != 1 || len(b.Data) != 1 {
+		t.Errorf("t2 Failed. get: %v, err: %v", b, err)
+	}
+
+	src = []byte{22, 1, 2, 0x02, 0x08, 1, 17, 1}
+	if _, err = DecodeWriteRequest(&src); err == nil {
+		t.Errorf("t3 should fail on wrong tag")
+	}
+}
@@ -0,0 +1,156 @@
+package dlms
+
+import (
+	"fmt"
+	"io"
+
+	"gosem/pkg/axdr"
+)
+
+type writeResultTag uint8
+
+const (
+	TagWriteResultSuccess         writeResultTag = 0
+	TagWriteResultDataAccessError writeResultTag = 1
+	TagWriteResultBlockNumber     writeResultTag = 2
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (s writeResultTag) Value() uint8 {
+	return uint8(s)
+}
+
+// WriteResult is one result of WriteResponse. Result is used by
+// TagWriteResultDataAccessError, BlockNumber by TagWriteResultBlockNumber
+type WriteResult struct {
+	Tag         writeResultTag
+	Result      AccessResultTag
+	BlockNumber uint16
+}
+
+func CreateWriteResultAsSuccess() *WriteResult {
+	return &WriteResult{Tag: TagWriteResultSuccess}
+}
+
+func CreateWriteResultAsError(result AccessResultTag) *WriteResult {
+	return &WriteResult{Tag: TagWriteResultDataAccessError, Result: result}
+}
+
+func CreateWriteResultAsBlockNumber(blockNum uint16) *WriteResult {
+	return &WriteResult{Tag: TagWriteResultBlockNumber, BlockNumber: blockNum}
+}
+
+func (ws WriteResult) Encode() (out []byte, err error) {
+	return ws.appendEncode(nil)
+}
+
+func (ws WriteResult) appendEncode(dst []byte) ([]byte, error) {
+	out := append(dst, ws.Tag.Value())
+
+	switch ws.Tag {
+	case TagWriteResultSuccess:
+	case TagWriteResultDataAccessError:
+		out = append(out, ws.Result.Value())
+	case TagWriteResultBlockNumber:
+		out = appendUint16(out, ws.BlockNumber)
+	default:
+		return dst, fmt.Errorf("write result tag %v is not supported", ws.Tag)
+	}
+	return out, nil
+}
+
+func DecodeWriteResult(ori *[]byte) (out WriteResult, err error) {
+	src := *ori
+	if len(src) < 1 {
+		err = ErrWrongLength(0, 1)
+		return
+	}
+	out.Tag = writeResultTag(src[0])
+	src = src[1:]
+
+	switch out.Tag {
+	case TagWriteResultSuccess:
+	case TagWriteResultDataAccessError:
+		if len(src) < 1 {
+			err = ErrWrongLength(0, 1)
+			return
+		}
+		out.Result, err = GetAccessTag(src[0])
+		src = src[1:]
+	case TagWriteResultBlockNumber:
+		out.BlockNumber, err = readUint16(&src)
+	default:
+		err = errDecode(0, fmt.Errorf("write result tag %v is not supported", out.Tag))
+	}
+	if err != nil {
+		return
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
+
+// WriteResponse implement CosemPDU, it holds one result for every variable
+// of WriteRequest
+type WriteResponse struct {
+	Results []WriteResult
+}
+
+// CreateWriteResponse creates WriteResponse, results cannot be empty or else
+// it will fail on Encode()
+func CreateWriteResponse(results []WriteResult) *WriteResponse {
+	return &WriteResponse{Results: results}
+}
+
+func (wr WriteResponse) Encode() (out []byte, err error) {
+	return wr.AppendEncode(nil)
+}
+
+func (wr WriteResponse) AppendEncode(dst []byte) ([]byte, error) {
+	if len(wr.Results) < 1 {
+		return dst, fmt.Errorf("results cannot have zero member")
+	}
+	out, err := axdr.AppendLength(append(dst, TagWriteResponse.Value()), len(wr.Results))
+	if err != nil {
+		return dst, err
+	}
+	for _, res := range wr.Results {
+		if out, err = res.appendEncode(out); err != nil {
+			return dst, err
+		}
+	}
+	return out, nil
+}
+
+func (wr WriteResponse) EncodeTo(w io.Writer) error { return encodeTo(w, wr) }
+
+func DecodeWriteResponse(ori *[]byte) (out WriteResponse, err error) {
+	src := *ori
+
+	if len(src) < 2 {
+		err = ErrWrongLength(len(src), 2)
+		return
+	}
+	if src[0] != TagWriteResponse.Value() {
+		err = ErrWrongTag(0, src[0], byte(TagWriteResponse))
+		return
+	}
+	src = src[1:]
+
+	count, err := readCount(&src)
+	if err != nil {
+		return
+	}
+	for i := 0; i < count; i++ {
+		res, e := DecodeWriteResult(&src)
+		if e != nil {
+			err = e
+			return
+		}
+		out.Results = append(out.Results, res)
+	}
+
+	(*ori) = (*ori)[len((*ori))-len(src):]
+	return
+}
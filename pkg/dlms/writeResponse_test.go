@@ -0,0 +1,47 @@
+package dlms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNew_WriteResponse(t *testing.T) {
+	results := []WriteResult{*CreateWriteResultAsSuccess(), *CreateWriteResultAsError(TagAccReadWriteDenied), *CreateWriteResultAsBlockNumber(5)}
+	var a WriteResponse = *CreateWriteResponse(results)
+	t1, e := a.Encode()
+	if e != nil {
+		t.Errorf("t1 Encode Failed. err: %v", e)
+	}
+	result := []byte{13, 3, 0, 1, 3, 2, 0, 5}
+	res := bytes.Compare(t1, result)
+	if res != 0 {
+		t.Errorf("t1 Failed. get: %d, should:%v", t1, result)
+	}
+
+	var b WriteResponse = *CreateWriteResponse(nil)
+	if _, e = b.Encode(); e == nil {
+		t.Errorf("t2 should fail on empty results")
+	}
+}
+
+func TestDecode_WriteResponse(t *testing.T) {
+	src := []byte{13, 3, 0, 1, 3, 2, 0, 5}
+	a, err := DecodeWriteResponse(&src)
+	if err != nil {
+		t.Fatalf("t1 failed on DecodeWriteResponse. Err: %v", err)
+	}
+	should := []WriteResult{*CreateWriteResultAsSuccess(), *CreateWriteResultAsError(TagAccReadWriteDenied), *CreateWriteResultAsBlockNumber(5)}
+	if len(a.Results) != len(should) {
+		t.Fatalf("t1 Failed. get: %v", a)
+	}
+	for i := range should {
+		if a.Results[i] != should[i] {
+			t.Errorf("t1 result %d Failed. get: %v, should:%v", i, a.Results[i], should[i])
+		}
+	}
+
+	src = []byte{13, 1, 4}
+	if _, err = DecodeWriteResponse(&src); err == nil {
+		t.Errorf("t2 should fail on unknown result tag")
+	}
+}
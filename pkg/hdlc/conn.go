@@ -0,0 +1,284 @@
+package hdlc
+
+import (
+	"fmt"
+	"io"
+)
+
+// Conn is client side of HDLC connection over rw, e.g. a serial port or
+// optical probe. Parameters holds values proposed on Connect and, after
+// Connect, the negotiated values seen from client.
+type Conn struct {
+	Client     Address
+	Server     Address
+	Parameters Parameters
+
+	rw     io.ReadWriter
+	buffer []byte
+	ns     uint8
+	nr     uint8
+}
+
+func NewConn(rw io.ReadWriter, client Address, server Address) *Conn {
+	return &Conn{
+		Client:     client,
+		Server:     server,
+		Parameters: DefaultParameters(),
+		rw:         rw,
+	}
+}
+
+func (c *Conn) writeFrame(f Frame) error {
+	out, err := f.Encode()
+	if err != nil {
+		return err
+	}
+	_, err = c.rw.Write(out)
+	return err
+}
+
+func (c *Conn) sendFrame(control Control, segmented bool, information []byte) error {
+	return c.writeFrame(Frame{
+		Segmented:   segmented,
+		Destination: c.Server,
+		Source:      c.Client,
+		Control:     control,
+		Information: information,
+	})
+}
+
+// ReadFrame reads next frame from rw, bytes before opening of frame are dropped
+func (c *Conn) ReadFrame() (out Frame, err error) {
+	chunk := make([]byte, 256)
+	for {
+		for len(c.buffer) > 0 && c.buffer[0] == flag {
+			c.buffer = c.buffer[1:]
+		}
+		if len(c.buffer) > 0 && c.buffer[0]&0xF0 != formatType {
+			c.buffer = c.buffer[1:]
+			continue
+		}
+		if len(c.buffer) >= 2 {
+			length := frameLength(c.buffer)
+			if len(c.buffer) >= length+1 {
+				if length < 2 || c.buffer[length] != flag {
+					// not a frame boundary, resynchronize on next byte
+					c.buffer = c.buffer[1:]
+					continue
+				}
+				raw := c.buffer[:length]
+				c.buffer = c.buffer[length+1:]
+				return decodeFrameContent(raw)
+			}
+		}
+
+		n, rerr := c.rw.Read(chunk)
+		c.buffer = append(c.buffer, chunk[:n]...)
+		if rerr != nil && n == 0 {
+			err = rerr
+			return
+		}
+	}
+}
+
+// Read frames until one addressed from server to client
+func (c *Conn) readServerFrame() (out Frame, err error) {
+	for {
+		out, err = c.ReadFrame()
+		if err != nil {
+			return
+		}
+		if out.Source == c.Server && out.Destination == c.Client {
+			return
+		}
+	}
+}
+
+func unexpectedFrame(f Frame) error {
+	switch f.Control.Type() {
+	case TypeDM:
+		return fmt.Errorf("server is in disconnected mode")
+	case TypeFRMR:
+		return fmt.Errorf("server rejected frame, FRMR %X", f.Information)
+	}
+	return fmt.Errorf("unexpected %v frame", f.Control.Type())
+}
+
+// Connect sends SNRM with proposed Parameters and negotiates them from UA
+func (c *Conn) Connect() (err error) {
+	if err = c.sendFrame(ControlSNRM, false, c.Parameters.Encode()); err != nil {
+		return
+	}
+	f, err := c.readServerFrame()
+	if err != nil {
+		return
+	}
+	if f.Control.Type() != TypeUA {
+		return unexpectedFrame(f)
+	}
+
+	server, err := DecodeParameters(f.Information)
+	if err != nil {
+		return
+	}
+	proposed := c.Parameters
+	c.Parameters = Parameters{
+		MaxInfoFieldLengthTransmit: minUint16(proposed.MaxInfoFieldLengthTransmit, server.MaxInfoFieldLengthReceive),
+		MaxInfoFieldLengthReceive:  minUint16(proposed.MaxInfoFieldLengthReceive, server.MaxInfoFieldLengthTransmit),
+		WindowSizeTransmit:         minUint32(proposed.WindowSizeTransmit, server.WindowSizeReceive),
+		WindowSizeReceive:          minUint32(proposed.WindowSizeReceive, server.WindowSizeTransmit),
+	}
+	c.ns, c.nr = 0, 0
+	return
+}
+
+// Disconnect sends DISC, server answers with UA or DM if already disconnected
+func (c *Conn) Disconnect() (err error) {
+	if err = c.sendFrame(ControlDISC, false, nil); err != nil {
+		return
+	}
+	f, err := c.readServerFrame()
+	if err != nil {
+		return
+	}
+	if t := f.Control.Type(); t != TypeUA && t != TypeDM {
+		return unexpectedFrame(f)
+	}
+	return
+}
+
+// Send puts LLC header in front of apdu and sends it on as many I-frames
+// as needed by negotiated maximum information field length. Up to
+// negotiated transmit window of frames are sent before waiting for RR,
+// frames which server did not acknowledge are sent again
+func (c *Conn) Send(apdu []byte) (err error) {
+	maxInfo := int(c.Parameters.MaxInfoFieldLengthTransmit)
+	if maxInfo < 1 {
+		return fmt.Errorf("max information field length is zero")
+	}
+	window := windowSize(c.Parameters.WindowSizeTransmit)
+
+	var segments [][]byte
+	data := append(append([]byte(nil), LLCCommand...), apdu...)
+	for len(data) > 0 {
+		size := len(data)
+		if size > maxInfo {
+			size = maxInfo
+		}
+		segments = append(segments, data[:size])
+		data = data[size:]
+	}
+
+	for first := 0; first < len(segments); {
+		ns := c.ns
+		end := first + window
+		if end > len(segments) {
+			end = len(segments)
+		}
+		for i := first; i < end; i++ {
+			segmented := i < len(segments)-1
+			if err = c.sendFrame(ControlI((ns+uint8(i-first))&0x07, c.nr, i == end-1), segmented, segments[i]); err != nil {
+				return
+			}
+		}
+		if end == len(segments) {
+			c.ns = (ns + uint8(end-first)) & 0x07
+			return
+		}
+
+		// server acknowledges the window, N(R) is the next frame it expects
+		f, rerr := c.readServerFrame()
+		if rerr != nil {
+			return rerr
+		}
+		if f.Control.Type() != TypeRR {
+			return unexpectedFrame(f)
+		}
+		acked := int((f.Control.ReceiveSequence() - ns) & 0x07)
+		if acked == 0 || acked > end-first {
+			return fmt.Errorf("server acknowledged N(R) %v, expected %v", f.Control.ReceiveSequence(), (ns+uint8(end-first))&0x07)
+		}
+		c.ns = f.Control.ReceiveSequence()
+		first += acked
+	}
+	return
+}
+
+// Receive reads I-frames until the last segment and returns reassembled
+// APDU without LLC header. Segments are acknowledged with RR when server
+// sets final bit or negotiated receive window is full. N(R) of every
+// I-frame must acknowledge all frames sent, or some of them were lost
+func (c *Conn) Receive() (out []byte, err error) {
+	window := windowSize(c.Parameters.WindowSizeReceive)
+	var data []byte
+	received := 0
+	for {
+		f, rerr := c.readServerFrame()
+		if rerr != nil {
+			return nil, rerr
+		}
+		if f.Control.Type() != TypeI {
+			return nil, unexpectedFrame(f)
+		}
+		if f.Control.SendSequence() != c.nr {
+			return nil, fmt.Errorf("server sent N(S) %v, expected %v", f.Control.SendSequence(), c.nr)
+		}
+		if f.Control.ReceiveSequence() != c.ns {
+			return nil, fmt.Errorf("server acknowledged N(R) %v, expected %v", f.Control.ReceiveSequence(), c.ns)
+		}
+		c.nr = (c.nr + 1) & 0x07
+		data = append(data, f.Information...)
+		received++
+
+		if !f.Segmented {
+			break
+		}
+		if !f.Control.Poll() && received < window {
+			continue
+		}
+		if err = c.sendFrame(ControlRR(c.nr, true), false, nil); err != nil {
+			return
+		}
+		received = 0
+	}
+
+	if len(data) < len(LLCResponse) || data[0] != LLCResponse[0] || data[1] != LLCResponse[1] {
+		err = fmt.Errorf("LLC header %X is not a response", data)
+		return
+	}
+	out = data[len(LLCResponse):]
+	return
+}
+
+// Transaction sends apdu and returns the response APDU
+func (c *Conn) Transaction(apdu []byte) (out []byte, err error) {
+	if err = c.Send(apdu); err != nil {
+		return
+	}
+	return c.Receive()
+}
+
+// Frames sent or received before acknowledge. Sequence numbers are
+// modulo 8, so at most 7 frames wait for it
+func windowSize(negotiated uint32) int {
+	if negotiated < 1 {
+		return 1
+	} else if negotiated > 7 {
+		return 7
+	}
+	return int(negotiated)
+}
+
+func minUint16(a uint16, b uint16) uint16 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func minUint32(a uint32, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
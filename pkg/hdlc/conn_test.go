@@ -0,0 +1,284 @@
+package hdlc
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// meter plays server side of HDLC over the other end of pipe. I-frames
+// counted from 1 in lose are dropped, windows holds number of I-frames
+// received in sequence before each RR. Responses are sent in windows of
+// params.WindowSizeTransmit frames, the last of them with final bit set
+// unless noFinal, acks counts RR received for them. N(R) of responses
+// is lowered by unacked, as if frames of client were lost
+type meter struct {
+	conn    *Conn
+	client  Address
+	server  Address
+	params  Parameters
+	ns, nr  uint8
+	maxInfo int
+	lose    map[int]bool
+	frames  int
+	window  int
+	windows []int
+	noFinal bool
+	acks    int
+	unacked uint8
+}
+
+func newMeter(rw net.Conn, params Parameters) *meter {
+	client, server := ClientAddress(16), ServerAddress(1, 17, 2)
+	return &meter{conn: NewConn(rw, server, client), client: client, server: server, params: params}
+}
+
+func (m *meter) reply(control Control, segmented bool, information []byte) error {
+	return m.conn.writeFrame(Frame{Segmented: segmented, Destination: m.client, Source: m.server, Control: control, Information: information})
+}
+
+// serve answers SNRM, echoes every APDU back on responses and stops on DISC
+func (m *meter) serve(t *testing.T) {
+	var data []byte
+	for {
+		f, err := m.conn.ReadFrame()
+		if err != nil {
+			return
+		}
+		switch f.Control.Type() {
+		case TypeSNRM:
+			proposed, _ := DecodeParameters(f.Information)
+			m.maxInfo = int(minUint16(m.params.MaxInfoFieldLengthTransmit, proposed.MaxInfoFieldLengthReceive))
+			m.reply(ControlUA, false, m.params.Encode())
+		case TypeDISC:
+			m.reply(ControlUA, false, nil)
+			return
+		case TypeI:
+			m.frames++
+			if m.lose[m.frames] {
+				continue
+			}
+			inSequence := f.Control.SendSequence() == m.nr
+			if inSequence {
+				m.nr = (m.nr + 1) & 0x07
+				data = append(data, f.Information...)
+				m.window++
+			}
+			if f.Segmented || !inSequence {
+				// frame out of sequence is dropped, RR asks for it again
+				if f.Control.Poll() {
+					m.windows = append(m.windows, m.window)
+					m.window = 0
+					m.reply(ControlRR(m.nr, true), false, nil)
+				}
+				continue
+			}
+			m.window = 0
+			if !bytes.HasPrefix(data, LLCCommand) {
+				t.Errorf("meter received APDU without LLC header: %X", data)
+			}
+			response := append(append([]byte(nil), LLCResponse...), data[len(LLCCommand):]...)
+			data = nil
+			window := windowSize(m.params.WindowSizeTransmit)
+			sent := 0
+			for len(response) > 0 {
+				size := len(response)
+				if size > m.maxInfo {
+					size = m.maxInfo
+				}
+				segmented := size < len(response)
+				sent++
+				final := !segmented || (sent == window && !m.noFinal)
+				m.reply(ControlI(m.ns, (m.nr-m.unacked)&0x07, final), segmented, response[:size])
+				m.ns = (m.ns + 1) & 0x07
+				response = response[size:]
+				if segmented && sent == window {
+					sent = 0
+					rr, err := m.conn.ReadFrame()
+					if err != nil || rr.Control.Type() != TypeRR || rr.Control.ReceiveSequence() != m.ns {
+						t.Errorf("meter expects RR(%v), get: %v, err:%v", m.ns, rr, err)
+						return
+					}
+					m.acks++
+				}
+			}
+		default:
+			m.reply(ControlFRMR, false, nil)
+		}
+	}
+}
+
+func TestConn(t *testing.T) {
+	clientSide, meterSide := net.Pipe()
+	defer clientSide.Close()
+	defer meterSide.Close()
+
+	m := newMeter(meterSide, Parameters{MaxInfoFieldLengthTransmit: 32, MaxInfoFieldLengthReceive: 20, WindowSizeTransmit: 1, WindowSizeReceive: 1})
+	done := make(chan struct{})
+	go func() {
+		m.serve(t)
+		close(done)
+	}()
+
+	c := NewConn(clientSide, ClientAddress(16), ServerAddress(1, 17, 2))
+	if err := c.Connect(); err != nil {
+		t.Fatalf("t1 Connect failed. err: %v", err)
+	}
+	should := Parameters{MaxInfoFieldLengthTransmit: 20, MaxInfoFieldLengthReceive: 32, WindowSizeTransmit: 1, WindowSizeReceive: 1}
+	if c.Parameters != should {
+		t.Errorf("t1 Failed. get: %v, should:%v", c.Parameters, should)
+	}
+
+	// short APDU fits single frame
+	apdu := []byte{0xC0, 0x01, 0xC1, 0x00, 0x08, 0x00, 0x00, 0x01, 0x00, 0x00, 0xFF, 0x02, 0x00}
+	t2, err := c.Transaction(apdu)
+	if err != nil || bytes.Compare(t2, apdu) != 0 {
+		t.Errorf("t2 Failed. get: %X, should:%X, err:%v", t2, apdu, err)
+	}
+
+	// long APDU is segmented both ways
+	apdu = make([]byte, 200)
+	for i := range apdu {
+		apdu[i] = byte(i)
+	}
+	t3, err := c.Transaction(apdu)
+	if err != nil || bytes.Compare(t3, apdu) != 0 {
+		t.Errorf("t3 Failed. get: %X, should:%X, err:%v", t3, apdu, err)
+	}
+
+	if err = c.Disconnect(); err != nil {
+		t.Errorf("t4 Disconnect failed. err: %v", err)
+	}
+	<-done
+}
+
+func TestConn_Rejected(t *testing.T) {
+	clientSide, meterSide := net.Pipe()
+	defer clientSide.Close()
+	defer meterSide.Close()
+
+	go func() {
+		m := newMeter(meterSide, DefaultParameters())
+		if _, err := m.conn.ReadFrame(); err == nil {
+			m.reply(ControlDM, false, nil)
+		}
+	}()
+
+	c := NewConn(clientSide, ClientAddress(16), ServerAddress(1, 17, 2))
+	if err := c.Connect(); err == nil {
+		t.Errorf("Connect answered by DM should fail")
+	}
+}
+
+func TestConn_ReceiveWindow(t *testing.T) {
+	for i, noFinal := range []bool{false, true} {
+		clientSide, meterSide := net.Pipe()
+		// RR sent too early would block the pipe, deadline makes it fail
+		clientSide.SetDeadline(time.Now().Add(time.Second))
+		meterSide.SetDeadline(time.Now().Add(time.Second))
+
+		m := newMeter(meterSide, Parameters{MaxInfoFieldLengthTransmit: 32, MaxInfoFieldLengthReceive: 0xFF, WindowSizeTransmit: 3, WindowSizeReceive: 1})
+		m.noFinal = noFinal
+		done := make(chan struct{})
+		go func() {
+			m.serve(t)
+			close(done)
+		}()
+
+		c := NewConn(clientSide, ClientAddress(16), ServerAddress(1, 17, 2))
+		c.Parameters.WindowSizeReceive = 3
+		if err := c.Connect(); err != nil {
+			t.Fatalf("t%d Connect failed. err: %v", i, err)
+		}
+
+		// 3 bytes of LLC header and 200 bytes of APDU make 7 frames of 32
+		// bytes, acknowledged after the 3rd and the 6th
+		apdu := make([]byte, 200)
+		for j := range apdu {
+			apdu[j] = byte(j)
+		}
+		get, err := c.Transaction(apdu)
+		if err != nil || bytes.Compare(get, apdu) != 0 {
+			t.Errorf("t%d Failed. get: %X, should:%X, err:%v", i, get, apdu, err)
+		}
+		if err = c.Disconnect(); err != nil {
+			t.Errorf("t%d Disconnect failed. err: %v", i, err)
+		}
+		<-done
+		if m.acks != 2 {
+			t.Errorf("t%d Failed. RR get: %v, should:2", i, m.acks)
+		}
+		clientSide.Close()
+		meterSide.Close()
+	}
+}
+
+func TestConn_LostFrame(t *testing.T) {
+	clientSide, meterSide := net.Pipe()
+	defer clientSide.Close()
+	defer meterSide.Close()
+
+	// meter acknowledges one frame less than sent
+	m := newMeter(meterSide, Parameters{MaxInfoFieldLengthTransmit: 32, MaxInfoFieldLengthReceive: 0xFF, WindowSizeTransmit: 1, WindowSizeReceive: 1})
+	m.unacked = 1
+	go m.serve(t)
+
+	c := NewConn(clientSide, ClientAddress(16), ServerAddress(1, 17, 2))
+	if err := c.Connect(); err != nil {
+		t.Fatalf("t1 Connect failed. err: %v", err)
+	}
+	if _, err := c.Transaction([]byte{0xC0, 0x01, 0xC1, 0x00}); err == nil {
+		t.Errorf("t1 should fail on N(R) not acknowledging sent frame")
+	}
+}
+
+func TestConn_Window(t *testing.T) {
+	clientSide, meterSide := net.Pipe()
+	defer clientSide.Close()
+	defer meterSide.Close()
+
+	// the second I-frame is lost once
+	m := newMeter(meterSide, Parameters{MaxInfoFieldLengthTransmit: 32, MaxInfoFieldLengthReceive: 20, WindowSizeTransmit: 1, WindowSizeReceive: 3})
+	m.lose = map[int]bool{2: true}
+	done := make(chan struct{})
+	go func() {
+		m.serve(t)
+		close(done)
+	}()
+
+	c := NewConn(clientSide, ClientAddress(16), ServerAddress(1, 17, 2))
+	c.Parameters.WindowSizeTransmit = 7
+	if err := c.Connect(); err != nil {
+		t.Fatalf("t1 Connect failed. err: %v", err)
+	}
+	if c.Parameters.WindowSizeTransmit != 3 {
+		t.Errorf("t1 Failed. window get: %v, should:3", c.Parameters.WindowSizeTransmit)
+	}
+
+	// 3 bytes of LLC header and 200 bytes of APDU make 11 frames of 20 bytes
+	apdu := make([]byte, 200)
+	for i := range apdu {
+		apdu[i] = byte(i)
+	}
+	t2, err := c.Transaction(apdu)
+	if err != nil || bytes.Compare(t2, apdu) != 0 {
+		t.Errorf("t2 Failed. get: %X, should:%X, err:%v", t2, apdu, err)
+	}
+	if err = c.Disconnect(); err != nil {
+		t.Errorf("t3 Disconnect failed. err: %v", err)
+	}
+	<-done
+
+	// frame 1 alone as 2 is lost, then windows of 3 from frame 2 again
+	should := []int{1, 3, 3, 3}
+	if len(m.windows) != len(should) {
+		t.Fatalf("t2 Failed. windows get: %v, should:%v", m.windows, should)
+	}
+	for i := range should {
+		if m.windows[i] != should[i] {
+			t.Errorf("t2 Failed. windows get: %v, should:%v", m.windows, should)
+			break
+		}
+	}
+}
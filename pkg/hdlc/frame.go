@@ -0,0 +1,183 @@
+package hdlc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	flag = 0x7E
+	// frame format type 3
+	formatType     = 0xA0
+	segmentBit     = 0x08
+	maxFrameLength = 0x07FF
+)
+
+// LLC headers put in front of the first I-frame of every APDU
+var (
+	LLCCommand  = []byte{0xE6, 0xE6, 0x00}
+	LLCResponse = []byte{0xE6, 0xE7, 0x00}
+)
+
+// Frame is single HDLC frame of format type 3. Segmented is set on
+// every segment of an APDU except the last one.
+type Frame struct {
+	Segmented   bool
+	Destination Address
+	Source      Address
+	Control     Control
+	Information []byte
+}
+
+var fcsTable = func() (table [256]uint16) {
+	for i := 0; i < 256; i++ {
+		v := uint16(i)
+		for b := 0; b < 8; b++ {
+			if v&1 == 1 {
+				v = (v >> 1) ^ 0x8408
+			} else {
+				v >>= 1
+			}
+		}
+		table[i] = v
+	}
+	return
+}()
+
+// CRC-16/X.25 used for both HCS and FCS, transmitted least significant byte first
+func checksum(data []byte) uint16 {
+	fcs := uint16(0xFFFF)
+	for _, b := range data {
+		fcs = (fcs >> 8) ^ fcsTable[(fcs^uint16(b))&0xFF]
+	}
+	return ^fcs
+}
+
+func appendChecksum(buf *bytes.Buffer) {
+	var crc [2]byte
+	binary.LittleEndian.PutUint16(crc[:], checksum(buf.Bytes()))
+	buf.Write(crc[:])
+}
+
+// Encode frame including opening and closing flag
+func (f Frame) Encode() (out []byte, err error) {
+	dst, err := f.Destination.Encode()
+	if err != nil {
+		return
+	}
+	src, err := f.Source.Encode()
+	if err != nil {
+		return
+	}
+
+	length := 2 + len(dst) + len(src) + 1 + 2
+	if len(f.Information) > 0 {
+		length += 2 + len(f.Information)
+	}
+	if length > maxFrameLength {
+		err = fmt.Errorf("frame length %v is bigger than %v", length, maxFrameLength)
+		return
+	}
+
+	var buf bytes.Buffer
+	format := byte(formatType) | byte(length>>8)&0x07
+	if f.Segmented {
+		format |= segmentBit
+	}
+	buf.WriteByte(format)
+	buf.WriteByte(byte(length))
+	buf.Write(dst)
+	buf.Write(src)
+	buf.WriteByte(byte(f.Control))
+	if len(f.Information) > 0 {
+		appendChecksum(&buf)
+		buf.Write(f.Information)
+	}
+	appendChecksum(&buf)
+
+	out = make([]byte, 0, buf.Len()+2)
+	out = append(out, flag)
+	out = append(out, buf.Bytes()...)
+	out = append(out, flag)
+	return
+}
+
+// DecodeFrame reads single frame from src, opening flag is optional
+// because consecutive frames may share one flag
+func DecodeFrame(ori *[]byte) (out Frame, err error) {
+	src := (*ori)[:]
+	if len(src) > 0 && src[0] == flag {
+		src = src[1:]
+	}
+	if len(src) < 2 {
+		err = fmt.Errorf("frame is too short, %v bytes", len(src))
+		return
+	}
+	length := frameLength(src)
+	if len(src) < length+1 {
+		err = fmt.Errorf("frame length is %v, only %v bytes received", length, len(src))
+		return
+	}
+	if src[length] != flag {
+		err = fmt.Errorf("frame is not closed by flag")
+		return
+	}
+
+	out, err = decodeFrameContent(src[:length])
+	if err != nil {
+		return
+	}
+	(*ori) = src[length+1:]
+	return
+}
+
+// Length of frame without flags, taken from frame format field
+func frameLength(src []byte) int {
+	return int(src[0]&0x07)<<8 | int(src[1])
+}
+
+// Decode frame without flags
+func decodeFrameContent(raw []byte) (out Frame, err error) {
+	if raw[0]&0xF0 != formatType {
+		err = fmt.Errorf("frame format 0x%02X is not type 3", raw[0])
+		return
+	}
+	if len(raw) < 2+1+1+1+2 {
+		err = fmt.Errorf("frame length %v is too short", len(raw))
+		return
+	}
+	if checksum(raw[:len(raw)-2]) != binary.LittleEndian.Uint16(raw[len(raw)-2:]) {
+		err = fmt.Errorf("frame check sequence is not valid")
+		return
+	}
+	out.Segmented = raw[0]&segmentBit != 0
+
+	src := raw[2 : len(raw)-2]
+	if out.Destination, err = DecodeAddress(&src); err != nil {
+		return
+	}
+	if out.Source, err = DecodeAddress(&src); err != nil {
+		return
+	}
+	if len(src) < 1 {
+		err = fmt.Errorf("frame has no control field")
+		return
+	}
+	out.Control = Control(src[0])
+	src = src[1:]
+
+	if len(src) > 0 {
+		if len(src) < 2 {
+			err = fmt.Errorf("frame has no header check sequence")
+			return
+		}
+		headerLength := len(raw) - 2 - len(src)
+		if checksum(raw[:headerLength]) != binary.LittleEndian.Uint16(src[0:2]) {
+			err = fmt.Errorf("header check sequence is not valid")
+			return
+		}
+		out.Information = append([]byte(nil), src[2:]...)
+	}
+	return
+}
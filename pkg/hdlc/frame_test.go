@@ -0,0 +1,94 @@
+package hdlc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestFrame_Encode(t *testing.T) {
+	// SNRM without information field
+	snrm := Frame{Destination: ServerAddress(1, 0, 1), Source: ClientAddress(16), Control: ControlSNRM}
+	t1, err := snrm.Encode()
+	expected, _ := hex.DecodeString("7EA0070321930F017E")
+	if err != nil || bytes.Compare(t1, expected) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X, err:%v", t1, expected, err)
+	}
+
+	// I-frame with information field carries HCS
+	i := Frame{
+		Destination: ServerAddress(1, 17, 2),
+		Source:      ClientAddress(16),
+		Control:     ControlI(0, 0, true),
+		Information: []byte{0xE6, 0xE6, 0x00, 0xC0, 0x01, 0xC1},
+	}
+	t2, err := i.Encode()
+	if err != nil {
+		t.Fatalf("t2 Failed. err:%v", err)
+	}
+	if t2[0] != flag || t2[len(t2)-1] != flag || frameLength(t2[1:]) != len(t2)-2 {
+		t.Errorf("t2 Failed. get: %X", t2)
+	}
+	if checksum(t2[1:7]) != uint16(t2[7])|uint16(t2[8])<<8 {
+		t.Errorf("t2 HCS is not valid. get: %X", t2)
+	}
+
+	i.Segmented = true
+	t3, _ := i.Encode()
+	if t3[1] != 0xA8 {
+		t.Errorf("t3 Segmentation bit is not set. get: %X", t3)
+	}
+
+	i.Information = make([]byte, 2048)
+	if _, err = i.Encode(); err == nil {
+		t.Errorf("t4 Frame longer than 2047 bytes should fail")
+	}
+}
+
+func TestDecodeFrame(t *testing.T) {
+	src, _ := hex.DecodeString("7EA0070321930F017E")
+	t1, err := DecodeFrame(&src)
+	if err != nil || t1.Control != ControlSNRM || t1.Destination != ServerAddress(1, 0, 1) || t1.Source != ClientAddress(16) || len(src) != 0 {
+		t.Errorf("t1 Failed. get: %v, err:%v", t1, err)
+	}
+
+	frames := []Frame{
+		{Segmented: true, Destination: ClientAddress(16), Source: ServerAddress(1, 17, 2), Control: ControlI(2, 3, false), Information: []byte{1, 2, 3}},
+		{Destination: ClientAddress(16), Source: ServerAddress(1, 17, 2), Control: ControlRR(4, true)},
+	}
+	// consecutive frames share the flag between them
+	var stream []byte
+	for _, f := range frames {
+		encoded, _ := f.Encode()
+		if len(stream) > 0 {
+			encoded = encoded[1:]
+		}
+		stream = append(stream, encoded...)
+	}
+	for i, f := range frames {
+		decoded, err := DecodeFrame(&stream)
+		if err != nil || !reflect.DeepEqual(decoded, f) {
+			t.Errorf("t2 frame %d Failed. get: %v, should:%v, err:%v", i, decoded, f, err)
+		}
+	}
+	if len(stream) != 0 {
+		t.Errorf("t2 Failed. %X left", stream)
+	}
+
+	src, _ = hex.DecodeString("7EA0070321930F027E")
+	if _, err = DecodeFrame(&src); err == nil {
+		t.Errorf("t3 Wrong FCS should fail")
+	}
+
+	encoded, _ := frames[0].Encode()
+	encoded[8] ^= 0xFF
+	if _, err = DecodeFrame(&encoded); err == nil {
+		t.Errorf("t4 Wrong HCS should fail")
+	}
+
+	src, _ = hex.DecodeString("7EA0070321930F01")
+	if _, err = DecodeFrame(&src); err == nil {
+		t.Errorf("t5 Frame without closing flag should fail")
+	}
+}
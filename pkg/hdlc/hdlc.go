@@ -0,0 +1,224 @@
+/*
+Provides HDLC based data link layer of DLMS/COSEM, as standardized by
+IEC 62056-46 and used on serial and optical links. It covers frame
+format type 3, addressing, control field, parameter negotiation and
+segmentation of APDUs over I-frames.
+*/
+
+package hdlc
+
+import (
+	"fmt"
+)
+
+// Address is HDLC address of client or server. Client address is always
+// 1 byte with Logical holding the client SAP. Server address is built of
+// upper HDLC address (Logical, the logical device) and lower HDLC address
+// (Physical), on 1, 2 or 4 bytes.
+type Address struct {
+	Logical  uint16
+	Physical uint16
+	Size     int
+}
+
+// ClientAddress creates 1 byte address of client SAP, e.g. 16 for public client
+func ClientAddress(sap uint8) Address {
+	return Address{Logical: uint16(sap), Size: 1}
+}
+
+// ServerAddress creates server address. Size 1 only holds logical address
+func ServerAddress(logical uint16, physical uint16, size int) Address {
+	return Address{Logical: logical, Physical: physical, Size: size}
+}
+
+// Encode address with extension bit, each byte carries 7 bits and
+// least significant bit set on the last byte
+func (a Address) Encode() (out []byte, err error) {
+	switch a.Size {
+	case 1:
+		if a.Logical > 0x7F || a.Physical != 0 {
+			err = fmt.Errorf("address %v does not fit 1 byte", a)
+			return
+		}
+		out = []byte{byte(a.Logical<<1) | 1}
+	case 2:
+		if a.Logical > 0x7F || a.Physical > 0x7F {
+			err = fmt.Errorf("address %v does not fit 2 bytes", a)
+			return
+		}
+		out = []byte{byte(a.Logical << 1), byte(a.Physical<<1) | 1}
+	case 4:
+		if a.Logical > 0x3FFF || a.Physical > 0x3FFF {
+			err = fmt.Errorf("address %v does not fit 4 bytes", a)
+			return
+		}
+		out = []byte{
+			byte((a.Logical >> 7) << 1),
+			byte((a.Logical & 0x7F) << 1),
+			byte((a.Physical >> 7) << 1),
+			byte((a.Physical&0x7F)<<1) | 1,
+		}
+	default:
+		err = fmt.Errorf("address size %v is not valid, must be 1, 2 or 4", a.Size)
+	}
+	return
+}
+
+// DecodeAddress reads address until byte with extension bit set
+func DecodeAddress(src *[]byte) (out Address, err error) {
+	size := 0
+	for size < len(*src) && size < 4 {
+		size++
+		if (*src)[size-1]&1 == 1 {
+			break
+		}
+	}
+	if size == 0 || (*src)[size-1]&1 != 1 {
+		err = fmt.Errorf("address has no end within %v bytes", size)
+		return
+	}
+
+	bt := (*src)[:size]
+	switch size {
+	case 1:
+		out.Logical = uint16(bt[0] >> 1)
+	case 2:
+		out.Logical = uint16(bt[0] >> 1)
+		out.Physical = uint16(bt[1] >> 1)
+	case 4:
+		out.Logical = uint16(bt[0]>>1)<<7 | uint16(bt[1]>>1)
+		out.Physical = uint16(bt[2]>>1)<<7 | uint16(bt[3]>>1)
+	default:
+		err = fmt.Errorf("address size %v is not valid, must be 1, 2 or 4", size)
+		return
+	}
+	out.Size = size
+	(*src) = (*src)[size:]
+	return
+}
+
+// FrameType is kind of frame, read from control field
+type FrameType uint8
+
+const (
+	TypeUnknown FrameType = iota
+	TypeI
+	TypeRR
+	TypeRNR
+	TypeSNRM
+	TypeDISC
+	TypeUA
+	TypeDM
+	TypeFRMR
+	TypeUI
+)
+
+func (t FrameType) String() string {
+	switch t {
+	case TypeI:
+		return "I"
+	case TypeRR:
+		return "RR"
+	case TypeRNR:
+		return "RNR"
+	case TypeSNRM:
+		return "SNRM"
+	case TypeDISC:
+		return "DISC"
+	case TypeUA:
+		return "UA"
+	case TypeDM:
+		return "DM"
+	case TypeFRMR:
+		return "FRMR"
+	case TypeUI:
+		return "UI"
+	default:
+		return "unknown"
+	}
+}
+
+// Control is the control field of frame. I-frame holds send & receive
+// sequence number, RR & RNR hold receive sequence number.
+type Control uint8
+
+// Unnumbered control fields, with poll/final bit set
+const (
+	ControlSNRM Control = 0x93
+	ControlDISC Control = 0x53
+	ControlUA   Control = 0x73
+	ControlDM   Control = 0x1F
+	ControlFRMR Control = 0x97
+	ControlUI   Control = 0x13
+)
+
+const pollBit = 0x10
+
+// ControlI creates control field of I-frame
+func ControlI(ns uint8, nr uint8, poll bool) Control {
+	c := Control((nr&0x07)<<5 | (ns&0x07)<<1)
+	if poll {
+		c |= pollBit
+	}
+	return c
+}
+
+// ControlRR creates control field of receive ready frame
+func ControlRR(nr uint8, poll bool) Control {
+	c := Control((nr&0x07)<<5 | 0x01)
+	if poll {
+		c |= pollBit
+	}
+	return c
+}
+
+// ControlRNR creates control field of receive not ready frame
+func ControlRNR(nr uint8, poll bool) Control {
+	c := Control((nr&0x07)<<5 | 0x05)
+	if poll {
+		c |= pollBit
+	}
+	return c
+}
+
+func (c Control) Type() FrameType {
+	if c&0x01 == 0 {
+		return TypeI
+	}
+	switch c &^ pollBit &^ 0xE0 {
+	case 0x01:
+		return TypeRR
+	case 0x05:
+		return TypeRNR
+	}
+	switch c &^ pollBit {
+	case ControlSNRM &^ pollBit:
+		return TypeSNRM
+	case ControlDISC &^ pollBit:
+		return TypeDISC
+	case ControlUA &^ pollBit:
+		return TypeUA
+	case ControlDM &^ pollBit:
+		return TypeDM
+	case ControlFRMR &^ pollBit:
+		return TypeFRMR
+	case ControlUI &^ pollBit:
+		return TypeUI
+	}
+	return TypeUnknown
+}
+
+// Poll returns poll/final bit
+func (c Control) Poll() bool {
+	return c&pollBit != 0
+}
+
+// SendSequence returns N(S) of I-frame
+func (c Control) SendSequence() uint8 {
+	return uint8(c>>1) & 0x07
+}
+
+// ReceiveSequence returns N(R) of I, RR and RNR frame
+func (c Control) ReceiveSequence() uint8 {
+	return uint8(c>>5) & 0x07
+}
@@ -0,0 +1,77 @@
+package hdlc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAddress(t *testing.T) {
+	tables := []struct {
+		address Address
+		encoded []byte
+	}{
+		{ClientAddress(16), []byte{0x21}},
+		{ServerAddress(1, 0, 1), []byte{0x03}},
+		{ServerAddress(1, 17, 2), []byte{0x02, 0x23}},
+		{ServerAddress(1, 0x3FFF, 4), []byte{0x00, 0x02, 0xFE, 0xFF}},
+		{ServerAddress(0x1234, 0x0456, 4), []byte{0x48, 0x68, 0x10, 0xAD}},
+	}
+	for i, table := range tables {
+		encoded, err := table.address.Encode()
+		if err != nil || bytes.Compare(encoded, table.encoded) != 0 {
+			t.Errorf("t%d Encode Failed. get: %X, should:%X, err:%v", i, encoded, table.encoded, err)
+		}
+		src := append(encoded, 0xFF)
+		decoded, err := DecodeAddress(&src)
+		if err != nil || decoded != table.address || len(src) != 1 {
+			t.Errorf("t%d Decode Failed. get: %v, should:%v, err:%v", i, decoded, table.address, err)
+		}
+	}
+
+	if _, err := ServerAddress(0x80, 0, 1).Encode(); err == nil {
+		t.Errorf("Logical address 0x80 should not fit 1 byte")
+	}
+	if _, err := ServerAddress(1, 1, 3).Encode(); err == nil {
+		t.Errorf("Address size 3 should fail")
+	}
+	src := []byte{0x02, 0x04, 0x23}
+	if _, err := DecodeAddress(&src); err == nil {
+		t.Errorf("Address of 3 bytes should fail")
+	}
+	src = []byte{0x02, 0x04}
+	if _, err := DecodeAddress(&src); err == nil {
+		t.Errorf("Address without end should fail")
+	}
+}
+
+func TestControl(t *testing.T) {
+	tables := []struct {
+		control Control
+		kind    FrameType
+	}{
+		{ControlSNRM, TypeSNRM},
+		{ControlDISC, TypeDISC},
+		{ControlUA, TypeUA},
+		{ControlDM, TypeDM},
+		{ControlFRMR, TypeFRMR},
+		{ControlUI, TypeUI},
+		{ControlI(3, 5, true), TypeI},
+		{ControlRR(7, true), TypeRR},
+		{ControlRNR(2, false), TypeRNR},
+		{Control(0x0B), TypeUnknown},
+	}
+	for i, table := range tables {
+		if table.control.Type() != table.kind {
+			t.Errorf("t%d Failed. get: %v, should:%v", i, table.control.Type(), table.kind)
+		}
+	}
+
+	c := ControlI(3, 5, true)
+	if c != 0xB6 || c.SendSequence() != 3 || c.ReceiveSequence() != 5 || !c.Poll() {
+		t.Errorf("I control get: %02X, N(S): %v, N(R): %v", uint8(c), c.SendSequence(), c.ReceiveSequence())
+	}
+	c = ControlRR(1, true)
+	if c != 0x31 || c.ReceiveSequence() != 1 {
+		t.Errorf("RR control get: %02X", uint8(c))
+	}
+}
@@ -0,0 +1,110 @@
+package hdlc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// HDLC parameter negotiation carried by SNRM and UA
+const (
+	parameterFormat       = 0x81
+	parameterGroup        = 0x80
+	parameterMaxInfoTx    = 0x05
+	parameterMaxInfoRx    = 0x06
+	parameterWindowSizeTx = 0x07
+	parameterWindowSizeRx = 0x08
+)
+
+// Parameters are seen from sender of the frame: on SNRM transmit is
+// client to server, on UA transmit is server to client.
+type Parameters struct {
+	MaxInfoFieldLengthTransmit uint16
+	MaxInfoFieldLengthReceive  uint16
+	WindowSizeTransmit         uint32
+	WindowSizeReceive          uint32
+}
+
+// DefaultParameters returns values used when parameters are not negotiated
+func DefaultParameters() Parameters {
+	return Parameters{
+		MaxInfoFieldLengthTransmit: 128,
+		MaxInfoFieldLengthReceive:  128,
+		WindowSizeTransmit:         1,
+		WindowSizeReceive:          1,
+	}
+}
+
+func writeParameter(buf *bytes.Buffer, id byte, value uint32, size int) {
+	var bt [4]byte
+	binary.BigEndian.PutUint32(bt[:], value)
+	buf.WriteByte(id)
+	buf.WriteByte(byte(size))
+	buf.Write(bt[4-size:])
+}
+
+// Encode parameters as information field of SNRM or UA
+func (p Parameters) Encode() []byte {
+	var params bytes.Buffer
+	for _, info := range []struct {
+		id    byte
+		value uint16
+	}{{parameterMaxInfoTx, p.MaxInfoFieldLengthTransmit}, {parameterMaxInfoRx, p.MaxInfoFieldLengthReceive}} {
+		if info.value > 0xFF {
+			writeParameter(&params, info.id, uint32(info.value), 2)
+		} else {
+			writeParameter(&params, info.id, uint32(info.value), 1)
+		}
+	}
+	writeParameter(&params, parameterWindowSizeTx, p.WindowSizeTransmit, 4)
+	writeParameter(&params, parameterWindowSizeRx, p.WindowSizeReceive, 4)
+
+	out := []byte{parameterFormat, parameterGroup, byte(params.Len())}
+	return append(out, params.Bytes()...)
+}
+
+// DecodeParameters reads information field of SNRM or UA. Parameter
+// which is not present keeps its default value
+func DecodeParameters(src []byte) (out Parameters, err error) {
+	out = DefaultParameters()
+	if len(src) == 0 {
+		return
+	}
+	if len(src) < 3 || src[0] != parameterFormat || src[1] != parameterGroup {
+		err = fmt.Errorf("parameter negotiation header %X is not valid", src)
+		return
+	}
+	if len(src) < 3+int(src[2]) {
+		err = fmt.Errorf("parameter length %v is longer than %v bytes received", src[2], len(src)-3)
+		return
+	}
+
+	params := src[3 : 3+int(src[2])]
+	for len(params) > 0 {
+		if len(params) < 2 || len(params) < 2+int(params[1]) {
+			err = fmt.Errorf("parameter 0x%02X is truncated", params[0])
+			return
+		}
+		id, size := params[0], int(params[1])
+		if size > 4 {
+			err = fmt.Errorf("parameter 0x%02X of %v bytes is not supported", id, size)
+			return
+		}
+		var bt [4]byte
+		copy(bt[4-size:], params[2:2+size])
+		value := binary.BigEndian.Uint32(bt[:])
+		params = params[2+size:]
+
+		switch id {
+		case parameterMaxInfoTx:
+			out.MaxInfoFieldLengthTransmit = uint16(value)
+		case parameterMaxInfoRx:
+			out.MaxInfoFieldLengthReceive = uint16(value)
+		case parameterWindowSizeTx:
+			out.WindowSizeTransmit = value
+		case parameterWindowSizeRx:
+			out.WindowSizeReceive = value
+		}
+	}
+	return
+}
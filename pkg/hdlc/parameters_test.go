@@ -0,0 +1,54 @@
+package hdlc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestParameters_Encode(t *testing.T) {
+	t1 := DefaultParameters().Encode()
+	result, _ := hex.DecodeString("818012050180060180070400000001080400000001")
+	if bytes.Compare(t1, result) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X", t1, result)
+	}
+
+	params := Parameters{MaxInfoFieldLengthTransmit: 0x400, MaxInfoFieldLengthReceive: 0x80, WindowSizeTransmit: 7, WindowSizeReceive: 1}
+	t2 := params.Encode()
+	result, _ = hex.DecodeString("81801305020400060180070400000007080400000001")
+	if bytes.Compare(t2, result) != 0 {
+		t.Errorf("t2 Failed. get: %X, should:%X", t2, result)
+	}
+}
+
+func TestDecodeParameters(t *testing.T) {
+	src, _ := hex.DecodeString("81801305020400060180070400000007080400000001")
+	t1, err := DecodeParameters(src)
+	should := Parameters{MaxInfoFieldLengthTransmit: 0x400, MaxInfoFieldLengthReceive: 0x80, WindowSizeTransmit: 7, WindowSizeReceive: 1}
+	if err != nil || t1 != should {
+		t.Errorf("t1 Failed. get: %v, should:%v, err:%v", t1, should, err)
+	}
+
+	// missing parameters keep default value
+	src, _ = hex.DecodeString("818004060200F8")
+	t2, err := DecodeParameters(src)
+	should = DefaultParameters()
+	should.MaxInfoFieldLengthReceive = 0xF8
+	if err != nil || t2 != should {
+		t.Errorf("t2 Failed. get: %v, should:%v, err:%v", t2, should, err)
+	}
+
+	t3, err := DecodeParameters(nil)
+	if err != nil || t3 != DefaultParameters() {
+		t.Errorf("t3 Failed. get: %v, err:%v", t3, err)
+	}
+
+	src, _ = hex.DecodeString("8180080502")
+	if _, err = DecodeParameters(src); err == nil {
+		t.Errorf("t4 Truncated parameters should fail")
+	}
+	src, _ = hex.DecodeString("818103050180")
+	if _, err = DecodeParameters(src); err == nil {
+		t.Errorf("t5 Wrong group should fail")
+	}
+}
@@ -0,0 +1,292 @@
+package security
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"gosem/pkg/dlms"
+)
+
+// Context holds everything needed to cipher APDUs of one association.
+// SystemTitle is of this side and used on Wrap, PeerSystemTitle is of the
+// other side and used on Unwrap. InvocationCounter is the counter used by
+// the next Wrap, it is incremented after every Wrap. When Store is set,
+// counters used by Wrap are reserved from it instead, and counters
+// received by Unwrap are checked against it to reject replayed APDUs.
+// Without Store, the last counter received from every peer system title
+// is kept in the context, so replay is rejected for as long as it lives.
+// Context with new keys should be created to start received counters
+// again.
+type Context struct {
+	Security          SecurityControl
+	SystemTitle       []byte
+	PeerSystemTitle   []byte
+	BlockCipherKey    []byte
+	BroadcastKey      []byte
+	AuthenticationKey []byte
+	DedicatedKey      []byte
+	InvocationCounter uint32
+	Store             KeyStore
+
+	received map[string]uint32
+}
+
+func CreateContext(sc SecurityControl, systemTitle []byte, peerSystemTitle []byte, ek []byte, ak []byte) *Context {
+	return &Context{
+		Security:          sc,
+		SystemTitle:       systemTitle,
+		PeerSystemTitle:   peerSystemTitle,
+		BlockCipherKey:    ek,
+		AuthenticationKey: ak,
+	}
+}
+
+// CreateContextFromStore returns context using keys and counters of
+// peerSystemTitle kept by store
+func CreateContextFromStore(store KeyStore, sc SecurityControl, systemTitle []byte, peerSystemTitle []byte) (*Context, error) {
+	keys, err := store.Keys(peerSystemTitle, sc.Suite())
+	if err != nil {
+		return nil, err
+	}
+	c := CreateContext(sc, systemTitle, peerSystemTitle, keys.BlockCipherKey, keys.AuthenticationKey)
+	c.BroadcastKey = keys.BroadcastKey
+	c.Store = store
+	return c, nil
+}
+
+// Select encryption key by security header and ciphered APDU kind
+func (c *Context) encryptionKey(sc SecurityControl, dedicated bool) ([]byte, error) {
+	switch {
+	case dedicated:
+		if len(c.DedicatedKey) == 0 {
+			return nil, fmt.Errorf("dedicated key is not set")
+		}
+		return c.DedicatedKey, nil
+	case sc.Broadcast():
+		if len(c.BroadcastKey) == 0 {
+			return nil, fmt.Errorf("broadcast key is not set")
+		}
+		return c.BroadcastKey, nil
+	default:
+		return c.BlockCipherKey, nil
+	}
+}
+
+// Wrap ciphers pdu into glo-* APDU, or ded-* APDU if dedicated is true,
+// using security policy of the context
+func (c *Context) Wrap(pdu dlms.CosemPDU, dedicated bool) (out *dlms.CipheredAPDU, err error) {
+	plain, err := pdu.Encode()
+	if err != nil {
+		return
+	}
+	if len(plain) < 1 {
+		err = fmt.Errorf("encoded APDU is empty")
+		return
+	}
+
+	tag, err := dlms.CipheredTag(plain[0], dedicated)
+	if err != nil {
+		return
+	}
+	ic, information, err := c.encrypt(plain, dedicated)
+	if err != nil {
+		return
+	}
+
+	out = dlms.CreateCipheredAPDU(tag, c.Security.Value(), ic, information)
+	return
+}
+
+// WrapGeneral ciphers pdu into general-glo-ciphering APDU, or
+// general-ded-ciphering APDU if dedicated is true, which carries
+// SystemTitle. Unlike Wrap, any APDU can be ciphered
+func (c *Context) WrapGeneral(pdu dlms.CosemPDU, dedicated bool) (out *dlms.GeneralCiphering, err error) {
+	plain, err := pdu.Encode()
+	if err != nil {
+		return
+	}
+	if len(plain) < 1 {
+		err = fmt.Errorf("encoded APDU is empty")
+		return
+	}
+
+	ic, information, err := c.encrypt(plain, dedicated)
+	if err != nil {
+		return
+	}
+
+	out = dlms.CreateGeneralCiphering(dedicated, c.SystemTitle, c.Security.Value(), ic, information)
+	return
+}
+
+// Cipher plain APDU with the next invocation counter
+func (c *Context) encrypt(plain []byte, dedicated bool) (ic uint32, information []byte, err error) {
+	ek, err := c.encryptionKey(c.Security, dedicated)
+	if err != nil {
+		return
+	}
+	return c.withCounter(func(ic uint32) ([]byte, error) {
+		return Encrypt(c.Security, c.SystemTitle, ic, ek, c.AuthenticationKey, plain)
+	})
+}
+
+// Run f with the next invocation counter. Counter reserved from Store is
+// never used again even if f fails, InvocationCounter is only incremented
+// when f succeeds
+func (c *Context) withCounter(f func(ic uint32) ([]byte, error)) (ic uint32, out []byte, err error) {
+	if c.Store != nil {
+		if ic, err = c.Store.ReserveCounter(c.PeerSystemTitle, c.Security.Suite(), 1); err != nil {
+			return
+		}
+		out, err = f(ic)
+		return
+	}
+
+	// counter must not wrap to 0, as nonce would be used again with the key
+	if c.InvocationCounter == math.MaxUint32 {
+		err = ErrCounterExhausted
+		return
+	}
+	ic = c.InvocationCounter
+	if out, err = f(ic); err != nil {
+		return
+	}
+	c.InvocationCounter++
+	return
+}
+
+// Record counter received from peer in Store, or in the context when
+// there is no Store. ErrReplay is returned when ic is not bigger than the
+// last one received from systemTitle
+func (c *Context) accept(systemTitle []byte, sc SecurityControl, ic uint32) error {
+	if c.Store != nil {
+		return c.Store.AcceptCounter(systemTitle, sc.Suite(), ic)
+	}
+	if last, ok := c.received[string(systemTitle)]; ok && ic <= last {
+		return ErrReplay
+	}
+	if c.received == nil {
+		c.received = make(map[string]uint32)
+	}
+	c.received[string(systemTitle)] = ic
+	return nil
+}
+
+// Security control of received APDU must be the one of the context, only
+// broadcast key bit may differ. Otherwise peer could downgrade protection,
+// down to plain APDU with security control 0. Authentication needs the
+// key, tag computed without it proves nothing
+func (c *Context) checkPolicy(sc SecurityControl) error {
+	if sc&^SecurityBroadcastKey != c.Security&^SecurityBroadcastKey {
+		return fmt.Errorf("%w: received %#02x, expected %#02x", ErrSecurityPolicy, sc.Value(), c.Security.Value())
+	}
+	if sc.Authenticated() && len(c.AuthenticationKey) == 0 {
+		return fmt.Errorf("%w: authentication key is not set", ErrSecurityPolicy)
+	}
+	return nil
+}
+
+// Unwrap deciphers glo-* or ded-* APDU sent by peer and decodes the
+// plain APDU inside, such as GetResponse or ActionResponse
+func (c *Context) Unwrap(apdu dlms.CipheredAPDU) (out dlms.CosemPDU, err error) {
+	plainTag, dedicated, err := dlms.PlainTag(apdu.Tag)
+	if err != nil {
+		return
+	}
+	sc := SecurityControl(apdu.SecurityHeader)
+	if err = c.checkPolicy(sc); err != nil {
+		return
+	}
+	ek, err := c.encryptionKey(sc, dedicated)
+	if err != nil {
+		return
+	}
+
+	plain, err := Decrypt(sc, c.PeerSystemTitle, apdu.InvocationCounter, ek, c.AuthenticationKey, apdu.Information)
+	if err != nil {
+		return
+	}
+	if err = c.accept(c.PeerSystemTitle, sc, apdu.InvocationCounter); err != nil {
+		return
+	}
+	if len(plain) < 1 || plain[0] != plainTag.Value() {
+		err = fmt.Errorf("deciphered APDU does not match ciphered tag %v", apdu.Tag)
+		return
+	}
+
+	return dlms.DecodeCosem(&plain)
+}
+
+// UnwrapGeneral deciphers general-glo-ciphering or general-ded-ciphering
+// APDU with the system title it carries, and decodes the plain APDU
+// inside. When PeerSystemTitle is set, the carried one must match it
+func (c *Context) UnwrapGeneral(apdu dlms.GeneralCiphering) (out dlms.CosemPDU, err error) {
+	if len(c.PeerSystemTitle) > 0 && !bytes.Equal(c.PeerSystemTitle, apdu.SystemTitle) {
+		err = fmt.Errorf("system title %X is not of peer %X", apdu.SystemTitle, c.PeerSystemTitle)
+		return
+	}
+	sc := SecurityControl(apdu.SecurityHeader)
+	if err = c.checkPolicy(sc); err != nil {
+		return
+	}
+	ek, err := c.encryptionKey(sc, apdu.Dedicated())
+	if err != nil {
+		return
+	}
+
+	plain, err := Decrypt(sc, apdu.SystemTitle, apdu.InvocationCounter, ek, c.AuthenticationKey, apdu.Information)
+	if err != nil {
+		return
+	}
+	if err = c.accept(apdu.SystemTitle, sc, apdu.InvocationCounter); err != nil {
+		return
+	}
+	if len(plain) < 1 {
+		err = fmt.Errorf("deciphered APDU is empty")
+		return
+	}
+
+	return dlms.DecodeCosem(&plain)
+}
+
+// UnwrapBytes decodes src as glo-*, ded-* or general ciphering APDU and
+// unwraps it
+func (c *Context) UnwrapBytes(src *[]byte) (out dlms.CosemPDU, err error) {
+	if isGeneralCiphering(*src) {
+		apdu, err := dlms.DecodeGeneralCiphering(src)
+		if err != nil {
+			return nil, err
+		}
+		return c.UnwrapGeneral(apdu)
+	}
+
+	apdu, err := dlms.DecodeCipheredAPDU(src)
+	if err != nil {
+		return
+	}
+	return c.Unwrap(apdu)
+}
+
+// ContextLookup returns context of peer which system title is given, it
+// is used by receiver which is not bound to a single peer
+type ContextLookup func(systemTitle []byte) (*Context, error)
+
+// UnwrapBySystemTitle deciphers general ciphering APDU by context of its
+// sender found by lookup. The context is returned with the plain APDU, so
+// the answer can be ciphered by it
+func UnwrapBySystemTitle(lookup ContextLookup, apdu dlms.GeneralCiphering) (out dlms.CosemPDU, c *Context, err error) {
+	if c, err = lookup(apdu.SystemTitle); err != nil {
+		return
+	}
+	if c == nil {
+		err = fmt.Errorf("no context of system title %X", apdu.SystemTitle)
+		return
+	}
+	out, err = c.UnwrapGeneral(apdu)
+	return
+}
+
+func isGeneralCiphering(src []byte) bool {
+	return len(src) > 0 && (src[0] == dlms.TagGeneralGloCiphering.Value() || src[0] == dlms.TagGeneralDedCiphering.Value())
+}
@@ -0,0 +1,214 @@
+package security
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"gosem/pkg/dlms"
+)
+
+func TestContext_Wrap(t *testing.T) {
+	ctx := CreateContext(SecurityAuthenticatedEncryption, testSystemTitle, testSystemTitle, testEK, testAK)
+	ctx.InvocationCounter = testIC
+
+	attrDesc := *dlms.CreateAttributeDescriptor(8, "0.0.1.0.0.255", 2)
+	pdu := dlms.CreateGetRequestNormal(0x0, attrDesc, nil)
+	apdu, err := ctx.Wrap(pdu, false)
+	if err != nil {
+		t.Fatalf("t1 Wrap failed. err: %v", err)
+	}
+	t1, err := apdu.Encode()
+	result, _ := hex.DecodeString("C81E30012345674113" + "12FF935A47566827C467BC7D825C3BE4A77C3FCC056B6B")
+	if err != nil || bytes.Compare(t1, result) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X, err:%v", t1, result, err)
+	}
+	if ctx.InvocationCounter != testIC+1 {
+		t.Errorf("t1 InvocationCounter should be incremented, get: %X", ctx.InvocationCounter)
+	}
+
+	// unwrap what was just wrapped, system title of both side is the same
+	plain, err := ctx.UnwrapBytes(&t1)
+	if err != nil {
+		t.Fatalf("t2 Unwrap failed. err: %v", err)
+	}
+	gr, ok := plain.(dlms.GetRequestNormal)
+	if !ok || gr.AttributeInfo.ClassId != 8 {
+		t.Errorf("t2 Unwrap get: %v", plain)
+	}
+
+	// ded-* uses dedicated key
+	_, err = ctx.Wrap(pdu, true)
+	if err == nil {
+		t.Errorf("t3 should fail without dedicated key")
+	}
+	ctx.DedicatedKey = testAK
+	apdu, err = ctx.Wrap(pdu, true)
+	if err != nil || apdu.Tag != dlms.TagDedGetRequest {
+		t.Errorf("t4 Wrap failed. get: %v, err: %v", apdu, err)
+	}
+	_, err = ctx.Unwrap(*apdu)
+	if err != nil {
+		t.Errorf("t4 Unwrap failed. err: %v", err)
+	}
+
+	// tampered invocation counter
+	apdu.InvocationCounter++
+	_, err = ctx.Unwrap(*apdu)
+	if err != ErrAuthenticationFailed {
+		t.Errorf("t5 should fail on authentication, get: %v", err)
+	}
+}
+
+func TestContext_WrapGeneral(t *testing.T) {
+	ctx := CreateContext(SecurityAuthenticatedEncryption, testSystemTitle, nil, testEK, testAK)
+	ctx.InvocationCounter = testIC
+
+	attrDesc := *dlms.CreateAttributeDescriptor(8, "0.0.1.0.0.255", 2)
+	pdu := dlms.CreateGetRequestNormal(0x0, attrDesc, nil)
+	apdu, err := ctx.WrapGeneral(pdu, false)
+	if err != nil {
+		t.Fatalf("t1 WrapGeneral failed. err: %v", err)
+	}
+	t1, err := apdu.Encode()
+	result, _ := hex.DecodeString("DB084D4D4D0000BC614E" + "1E30012345674113" + "12FF935A47566827C467BC7D825C3BE4A77C3FCC056B6B")
+	if err != nil || bytes.Compare(t1, result) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X, err:%v", t1, result, err)
+	}
+
+	// receiver without peer system title takes the carried one
+	plain, err := ctx.UnwrapBytes(&t1)
+	if err != nil {
+		t.Fatalf("t2 Unwrap failed. err: %v", err)
+	}
+	if _, ok := plain.(dlms.GetRequestNormal); !ok {
+		t.Errorf("t2 Unwrap get: %v", plain)
+	}
+
+	ctx.PeerSystemTitle = testEK[:8]
+	if _, err = ctx.UnwrapGeneral(*apdu); err == nil {
+		t.Errorf("t3 should fail on system title of another peer")
+	}
+
+	// general-ded-ciphering uses dedicated key, any APDU can be carried
+	ctx.PeerSystemTitle = nil
+	ctx.DedicatedKey = testAK
+	apdu, err = ctx.WrapGeneral(dlms.CreateGeneralBlockTransfer(true, false, 1, 1, 0, []byte{1, 2}), true)
+	if err != nil || apdu.Tag != dlms.TagGeneralDedCiphering {
+		t.Fatalf("t4 WrapGeneral failed. get: %v, err: %v", apdu, err)
+	}
+
+	contexts := map[string]*Context{string(testSystemTitle): ctx}
+	lookup := func(systemTitle []byte) (*Context, error) {
+		return contexts[string(systemTitle)], nil
+	}
+	plain, found, err := UnwrapBySystemTitle(lookup, *apdu)
+	if err != nil || found != ctx {
+		t.Fatalf("t4 Unwrap failed. err: %v", err)
+	}
+	if _, ok := plain.(dlms.GeneralBlockTransfer); !ok {
+		t.Errorf("t4 Unwrap get: %v", plain)
+	}
+
+	apdu.SystemTitle = testEK[:8]
+	if _, _, err = UnwrapBySystemTitle(lookup, *apdu); err == nil {
+		t.Errorf("t5 should fail on unknown system title")
+	}
+}
+
+func TestContext_UnwrapPolicy(t *testing.T) {
+	ctx := CreateContext(SecurityAuthenticatedEncryption, testSystemTitle, testSystemTitle, testEK, testAK)
+	attrDesc := *dlms.CreateAttributeDescriptor(8, "0.0.1.0.0.255", 2)
+	pdu := dlms.CreateGetRequestNormal(0x0, attrDesc, nil)
+	plain, _ := pdu.Encode()
+
+	// forged APDU without any protection
+	forged := dlms.CreateCipheredAPDU(dlms.TagGloGetRequest, SecurityNone.Value(), 1, plain)
+	if _, err := ctx.Unwrap(*forged); !errors.Is(err, ErrSecurityPolicy) {
+		t.Errorf("t1 Failed. err:%v", err)
+	}
+	general := dlms.CreateGeneralCiphering(false, testSystemTitle, SecurityNone.Value(), 1, plain)
+	if _, err := ctx.UnwrapGeneral(*general); !errors.Is(err, ErrSecurityPolicy) {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+
+	// authentication only is weaker than the policy
+	weak := CreateContext(SecurityAuthentication, testSystemTitle, testSystemTitle, testEK, testAK)
+	apdu, _ := weak.Wrap(pdu, false)
+	if _, err := ctx.Unwrap(*apdu); !errors.Is(err, ErrSecurityPolicy) {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+	generalApdu, _ := weak.WrapGeneral(pdu, false)
+	if _, err := ctx.UnwrapGeneral(*generalApdu); !errors.Is(err, ErrSecurityPolicy) {
+		t.Errorf("t4 Failed. err:%v", err)
+	}
+
+	// broadcast key bit is allowed
+	ctx.BroadcastKey = testAK
+	broadcast := CreateContext(SecurityAuthenticatedEncryption|SecurityBroadcastKey, testSystemTitle, testSystemTitle, nil, testAK)
+	broadcast.BroadcastKey = testAK
+	apdu, _ = broadcast.Wrap(pdu, false)
+	if _, err := ctx.Unwrap(*apdu); err != nil {
+		t.Errorf("t5 Failed. err:%v", err)
+	}
+
+	// authentication without key is refused
+	noKey := CreateContext(SecurityAuthenticatedEncryption, testSystemTitle, testSystemTitle, testEK, nil)
+	apdu, _ = noKey.Wrap(pdu, false)
+	if _, err := noKey.Unwrap(*apdu); !errors.Is(err, ErrSecurityPolicy) {
+		t.Errorf("t6 Failed. err:%v", err)
+	}
+}
+
+func TestContext_Replay(t *testing.T) {
+	sender := CreateContext(SecurityAuthenticatedEncryption, testSystemTitle, nil, testEK, testAK)
+	receiver := CreateContext(SecurityAuthenticatedEncryption, nil, testSystemTitle, testEK, testAK)
+	attrDesc := *dlms.CreateAttributeDescriptor(8, "0.0.1.0.0.255", 2)
+	pdu := dlms.CreateGetRequestNormal(0x0, attrDesc, nil)
+
+	first, _ := sender.Wrap(pdu, false)
+	second, _ := sender.Wrap(pdu, false)
+	if _, err := receiver.Unwrap(*second); err != nil {
+		t.Fatalf("t1 Unwrap failed. err: %v", err)
+	}
+	if _, err := receiver.Unwrap(*second); err != ErrReplay {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+	if _, err := receiver.Unwrap(*first); err != ErrReplay {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+
+	// counters are kept per system title of sender
+	receiver.PeerSystemTitle = nil
+	other := CreateContext(SecurityAuthenticatedEncryption, testEK[:8], nil, testEK, testAK)
+	general, _ := other.WrapGeneral(pdu, false)
+	if _, err := receiver.UnwrapGeneral(*general); err != nil {
+		t.Errorf("t4 Failed. err:%v", err)
+	}
+	general, _ = sender.WrapGeneral(pdu, false)
+	if _, err := receiver.UnwrapGeneral(*general); err != nil {
+		t.Errorf("t5 Failed. err:%v", err)
+	}
+	if _, err := receiver.UnwrapGeneral(*general); err != ErrReplay {
+		t.Errorf("t6 Failed. err:%v", err)
+	}
+}
+
+func TestContext_CounterExhausted(t *testing.T) {
+	ctx := CreateContext(SecurityAuthenticatedEncryption, testSystemTitle, testSystemTitle, testEK, testAK)
+	ctx.InvocationCounter = 0xFFFFFFFE
+	attrDesc := *dlms.CreateAttributeDescriptor(8, "0.0.1.0.0.255", 2)
+	pdu := dlms.CreateGetRequestNormal(0x0, attrDesc, nil)
+
+	apdu, err := ctx.Wrap(pdu, false)
+	if err != nil || apdu.InvocationCounter != 0xFFFFFFFE {
+		t.Fatalf("t1 Wrap failed. get: %v, err: %v", apdu, err)
+	}
+	if _, err = ctx.Wrap(pdu, false); err != ErrCounterExhausted {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+	if _, err = ctx.WrapGeneral(pdu, false); err != ErrCounterExhausted || ctx.InvocationCounter != 0xFFFFFFFF {
+		t.Errorf("t3 Failed. counter: %X, err:%v", ctx.InvocationCounter, err)
+	}
+}
@@ -0,0 +1,260 @@
+package security
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// How many invocation counters FileKeyStore writes ahead at once
+const DefaultCounterBlock = 100
+
+// FileKeyStore is KeyStore persisted as JSON file. Keys are written to
+// the file before the method returns. Counters to send are reserved in
+// the file CounterBlock at a time, so a reserved invocation counter is
+// never given again after restart, at most CounterBlock counters are
+// skipped. Received counter is written when it is CounterBlock or more
+// past the one in the file, and by Flush. When process ends without
+// Flush, APDUs received since the last write, less than CounterBlock
+// counters of each peer, may be replayed after restart.
+type FileKeyStore struct {
+	CounterBlock uint32
+
+	path   string
+	mutex  sync.Mutex
+	memory *MemoryKeyStore
+	saved  map[storeKey]fileCounters
+}
+
+// Counters as they are written in the file
+type fileCounters struct {
+	next         uint64
+	lastReceived uint32
+	received     bool
+}
+
+type fileEntry struct {
+	SystemTitle       string `json:"system_title"`
+	Suite             uint8  `json:"suite"`
+	BlockCipherKey    string `json:"block_cipher_key,omitempty"`
+	BroadcastKey      string `json:"broadcast_key,omitempty"`
+	AuthenticationKey string `json:"authentication_key,omitempty"`
+	MasterKey         string `json:"master_key,omitempty"`
+	NextCounter       uint64 `json:"next_counter"`
+	LastReceived      uint32 `json:"last_received"`
+	Received          bool   `json:"received"`
+}
+
+// OpenFileKeyStore loads key store from path, the file is created on the
+// first change if it does not exist
+func OpenFileKeyStore(path string) (*FileKeyStore, error) {
+	s := &FileKeyStore{CounterBlock: DefaultCounterBlock, path: path, memory: NewMemoryKeyStore(), saved: make(map[storeKey]fileCounters)}
+	src, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fileEntry
+	if err = json.Unmarshal(src, &entries); err != nil {
+		return nil, err
+	}
+	for _, fe := range entries {
+		e := &storeEntry{nextCounter: fe.NextCounter, lastReceived: fe.LastReceived, received: fe.Received}
+		fields := []struct {
+			dst *[]byte
+			src string
+		}{
+			{&e.keys.BlockCipherKey, fe.BlockCipherKey},
+			{&e.keys.BroadcastKey, fe.BroadcastKey},
+			{&e.keys.AuthenticationKey, fe.AuthenticationKey},
+			{&e.keys.MasterKey, fe.MasterKey},
+		}
+		for _, f := range fields {
+			if *f.dst, err = decodeHexKey(f.src); err != nil {
+				return nil, err
+			}
+		}
+		title, err := hex.DecodeString(fe.SystemTitle)
+		if err != nil {
+			return nil, err
+		}
+		k := storeKey{string(title), fe.Suite}
+		s.memory.entries[k] = e
+		s.saved[k] = fileCounters{next: fe.NextCounter, lastReceived: fe.LastReceived, received: fe.Received}
+	}
+	return s, nil
+}
+
+func decodeHexKey(src string) ([]byte, error) {
+	if src == "" {
+		return nil, nil
+	}
+	return hex.DecodeString(src)
+}
+
+// Write the whole store to a temporary file then rename it, so the file
+// is never left half written. Directory is synced too, so the rename
+// itself survives power loss. Counter to send is written as reserved in
+// saved when it is ahead of the one in memory
+func (s *FileKeyStore) save() error {
+	s.memory.mutex.Lock()
+	entries := make([]fileEntry, 0, len(s.memory.entries))
+	written := make(map[storeKey]fileCounters, len(s.memory.entries))
+	for k, e := range s.memory.entries {
+		fc := fileCounters{next: e.nextCounter, lastReceived: e.lastReceived, received: e.received}
+		if sv := s.saved[k]; sv.next > fc.next {
+			fc.next = sv.next
+		}
+		written[k] = fc
+		entries = append(entries, fileEntry{
+			SystemTitle:       hex.EncodeToString([]byte(k.systemTitle)),
+			Suite:             k.suite,
+			BlockCipherKey:    hex.EncodeToString(e.keys.BlockCipherKey),
+			BroadcastKey:      hex.EncodeToString(e.keys.BroadcastKey),
+			AuthenticationKey: hex.EncodeToString(e.keys.AuthenticationKey),
+			MasterKey:         hex.EncodeToString(e.keys.MasterKey),
+			NextCounter:       fc.next,
+			LastReceived:      fc.lastReceived,
+			Received:          fc.received,
+		})
+	}
+	s.memory.mutex.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].SystemTitle != entries[j].SystemTitle {
+			return entries[i].SystemTitle < entries[j].SystemTitle
+		}
+		return entries[i].Suite < entries[j].Suite
+	})
+
+	src, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(src); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	if err = syncDir(dir); err != nil {
+		return err
+	}
+	s.saved = written
+	return nil
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if cerr := dir.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *FileKeyStore) Keys(systemTitle []byte, suite uint8) (Keys, error) {
+	return s.memory.Keys(systemTitle, suite)
+}
+
+func (s *FileKeyStore) SetKeys(systemTitle []byte, suite uint8, keys Keys) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.memory.SetKeys(systemTitle, suite, keys)
+	return s.save()
+}
+
+// ReserveCounter writes the file only when counters run past the ones
+// reserved in it, then reserves CounterBlock more
+func (s *FileKeyStore) ReserveCounter(systemTitle []byte, suite uint8, n uint32) (out uint32, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if out, err = s.memory.ReserveCounter(systemTitle, suite, n); err != nil {
+		return
+	}
+	k := storeKey{string(systemTitle), suite}
+	old := s.saved[k]
+	end := uint64(out) + uint64(n)
+	if end <= old.next {
+		return
+	}
+
+	next := old
+	next.next = end + uint64(s.counterBlock())
+	if next.next > 1<<32 {
+		next.next = 1 << 32
+	}
+	s.saved[k] = next
+	if err = s.save(); err != nil {
+		s.saved[k] = old
+		out = 0
+	}
+	return
+}
+
+// AcceptCounter writes the file only on the first received counter and
+// when counter is CounterBlock or more past the one in it, counter is
+// not accepted when it cannot be written
+func (s *FileKeyStore) AcceptCounter(systemTitle []byte, suite uint8, ic uint32) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	k := storeKey{string(systemTitle), suite}
+	old := s.saved[k]
+	s.memory.mutex.Lock()
+	var lastReceived uint32
+	var received bool
+	if e, ok := s.memory.entries[k]; ok {
+		lastReceived, received = e.lastReceived, e.received
+	}
+	s.memory.mutex.Unlock()
+
+	if err = s.memory.AcceptCounter(systemTitle, suite, ic); err != nil {
+		return
+	}
+	if old.received && uint64(ic) < uint64(old.lastReceived)+uint64(s.counterBlock()) {
+		return
+	}
+	if err = s.save(); err != nil {
+		s.memory.mutex.Lock()
+		if e, ok := s.memory.entries[k]; ok {
+			e.lastReceived, e.received = lastReceived, received
+		}
+		s.memory.mutex.Unlock()
+	}
+	return
+}
+
+// Flush writes the store as it is in memory, call it before process ends
+// so that no received counter is lost
+func (s *FileKeyStore) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.save()
+}
+
+func (s *FileKeyStore) counterBlock() uint32 {
+	if s.CounterBlock == 0 {
+		return DefaultCounterBlock
+	}
+	return s.CounterBlock
+}
@@ -0,0 +1,229 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"gosem/pkg/axdr"
+	"gosem/pkg/dlms"
+)
+
+// HLSChallengeLength is the length of challenge generated by NewHLS,
+// DLMS allows challenges of HLSMinChallengeLength to HLSMaxChallengeLength
+const (
+	HLSChallengeLength    = 16
+	HLSMinChallengeLength = 8
+	HLSMaxChallengeLength = 64
+)
+
+// Association LN object and its reply_to_HLS_authentication method
+const (
+	hlsClassId uint16 = 15
+	hlsObis           = "0.0.40.0.0.255"
+	hlsMethod  int8   = 1
+)
+
+// HLSExchange is the pass 1 and pass 2 of HLS seen from one side.
+// Challenge is sent by this side (CtoS on client, StoC on server),
+// PeerChallenge is received from the other side.
+type HLSExchange struct {
+	SystemTitle     []byte
+	PeerSystemTitle []byte
+	Challenge       []byte
+	PeerChallenge   []byte
+}
+
+// HLSMechanism computes and checks the pass 3 and pass 4 of HLS.
+// Response returns f(PeerChallenge) sent by this side, Verify checks
+// f(Challenge) sent by the other side.
+type HLSMechanism interface {
+	Mechanism() dlms.AuthenticationMechanism
+	Response(x HLSExchange) ([]byte, error)
+	Verify(x HLSExchange, response []byte) error
+}
+
+// GMAC is HLS mechanism 5. Keys and security suite are taken from Context,
+// response is SC || IC || GMAC tag of the challenge, and the invocation
+// counter of Context is used as by Wrap. Counter of the peer response is
+// checked against replay as by Unwrap. System titles of both sides must
+// be 8 bytes
+type GMAC struct {
+	Context *Context
+}
+
+func CreateGMAC(c *Context) *GMAC {
+	return &GMAC{Context: c}
+}
+
+func (g *GMAC) Mechanism() dlms.AuthenticationMechanism {
+	return dlms.TagMechHighGMAC
+}
+
+func (g *GMAC) tag(systemTitle []byte, sc SecurityControl, ic uint32, challenge []byte) (out []byte, err error) {
+	information, err := Encrypt(sc, systemTitle, ic, g.Context.BlockCipherKey, g.Context.AuthenticationKey, challenge)
+	if err != nil {
+		return
+	}
+	out = information[len(challenge):]
+	return
+}
+
+func checkSystemTitle(name string, systemTitle []byte) error {
+	if len(systemTitle) != 8 {
+		return fmt.Errorf("%v is %v bytes, should be 8", name, len(systemTitle))
+	}
+	return nil
+}
+
+func (g *GMAC) Response(x HLSExchange) (out []byte, err error) {
+	if err = checkSystemTitle("system title", x.SystemTitle); err != nil {
+		return
+	}
+	sc := SecurityAuthentication | SecurityControl(g.Context.Security.Suite())
+	ic, tag, err := g.Context.withCounter(func(ic uint32) ([]byte, error) {
+		return g.tag(x.SystemTitle, sc, ic, x.PeerChallenge)
+	})
+	if err != nil {
+		return
+	}
+
+	out = make([]byte, 5, 5+len(tag))
+	out[0] = sc.Value()
+	binary.BigEndian.PutUint32(out[1:], ic)
+	out = append(out, tag...)
+	return
+}
+
+func (g *GMAC) Verify(x HLSExchange, response []byte) (err error) {
+	if err = checkSystemTitle("system title of peer", x.PeerSystemTitle); err != nil {
+		return
+	}
+	if len(response) != 5+TagLength {
+		return fmt.Errorf("GMAC response is %v bytes, should be %v", len(response), 5+TagLength)
+	}
+	sc := SecurityControl(response[0])
+	if sc != SecurityAuthentication|SecurityControl(g.Context.Security.Suite()) {
+		return fmt.Errorf("%w: GMAC response security control %#02x is not authentication only of suite %v", ErrSecurityPolicy, response[0], g.Context.Security.Suite())
+	}
+	ic := binary.BigEndian.Uint32(response[1:5])
+	tag, err := g.tag(x.PeerSystemTitle, sc, ic, x.Challenge)
+	if err != nil {
+		return
+	}
+	if subtle.ConstantTimeCompare(tag, response[5:]) != 1 {
+		return ErrAuthenticationFailed
+	}
+	return g.Context.accept(x.PeerSystemTitle, sc, ic)
+}
+
+// HLS drives the challenge-response of one association with a
+// HLSMechanism. The client sends Challenge in AARQ, sets challenge of
+// server from AARE by SetPeer, then sends Request and checks the answer by
+// CheckResponse. The server sends Challenge in AARE and answers Request
+// by Reply.
+type HLS struct {
+	Mechanism HLSMechanism
+	Exchange  HLSExchange
+}
+
+// NewHLS returns HLS with new random challenge of HLSChallengeLength bytes
+func NewHLS(m HLSMechanism, systemTitle []byte) (*HLS, error) {
+	challenge := make([]byte, HLSChallengeLength)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return &HLS{Mechanism: m, Exchange: HLSExchange{SystemTitle: systemTitle, Challenge: challenge}}, nil
+}
+
+// Challenge returns challenge of this side, sent in AARQ or AARE
+func (h *HLS) Challenge() []byte {
+	return h.Exchange.Challenge
+}
+
+// SetPeer sets system title and challenge of the other side, received in
+// AARQ or AARE. Challenge must be HLSMinChallengeLength to
+// HLSMaxChallengeLength bytes. System title is checked by the mechanism
+// using it, it may be nil for the others
+func (h *HLS) SetPeer(systemTitle []byte, challenge []byte) error {
+	if n := len(challenge); n < HLSMinChallengeLength || n > HLSMaxChallengeLength {
+		return fmt.Errorf("challenge of peer is %v bytes, should be %v to %v", n, HLSMinChallengeLength, HLSMaxChallengeLength)
+	}
+	h.Exchange.PeerSystemTitle = systemTitle
+	h.Exchange.PeerChallenge = challenge
+	return nil
+}
+
+// Request returns reply_to_HLS_authentication invocation carrying
+// f(StoC), sent by client after association is accepted
+func (h *HLS) Request(invokeId uint8) (out *dlms.ActionRequestNormal, err error) {
+	response, err := h.Mechanism.Response(h.Exchange)
+	if err != nil {
+		return
+	}
+	mth := *dlms.CreateMethodDescriptor(hlsClassId, hlsObis, hlsMethod)
+	out = dlms.CreateActionRequestNormal(invokeId, mth, axdr.CreateAxdrOctetString(hex.EncodeToString(response)))
+	return
+}
+
+// CheckResponse checks f(CtoS) returned by server to Request
+func (h *HLS) CheckResponse(resp dlms.ActionResponseNormal) (err error) {
+	if resp.Response.Result != dlms.TagActSuccess {
+		return &dlms.ActionError{Result: resp.Response.Result}
+	}
+	if resp.Response.ReturnParam == nil || !resp.Response.ReturnParam.IsData {
+		return fmt.Errorf("reply_to_HLS_authentication returned no data")
+	}
+	data, err := resp.Response.ReturnParam.ValueAsData()
+	if err != nil {
+		return
+	}
+	response, err := octetString(data)
+	if err != nil {
+		return
+	}
+	return h.Mechanism.Verify(h.Exchange, response)
+}
+
+// Reply answers reply_to_HLS_authentication invocation of client. When
+// f(StoC) is not valid, out is the response refusing the invocation and
+// err tells why, so the server can send it and keep association
+// unauthenticated.
+func (h *HLS) Reply(req dlms.ActionRequestNormal) (out *dlms.ActionResponseNormal, err error) {
+	mth := req.MethodInfo
+	if mth.ClassId != hlsClassId || mth.MethodId != hlsMethod || mth.InstanceId != *dlms.CreateObis(hlsObis) {
+		err = fmt.Errorf("method %v.%v of class %v is not reply_to_HLS_authentication", mth.InstanceId, mth.MethodId, mth.ClassId)
+		return
+	}
+
+	err = fmt.Errorf("reply_to_HLS_authentication has no parameter")
+	var response []byte
+	if req.MethodParam != nil {
+		response, err = octetString(*req.MethodParam)
+	}
+	if err == nil {
+		err = h.Mechanism.Verify(h.Exchange, response)
+	}
+	if err != nil {
+		out = dlms.CreateActionResponseNormal(req.InvokePriority, *dlms.CreateActResponse(dlms.TagActReadWriteDenied, nil))
+		return
+	}
+
+	f, err := h.Mechanism.Response(h.Exchange)
+	if err != nil {
+		return
+	}
+	ret := dlms.CreateGetDataResultAsData(*axdr.CreateAxdrOctetString(hex.EncodeToString(f)))
+	out = dlms.CreateActionResponseNormal(req.InvokePriority, *dlms.CreateActResponse(dlms.TagActSuccess, ret))
+	return
+}
+
+func octetString(data axdr.DlmsData) ([]byte, error) {
+	value, ok := data.Value.(string)
+	if data.Tag != axdr.TagOctetString || !ok {
+		return nil, fmt.Errorf("HLS response is %v, should be octet-string", data.Tag)
+	}
+	return hex.DecodeString(value)
+}
@@ -0,0 +1,139 @@
+package security
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"gosem/pkg/dlms"
+)
+
+func TestGMAC(t *testing.T) {
+	clientTitle, _ := hex.DecodeString("4D4D4D0000000001")
+	stoc, _ := hex.DecodeString("503677524A323146")
+
+	client := CreateContext(SecurityAuthentication, clientTitle, testSystemTitle, testEK, testAK)
+	client.InvocationCounter = 1
+	x := HLSExchange{SystemTitle: clientTitle, PeerSystemTitle: testSystemTitle, PeerChallenge: stoc}
+	t1, err := CreateGMAC(client).Response(x)
+	result, _ := hex.DecodeString("10000000011A52FE7DD3E72748973C1E28")
+	if err != nil || !bytes.Equal(t1, result) {
+		t.Errorf("t1 Failed. get: %X, should:%X, err:%v", t1, result, err)
+	}
+	if client.InvocationCounter != 2 {
+		t.Errorf("t1 InvocationCounter should be incremented, get: %v", client.InvocationCounter)
+	}
+
+	// server checks f(StoC) with system title of client
+	server := CreateContext(SecurityAuthentication, testSystemTitle, clientTitle, testEK, testAK)
+	sx := HLSExchange{SystemTitle: testSystemTitle, PeerSystemTitle: clientTitle, Challenge: stoc}
+	if err = CreateGMAC(server).Verify(sx, t1); err != nil {
+		t.Errorf("t2 Failed. err:%v", err)
+	}
+
+	t1[len(t1)-1] ^= 0x01
+	if err = CreateGMAC(server).Verify(sx, t1); err != ErrAuthenticationFailed {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+	if err = CreateGMAC(server).Verify(sx, t1[:10]); err == nil {
+		t.Errorf("t4 short response should fail")
+	}
+	t1[len(t1)-1] ^= 0x01
+
+	// response must be of the suite of context
+	server.Security = SecurityAuthentication | 0x01
+	if err = CreateGMAC(server).Verify(sx, t1); !errors.Is(err, ErrSecurityPolicy) {
+		t.Errorf("t5 Failed. err:%v", err)
+	}
+
+	// counter of response is recorded in store, the same response is refused
+	store := NewMemoryKeyStore()
+	store.SetKeys(clientTitle, 0, Keys{BlockCipherKey: testEK, AuthenticationKey: testAK})
+	server.Security = SecurityAuthentication
+	server.Store = store
+	if err = CreateGMAC(server).Verify(sx, t1); err != nil {
+		t.Errorf("t6 Failed. err:%v", err)
+	}
+	if err = CreateGMAC(server).Verify(sx, t1); !errors.Is(err, ErrReplay) {
+		t.Errorf("t6 replayed response should fail. err:%v", err)
+	}
+}
+
+func TestHLS(t *testing.T) {
+	clientTitle, _ := hex.DecodeString("4D4D4D0000000001")
+	client, err := NewHLS(CreateGMAC(CreateContext(SecurityAuthentication, clientTitle, nil, testEK, testAK)), clientTitle)
+	if err != nil {
+		t.Fatalf("NewHLS failed. err: %v", err)
+	}
+	server, _ := NewHLS(CreateGMAC(CreateContext(SecurityAuthentication, testSystemTitle, nil, testEK, testAK)), testSystemTitle)
+	if len(client.Challenge()) != HLSChallengeLength || bytes.Equal(client.Challenge(), server.Challenge()) {
+		t.Errorf("t1 Failed. challenges: %X, %X", client.Challenge(), server.Challenge())
+	}
+
+	// pass 1 and 2 are carried by AARQ and AARE
+	if err = server.SetPeer(clientTitle, client.Challenge()[:7]); err == nil {
+		t.Errorf("t1 SetPeer should fail on short challenge")
+	}
+	if err = server.SetPeer(clientTitle, make([]byte, 65)); err == nil {
+		t.Errorf("t1 SetPeer should fail on long challenge")
+	}
+	if err = server.SetPeer(nil, client.Challenge()); err != nil {
+		t.Errorf("t1 SetPeer failed without system title. err: %v", err)
+	}
+	server.SetPeer(clientTitle, client.Challenge())
+	client.SetPeer(testSystemTitle, server.Challenge())
+
+	req, err := client.Request(0x41)
+	if err != nil {
+		t.Fatalf("t2 Request failed. err: %v", err)
+	}
+	src, _ := req.Encode()
+	decoded, err := dlms.DecodeCosem(&src)
+	if err != nil {
+		t.Fatalf("t2 decode failed. err: %v", err)
+	}
+	areq := decoded.(dlms.ActionRequestNormal)
+	if areq.MethodInfo.ClassId != 15 || areq.MethodInfo.MethodId != 1 || areq.MethodInfo.InstanceId.String() != "0.0.40.0.0.255" {
+		t.Errorf("t2 Failed. method: %v", areq.MethodInfo)
+	}
+
+	resp, err := server.Reply(areq)
+	if err != nil {
+		t.Fatalf("t3 Reply failed. err: %v", err)
+	}
+	src, _ = resp.Encode()
+	decoded, err = dlms.DecodeCosem(&src)
+	if err != nil {
+		t.Fatalf("t3 decode failed. err: %v", err)
+	}
+	if err = client.CheckResponse(decoded.(dlms.ActionResponseNormal)); err != nil {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+
+	// server with another key refuses f(StoC)
+	wrong, _ := NewHLS(CreateGMAC(CreateContext(SecurityAuthentication, testSystemTitle, nil, testEK, testEK)), testSystemTitle)
+	wrong.SetPeer(clientTitle, client.Challenge())
+	req, _ = client.Request(0x42)
+	resp, err = wrong.Reply(*req)
+	if err != ErrAuthenticationFailed || resp == nil || resp.Response.Result != dlms.TagActReadWriteDenied {
+		t.Errorf("t4 Failed. get: %v, err:%v", resp, err)
+	}
+	err = client.CheckResponse(*resp)
+	var actionErr *dlms.ActionError
+	if !errors.As(err, &actionErr) || actionErr.Result != dlms.TagActReadWriteDenied {
+		t.Errorf("t4 Failed. err:%v", err)
+	}
+
+	// f(CtoS) computed on challenge of another association is refused
+	client.SetPeer(testSystemTitle, server.Challenge())
+	req, _ = client.Request(0x43)
+	resp, err = server.Reply(*req)
+	if err != nil {
+		t.Fatalf("t5 Reply failed. err: %v", err)
+	}
+	client.Exchange.Challenge = wrong.Challenge()
+	if err = client.CheckResponse(*resp); err != ErrAuthenticationFailed {
+		t.Errorf("t5 Failed. err:%v", err)
+	}
+}
@@ -0,0 +1,441 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"gosem/pkg/axdr"
+	"gosem/pkg/dlms"
+)
+
+// key_agreement method of Security Setup
+const keyAgreementMethod int8 = 3
+
+// Cryptographic algorithm ids of DLMS, used as AlgorithmID of KDF OtherInfo
+var (
+	AlgorithmAESGCM128  = []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x03, 0x00}
+	AlgorithmAESGCM256  = []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x03, 0x01}
+	AlgorithmAESWrap128 = []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x03, 0x02}
+	AlgorithmAESWrap256 = []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x03, 0x03}
+)
+
+// SuiteKeyLength returns length of symmetric keys of security suite,
+// 16 bytes for suite 0 and 1, 32 bytes for suite 2
+func SuiteKeyLength(suite uint8) (int, error) {
+	switch suite {
+	case 0, 1:
+		return 16, nil
+	case 2:
+		return 32, nil
+	}
+	return 0, fmt.Errorf("security suite %v is not supported", suite)
+}
+
+// OtherInfo returns OtherInfo of KDF, AlgorithmID || PartyUInfo ||
+// PartyVInfo where party U is the initiator and party V the responder.
+// The parties are identified by their system titles
+func OtherInfo(algorithmId []byte, partyU []byte, partyV []byte) []byte {
+	out := make([]byte, 0, len(algorithmId)+len(partyU)+len(partyV))
+	out = append(out, algorithmId...)
+	out = append(out, partyU...)
+	return append(out, partyV...)
+}
+
+// KDF is the NIST SP 800-56A concatenation key derivation function, with
+// SHA-256 for suite 1 and SHA-384 for suite 2. It derives keyLength bytes
+// from shared secret z as H(counter || z || otherInfo) || ...
+func KDF(suite uint8, z []byte, otherInfo []byte, keyLength int) (out []byte, err error) {
+	var h hash.Hash
+	switch suite {
+	case 1:
+		h = sha256.New()
+	case 2:
+		h = sha512.New384()
+	default:
+		err = fmt.Errorf("security suite %v has no key derivation function", suite)
+		return
+	}
+
+	var counter [4]byte
+	for i := uint32(1); len(out) < keyLength; i++ {
+		h.Reset()
+		binary.BigEndian.PutUint32(counter[:], i)
+		h.Write(counter[:])
+		h.Write(z)
+		h.Write(otherInfo)
+		out = h.Sum(out)
+	}
+	out = out[:keyLength]
+	return
+}
+
+// Size of one coordinate of curve point
+func coordinateSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
+
+// EncodePublicKey returns public key as x || y, as carried by key_data
+func EncodePublicKey(key *ecdsa.PublicKey) []byte {
+	size := coordinateSize(key.Curve)
+	out := make([]byte, 2*size)
+	key.X.FillBytes(out[:size])
+	key.Y.FillBytes(out[size:])
+	return out
+}
+
+// DecodePublicKey is the reverse of EncodePublicKey, the point must be on
+// curve
+func DecodePublicKey(curve elliptic.Curve, src []byte) (out *ecdsa.PublicKey, err error) {
+	size := coordinateSize(curve)
+	if len(src) != 2*size {
+		err = fmt.Errorf("public key is %v bytes, should be %v", len(src), 2*size)
+		return
+	}
+	x := new(big.Int).SetBytes(src[:size])
+	y := new(big.Int).SetBytes(src[size:])
+	if !curve.IsOnCurve(x, y) {
+		err = fmt.Errorf("public key is not on curve %v", curve.Params().Name)
+		return
+	}
+	out = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	return
+}
+
+// Shared secret Z of ECC CDH, x coordinate of private * public. Point at
+// infinity, returned as 0, is not a valid secret
+func sharedSecret(private *ecdsa.PrivateKey, public *ecdsa.PublicKey) (out []byte, err error) {
+	if private.Curve != public.Curve {
+		err = fmt.Errorf("keys are not on the same curve")
+		return
+	}
+	x, y := private.Curve.ScalarMult(public.X, public.Y, private.D.Bytes())
+	if x.Sign() == 0 && y.Sign() == 0 {
+		err = fmt.Errorf("shared secret is point at infinity")
+		return
+	}
+	out = make([]byte, coordinateSize(private.Curve))
+	x.FillBytes(out)
+	return
+}
+
+// Symmetric key of suite derived from private key of this side and
+// public key of the other side
+func deriveKey(suite uint8, private *ecdsa.PrivateKey, public *ecdsa.PublicKey, otherInfo []byte) (out []byte, err error) {
+	z, err := sharedSecret(private, public)
+	if err != nil {
+		return
+	}
+	keyLength, err := SuiteKeyLength(suite)
+	if err != nil {
+		return
+	}
+	return KDF(suite, z, otherInfo, keyLength)
+}
+
+// Curve of suite, and check key is on it
+func suiteKeyCurve(suite uint8, key *ecdsa.PublicKey) (curve elliptic.Curve, err error) {
+	if curve, err = SuiteCurve(suite); err != nil {
+		return
+	}
+	if key == nil || key.Curve != curve {
+		err = fmt.Errorf("key is not on curve %v of security suite %v", curve.Params().Name, suite)
+	}
+	return
+}
+
+// KeyAgreementScheme is the key agreement scheme run by key_agreement
+type KeyAgreementScheme uint8
+
+const (
+	// Ephemeral Unified Model C(2e, 0s), both sides send signed ephemeral key
+	SchemeEphemeralUnifiedModel KeyAgreementScheme = 0
+	// One-Pass Diffie-Hellman C(1e, 1s), only the client sends signed
+	// ephemeral key, server uses its static key agreement key
+	SchemeOnePassDiffieHellman KeyAgreementScheme = 1
+)
+
+// KeyAgreement is one run of key_agreement of Security Setup. The client
+// is party U and sends its ephemeral public key signed by its signing key,
+// as key_data of KeyId, by Request. The server is party V and answers by
+// Reply, with its own signed ephemeral key by SchemeEphemeralUnifiedModel
+// or with no data by SchemeOnePassDiffieHellman. Both derive the same key
+// with AlgorithmID of the suite.
+type KeyAgreement struct {
+	Scheme          KeyAgreementScheme
+	Suite           uint8
+	Id              KeyId
+	SystemTitle     []byte
+	PeerSystemTitle []byte
+	SigningKey      *ecdsa.PrivateKey
+	PeerSigningKey  *ecdsa.PublicKey
+	// static key agreement key of server, used by one-pass scheme
+	StaticKey     *ecdsa.PrivateKey
+	PeerStaticKey *ecdsa.PublicKey
+
+	ephemeral *ecdsa.PrivateKey
+}
+
+// NewKeyAgreement returns KeyAgreement with new ephemeral key on curve of
+// suite. Signing key of both sides must be on the same curve
+func NewKeyAgreement(suite uint8, id KeyId, systemTitle []byte, peerSystemTitle []byte, signingKey *ecdsa.PrivateKey, peerSigningKey *ecdsa.PublicKey) (*KeyAgreement, error) {
+	if err := checkAgreedKeyId(id); err != nil {
+		return nil, err
+	}
+	if signingKey == nil {
+		return nil, fmt.Errorf("signing key is not set")
+	}
+	curve, err := suiteKeyCurve(suite, peerSigningKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = suiteKeyCurve(suite, &signingKey.PublicKey); err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyAgreement{
+		Suite:           suite,
+		Id:              id,
+		SystemTitle:     systemTitle,
+		PeerSystemTitle: peerSystemTitle,
+		SigningKey:      signingKey,
+		PeerSigningKey:  peerSigningKey,
+		ephemeral:       ephemeral,
+	}, nil
+}
+
+// NewOnePassInitiator returns client side of SchemeOnePassDiffieHellman,
+// with new ephemeral key signed by signingKey. peerStaticKey is static key
+// agreement key of server
+func NewOnePassInitiator(suite uint8, id KeyId, systemTitle []byte, peerSystemTitle []byte, signingKey *ecdsa.PrivateKey, peerStaticKey *ecdsa.PublicKey) (*KeyAgreement, error) {
+	if err := checkAgreedKeyId(id); err != nil {
+		return nil, err
+	}
+	if signingKey == nil {
+		return nil, fmt.Errorf("signing key is not set")
+	}
+	curve, err := suiteKeyCurve(suite, peerStaticKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = suiteKeyCurve(suite, &signingKey.PublicKey); err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyAgreement{
+		Scheme:          SchemeOnePassDiffieHellman,
+		Suite:           suite,
+		Id:              id,
+		SystemTitle:     systemTitle,
+		PeerSystemTitle: peerSystemTitle,
+		SigningKey:      signingKey,
+		PeerStaticKey:   peerStaticKey,
+		ephemeral:       ephemeral,
+	}, nil
+}
+
+// NewOnePassResponder returns server side of SchemeOnePassDiffieHellman.
+// staticKey is own static key agreement key, peerSigningKey checks
+// ephemeral key of client
+func NewOnePassResponder(suite uint8, id KeyId, systemTitle []byte, peerSystemTitle []byte, staticKey *ecdsa.PrivateKey, peerSigningKey *ecdsa.PublicKey) (*KeyAgreement, error) {
+	if err := checkAgreedKeyId(id); err != nil {
+		return nil, err
+	}
+	if staticKey == nil {
+		return nil, fmt.Errorf("static key is not set")
+	}
+	if _, err := suiteKeyCurve(suite, peerSigningKey); err != nil {
+		return nil, err
+	}
+	if _, err := suiteKeyCurve(suite, &staticKey.PublicKey); err != nil {
+		return nil, err
+	}
+	return &KeyAgreement{
+		Scheme:          SchemeOnePassDiffieHellman,
+		Suite:           suite,
+		Id:              id,
+		SystemTitle:     systemTitle,
+		PeerSystemTitle: peerSystemTitle,
+		StaticKey:       staticKey,
+		PeerSigningKey:  peerSigningKey,
+	}, nil
+}
+
+// Master key is only transferred, other keys may be agreed
+func checkAgreedKeyId(id KeyId) error {
+	if id > KeyAuthentication {
+		return fmt.Errorf("key id %v cannot be agreed", id)
+	}
+	return nil
+}
+
+func (ka *KeyAgreement) onePass() bool {
+	return ka.Scheme == SchemeOnePassDiffieHellman
+}
+
+// Algorithm of the agreed key
+func (ka *KeyAgreement) algorithmId() []byte {
+	if ka.Suite == 2 {
+		return AlgorithmAESGCM256
+	}
+	return AlgorithmAESGCM128
+}
+
+// KeyData returns ephemeral public key of this side followed by its
+// signature, signature covers key id and the public key. Server of
+// one-pass scheme has no ephemeral key
+func (ka *KeyAgreement) KeyData() (out []byte, err error) {
+	if ka.ephemeral == nil || ka.SigningKey == nil {
+		err = fmt.Errorf("key agreement has no ephemeral key to send")
+		return
+	}
+	public := EncodePublicKey(&ka.ephemeral.PublicKey)
+	signature, err := Sign(ka.SigningKey, append([]byte{ka.Id.Value()}, public...))
+	if err != nil {
+		return
+	}
+	out = append(public, signature...)
+	return
+}
+
+// Check key_data of peer and return the signed ephemeral key it carries
+func (ka *KeyAgreement) peerKey(data axdr.DlmsData) (out *ecdsa.PublicKey, err error) {
+	curve, err := SuiteCurve(ka.Suite)
+	if err != nil {
+		return
+	}
+	list, err := decodeKeyStructures(data)
+	if err != nil {
+		return
+	}
+	if len(list) != 1 || list[0].Id != ka.Id {
+		err = fmt.Errorf("key agreement data should hold key id %v only", ka.Id)
+		return
+	}
+
+	size := 2 * coordinateSize(curve)
+	keyData := list[0].Key
+	if len(keyData) != 2*size {
+		err = fmt.Errorf("key agreement data is %v bytes, should be %v", len(keyData), 2*size)
+		return
+	}
+	public, signature := keyData[:size], keyData[size:]
+	if err = Verify(ka.PeerSigningKey, append([]byte{ka.Id.Value()}, public...), signature); err != nil {
+		return
+	}
+	return DecodePublicKey(curve, public)
+}
+
+// Derive the agreed key, party U is the client
+func (ka *KeyAgreement) derive(private *ecdsa.PrivateKey, public *ecdsa.PublicKey, client bool) ([]byte, error) {
+	if private == nil || public == nil {
+		return nil, fmt.Errorf("key agreement has no key to derive from")
+	}
+	otherInfo := OtherInfo(ka.algorithmId(), ka.PeerSystemTitle, ka.SystemTitle)
+	if client {
+		otherInfo = OtherInfo(ka.algorithmId(), ka.SystemTitle, ka.PeerSystemTitle)
+	}
+	return deriveKey(ka.Suite, private, public, otherInfo)
+}
+
+func (ka *KeyAgreement) data() (out *axdr.DlmsData, err error) {
+	keyData, err := ka.KeyData()
+	if err != nil {
+		return
+	}
+	out = keyStructures([]KeyData{{Id: ka.Id, Key: keyData}})
+	return
+}
+
+// Request returns invocation of key_agreement of Security Setup object obis
+func (ka *KeyAgreement) Request(invokeId uint8, obis string) (out *dlms.ActionRequestNormal, err error) {
+	param, err := ka.data()
+	if err != nil {
+		return
+	}
+	mth := *dlms.CreateMethodDescriptor(SecuritySetupClassId, obis, keyAgreementMethod)
+	out = dlms.CreateActionRequestNormal(invokeId, mth, param)
+	return
+}
+
+// CheckResponse verifies key_data returned by server to Request and
+// returns the agreed key. By one-pass scheme, server returns no data and
+// the key is derived from static key of server
+func (ka *KeyAgreement) CheckResponse(resp dlms.ActionResponseNormal) (out []byte, err error) {
+	if resp.Response.Result != dlms.TagActSuccess {
+		err = &dlms.ActionError{Result: resp.Response.Result}
+		return
+	}
+	if ka.onePass() {
+		if resp.Response.ReturnParam != nil {
+			err = fmt.Errorf("key_agreement of one-pass scheme returned data")
+			return
+		}
+		return ka.derive(ka.ephemeral, ka.PeerStaticKey, true)
+	}
+	if resp.Response.ReturnParam == nil || !resp.Response.ReturnParam.IsData {
+		err = fmt.Errorf("key_agreement returned no data")
+		return
+	}
+	data, err := resp.Response.ReturnParam.ValueAsData()
+	if err != nil {
+		return
+	}
+	peer, err := ka.peerKey(data)
+	if err != nil {
+		return
+	}
+	return ka.derive(ka.ephemeral, peer, true)
+}
+
+// Reply answers key_agreement invocation of client and returns the agreed
+// key. When key_data of client is not valid, out is the response refusing
+// the invocation and err tells why.
+func (ka *KeyAgreement) Reply(req dlms.ActionRequestNormal) (out *dlms.ActionResponseNormal, key []byte, err error) {
+	mth := req.MethodInfo
+	if mth.ClassId != SecuritySetupClassId || mth.MethodId != keyAgreementMethod {
+		err = fmt.Errorf("method %v.%v of class %v is not key_agreement", mth.InstanceId, mth.MethodId, mth.ClassId)
+		return
+	}
+
+	err = fmt.Errorf("key_agreement has no parameter")
+	if req.MethodParam != nil {
+		var peer *ecdsa.PublicKey
+		if peer, err = ka.peerKey(*req.MethodParam); err == nil {
+			private := ka.ephemeral
+			if ka.onePass() {
+				private = ka.StaticKey
+			}
+			key, err = ka.derive(private, peer, false)
+		}
+	}
+	if err != nil {
+		out = dlms.CreateActionResponseNormal(req.InvokePriority, *dlms.CreateActResponse(dlms.TagActOtherReason, nil))
+		return
+	}
+	if ka.onePass() {
+		out = dlms.CreateActionResponseNormal(req.InvokePriority, *dlms.CreateActResponse(dlms.TagActSuccess, nil))
+		return
+	}
+
+	data, err := ka.data()
+	if err != nil {
+		key = nil
+		return
+	}
+	ret := dlms.CreateGetDataResultAsData(*data)
+	out = dlms.CreateActionResponseNormal(req.InvokePriority, *dlms.CreateActResponse(dlms.TagActSuccess, ret))
+	return
+}
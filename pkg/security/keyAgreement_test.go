@@ -0,0 +1,172 @@
+package security
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"gosem/pkg/dlms"
+)
+
+var testServerTitle, _ = hex.DecodeString("4D4D4D0000000001")
+
+func TestKDF(t *testing.T) {
+	z := bytes.Repeat([]byte{0x5A}, 32)
+	otherInfo := OtherInfo(AlgorithmAESGCM128, testSystemTitle, testServerTitle)
+	if len(otherInfo) != 7+8+8 || !bytes.Equal(otherInfo[:7], []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x03, 0x00}) {
+		t.Errorf("t1 Failed. get: %X", otherInfo)
+	}
+
+	// single round is H(00000001 || Z || OtherInfo)
+	h := sha256.New()
+	h.Write([]byte{0, 0, 0, 1})
+	h.Write(z)
+	h.Write(otherInfo)
+	result := h.Sum(nil)
+	out, err := KDF(1, z, otherInfo, 16)
+	if err != nil || !bytes.Equal(out, result[:16]) {
+		t.Errorf("t2 Failed. get: %X, should:%X, err:%v", out, result[:16], err)
+	}
+
+	// longer output goes on with counter 2
+	out, err = KDF(1, z, otherInfo, 40)
+	if err != nil || len(out) != 40 || !bytes.Equal(out[:32], result) {
+		t.Errorf("t3 Failed. get: %X, err:%v", out, err)
+	}
+	out, err = KDF(2, z, otherInfo, 32)
+	if err != nil || len(out) != 32 {
+		t.Errorf("t4 Failed. get: %X, err:%v", out, err)
+	}
+	if _, err = KDF(0, z, otherInfo, 16); err == nil {
+		t.Errorf("t5 suite 0 should fail")
+	}
+}
+
+func TestOnePass(t *testing.T) {
+	for suite := uint8(1); suite <= 2; suite++ {
+		curve, _ := SuiteCurve(suite)
+		clientKey, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		static, _ := ecdsa.GenerateKey(curve, rand.Reader)
+
+		client, err := NewOnePassInitiator(suite, KeyGlobalUnicast, testSystemTitle, testServerTitle, clientKey, &static.PublicKey)
+		if err != nil {
+			t.Fatalf("suite %v NewOnePassInitiator failed. err: %v", suite, err)
+		}
+		server, err := NewOnePassResponder(suite, KeyGlobalUnicast, testServerTitle, testSystemTitle, static, &clientKey.PublicKey)
+		if err != nil {
+			t.Fatalf("suite %v NewOnePassResponder failed. err: %v", suite, err)
+		}
+
+		req, err := client.Request(0x41, SecuritySetupObis)
+		if err != nil {
+			t.Fatalf("suite %v t1 Request failed. err: %v", suite, err)
+		}
+		src, _ := req.Encode()
+		decoded, _ := dlms.DecodeCosem(&src)
+		ar := decoded.(dlms.ActionRequestNormal)
+
+		// server answers without key data
+		resp, serverSide, err := server.Reply(ar)
+		if err != nil || resp.Response.Result != dlms.TagActSuccess || resp.Response.ReturnParam != nil {
+			t.Fatalf("suite %v t2 Failed. get: %v, err: %v", suite, resp, err)
+		}
+		src, _ = resp.Encode()
+		decoded, _ = dlms.DecodeCosem(&src)
+		clientSide, err := client.CheckResponse(decoded.(dlms.ActionResponseNormal))
+		length, _ := SuiteKeyLength(suite)
+		if err != nil || len(clientSide) != length || !bytes.Equal(clientSide, serverSide) {
+			t.Errorf("suite %v t3 Failed. get: %X, should:%X, err:%v", suite, clientSide, serverSide, err)
+		}
+
+		// ephemeral key of client must be signed by its signing key
+		other, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		server.PeerSigningKey = &other.PublicKey
+		if resp, _, err = server.Reply(ar); err != ErrSignatureFailed || resp.Response.Result != dlms.TagActOtherReason {
+			t.Errorf("suite %v t4 Failed. get: %v, err:%v", suite, resp, err)
+		}
+		if _, err = server.KeyData(); err == nil {
+			t.Errorf("suite %v t5 server of one-pass scheme has no key data", suite)
+		}
+	}
+
+	curve, _ := SuiteCurve(1)
+	key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	if _, err := NewOnePassInitiator(2, KeyGlobalUnicast, testSystemTitle, testServerTitle, key, &key.PublicKey); err == nil {
+		t.Errorf("t6 P-256 key with suite 2 should fail")
+	}
+}
+
+func TestSharedSecret(t *testing.T) {
+	curve, _ := SuiteCurve(1)
+	key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	z, err := sharedSecret(key, &key.PublicKey)
+	if err != nil || len(z) != 32 {
+		t.Errorf("t1 Failed. get: %X, err:%v", z, err)
+	}
+
+	// order of curve times any point is the point at infinity
+	zero := &ecdsa.PrivateKey{PublicKey: key.PublicKey, D: curve.Params().N}
+	if _, err = sharedSecret(zero, &key.PublicKey); err == nil {
+		t.Errorf("t2 point at infinity should fail")
+	}
+}
+
+func TestKeyAgreement(t *testing.T) {
+	for suite := uint8(1); suite <= 2; suite++ {
+		curve, _ := SuiteCurve(suite)
+		clientKey, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		serverKey, _ := ecdsa.GenerateKey(curve, rand.Reader)
+
+		client, err := NewKeyAgreement(suite, KeyGlobalUnicast, testSystemTitle, testServerTitle, clientKey, &serverKey.PublicKey)
+		if err != nil {
+			t.Fatalf("suite %v NewKeyAgreement failed. err: %v", suite, err)
+		}
+		server, _ := NewKeyAgreement(suite, KeyGlobalUnicast, testServerTitle, testSystemTitle, serverKey, &clientKey.PublicKey)
+
+		req, err := client.Request(0x41, SecuritySetupObis)
+		if err != nil {
+			t.Fatalf("suite %v t1 Request failed. err: %v", suite, err)
+		}
+		src, _ := req.Encode()
+		decoded, err := dlms.DecodeCosem(&src)
+		if err != nil {
+			t.Fatalf("suite %v t1 decode failed. err: %v", suite, err)
+		}
+		ar := decoded.(dlms.ActionRequestNormal)
+		if ar.MethodInfo.ClassId != 64 || ar.MethodInfo.MethodId != 3 {
+			t.Errorf("suite %v t1 Failed. method: %v", suite, ar.MethodInfo)
+		}
+
+		resp, serverSide, err := server.Reply(ar)
+		if err != nil {
+			t.Fatalf("suite %v t2 Reply failed. err: %v", suite, err)
+		}
+		src, _ = resp.Encode()
+		decoded, _ = dlms.DecodeCosem(&src)
+		clientSide, err := client.CheckResponse(decoded.(dlms.ActionResponseNormal))
+		length, _ := SuiteKeyLength(suite)
+		if err != nil || len(clientSide) != length || !bytes.Equal(clientSide, serverSide) {
+			t.Errorf("suite %v t3 Failed. get: %X, should:%X, err:%v", suite, clientSide, serverSide, err)
+		}
+
+		// server does not trust signing key of client
+		other, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		server.PeerSigningKey = &other.PublicKey
+		resp, _, err = server.Reply(ar)
+		if err != ErrSignatureFailed || resp == nil || resp.Response.Result != dlms.TagActOtherReason {
+			t.Errorf("suite %v t4 Failed. get: %v, err:%v", suite, resp, err)
+		}
+		if _, err = client.CheckResponse(*resp); err == nil {
+			t.Errorf("suite %v t4 refused key agreement should fail", suite)
+		}
+	}
+
+	curve, _ := SuiteCurve(1)
+	key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	if _, err := NewKeyAgreement(1, KeyMaster, testSystemTitle, testServerTitle, key, &key.PublicKey); err == nil {
+		t.Errorf("t5 master key should not be agreed")
+	}
+}
@@ -0,0 +1,155 @@
+package security
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrKeysNotFound     = errors.New("no keys for system title and security suite")
+	ErrReplay           = errors.New("invocation counter is not bigger than the last received one")
+	ErrCounterExhausted = errors.New("invocation counters of the key are exhausted")
+)
+
+// Keys of one peer and security suite
+type Keys struct {
+	BlockCipherKey    []byte
+	BroadcastKey      []byte
+	AuthenticationKey []byte
+	MasterKey         []byte
+}
+
+// KeyStore supplies keys by system title of peer and security suite, and
+// keeps invocation counters of those keys. An invocation counter must
+// never be used twice with the same key, so the store reserves counters
+// to send and rejects received counters not bigger than the last one.
+// Implementations are safe for concurrent use.
+type KeyStore interface {
+	// Keys returns keys of peer systemTitle, or ErrKeysNotFound
+	Keys(systemTitle []byte, suite uint8) (Keys, error)
+	// SetKeys stores keys of peer systemTitle. Counter to send is kept,
+	// received counter starts again when keys change, as peer restarts
+	// its counter with new keys
+	SetKeys(systemTitle []byte, suite uint8, keys Keys) error
+	// ReserveCounter reserves n invocation counters to send to peer
+	// systemTitle and returns the first one
+	ReserveCounter(systemTitle []byte, suite uint8, n uint32) (uint32, error)
+	// AcceptCounter records ic received from peer systemTitle, it returns
+	// ErrReplay when ic is not bigger than the last received one
+	AcceptCounter(systemTitle []byte, suite uint8, ic uint32) error
+}
+
+func (k Keys) clone() Keys {
+	return Keys{
+		BlockCipherKey:    cloneBytes(k.BlockCipherKey),
+		BroadcastKey:      cloneBytes(k.BroadcastKey),
+		AuthenticationKey: cloneBytes(k.AuthenticationKey),
+		MasterKey:         cloneBytes(k.MasterKey),
+	}
+}
+
+func cloneBytes(src []byte) []byte {
+	if src == nil {
+		return nil
+	}
+	return append([]byte{}, src...)
+}
+
+func (k Keys) equal(other Keys) bool {
+	return bytes.Equal(k.BlockCipherKey, other.BlockCipherKey) &&
+		bytes.Equal(k.BroadcastKey, other.BroadcastKey) &&
+		bytes.Equal(k.AuthenticationKey, other.AuthenticationKey) &&
+		bytes.Equal(k.MasterKey, other.MasterKey)
+}
+
+type storeKey struct {
+	systemTitle string
+	suite       uint8
+}
+
+type storeEntry struct {
+	keys         Keys
+	nextCounter  uint64
+	lastReceived uint32
+	received     bool
+}
+
+// MemoryKeyStore is KeyStore living in memory, counters are lost when
+// process ends. Keys are copied in and out, so the caller may reuse its
+// slices
+type MemoryKeyStore struct {
+	mutex   sync.Mutex
+	entries map[storeKey]*storeEntry
+}
+
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{entries: make(map[storeKey]*storeEntry)}
+}
+
+func (s *MemoryKeyStore) entry(systemTitle []byte, suite uint8) (*storeEntry, error) {
+	e, ok := s.entries[storeKey{string(systemTitle), suite}]
+	if !ok {
+		return nil, fmt.Errorf("%w: %X suite %v", ErrKeysNotFound, systemTitle, suite)
+	}
+	return e, nil
+}
+
+func (s *MemoryKeyStore) Keys(systemTitle []byte, suite uint8) (out Keys, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, err := s.entry(systemTitle, suite)
+	if err != nil {
+		return
+	}
+	out = e.keys.clone()
+	return
+}
+
+func (s *MemoryKeyStore) SetKeys(systemTitle []byte, suite uint8, keys Keys) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	k := storeKey{string(systemTitle), suite}
+	keys = keys.clone()
+	if e, ok := s.entries[k]; ok {
+		if !e.keys.equal(keys) {
+			e.lastReceived, e.received = 0, false
+		}
+		e.keys = keys
+		return nil
+	}
+	s.entries[k] = &storeEntry{keys: keys}
+	return nil
+}
+
+func (s *MemoryKeyStore) ReserveCounter(systemTitle []byte, suite uint8, n uint32) (out uint32, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, err := s.entry(systemTitle, suite)
+	if err != nil {
+		return
+	}
+	if e.nextCounter+uint64(n) > 1<<32 {
+		err = ErrCounterExhausted
+		return
+	}
+	out = uint32(e.nextCounter)
+	e.nextCounter += uint64(n)
+	return
+}
+
+func (s *MemoryKeyStore) AcceptCounter(systemTitle []byte, suite uint8, ic uint32) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, err := s.entry(systemTitle, suite)
+	if err != nil {
+		return
+	}
+	if e.received && ic <= e.lastReceived {
+		return ErrReplay
+	}
+	e.lastReceived = ic
+	e.received = true
+	return
+}
@@ -0,0 +1,219 @@
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gosem/pkg/dlms"
+)
+
+func TestMemoryKeyStore(t *testing.T) {
+	s := NewMemoryKeyStore()
+	if _, err := s.Keys(testSystemTitle, 0); !errors.Is(err, ErrKeysNotFound) {
+		t.Errorf("t1 Failed. err:%v", err)
+	}
+	if _, err := s.ReserveCounter(testSystemTitle, 0, 1); !errors.Is(err, ErrKeysNotFound) {
+		t.Errorf("t1 Failed. err:%v", err)
+	}
+
+	s.SetKeys(testSystemTitle, 0, Keys{BlockCipherKey: testEK, AuthenticationKey: testAK})
+	ic, err := s.ReserveCounter(testSystemTitle, 0, 10)
+	if err != nil || ic != 0 {
+		t.Errorf("t2 Failed. get: %v, err:%v", ic, err)
+	}
+	ic, err = s.ReserveCounter(testSystemTitle, 0, 1)
+	if err != nil || ic != 10 {
+		t.Errorf("t3 Failed. get: %v, err:%v", ic, err)
+	}
+	// counters are kept when keys change, and are per suite
+	s.SetKeys(testSystemTitle, 0, Keys{BlockCipherKey: testAK, AuthenticationKey: testAK})
+	ic, _ = s.ReserveCounter(testSystemTitle, 0, 1)
+	if ic != 11 {
+		t.Errorf("t4 Failed. get: %v", ic)
+	}
+	if _, err = s.ReserveCounter(testSystemTitle, 1, 1); !errors.Is(err, ErrKeysNotFound) {
+		t.Errorf("t4 Failed. err:%v", err)
+	}
+	if _, err = s.ReserveCounter(testSystemTitle, 0, 0xFFFFFFFF); err != ErrCounterExhausted {
+		t.Errorf("t5 Failed. err:%v", err)
+	}
+
+	if err = s.AcceptCounter(testSystemTitle, 0, 0); err != nil {
+		t.Errorf("t6 Failed. err:%v", err)
+	}
+	if err = s.AcceptCounter(testSystemTitle, 0, 0); err != ErrReplay {
+		t.Errorf("t7 Failed. err:%v", err)
+	}
+	if err = s.AcceptCounter(testSystemTitle, 0, 5); err != nil {
+		t.Errorf("t8 Failed. err:%v", err)
+	}
+	if err = s.AcceptCounter(testSystemTitle, 0, 4); err != ErrReplay {
+		t.Errorf("t9 Failed. err:%v", err)
+	}
+
+	// same keys keep received counter, new keys start it again
+	s.SetKeys(testSystemTitle, 0, Keys{BlockCipherKey: testAK, AuthenticationKey: testAK})
+	if err = s.AcceptCounter(testSystemTitle, 0, 5); err != ErrReplay {
+		t.Errorf("t10 Failed. err:%v", err)
+	}
+	s.SetKeys(testSystemTitle, 0, Keys{BlockCipherKey: testEK, AuthenticationKey: testAK})
+	if err = s.AcceptCounter(testSystemTitle, 0, 1); err != nil {
+		t.Errorf("t11 Failed. err:%v", err)
+	}
+	if ic, _ = s.ReserveCounter(testSystemTitle, 0, 1); ic != 12 {
+		t.Errorf("t11 Failed. counter to send get: %v", ic)
+	}
+
+	// keys are copied, caller may reuse its slices
+	ek := append([]byte{}, testEK...)
+	s.SetKeys(testSystemTitle, 2, Keys{BlockCipherKey: ek})
+	ek[0] ^= 0xFF
+	out, _ := s.Keys(testSystemTitle, 2)
+	out.BlockCipherKey[1] ^= 0xFF
+	if out, _ = s.Keys(testSystemTitle, 2); string(out.BlockCipherKey) != string(testEK) {
+		t.Errorf("t12 Failed. get: %X", out.BlockCipherKey)
+	}
+}
+
+func TestKeyStore_Concurrent(t *testing.T) {
+	file, err := OpenFileKeyStore(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("OpenFileKeyStore failed. err: %v", err)
+	}
+	for _, s := range []KeyStore{NewMemoryKeyStore(), file} {
+		s.SetKeys(testSystemTitle, 0, Keys{BlockCipherKey: testEK})
+
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		seen := make(map[uint32]bool)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					ic, err := s.ReserveCounter(testSystemTitle, 0, 1)
+					mutex.Lock()
+					if err != nil || seen[ic] {
+						t.Errorf("%T counter %v reserved twice, err:%v", s, ic, err)
+					}
+					seen[ic] = true
+					mutex.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestFileKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	s, err := OpenFileKeyStore(path)
+	if err != nil {
+		t.Fatalf("t1 OpenFileKeyStore failed. err: %v", err)
+	}
+	s.CounterBlock = 10
+	keys := Keys{BlockCipherKey: testEK, AuthenticationKey: testAK, MasterKey: testAK}
+	if err = s.SetKeys(testSystemTitle, 0, keys); err != nil {
+		t.Fatalf("t1 SetKeys failed. err: %v", err)
+	}
+	s.ReserveCounter(testSystemTitle, 0, 5)
+	s.AcceptCounter(testSystemTitle, 0, 7)
+
+	// reopened store goes on after the reserved block of counters
+	s, err = OpenFileKeyStore(path)
+	if err != nil {
+		t.Fatalf("t2 OpenFileKeyStore failed. err: %v", err)
+	}
+	out, err := s.Keys(testSystemTitle, 0)
+	if err != nil || string(out.BlockCipherKey) != string(keys.BlockCipherKey) || string(out.MasterKey) != string(keys.MasterKey) || out.BroadcastKey != nil {
+		t.Errorf("t2 Failed. get: %v, err:%v", out, err)
+	}
+	ic, err := s.ReserveCounter(testSystemTitle, 0, 1)
+	if err != nil || ic != 15 {
+		t.Errorf("t3 Failed. get: %v, err:%v", ic, err)
+	}
+	if err = s.AcceptCounter(testSystemTitle, 0, 7); err != ErrReplay {
+		t.Errorf("t4 Failed. err:%v", err)
+	}
+
+	// file is written once per block of counters to send
+	s.CounterBlock = 10
+	saved, _ := os.ReadFile(path)
+	for i := 0; i < 5; i++ {
+		s.ReserveCounter(testSystemTitle, 0, 1)
+	}
+	if now, _ := os.ReadFile(path); string(now) != string(saved) {
+		t.Errorf("t5 Failed. file is written on every counter")
+	}
+
+	// received counters are written once per block as well
+	for i := 0; i < 5; i++ {
+		s.AcceptCounter(testSystemTitle, 0, uint32(8+i))
+	}
+	if now, _ := os.ReadFile(path); string(now) != string(saved) {
+		t.Errorf("t6 Failed. file is written on every received counter")
+	}
+
+	// without Flush, counters received after the last write are lost,
+	// never the written one
+	s, _ = OpenFileKeyStore(path)
+	s.CounterBlock = 10
+	if err = s.AcceptCounter(testSystemTitle, 0, 7); err != ErrReplay {
+		t.Errorf("t7 Failed. err:%v", err)
+	}
+	if err = s.AcceptCounter(testSystemTitle, 0, 12); err != nil {
+		t.Errorf("t7 Failed. err:%v", err)
+	}
+
+	// counter a block past the written one is written at once
+	s.AcceptCounter(testSystemTitle, 0, 17)
+	s, _ = OpenFileKeyStore(path)
+	s.CounterBlock = 10
+	if err = s.AcceptCounter(testSystemTitle, 0, 17); err != ErrReplay {
+		t.Errorf("t8 Failed. err:%v", err)
+	}
+
+	// Flush writes the rest
+	s.AcceptCounter(testSystemTitle, 0, 18)
+	if err = s.Flush(); err != nil {
+		t.Errorf("t9 Flush failed. err: %v", err)
+	}
+	s, _ = OpenFileKeyStore(path)
+	if err = s.AcceptCounter(testSystemTitle, 0, 18); err != ErrReplay {
+		t.Errorf("t9 Failed. err:%v", err)
+	}
+	if ic, _ = s.ReserveCounter(testSystemTitle, 0, 1); ic <= 20 {
+		t.Errorf("t9 Failed. counter %v is reserved again", ic)
+	}
+}
+
+func TestContext_Store(t *testing.T) {
+	store := NewMemoryKeyStore()
+	store.SetKeys(testSystemTitle, 0, Keys{BlockCipherKey: testEK, AuthenticationKey: testAK})
+	ctx, err := CreateContextFromStore(store, SecurityAuthenticatedEncryption, testSystemTitle, testSystemTitle)
+	if err != nil {
+		t.Fatalf("CreateContextFromStore failed. err: %v", err)
+	}
+	store.ReserveCounter(testSystemTitle, 0, testIC)
+
+	attrDesc := *dlms.CreateAttributeDescriptor(8, "0.0.1.0.0.255", 2)
+	apdu, err := ctx.Wrap(dlms.CreateGetRequestNormal(0x0, attrDesc, nil), false)
+	if err != nil || apdu.InvocationCounter != testIC {
+		t.Fatalf("t1 Wrap failed. get: %v, err: %v", apdu, err)
+	}
+	next, _ := store.ReserveCounter(testSystemTitle, 0, 1)
+	if next != testIC+1 || ctx.InvocationCounter != 0 {
+		t.Errorf("t1 counter should be reserved from store, get: %v", next)
+	}
+
+	// system title of both side is the same, so APDU can be unwrapped once
+	if _, err = ctx.Unwrap(*apdu); err != nil {
+		t.Errorf("t2 Unwrap failed. err: %v", err)
+	}
+	if _, err = ctx.Unwrap(*apdu); err != ErrReplay {
+		t.Errorf("t3 replayed APDU should fail. err: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package security
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"gosem/pkg/axdr"
+	"gosem/pkg/dlms"
+)
+
+// KeyId tells which key is transferred by global_key_transfer
+type KeyId uint8
+
+const (
+	KeyGlobalUnicast   KeyId = 0
+	KeyGlobalBroadcast KeyId = 1
+	KeyAuthentication  KeyId = 2
+	KeyMaster          KeyId = 3
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (k KeyId) Value() uint8 {
+	return uint8(k)
+}
+
+// Security Setup class and its global_key_transfer method. Logical name
+// of Security Setup differs by association, 0.0.43.0.0.255 is the usual one
+const (
+	SecuritySetupClassId uint16 = 64
+	SecuritySetupObis           = "0.0.43.0.0.255"
+	globalKeyTransfer    int8   = 2
+)
+
+// KeyData is one plain key to be transferred
+type KeyData struct {
+	Id  KeyId
+	Key []byte
+}
+
+// KeyDataArray returns key_data array of global_key_transfer, every key
+// is wrapped under kek which is the master key
+func KeyDataArray(kek []byte, keys []KeyData) (out *axdr.DlmsData, err error) {
+	if len(keys) == 0 {
+		err = fmt.Errorf("no key to transfer")
+		return
+	}
+	wrapped := make([]KeyData, len(keys))
+	for i, kd := range keys {
+		wrapped[i].Id = kd.Id
+		if wrapped[i].Key, err = WrapKey(kek, kd.Key); err != nil {
+			err = fmt.Errorf("key %v: %w", i, err)
+			return
+		}
+	}
+	out = keyStructures(wrapped)
+	return
+}
+
+// DecodeKeyDataArray is the reverse of KeyDataArray, used by server to
+// unwrap keys received by global_key_transfer
+func DecodeKeyDataArray(kek []byte, data axdr.DlmsData) (out []KeyData, err error) {
+	if out, err = decodeKeyStructures(data); err != nil {
+		return
+	}
+	for i := range out {
+		if out[i].Key, err = UnwrapKey(kek, out[i].Key); err != nil {
+			err = fmt.Errorf("key %v: %w", i, err)
+			return nil, err
+		}
+	}
+	return
+}
+
+// Array of structure of key id enum and octet-string, shared by
+// global_key_transfer and key_agreement
+func keyStructures(list []KeyData) *axdr.DlmsData {
+	elements := make([]*axdr.DlmsData, len(list))
+	for i, kd := range list {
+		elements[i] = axdr.CreateAxdrStructure([]*axdr.DlmsData{
+			axdr.CreateAxdrEnum(kd.Id.Value()),
+			axdr.CreateAxdrOctetString(hex.EncodeToString(kd.Key)),
+		})
+	}
+	return axdr.CreateAxdrArray(elements)
+}
+
+func decodeKeyStructures(data axdr.DlmsData) (out []KeyData, err error) {
+	elements, ok := data.Value.([]*axdr.DlmsData)
+	if data.Tag != axdr.TagArray || !ok {
+		err = fmt.Errorf("key data is %v, should be array", data.Tag)
+		return
+	}
+
+	out = make([]KeyData, len(elements))
+	for i, element := range elements {
+		fields, ok := element.Value.([]*axdr.DlmsData)
+		if element.Tag != axdr.TagStructure || !ok || len(fields) != 2 {
+			err = fmt.Errorf("key data %v is not structure of key id and octet-string", i)
+			return
+		}
+		id, ok := fields[0].Value.(uint8)
+		if fields[0].Tag != axdr.TagEnum || !ok || id > KeyMaster.Value() {
+			err = fmt.Errorf("key data %v key id %v is not valid", i, fields[0].Value)
+			return
+		}
+		value, ok := fields[1].Value.(string)
+		if fields[1].Tag != axdr.TagOctetString || !ok {
+			err = fmt.Errorf("key data %v is %v, should be octet-string", i, fields[1].Tag)
+			return
+		}
+		out[i].Id = KeyId(id)
+		if out[i].Key, err = hex.DecodeString(value); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// CreateGlobalKeyTransfer returns invocation of global_key_transfer of
+// Security Setup object obis, carrying keys wrapped under kek
+func CreateGlobalKeyTransfer(invokeId uint8, obis string, kek []byte, keys []KeyData) (out *dlms.ActionRequestNormal, err error) {
+	param, err := KeyDataArray(kek, keys)
+	if err != nil {
+		return
+	}
+	mth := *dlms.CreateMethodDescriptor(SecuritySetupClassId, obis, globalKeyTransfer)
+	out = dlms.CreateActionRequestNormal(invokeId, mth, param)
+	return
+}
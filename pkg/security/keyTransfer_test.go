@@ -0,0 +1,55 @@
+package security
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"gosem/pkg/dlms"
+)
+
+func TestCreateGlobalKeyTransfer(t *testing.T) {
+	kek := testAK
+	keys := []KeyData{{KeyGlobalUnicast, testEK}, {KeyAuthentication, testAK}}
+	req, err := CreateGlobalKeyTransfer(0x41, SecuritySetupObis, kek, keys)
+	if err != nil {
+		t.Fatalf("t1 CreateGlobalKeyTransfer failed. err: %v", err)
+	}
+	src, _ := req.Encode()
+	decoded, err := dlms.DecodeCosem(&src)
+	if err != nil {
+		t.Fatalf("t1 decode failed. err: %v", err)
+	}
+	ar := decoded.(dlms.ActionRequestNormal)
+	if ar.MethodInfo.ClassId != 64 || ar.MethodInfo.MethodId != 2 || ar.MethodInfo.InstanceId.String() != "0.0.43.0.0.255" || ar.MethodParam == nil {
+		t.Fatalf("t1 Failed. get: %v", ar)
+	}
+
+	// array of 2 structures, enum key_id then 24 bytes octet-string
+	param, _ := ar.MethodParam.AppendEncode(nil)
+	if len(param) != 2+2*(2+2+2+24) || !bytes.Equal(param[:7], []byte{1, 2, 2, 2, 22, 0, 9}) || param[7] != 24 {
+		t.Errorf("t2 Failed. get: %X", param)
+	}
+
+	out, err := DecodeKeyDataArray(kek, *ar.MethodParam)
+	if err != nil || len(out) != 2 {
+		t.Fatalf("t3 DecodeKeyDataArray failed. get: %v, err: %v", out, err)
+	}
+	for i := range keys {
+		if out[i].Id != keys[i].Id || !bytes.Equal(out[i].Key, keys[i].Key) {
+			t.Errorf("t3 key %v get: %v, should:%v", i, out[i], keys[i])
+		}
+	}
+
+	out, err = DecodeKeyDataArray(testEK, *ar.MethodParam)
+	if !errors.Is(err, ErrKeyUnwrap) || out != nil {
+		t.Errorf("t4 another KEK should fail. err:%v", err)
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "key 0: ") {
+		t.Errorf("t4 error should tell which key failed. err:%v", err)
+	}
+	if _, err = CreateGlobalKeyTransfer(0x41, SecuritySetupObis, kek, nil); err == nil {
+		t.Errorf("t5 no key should fail")
+	}
+}
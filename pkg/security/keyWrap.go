@@ -0,0 +1,81 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var ErrKeyUnwrap = errors.New("wrapped key integrity check failed")
+
+// Default initial value of RFC 3394
+var keyWrapIV = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+// WrapKey wraps key under kek as defined by RFC 3394. Key is a multiple
+// of 8 bytes and at least 16 bytes, output is 8 bytes longer than key
+func WrapKey(kek []byte, key []byte) (out []byte, err error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		err = fmt.Errorf("key to wrap is %v bytes, should be multiple of 8 and at least 16", len(key))
+		return
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return
+	}
+
+	n := len(key) / 8
+	out = make([]byte, 8+len(key))
+	copy(out, keyWrapIV)
+	copy(out[8:], key)
+
+	var b [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b[:8], out[:8])
+			copy(b[8:], out[8*i:8*i+8])
+			block.Encrypt(b[:], b[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(b[:8])^t)
+			copy(out[8*i:8*i+8], b[8:])
+		}
+	}
+	return
+}
+
+// UnwrapKey is the reverse of WrapKey, it returns ErrKeyUnwrap when
+// wrapped key was not wrapped under kek or was altered
+func UnwrapKey(kek []byte, wrapped []byte) (out []byte, err error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		err = fmt.Errorf("wrapped key is %v bytes, should be multiple of 8 and at least 24", len(wrapped))
+		return
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return
+	}
+
+	n := len(wrapped)/8 - 1
+	a := make([]byte, 8)
+	copy(a, wrapped[:8])
+	out = make([]byte, len(wrapped)-8)
+	copy(out, wrapped[8:])
+
+	var b [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], out[8*(i-1):8*i])
+			block.Decrypt(b[:], b[:])
+			copy(a, b[:8])
+			copy(out[8*(i-1):8*i], b[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, ErrKeyUnwrap
+	}
+	return
+}
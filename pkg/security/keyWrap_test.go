@@ -0,0 +1,46 @@
+package security
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestWrapKey(t *testing.T) {
+	// test vectors of RFC 3394 section 4
+	tables := []struct {
+		kek    string
+		key    string
+		result string
+	}{
+		{"000102030405060708090A0B0C0D0E0F", "00112233445566778899AABBCCDDEEFF", "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"},
+		{"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F", "00112233445566778899AABBCCDDEEFF", "64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7"},
+		{"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F", "00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F", "28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21"},
+	}
+	for idx, table := range tables {
+		kek, _ := hex.DecodeString(table.kek)
+		key, _ := hex.DecodeString(table.key)
+		result, _ := hex.DecodeString(table.result)
+
+		out, err := WrapKey(kek, key)
+		if err != nil || !bytes.Equal(out, result) {
+			t.Errorf("combination %v WrapKey failed. get: %X, should:%X, err:%v", idx, out, result, err)
+		}
+		out, err = UnwrapKey(kek, result)
+		if err != nil || !bytes.Equal(out, key) {
+			t.Errorf("combination %v UnwrapKey failed. get: %X, should:%X, err:%v", idx, out, key, err)
+		}
+
+		result[len(result)-1] ^= 0x01
+		if _, err = UnwrapKey(kek, result); err != ErrKeyUnwrap {
+			t.Errorf("combination %v altered key should fail. err:%v", idx, err)
+		}
+	}
+
+	if _, err := WrapKey(testEK, testEK[:12]); err == nil {
+		t.Errorf("key of 12 bytes should fail")
+	}
+	if _, err := UnwrapKey(testEK, testEK); err == nil {
+		t.Errorf("wrapped key of 16 bytes should fail")
+	}
+}
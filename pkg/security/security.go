@@ -0,0 +1,203 @@
+/*
+Provides ciphering of xDLMS APDUs as defined by DLMS/COSEM security
+suite 0, 1 and 2: AES-GCM with 12 bytes authentication tag, where the
+initialization vector is built from system title of the sender and
+invocation counter. Suite 1 and 2 also sign general-signing APDUs with
+ECDSA on P-256 and P-384, and agree keys with ECDH.
+*/
+
+package security
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// SecurityControl is the security header byte sent in front of every
+// ciphered APDU. Bit 0-3 is the security suite id, bit 4 authentication,
+// bit 5 encryption, bit 6 broadcast key and bit 7 compression.
+type SecurityControl uint8
+
+const (
+	SecurityNone                    SecurityControl = 0x00
+	SecurityAuthentication          SecurityControl = 0x10
+	SecurityEncryption              SecurityControl = 0x20
+	SecurityAuthenticatedEncryption SecurityControl = 0x30
+	SecurityBroadcastKey            SecurityControl = 0x40
+	SecurityCompression             SecurityControl = 0x80
+)
+
+// Length of authentication tag appended to authenticated APDU
+const TagLength = 12
+
+var (
+	ErrAuthenticationFailed = errors.New("authentication tag is not valid")
+	ErrCompression          = errors.New("compression of ciphered APDU is not supported")
+	ErrSecurityPolicy       = errors.New("security control does not match security policy")
+)
+
+// Value will return primitive value of the target.
+// This is used for comparing with non custom typed object
+func (sc SecurityControl) Value() uint8 {
+	return uint8(sc)
+}
+
+// Suite returns security suite id, 0 for AES-GCM-128, 1 for
+// ECDH-ECDSA-AES-GCM-128-SHA-256 and 2 for ECDH-ECDSA-AES-GCM-256-SHA-384
+func (sc SecurityControl) Suite() uint8 {
+	return uint8(sc) & 0x0F
+}
+
+func (sc SecurityControl) Authenticated() bool {
+	return sc&SecurityAuthentication != 0
+}
+
+func (sc SecurityControl) Encrypted() bool {
+	return sc&SecurityEncryption != 0
+}
+
+func (sc SecurityControl) Broadcast() bool {
+	return sc&SecurityBroadcastKey != 0
+}
+
+func (sc SecurityControl) Compressed() bool {
+	return sc&SecurityCompression != 0
+}
+
+// Build 12 bytes initialization vector from system title & invocation counter
+func initializationVector(systemTitle []byte, ic uint32) ([]byte, error) {
+	if len(systemTitle) != 8 {
+		return nil, fmt.Errorf("system title must be 8 bytes, received %v", len(systemTitle))
+	}
+	iv := make([]byte, 12)
+	copy(iv, systemTitle)
+	binary.BigEndian.PutUint32(iv[8:], ic)
+	return iv, nil
+}
+
+func newBlockCipher(sc SecurityControl, key []byte) (cipher.Block, error) {
+	keyLength := 16
+	if sc.Suite() == 2 {
+		keyLength = 32
+	} else if sc.Suite() > 2 {
+		return nil, fmt.Errorf("security suite %v is not supported", sc.Suite())
+	}
+	if len(key) != keyLength {
+		return nil, fmt.Errorf("security suite %v expects %v bytes key, received %v", sc.Suite(), keyLength, len(key))
+	}
+	return aes.NewCipher(key)
+}
+
+// GCM without authentication is plain CTR starting at counter 2
+func counterMode(block cipher.Block, iv []byte, src []byte) []byte {
+	counter := make([]byte, 16)
+	copy(counter, iv)
+	counter[15] = 2
+	out := make([]byte, len(src))
+	cipher.NewCTR(block, counter).XORKeyStream(out, src)
+	return out
+}
+
+// Encrypt protects plain according to sc and returns the ciphered
+// information, without security header and invocation counter.
+// Authenticated APDU has the authentication tag appended. Additional
+// authenticated data is sc || ak, followed by plain when it is not encrypted.
+func Encrypt(sc SecurityControl, systemTitle []byte, ic uint32, ek []byte, ak []byte, plain []byte) (out []byte, err error) {
+	if sc.Compressed() {
+		err = ErrCompression
+		return
+	}
+	if !sc.Authenticated() && !sc.Encrypted() {
+		out = append([]byte(nil), plain...)
+		return
+	}
+
+	iv, err := initializationVector(systemTitle, ic)
+	if err != nil {
+		return
+	}
+	block, err := newBlockCipher(sc, ek)
+	if err != nil {
+		return
+	}
+	if !sc.Authenticated() {
+		out = counterMode(block, iv, plain)
+		return
+	}
+
+	gcm, err := cipher.NewGCMWithTagSize(block, TagLength)
+	if err != nil {
+		return
+	}
+	var aad bytes.Buffer
+	aad.WriteByte(sc.Value())
+	aad.Write(ak)
+	if sc.Encrypted() {
+		out = gcm.Seal(nil, iv, plain, aad.Bytes())
+		return
+	}
+	aad.Write(plain)
+	tag := gcm.Seal(nil, iv, nil, aad.Bytes())
+	out = append(append([]byte(nil), plain...), tag...)
+	return
+}
+
+// Decrypt is the reverse of Encrypt, it returns ErrAuthenticationFailed
+// if the authentication tag does not match
+func Decrypt(sc SecurityControl, systemTitle []byte, ic uint32, ek []byte, ak []byte, information []byte) (out []byte, err error) {
+	if sc.Compressed() {
+		err = ErrCompression
+		return
+	}
+	if !sc.Authenticated() && !sc.Encrypted() {
+		out = append([]byte(nil), information...)
+		return
+	}
+
+	iv, err := initializationVector(systemTitle, ic)
+	if err != nil {
+		return
+	}
+	block, err := newBlockCipher(sc, ek)
+	if err != nil {
+		return
+	}
+	if !sc.Authenticated() {
+		out = counterMode(block, iv, information)
+		return
+	}
+
+	if len(information) < TagLength {
+		err = fmt.Errorf("ciphered information is %v bytes, shorter than authentication tag", len(information))
+		return
+	}
+	gcm, err := cipher.NewGCMWithTagSize(block, TagLength)
+	if err != nil {
+		return
+	}
+	var aad bytes.Buffer
+	aad.WriteByte(sc.Value())
+	aad.Write(ak)
+	if sc.Encrypted() {
+		out, err = gcm.Open(nil, iv, information, aad.Bytes())
+		if err != nil {
+			err = ErrAuthenticationFailed
+		}
+		return
+	}
+
+	plain := information[:len(information)-TagLength]
+	aad.Write(plain)
+	tag := gcm.Seal(nil, iv, nil, aad.Bytes())
+	if subtle.ConstantTimeCompare(tag, information[len(information)-TagLength:]) != 1 {
+		err = ErrAuthenticationFailed
+		return
+	}
+	out = append([]byte(nil), plain...)
+	return
+}
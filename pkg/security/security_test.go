@@ -0,0 +1,77 @@
+package security
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+var (
+	testSystemTitle, _ = hex.DecodeString("4D4D4D0000BC614E")
+	testEK, _          = hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	testAK, _          = hex.DecodeString("D0D1D2D3D4D5D6D7D8D9DADBDCDDDEDF")
+	testPlain, _       = hex.DecodeString("C0010000080000010000FF0200")
+)
+
+const testIC uint32 = 0x01234567
+
+func TestEncrypt(t *testing.T) {
+	tables := []struct {
+		sc     SecurityControl
+		result string
+	}{
+		{SecurityAuthenticatedEncryption, "411312FF935A47566827C467BC7D825C3BE4A77C3FCC056B6B"},
+		{SecurityEncryption, "411312FF935A47566827C467BC"},
+		{SecurityAuthentication, "C0010000080000010000FF020006725D910F9221D263877516"},
+		{SecurityNone, "C0010000080000010000FF0200"},
+	}
+	for idx, table := range tables {
+		out, err := Encrypt(table.sc, testSystemTitle, testIC, testEK, testAK, testPlain)
+		result, _ := hex.DecodeString(table.result)
+		if err != nil || bytes.Compare(out, result) != 0 {
+			t.Errorf("combination %v failed. get: %X, should:%X, err:%v", idx, out, result, err)
+		}
+
+		plain, err := Decrypt(table.sc, testSystemTitle, testIC, testEK, testAK, out)
+		if err != nil || bytes.Compare(plain, testPlain) != 0 {
+			t.Errorf("combination %v decrypt failed. get: %X, err:%v", idx, plain, err)
+		}
+	}
+}
+
+func TestDecrypt_Error(t *testing.T) {
+	information, _ := hex.DecodeString("411312FF935A47566827C467BC7D825C3BE4A77C3FCC056B6B")
+	information[0] ^= 0xFF
+	_, err := Decrypt(SecurityAuthenticatedEncryption, testSystemTitle, testIC, testEK, testAK, information)
+	if err != ErrAuthenticationFailed {
+		t.Errorf("t1 should fail on authentication, get: %v", err)
+	}
+
+	information, _ = hex.DecodeString("C0010000080000010000FF020006725D910F9221D263877516")
+	_, err = Decrypt(SecurityAuthentication, testSystemTitle, testIC+1, testEK, testAK, information)
+	if err != ErrAuthenticationFailed {
+		t.Errorf("t2 should fail on authentication, get: %v", err)
+	}
+
+	_, err = Decrypt(SecurityAuthentication, testSystemTitle[:4], testIC, testEK, testAK, information)
+	if err == nil {
+		t.Errorf("t3 should fail on short system title")
+	}
+
+	_, err = Decrypt(SecurityAuthentication, testSystemTitle, testIC, testEK[:8], testAK, information)
+	if err == nil {
+		t.Errorf("t4 should fail on short key")
+	}
+
+	_, err = Decrypt(SecurityAuthenticatedEncryption|SecurityCompression, testSystemTitle, testIC, testEK, testAK, information)
+	if err != ErrCompression {
+		t.Errorf("t5 should fail on compression, get: %v", err)
+	}
+}
+
+func TestSecurityControl(t *testing.T) {
+	sc := SecurityControl(0x32)
+	if sc.Suite() != 2 || !sc.Authenticated() || !sc.Encrypted() || sc.Broadcast() {
+		t.Errorf("t1 failed. get: %v", sc)
+	}
+}
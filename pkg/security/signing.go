@@ -0,0 +1,123 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"gosem/pkg/dlms"
+)
+
+var ErrSignatureFailed = errors.New("signature is not valid")
+
+// SuiteCurve returns elliptic curve of security suite, P-256 for suite 1
+// and P-384 for suite 2. Suite 0 does not use public keys
+func SuiteCurve(suite uint8) (elliptic.Curve, error) {
+	switch suite {
+	case 1:
+		return elliptic.P256(), nil
+	case 2:
+		return elliptic.P384(), nil
+	}
+	return nil, fmt.Errorf("security suite %v has no elliptic curve", suite)
+}
+
+// Hash data with the hash function of security suite using curve
+func digest(curve elliptic.Curve, data []byte) ([]byte, error) {
+	switch curve {
+	case elliptic.P256():
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	case elliptic.P384():
+		sum := sha512.Sum384(data)
+		return sum[:], nil
+	}
+	return nil, fmt.Errorf("elliptic curve %v is not used by any security suite", curve.Params().Name)
+}
+
+// Sign returns ECDSA signature of data as r || s. Key of P-256 signs with
+// SHA-256 as suite 1, key of P-384 signs with SHA-384 as suite 2
+func Sign(key *ecdsa.PrivateKey, data []byte) (out []byte, err error) {
+	hash, err := digest(key.Curve, data)
+	if err != nil {
+		return
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash)
+	if err != nil {
+		return
+	}
+
+	size := coordinateSize(key.Curve)
+	out = make([]byte, 2*size)
+	r.FillBytes(out[:size])
+	s.FillBytes(out[size:])
+	return
+}
+
+// Verify checks signature made by Sign, it returns ErrSignatureFailed
+// when signature does not match
+func Verify(key *ecdsa.PublicKey, data []byte, signature []byte) (err error) {
+	hash, err := digest(key.Curve, data)
+	if err != nil {
+		return
+	}
+	size := coordinateSize(key.Curve)
+	if len(signature) != 2*size {
+		return fmt.Errorf("signature is %v bytes, should be %v", len(signature), 2*size)
+	}
+
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(key, hash, r, s) {
+		return ErrSignatureFailed
+	}
+	return
+}
+
+// SignAPDU sets Signature of general-signing apdu
+func SignAPDU(key *ecdsa.PrivateKey, apdu *dlms.GeneralSigning) (err error) {
+	data, err := apdu.SignedData()
+	if err != nil {
+		return
+	}
+	apdu.Signature, err = Sign(key, data)
+	return
+}
+
+// VerifyAPDU checks Signature of general-signing apdu made by owner of key
+func VerifyAPDU(key *ecdsa.PublicKey, apdu dlms.GeneralSigning) (err error) {
+	data, err := apdu.SignedData()
+	if err != nil {
+		return
+	}
+	return Verify(key, data, apdu.Signature)
+}
+
+// SignPDU carries encoded pdu in general-signing APDU signed by key, pdu
+// may be any APDU including ciphered one
+func SignPDU(key *ecdsa.PrivateKey, pdu dlms.CosemPDU, transactionId []byte, originator []byte, recipient []byte) (out *dlms.GeneralSigning, err error) {
+	content, err := pdu.Encode()
+	if err != nil {
+		return
+	}
+	out = dlms.CreateGeneralSigning(transactionId, originator, recipient, nil, nil, content)
+	if err = SignAPDU(key, out); err != nil {
+		out = nil
+	}
+	return
+}
+
+// VerifyPDU checks signature of general-signing apdu and decodes the APDU
+// it carries
+func VerifyPDU(key *ecdsa.PublicKey, apdu dlms.GeneralSigning) (out dlms.CosemPDU, err error) {
+	if err = VerifyAPDU(key, apdu); err != nil {
+		return
+	}
+	content := apdu.Content
+	return dlms.DecodeCosem(&content)
+}
@@ -0,0 +1,84 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"gosem/pkg/dlms"
+)
+
+func TestSign(t *testing.T) {
+	for suite := uint8(1); suite <= 2; suite++ {
+		curve, err := SuiteCurve(suite)
+		if err != nil {
+			t.Fatalf("suite %v SuiteCurve failed. err: %v", suite, err)
+		}
+		key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+
+		signature, err := Sign(key, testPlain)
+		size := 2 * (curve.Params().BitSize / 8)
+		if err != nil || len(signature) != size {
+			t.Errorf("suite %v t1 Failed. get: %X, err:%v", suite, signature, err)
+		}
+		if err = Verify(&key.PublicKey, testPlain, signature); err != nil {
+			t.Errorf("suite %v t2 Failed. err:%v", suite, err)
+		}
+
+		signature[size-1] ^= 0x01
+		if err = Verify(&key.PublicKey, testPlain, signature); err != ErrSignatureFailed {
+			t.Errorf("suite %v t3 Failed. err:%v", suite, err)
+		}
+		if err = Verify(&key.PublicKey, testPlain, signature[1:]); err == nil {
+			t.Errorf("suite %v t4 short signature should fail", suite)
+		}
+	}
+
+	if _, err := SuiteCurve(0); err == nil {
+		t.Errorf("t5 suite 0 should have no curve")
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if _, err := Sign(key, testPlain); err == nil {
+		t.Errorf("t6 P-224 key should fail")
+	}
+}
+
+func TestSignPDU(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// signed content is a ciphered APDU
+	ctx := CreateContext(SecurityAuthenticatedEncryption, testSystemTitle, nil, testEK, testAK)
+	attrDesc := *dlms.CreateAttributeDescriptor(8, "0.0.1.0.0.255", 2)
+	ciphered, err := ctx.WrapGeneral(dlms.CreateGetRequestNormal(0x41, attrDesc, nil), false)
+	if err != nil {
+		t.Fatalf("WrapGeneral failed. err: %v", err)
+	}
+
+	apdu, err := SignPDU(key, ciphered, []byte{0, 0, 0, 1}, testSystemTitle, nil)
+	if err != nil {
+		t.Fatalf("t1 SignPDU failed. err: %v", err)
+	}
+	src, _ := apdu.Encode()
+	decoded, err := dlms.DecodeCosem(&src)
+	if err != nil {
+		t.Fatalf("t1 decode failed. err: %v", err)
+	}
+
+	plain, err := VerifyPDU(&key.PublicKey, decoded.(dlms.GeneralSigning))
+	if err != nil {
+		t.Fatalf("t2 VerifyPDU failed. err: %v", err)
+	}
+	if _, ok := plain.(dlms.GeneralCiphering); !ok {
+		t.Errorf("t2 get: %v", plain)
+	}
+
+	if _, err = VerifyPDU(&other.PublicKey, *apdu); err != ErrSignatureFailed {
+		t.Errorf("t3 Failed. err:%v", err)
+	}
+	apdu.TransactionId[3] = 2
+	if err = VerifyAPDU(&key.PublicKey, *apdu); err != ErrSignatureFailed {
+		t.Errorf("t4 Failed. err:%v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package wrapper
+
+import (
+	"fmt"
+	"io"
+	"net"
+)
+
+// Conn implement client.Transport over a stream (TCP) or datagram (UDP)
+// connection. Source is wPort of this side, Destination is wPort of peer.
+type Conn struct {
+	Source      uint16
+	Destination uint16
+
+	rw     io.ReadWriter
+	buffer []byte // reused by Receive
+}
+
+func NewConn(rw io.ReadWriter, source uint16, destination uint16) *Conn {
+	return &Conn{Source: source, Destination: destination, rw: rw}
+}
+
+// Dial connects to address on network, e.g. "tcp" or "udp"
+func Dial(network string, address string, source uint16, destination uint16) (*Conn, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(conn, source, destination), nil
+}
+
+func (c *Conn) Send(apdu []byte) (err error) {
+	out, err := Encode(c.Source, c.Destination, apdu)
+	if err != nil {
+		return
+	}
+	_, err = c.rw.Write(out)
+	return
+}
+
+// Receive returns next APDU sent from Destination to Source. Stream is
+// read as header and then exactly the length it states, while datagram
+// is read at once and must be exactly as long as its header states. Buffer
+// holds the biggest wrapped APDU and one byte more, so longer datagram is
+// not cut silently
+func (c *Conn) Receive() (out []byte, err error) {
+	if c.buffer == nil {
+		c.buffer = make([]byte, HeaderSize+0xFFFF+1)
+	}
+
+	var n int
+	_, datagram := c.rw.(net.PacketConn)
+	if datagram {
+		if n, err = (progressReader{c.rw}).Read(c.buffer); err != nil {
+			return
+		}
+	} else {
+		r := progressReader{c.rw}
+		if _, err = io.ReadFull(r, c.buffer[:HeaderSize]); err != nil {
+			return
+		}
+		src := c.buffer[:HeaderSize]
+		header, herr := DecodeHeader(&src)
+		if herr != nil {
+			return nil, herr
+		}
+		n = HeaderSize + int(header.Length)
+		if _, err = io.ReadFull(r, c.buffer[HeaderSize:n]); err != nil {
+			return
+		}
+	}
+
+	src := c.buffer[:n]
+	header, out, err := Decode(&src)
+	if err != nil {
+		return
+	}
+	if datagram && len(src) != 0 {
+		err = fmt.Errorf("wrapper length is %v, datagram carries %v bytes", header.Length, n-HeaderSize)
+		out = nil
+		return
+	}
+	if header.Source != c.Destination || header.Destination != c.Source {
+		err = fmt.Errorf("APDU from wPort %v to %v is not for this connection (%v to %v)", header.Source, header.Destination, c.Destination, c.Source)
+		out = nil
+	}
+	return
+}
+
+// progressReader fails with io.ErrNoProgress when reader returns
+// neither data nor error, so reading does not loop forever
+type progressReader struct {
+	r io.Reader
+}
+
+func (p progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n == 0 && err == nil && len(b) > 0 {
+		return 0, io.ErrNoProgress
+	}
+	return n, err
+}
+
+// Close closes underlying connection if it can be closed
+func (c *Conn) Close() error {
+	if closer, ok := c.rw.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package wrapper
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestConn_Pipe(t *testing.T) {
+	clientSide, meterSide := net.Pipe()
+	client := NewConn(clientSide, WPortPublicClient, WPortManagement)
+	meter := NewConn(meterSide, WPortManagement, WPortPublicClient)
+	defer client.Close()
+	defer meter.Close()
+
+	request := []byte{0xC0, 0x01, 0xC1, 0x00, 0x08, 0x00, 0x00, 0x01, 0x00, 0x00, 0xFF, 0x02, 0x00}
+	go func() {
+		apdu, err := meter.Receive()
+		if err != nil {
+			return
+		}
+		// response is written in two parts to check reassembly of stream
+		out, _ := Encode(meter.Source, meter.Destination, append([]byte{0xC4}, apdu[1:]...))
+		meterSide.Write(out[:5])
+		meterSide.Write(out[5:])
+	}()
+
+	if err := client.Send(request); err != nil {
+		t.Fatalf("t1 Send failed. err: %v", err)
+	}
+	t1, err := client.Receive()
+	should := append([]byte{0xC4}, request[1:]...)
+	if err != nil || bytes.Compare(t1, should) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X, err:%v", t1, should, err)
+	}
+}
+
+func TestConn_WrongPort(t *testing.T) {
+	clientSide, meterSide := net.Pipe()
+	client := NewConn(clientSide, WPortPublicClient, WPortManagement)
+	defer client.Close()
+	defer meterSide.Close()
+
+	go func() {
+		out, _ := Encode(0x0002, WPortPublicClient, []byte{0xC4})
+		meterSide.Write(out)
+	}()
+	if _, err := client.Receive(); err == nil {
+		t.Errorf("APDU from other wPort should fail")
+	}
+}
+
+func TestConn_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on localhost: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		meter := NewConn(conn, WPortManagement, WPortPublicClient)
+		defer meter.Close()
+		for {
+			apdu, err := meter.Receive()
+			if err != nil {
+				return
+			}
+			meter.Send(apdu)
+		}
+	}()
+
+	client, err := Dial("tcp", listener.Addr().String(), WPortPublicClient, WPortManagement)
+	if err != nil {
+		t.Fatalf("Dial failed. err: %v", err)
+	}
+	defer client.Close()
+
+	for i, length := range []int{1, 300, 0xFFFF} {
+		apdu := make([]byte, length)
+		for j := range apdu {
+			apdu[j] = byte(j)
+		}
+		if err = client.Send(apdu); err != nil {
+			t.Fatalf("t%d Send failed. err: %v", i, err)
+		}
+		get, err := client.Receive()
+		if err != nil || bytes.Compare(get, apdu) != 0 {
+			t.Errorf("t%d Failed. get %v bytes, should %v bytes, err:%v", i, len(get), len(apdu), err)
+		}
+	}
+}
+
+// stalled returns neither data nor error
+type stalled struct{}
+
+func (stalled) Read(b []byte) (int, error)  { return 0, nil }
+func (stalled) Write(b []byte) (int, error) { return len(b), nil }
+
+func TestConn_NoProgress(t *testing.T) {
+	client := NewConn(stalled{}, WPortPublicClient, WPortManagement)
+	if _, err := client.Receive(); err != io.ErrNoProgress {
+		t.Errorf("t1 Failed. err:%v, should:%v", err, io.ErrNoProgress)
+	}
+}
+
+func TestConn_UDP(t *testing.T) {
+	meterSide, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on localhost: %v", err)
+	}
+	defer meterSide.Close()
+
+	go func() {
+		buf := make([]byte, HeaderSize+0xFFFF)
+		for {
+			n, addr, err := meterSide.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			src := buf[:n]
+			_, apdu, err := Decode(&src)
+			if err != nil {
+				return
+			}
+			out, _ := Encode(WPortManagement, WPortPublicClient, apdu)
+			if len(apdu) == 2 {
+				// datagram longer than its header states
+				out = append(out, 0)
+			}
+			meterSide.WriteTo(out, addr)
+		}
+	}()
+
+	client, err := Dial("udp", meterSide.LocalAddr().String(), WPortPublicClient, WPortManagement)
+	if err != nil {
+		t.Fatalf("Dial failed. err: %v", err)
+	}
+	defer client.Close()
+
+	for i, length := range []int{1, 300} {
+		apdu := bytes.Repeat([]byte{byte(i + 1)}, length)
+		if err = client.Send(apdu); err != nil {
+			t.Fatalf("t%d Send failed. err: %v", i, err)
+		}
+		get, err := client.Receive()
+		if err != nil || bytes.Compare(get, apdu) != 0 {
+			t.Errorf("t%d Failed. get %v bytes, should %v bytes, err:%v", i, len(get), len(apdu), err)
+		}
+	}
+
+	if err = client.Send([]byte{1, 2}); err != nil {
+		t.Fatalf("t2 Send failed. err: %v", err)
+	}
+	if get, err := client.Receive(); err == nil {
+		t.Errorf("t2 should fail on datagram longer than wrapper length. get: %v", get)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Provides TCP-UDP/IP wrapper of DLMS/COSEM, as standardized by
+IEC 62056-47. Every APDU is prefixed by 8 bytes header holding version,
+source wPort, destination wPort and length of the APDU.
+*/
+
+package wrapper
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	Version    uint16 = 0x0001
+	HeaderSize        = 8
+)
+
+// Well known wPorts
+const (
+	WPortManagement   uint16 = 0x0001
+	WPortPublicClient uint16 = 0x0010
+)
+
+type Header struct {
+	Version     uint16
+	Source      uint16
+	Destination uint16
+	Length      uint16
+}
+
+func CreateHeader(source uint16, destination uint16, length uint16) *Header {
+	return &Header{
+		Version:     Version,
+		Source:      source,
+		Destination: destination,
+		Length:      length,
+	}
+}
+
+func (h Header) Encode() []byte {
+	out := make([]byte, HeaderSize)
+	binary.BigEndian.PutUint16(out[0:2], h.Version)
+	binary.BigEndian.PutUint16(out[2:4], h.Source)
+	binary.BigEndian.PutUint16(out[4:6], h.Destination)
+	binary.BigEndian.PutUint16(out[6:8], h.Length)
+	return out
+}
+
+func DecodeHeader(ori *[]byte) (out Header, err error) {
+	src := (*ori)[:]
+	if len(src) < HeaderSize {
+		err = fmt.Errorf("wrapper header needs %v bytes, only %v received", HeaderSize, len(src))
+		return
+	}
+	out.Version = binary.BigEndian.Uint16(src[0:2])
+	if out.Version != Version {
+		err = fmt.Errorf("wrapper version %v is not supported", out.Version)
+		return
+	}
+	out.Source = binary.BigEndian.Uint16(src[2:4])
+	out.Destination = binary.BigEndian.Uint16(src[4:6])
+	out.Length = binary.BigEndian.Uint16(src[6:8])
+
+	(*ori) = src[HeaderSize:]
+	return
+}
+
+// Encode puts header in front of apdu
+func Encode(source uint16, destination uint16, apdu []byte) (out []byte, err error) {
+	if len(apdu) > 0xFFFF {
+		err = fmt.Errorf("APDU length %v is bigger than %v", len(apdu), 0xFFFF)
+		return
+	}
+	out = CreateHeader(source, destination, uint16(len(apdu))).Encode()
+	out = append(out, apdu...)
+	return
+}
+
+// Decode reads one wrapped APDU from src
+func Decode(ori *[]byte) (header Header, apdu []byte, err error) {
+	src := (*ori)[:]
+	if header, err = DecodeHeader(&src); err != nil {
+		return
+	}
+	if len(src) < int(header.Length) {
+		err = fmt.Errorf("wrapper length is %v, only %v bytes received", header.Length, len(src))
+		return
+	}
+	apdu = append([]byte(nil), src[:header.Length]...)
+	(*ori) = src[header.Length:]
+	return
+}
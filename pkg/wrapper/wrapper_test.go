@@ -0,0 +1,64 @@
+package wrapper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	h := *CreateHeader(WPortPublicClient, WPortManagement, 13)
+	t1 := h.Encode()
+	result, _ := hex.DecodeString("000100100001000D")
+	if bytes.Compare(t1, result) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X", t1, result)
+	}
+
+	t2, err := DecodeHeader(&t1)
+	if err != nil || t2 != h || len(t1) != 0 {
+		t.Errorf("t2 Failed. get: %v, should:%v, err:%v", t2, h, err)
+	}
+
+	src, _ := hex.DecodeString("000200100001000D")
+	if _, err = DecodeHeader(&src); err == nil {
+		t.Errorf("t3 Wrong version should fail")
+	}
+	src = src[:7]
+	if _, err = DecodeHeader(&src); err == nil {
+		t.Errorf("t4 Short header should fail")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	apdu, _ := hex.DecodeString("C001C100080000010000FF0200")
+	t1, err := Encode(WPortPublicClient, WPortManagement, apdu)
+	result, _ := hex.DecodeString("000100100001000DC001C100080000010000FF0200")
+	if err != nil || bytes.Compare(t1, result) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X, err:%v", t1, result, err)
+	}
+
+	if _, err = Encode(WPortPublicClient, WPortManagement, make([]byte, 0x10000)); err == nil {
+		t.Errorf("t2 APDU longer than 0xFFFF should fail")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	src, _ := hex.DecodeString("0001000100100009C401C1000600000001" + "0001")
+	header, apdu, err := Decode(&src)
+	result, _ := hex.DecodeString("C401C1000600000001")
+	if err != nil || bytes.Compare(apdu, result) != 0 {
+		t.Errorf("t1 Failed. get: %X, should:%X, err:%v", apdu, result, err)
+	}
+	if header.Source != WPortManagement || header.Destination != WPortPublicClient || header.Length != 9 {
+		t.Errorf("t1 Failed. get: %v", header)
+	}
+	if len(src) != 2 {
+		t.Errorf("t1 Failed. %X left", src)
+	}
+
+	// length is longer than received bytes
+	src, _ = hex.DecodeString("000100010010000AC401C1000600000001")
+	if _, _, err = Decode(&src); err == nil {
+		t.Errorf("t2 Wrong length should fail")
+	}
+}